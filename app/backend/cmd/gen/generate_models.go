@@ -2,7 +2,9 @@ package main
 
 import (
 	"log"
+	"os"
 
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
 	"gorm.io/gen"
 )
@@ -11,16 +13,22 @@ func main() {
 	// Generator Config 定義
 	g := gen.NewGenerator(gen.Config{
 		OutPath: "./internal/infrastructure/db/postgres/gen/query", // 出力パス
-		Mode: gen.WithoutContext | // コンテキスト無し
-			gen.WithDefaultQuery | // デフォルトのクエリ構築を生成
+		// クエリは WithContext(ctx) でリクエストのコンテキストを受け取る
+		Mode: gen.WithDefaultQuery | // デフォルトのクエリ構築を生成
 			gen.WithQueryInterface, // クエリのインタフェースを生成
 		FieldWithIndexTag: true, // 構造体のフィールドに "index" タグを付与
 		FieldWithTypeTag:  true, // フィールドに型情報をタグとして出力
 		FieldNullable:     true, // Nullable サポート
 	})
 
+	// marketplace と同じ設定 (ファイル・環境変数・フラグ) を読み込む
+	cfg, err := config.Load("gen", os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	// データベース接続を取得
-	db, err := postgres.NewConnection()
+	db, err := postgres.NewConnection(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
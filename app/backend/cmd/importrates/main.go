@@ -0,0 +1,50 @@
+// Command importrates loads daily exchange rates from a CSV file with the header "date,base,quote,rate".
+//
+// Usage:
+//
+//	importrates rates.csv
+//	importrates < rates.csv
+//
+// The database is configured like for the marketplace server, by the file named by MARKETPLACE_CONFIG
+// and the MARKETPLACE_* environment variables.
+package main
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	var input io.Reader = os.Stdin
+	if len(os.Args) > 1 {
+		file, err := os.Open(os.Args[1])
+		if err != nil {
+			log.Fatalf("Failed to open rate file: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	cfg, err := config.Load("importrates", nil, os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	gormDB, err := postgres.NewConnection(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	exchangeRateService := services.NewExchangeRateService(postgres.NewGormUnitOfWork(gormDB))
+	imported, err := exchangeRateService.ImportRatesCSV(context.Background(), input)
+	if err != nil {
+		log.Fatalf("Failed to import exchange rates: %v", err)
+	}
+
+	log.Printf("Imported %d exchange rates", imported)
+}
@@ -9,57 +9,212 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	"github.com/labstack/echo/v4"
 	_ "github.com/sklinkert/go-ddd/docs" // Swaggerドキュメントのインポート
 	"github.com/sklinkert/go-ddd/internal/application/services"
 	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	domainservices "github.com/sklinkert/go-ddd/internal/domain/services"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
 	postgres2 "github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/webhook"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
 	echoSwagger "github.com/swaggo/echo-swagger"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 )
 
 func main() {
-	dsn := "host=localhost user=root password=password dbname=mydb port=5432 sslmode=disable TimeZone=Asia/Shanghai"
-	port := ":9090"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runMigrate(ctx, os.Args[2:])
+		stop()
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatalf("Failed to migrate: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.Load("marketplace", os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	log.Printf("Configuration:\n%s", cfg)
+
+	// SIGTERM and SIGINT shut the server down gracefully, a second signal kills it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Background jobs are stopped only after the requests in flight completed, as those may still publish events
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	gormDB, err := postgres2.NewConnection(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = gormDB.AutoMigrate()
+	migrator, err := postgres2.NewMigrator(gormDB)
 	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	// Without auto migration the migrations are applied by "marketplace migrate up" before the deployment,
+	// the readiness probe fails as long as some are pending
+	if cfg.Database.AutoMigrate {
+		migrated, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		for _, m := range migrated {
+			log.Printf("Applied migration %s", m.ID())
+		}
+	}
+
+	// Without the search tables, e.g. while migrations are pending, the readiness probe fails
+	// and indexes the products once they exist
+	if err := postgres2.IndexProductsForSearch(gormDB); errors.Is(err, services.ErrProductSearchUnavailable) {
+		log.Printf("Product search is unavailable: %v", err)
+	} else if err != nil {
+		log.Fatalf("Failed to index products for search: %v", err)
 	}
 
 	// Initialize repositories
 	productRepo := postgres2.NewGormProductRepository(gormDB)
 	sellerRepo := postgres2.NewGormSellerRepository(gormDB)
 	userRepo := postgres2.NewGormUserRepository(gormDB)
+	refreshTokenRepo := postgres2.NewGormRefreshTokenRepository(gormDB)
+	revokedTokenRepo := postgres2.NewGormRevokedTokenRepository(gormDB)
+	loginAttemptRepo := postgres2.NewGormLoginAttemptRepository(gormDB)
+	roleRepo := postgres2.NewGormRoleRepository(gormDB)
+	sellerMembershipRepo := postgres2.NewGormSellerMembershipRepository(gormDB)
+	exchangeRateRepo := postgres2.NewGormExchangeRateRepository(gormDB)
+	productSearchRepo := postgres2.NewGormProductSearchRepository(gormDB)
+	outboxRepo := postgres2.NewGormOutboxRepository(gormDB)
+	unitOfWork := postgres2.NewGormUnitOfWork(gormDB)
+	webhookSubscriptionRepo := postgres2.NewGormWebhookSubscriptionRepository(gormDB)
+	webhookDeliveryRepo := postgres2.NewGormWebhookDeliveryRepository(gormDB)
+	idempotencyRepo := postgres2.NewGormIdempotencyRepository(gormDB)
+	auditRepo := postgres2.NewGormAuditRepository(gormDB)
+
+	// Initialize password hasher
+	passwordHasher, err := auth.NewPasswordHasher(cfg.Password)
+	if err != nil {
+		log.Fatalf("Failed to initialize password hasher: %v", err)
+	}
 
 	// Initialize services
-	productService := services.NewProductService(productRepo, sellerRepo)
-	sellerService := services.NewSellerService(sellerRepo)
-	userService := services.NewUserService(userRepo)
+	currencyConverter := domainservices.NewCurrencyConverter(exchangeRateRepo)
+	auditService := services.NewAuditService(auditRepo)
+	productService := services.NewProductService(productRepo, sellerRepo, sellerMembershipRepo, currencyConverter, unitOfWork, auditService)
+	exchangeRateService := services.NewExchangeRateService(unitOfWork)
+	productSearchService := services.NewProductSearchService(productSearchRepo)
+	sellerService := services.NewSellerService(sellerRepo, sellerMembershipRepo, unitOfWork, auditService)
+	userService := services.NewUserService(userRepo, loginAttemptRepo, passwordHasher, cfg.LoginProtection, unitOfWork, auditService)
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	webhookService := services.NewWebhookService(webhookSubscriptionRepo, webhookDeliveryRepo, webhook.NewHTTPSender(cfg.Webhook.Timeout), cfg.Webhook)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, cfg.Idempotency)
+	runWorker(idempotencyService.Run)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, revokedTokenRepo, cfg.JWT)
+	runWorker(authService.Run)
+	runWorker(services.NewPurgeService(productRepo, sellerRepo, cfg.SoftDelete).Run)
+	healthService := services.NewHealthService(cfg.Server.HealthCheckTimeout, postgres2.NewDatabaseHealthCheck(gormDB), postgres2.NewMigrationHealthCheck(migrator), postgres2.NewSearchIndexHealthCheck(gormDB))
+	if err := roleService.EnsureDefaultRoles(context.Background()); err != nil {
+		log.Fatalf("Failed to create default roles: %v", err)
+	}
 
-	// Initialize JWT config
-	jwtConfig := config.NewJWTConfig()
+	// Deliver the domain events stored by the repositories to in-process subscribers
+	eventDispatcher := services.NewEventDispatcher(outboxRepo, cfg.Outbox)
+	eventDispatcher.SubscribeAll(func(ctx context.Context, event entities.DomainEvent) error {
+		log.Printf("Domain event %s of %s", event.EventName(), event.AggregateId())
+		return nil
+	})
+	eventDispatcher.SubscribeAll(webhookService.HandleDomainEvent)
+	runWorker(eventDispatcher.Run)
+	runWorker(webhookService.Run)
+
+	tokenManager, err := auth.NewTokenManager(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to initialize token manager: %v", err)
+	}
 
 	e := echo.New()
+	// Use the peer address as client IP so that failed logins cannot be
+	// attributed to spoofed X-Forwarded-For addresses
+	e.IPExtractor = echo.ExtractIPDirect()
+	// Answer errors returned by handlers with RFC 7807 problem details
+	e.HTTPErrorHandler = rest.HTTPErrorHandler
+	// Give every request a deadline and a request ID, services receive it through the request context
+	e.Use(middleware.RequestContext(cfg.Request))
 	// Swagger UIのエンドポイントを設定
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	// Initialize controllers
-	rest.NewProductController(e, productService)
-	rest.NewSellerController(e, sellerService)
-	rest.NewAuthController(e, userService, jwtConfig)
-	rest.NewUserController(e, userService)
+	rest.NewHealthController(e, healthService)
+	authMiddleware := middleware.NewAuth(tokenManager, authService, roleService)
+	idempotency := middleware.NewIdempotency(idempotencyService, cfg.Request)
+	rest.NewProductController(e, productService, authMiddleware, idempotency)
+	rest.NewProductSearchController(e, productSearchService)
+	rest.NewSellerController(e, sellerService, authMiddleware, idempotency)
+	rest.NewAuthController(e, userService, authService, tokenManager, authMiddleware)
+	rest.NewUserController(e, userService, roleService, authMiddleware)
+	rest.NewRoleController(e, roleService, authMiddleware)
+	rest.NewExchangeRateController(e, exchangeRateService, authMiddleware)
+	rest.NewWebhookController(e, webhookService, authMiddleware)
+	rest.NewAuditController(e, auditService, authMiddleware)
+
+	go func() {
+		if err := e.Start(cfg.Server.Address); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down, waiting up to %s for requests in flight", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and wait for the requests in flight
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to drain requests in flight: %v", err)
+	}
+
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		log.Printf("Background jobs did not stop in time")
+	}
 
-	if err := e.Start(port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// The connections are closed last, after nothing uses them anymore
+	if sqlDB, err := gormDB.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Failed to close database connections: %v", err)
+		}
 	}
+	log.Printf("Server stopped")
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/migration"
+	postgres2 "github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: marketplace migrate [flags] up | down [steps] | status | create <name>"
+
+// runMigrate runs the migrate command, which manages the schema of the database
+func runMigrate(ctx context.Context, args []string) error {
+	cfg, operands, err := config.LoadWithOperands("marketplace migrate", args, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// New migrations are written to the source tree, so no database is needed
+	if operands[0] == "create" {
+		if len(operands) != 2 {
+			return errors.New(migrateUsage)
+		}
+		migrations, err := postgres2.Migrations()
+		if err != nil {
+			return err
+		}
+		upPath, downPath, err := migration.Create(postgres2.MigrationsDir, operands[1], migrations)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return nil
+	}
+
+	gormDB, err := postgres2.NewConnection(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if sqlDB, err := gormDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	migrator, err := postgres2.NewMigrator(gormDB)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case operands[0] == "up" && len(operands) == 1:
+		migrated, err := migrator.Up(ctx)
+		for _, m := range migrated {
+			fmt.Printf("Applied %s\n", m.ID())
+		}
+		if err == nil && len(migrated) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+	case operands[0] == "down" && len(operands) <= 2:
+		steps := 1
+		if len(operands) == 2 {
+			if steps, err = strconv.Atoi(operands[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", operands[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %s\n", m.ID())
+		}
+		return err
+	case operands[0] == "status" && len(operands) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(out, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := ""
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+		}
+		return out.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
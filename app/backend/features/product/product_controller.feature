@@ -23,3 +23,28 @@
     もし "/api/v1/products/00000000-0000-0000-0000-000000000001"にGETリクエストを送信します
     ならば レスポンスステータスコードは200であるべきです
     かつ レスポンスは商品詳細を含むべきです
+
+  シナリオ: APIを介して商品を更新する
+    前提 システムにID "00000000-0000-0000-0000-000000000001"の商品があります
+    かつ APIのための商品詳細を持っています
+      | 名前           | 価格  |
+      | 更新された商品 | 15.99 |
+    もし 商品詳細を含めて"/api/v1/products/00000000-0000-0000-0000-000000000001"にPUTリクエストを送信します
+    ならば レスポンスステータスコードは200であるべきです
+    かつ レスポンスは更新された商品詳細を含むべきです
+
+  シナリオ: APIを介して商品の価格だけを更新する
+    前提 システムにID "00000000-0000-0000-0000-000000000001"の商品があります
+    かつ APIのための商品詳細を持っています
+      | 価格  |
+      | 12.50 |
+    もし 商品詳細を含めて"/api/v1/products/00000000-0000-0000-0000-000000000001"にPATCHリクエストを送信します
+    ならば レスポンスステータスコードは200であるべきです
+    かつ レスポンスは更新された商品詳細を含むべきです
+
+  シナリオ: APIを介して商品を削除する
+    前提 システムにID "00000000-0000-0000-0000-000000000001"の商品があります
+    もし "/api/v1/products/00000000-0000-0000-0000-000000000001"にDELETEリクエストを送信します
+    ならば レスポンスステータスコードは204であるべきです
+    かつ "/api/v1/products/00000000-0000-0000-0000-000000000001"にGETリクエストを送信します
+    かつ レスポンスステータスコードは404であるべきです
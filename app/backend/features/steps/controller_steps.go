@@ -9,10 +9,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
 	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	domainservices "github.com/sklinkert/go-ddd/internal/domain/services"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
 	"github.com/testcontainers/testcontainers-go"
@@ -21,7 +26,6 @@ import (
 	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -32,6 +36,8 @@ type ControllerContext struct {
 	sellerService     interfaces.SellerService
 	echoInstance      *echo.Echo
 	productController *rest.ProductController
+	accessToken       string
+	userID            string
 	requestBody       map[string]interface{}
 	response          *httptest.ResponseRecorder
 	products          []*entities.Product
@@ -93,9 +99,12 @@ func (c *ControllerContext) setupTestDatabase() error {
 	}
 	c.db = db
 
-	// AutoMigrate our models
-	err = db.AutoMigrate(&postgres.Product{}, &postgres.Seller{})
+	// Apply the schema migrations like the server does
+	migrator, err := postgres.NewMigrator(db)
 	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := migrator.Up(c.ctx); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
@@ -114,6 +123,9 @@ func (c *ControllerContext) RegisterSteps(ctx *godog.ScenarioContext) {
 	ctx.Step(`^レスポンスは商品のリストを含むべきです$`, c.theResponseShouldContainAListOfProducts)
 	ctx.Step(`^システムにID "([^"]*)"の商品があります$`, c.thereIsAProductWithIDInTheSystem)
 	ctx.Step(`^レスポンスは商品詳細を含むべきです$`, c.theResponseShouldContainTheProductDetails)
+	ctx.Step(`^商品詳細を含めて"([^"]*)"に(PUT|PATCH)リクエストを送信します$`, c.iSendARequestToWithTheProductDetails)
+	ctx.Step(`^"([^"]*)"にDELETEリクエストを送信します$`, c.iSendADELETERequestTo)
+	ctx.Step(`^レスポンスは更新された商品詳細を含むべきです$`, c.theResponseShouldContainTheUpdatedProductDetails)
 
 	// Keep English step definitions for backward compatibility
 	ctx.Step(`^I have product details for API$`, c.iHaveProductDetailsForAPI)
@@ -125,6 +137,11 @@ func (c *ControllerContext) RegisterSteps(ctx *godog.ScenarioContext) {
 	ctx.Step(`^the response should contain a list of products$`, c.theResponseShouldContainAListOfProducts)
 	ctx.Step(`^there is a product with ID "([^"]*)" in the system$`, c.thereIsAProductWithIDInTheSystem)
 	ctx.Step(`^the response should contain the product details$`, c.theResponseShouldContainTheProductDetails)
+	ctx.Step(`^I send a (PUT|PATCH) request to "([^"]*)" with the product details$`, func(method, path string) error {
+		return c.iSendARequestToWithTheProductDetails(path, method)
+	})
+	ctx.Step(`^I send a DELETE request to "([^"]*)"$`, c.iSendADELETERequestTo)
+	ctx.Step(`^the response should contain the updated product details$`, c.theResponseShouldContainTheUpdatedProductDetails)
 
 	ctx.BeforeScenario(func(*godog.Scenario) {
 		if err := c.setupController(); err != nil {
@@ -137,6 +154,7 @@ func (c *ControllerContext) RegisterSteps(ctx *godog.ScenarioContext) {
 		if c.db != nil {
 			c.db.Exec("DELETE FROM products")
 			c.db.Exec("DELETE FROM sellers")
+			c.db.Exec("DELETE FROM users")
 		}
 
 		// Stop and remove PostgreSQL container
@@ -157,16 +175,59 @@ func (c *ControllerContext) setupController() error {
 	// Create repositories
 	productRepo := postgres.NewGormProductRepository(c.db)
 	sellerRepo := postgres.NewGormSellerRepository(c.db)
+	unitOfWork := postgres.NewGormUnitOfWork(c.db)
 
 	// Create services
-	c.productService = services.NewProductService(productRepo, sellerRepo)
-	c.sellerService = services.NewSellerService(sellerRepo)
+	sellerMembershipRepo := postgres.NewGormSellerMembershipRepository(c.db)
+	currencyConverter := domainservices.NewCurrencyConverter(postgres.NewGormExchangeRateRepository(c.db))
+	auditService := services.NewAuditService(postgres.NewGormAuditRepository(c.db))
+	c.productService = services.NewProductService(productRepo, sellerRepo, sellerMembershipRepo, currencyConverter, unitOfWork, auditService)
+	c.sellerService = services.NewSellerService(sellerRepo, sellerMembershipRepo, unitOfWork, auditService)
+
+	// Create a seller owner whose access token is sent with write requests
+	userRepo := postgres.NewGormUserRepository(c.db)
+	roleService := services.NewRoleService(postgres.NewGormRoleRepository(c.db), userRepo)
+	if err := roleService.EnsureDefaultRoles(c.ctx); err != nil {
+		return fmt.Errorf("failed to create default roles: %w", err)
+	}
+	user, err := entities.NewUser(uuid.New().String(), "testuser", "test@example.com", "unused-password-hash")
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := user.UpdateRole(entities.RoleSellerOwner); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	if err := userRepo.Save(c.ctx, user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	authService := services.NewAuthService(
+		userRepo,
+		postgres.NewGormRefreshTokenRepository(c.db),
+		postgres.NewGormRevokedTokenRepository(c.db),
+		config.NewJWTConfig(),
+	)
+	tokenManager, err := auth.NewTokenManager(config.NewJWTConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create token manager: %w", err)
+	}
+	c.userID = user.ID
+	if c.accessToken, err = tokenManager.GenerateToken(user.ID, user.Email); err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
 
 	// Create a new Echo instance
 	c.echoInstance = echo.New()
+	c.echoInstance.HTTPErrorHandler = rest.HTTPErrorHandler
 
 	// Create a new product controller
-	c.productController = rest.NewProductController(c.echoInstance, c.productService)
+	idempotencyService := services.NewIdempotencyService(postgres.NewGormIdempotencyRepository(c.db), config.NewIdempotencyConfig())
+	c.productController = rest.NewProductController(
+		c.echoInstance,
+		c.productService,
+		middleware.NewAuth(tokenManager, authService, roleService),
+		middleware.NewIdempotency(idempotencyService),
+	)
 
 	// Initialize the response recorder
 	c.response = httptest.NewRecorder()
@@ -187,8 +248,8 @@ func (c *ControllerContext) iHaveProductDetailsForAPI(table *godog.Table) error
 			case "name", "名前":
 				c.requestBody["Name"] = cell.Value
 			case "price", "価格":
-				// Convert price to float64
-				price, err := strconv.ParseFloat(cell.Value, 64)
+				// Keep the price as exact decimal string
+				price, err := entities.ParseMoney(cell.Value, entities.DefaultCurrency)
 				if err != nil {
 					return fmt.Errorf("failed to parse price: %w", err)
 				}
@@ -209,8 +270,9 @@ func (c *ControllerContext) iSendAPOSTRequestToWithTheProductDetails(path string
 	if sellerID, ok := c.requestBody["SellerId"].(string); ok && sellerID == "00000000-0000-0000-0000-000000000001" {
 		// Create a seller
 		sellerName := "Test Seller " + uuid.New().String()
-		sellerResult, err := c.sellerService.CreateSeller(&command.CreateSellerCommand{
-			Name: sellerName,
+		sellerResult, err := c.sellerService.CreateSeller(c.ctx, &command.CreateSellerCommand{
+			Name:  sellerName,
+			Actor: common.Actor{UserId: c.userID},
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create seller: %w", err)
@@ -229,6 +291,7 @@ func (c *ControllerContext) iSendAPOSTRequestToWithTheProductDetails(path string
 	// Create a new HTTP request
 	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(jsonBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+c.accessToken)
 
 	// Create a new response recorder
 	c.response = httptest.NewRecorder()
@@ -268,8 +331,9 @@ func (c *ControllerContext) theResponseShouldContainTheCreatedProductDetails() e
 func (c *ControllerContext) thereAreProductsInTheSystem() error {
 	// Create a seller first
 	sellerName := "Test Seller " + uuid.New().String()
-	sellerResult, err := c.sellerService.CreateSeller(&command.CreateSellerCommand{
-		Name: sellerName,
+	sellerResult, err := c.sellerService.CreateSeller(c.ctx, &command.CreateSellerCommand{
+		Name:  sellerName,
+		Actor: common.Actor{UserId: c.userID},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create seller: %w", err)
@@ -278,14 +342,15 @@ func (c *ControllerContext) thereAreProductsInTheSystem() error {
 	// Create multiple products
 	for i := 1; i <= 2; i++ {
 		productName := fmt.Sprintf("Product %d", i)
-		productPrice := float64(i*10) + 0.99
+		productPrice := entities.Money{Amount: int64(i*1000 + 99), Currency: entities.CurrencyUSD}
 		createProductCmd := &command.CreateProductCommand{
 			Name:     productName,
 			Price:    productPrice,
 			SellerId: sellerResult.Result.Id,
+			Actor:    common.Actor{UserId: c.userID},
 		}
 
-		_, err := c.productService.CreateProduct(createProductCmd)
+		_, err := c.productService.CreateProduct(c.ctx, createProductCmd)
 		if err != nil {
 			return fmt.Errorf("failed to create product: %w", err)
 		}
@@ -294,14 +359,17 @@ func (c *ControllerContext) thereAreProductsInTheSystem() error {
 	return nil
 }
 
-func (c *ControllerContext) iSendAGETRequestTo(path string) error {
-	// If the path contains a product ID placeholder, replace it with the actual product ID
+// resolvePath replaces the product ID placeholder of the feature files with the ID of the created product
+func (c *ControllerContext) resolvePath(path string) string {
 	if c.products != nil && len(c.products) > 0 && strings.Contains(path, "00000000-0000-0000-0000-000000000001") {
 		path = strings.Replace(path, "00000000-0000-0000-0000-000000000001", c.products[0].Id.String(), 1)
 	}
+	return path
+}
 
+func (c *ControllerContext) iSendAGETRequestTo(path string) error {
 	// Create a new HTTP request
-	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req := httptest.NewRequest(http.MethodGet, c.resolvePath(path), nil)
 
 	// Create a new response recorder
 	c.response = httptest.NewRecorder()
@@ -333,8 +401,9 @@ func (c *ControllerContext) thereIsAProductWithIDInTheSystem(id string) error {
 
 	// Create a seller first
 	sellerName := "Test Seller " + uuid.New().String()
-	sellerResult, err := c.sellerService.CreateSeller(&command.CreateSellerCommand{
-		Name: sellerName,
+	sellerResult, err := c.sellerService.CreateSeller(c.ctx, &command.CreateSellerCommand{
+		Name:  sellerName,
+		Actor: common.Actor{UserId: c.userID},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create seller: %w", err)
@@ -342,14 +411,15 @@ func (c *ControllerContext) thereIsAProductWithIDInTheSystem(id string) error {
 
 	// Create a product
 	productName := "Test Product"
-	productPrice := 10.99
+	productPrice := entities.Money{Amount: 1099, Currency: entities.CurrencyUSD}
 	createProductCmd := &command.CreateProductCommand{
 		Name:     productName,
 		Price:    productPrice,
 		SellerId: sellerResult.Result.Id,
+		Actor:    common.Actor{UserId: c.userID},
 	}
 
-	createResult, err := c.productService.CreateProduct(createProductCmd)
+	createResult, err := c.productService.CreateProduct(c.ctx, createProductCmd)
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
@@ -383,4 +453,56 @@ func (c *ControllerContext) theResponseShouldContainTheProductDetails() error {
 	return nil
 }
 
+func (c *ControllerContext) iSendARequestToWithTheProductDetails(path, method string) error {
+	jsonBody, err := json.Marshal(c.requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req := httptest.NewRequest(method, c.resolvePath(path), bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+c.accessToken)
+
+	c.response = httptest.NewRecorder()
+	c.echoInstance.ServeHTTP(c.response, req)
+
+	return nil
+}
+
+func (c *ControllerContext) iSendADELETERequestTo(path string) error {
+	req := httptest.NewRequest(http.MethodDelete, c.resolvePath(path), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+c.accessToken)
+
+	c.response = httptest.NewRecorder()
+	c.echoInstance.ServeHTTP(c.response, req)
+
+	return nil
+}
+
+func (c *ControllerContext) theResponseShouldContainTheUpdatedProductDetails() error {
+	var productResponse response.ProductResponse
+	if err := json.Unmarshal(c.response.Body.Bytes(), &productResponse); err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+
+	// Fields missing from the request keep the values of the existing product
+	expectedName := c.products[0].Name
+	if name, ok := c.requestBody["Name"].(string); ok {
+		expectedName = name
+	}
+	expectedPrice := c.products[0].Price
+	if price, ok := c.requestBody["Price"].(entities.Money); ok {
+		expectedPrice = price
+	}
+
+	if productResponse.Name != expectedName {
+		return fmt.Errorf("expected product name %s but got %s", expectedName, productResponse.Name)
+	}
+	if productResponse.Price != expectedPrice {
+		return fmt.Errorf("expected product price %s but got %s", expectedPrice, productResponse.Price)
+	}
+
+	return nil
+}
+
 // MockProductService has been replaced with a real ProductService using TestContainers
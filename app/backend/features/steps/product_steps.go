@@ -5,7 +5,6 @@ import (
 	"github.com/cucumber/godog"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
-	"strconv"
 )
 
 // ProductContext holds the state for product-related steps
@@ -90,7 +89,7 @@ func (p *ProductContext) iCreateANewProduct() error {
 	name := p.productDetails["name"]
 	priceStr := p.productDetails["price"]
 
-	price, err := strconv.ParseFloat(priceStr, 64)
+	price, err := entities.ParseMoney(priceStr, entities.DefaultCurrency)
 	if err != nil {
 		return fmt.Errorf("failed to parse price: %w", err)
 	}
@@ -130,7 +129,7 @@ func (p *ProductContext) iHaveAnExistingProduct() error {
 	}
 
 	// Create a sample product for testing
-	p.product = entities.NewProduct("Existing Product", 9.99, *validatedSeller)
+	p.product = entities.NewProduct("Existing Product", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	return nil
 }
 
@@ -162,7 +161,7 @@ func (p *ProductContext) iUpdateTheProductDetails(table *godog.Table) error {
 
 	// Update the product price
 	priceStr := p.productDetails["price"]
-	price, err := strconv.ParseFloat(priceStr, 64)
+	price, err := entities.ParseMoney(priceStr, entities.DefaultCurrency)
 	if err != nil {
 		return fmt.Errorf("failed to parse price: %w", err)
 	}
@@ -183,7 +182,7 @@ func (p *ProductContext) theProductDetailsShouldBeUpdatedInTheSystem() error {
 	}
 
 	priceStr := p.productDetails["price"]
-	expectedPrice, _ := strconv.ParseFloat(priceStr, 64)
+	expectedPrice, _ := entities.ParseMoney(priceStr, entities.DefaultCurrency)
 	if p.product.Price != expectedPrice {
 		return fmt.Errorf("product price was not updated correctly")
 	}
@@ -0,0 +1,14 @@
+package command
+
+import (
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+type AddSellerMemberCommand struct {
+	SellerId uuid.UUID
+	UserId   string
+	Role     entities.SellerMemberRole
+	Actor    common.Actor
+}
@@ -3,15 +3,15 @@ package command
 import (
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
 )
 
 type CreateProductCommand struct {
-	// TODO: Implement idempotency key
-
 	Id       uuid.UUID
 	Name     string
-	Price    float64
+	Price    entities.Money
 	SellerId uuid.UUID
+	Actor    common.Actor
 }
 
 type CreateProductCommandResult struct {
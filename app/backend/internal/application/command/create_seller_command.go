@@ -3,9 +3,9 @@ package command
 import "github.com/sklinkert/go-ddd/internal/application/common"
 
 type CreateSellerCommand struct {
-	// TODO: Implement idempotency key
-
 	Name string
+	// Actor becomes the owner of the new seller
+	Actor common.Actor
 }
 
 type CreateSellerCommandResult struct {
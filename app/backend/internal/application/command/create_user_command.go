@@ -0,0 +1,12 @@
+package command
+
+import "github.com/sklinkert/go-ddd/internal/domain/entities"
+
+type CreateUserCommand struct {
+	Username string
+	Email    string
+	Password string
+	// Role and Status are the defaults of new users if they are empty
+	Role   entities.UserRole
+	Status entities.UserStatus
+}
@@ -0,0 +1,7 @@
+package command
+
+import "github.com/sklinkert/go-ddd/internal/application/common"
+
+type RestoreProductCommandResult struct {
+	Result *common.ProductResult
+}
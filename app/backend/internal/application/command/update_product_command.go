@@ -0,0 +1,22 @@
+package command
+
+import (
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// UpdateProductCommand changes the name and/or price of a product.
+// Fields left nil keep their current value.
+type UpdateProductCommand struct {
+	Id    uuid.UUID
+	Name  *string
+	Price *entities.Money
+	// ExpectedVersion is the version of the product the client read, nil to update any version
+	ExpectedVersion *int
+	Actor           common.Actor
+}
+
+type UpdateProductCommandResult struct {
+	Result *common.ProductResult
+}
@@ -6,10 +6,11 @@ import (
 )
 
 type UpdateSellerCommand struct {
-	// TODO: Implement idempotency key
-
 	Id   uuid.UUID
 	Name string
+	// ExpectedVersion is the version of the seller the client read, nil to update any version
+	ExpectedVersion *int
+	Actor           common.Actor
 }
 
 type UpdateSellerCommandResult struct {
@@ -0,0 +1,12 @@
+package command
+
+// UpdateUserCommand changes the username, email and/or password of a user.
+// Fields left nil keep their current value.
+type UpdateUserCommand struct {
+	Id       string
+	Username *string
+	Email    *string
+	Password *string
+	// ExpectedVersion is the version of the user the client read, nil to update any version
+	ExpectedVersion *int
+}
@@ -0,0 +1,10 @@
+package command
+
+import "github.com/sklinkert/go-ddd/internal/domain/entities"
+
+type UpdateUserRoleCommand struct {
+	Id   string
+	Role entities.UserRole
+	// ExpectedVersion is the version of the user the client read, nil to update any version
+	ExpectedVersion *int
+}
@@ -0,0 +1,12 @@
+package command
+
+import "github.com/sklinkert/go-ddd/internal/domain/entities"
+
+// UpdateUserStatusCommand changes the status of a user, Reason is recorded with the change
+type UpdateUserStatusCommand struct {
+	Id     string
+	Status entities.UserStatus
+	Reason string
+	// ExpectedVersion is the version of the user the client read, nil to update any version
+	ExpectedVersion *int
+}
@@ -0,0 +1,8 @@
+package common
+
+// Actor is the user on whose behalf a command is executed
+type Actor struct {
+	UserId string
+	// ManagesAllSellers is set for staff who may act on behalf of any seller
+	ManagesAllSellers bool
+}
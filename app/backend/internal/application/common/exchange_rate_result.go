@@ -0,0 +1,13 @@
+package common
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+type ExchangeRateResult struct {
+	BaseCurrency  entities.Currency
+	QuoteCurrency entities.Currency
+	Rate          string
+	EffectiveDate time.Time
+}
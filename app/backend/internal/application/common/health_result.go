@@ -0,0 +1,18 @@
+package common
+
+import "time"
+
+// HealthResult is the outcome of the health checks of the server
+type HealthResult struct {
+	// Healthy is true if every check passed
+	Healthy bool
+	Checks  []HealthCheckResult
+}
+
+// HealthCheckResult is the outcome of a single health check
+type HealthCheckResult struct {
+	Name string
+	// Error describes why the check failed, it is empty if the check passed
+	Error    string
+	Duration time.Duration
+}
@@ -0,0 +1,11 @@
+package common
+
+// PageResult describes the page of a listing
+type PageResult struct {
+	Limit  int
+	Offset int
+	// TotalCount is the number of matching rows across all pages
+	TotalCount int64
+	// NextCursor selects the following page, it is empty on the last page
+	NextCursor string
+}
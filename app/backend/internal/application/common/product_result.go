@@ -2,14 +2,22 @@ package common
 
 import (
 	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"time"
 )
 
 type ProductResult struct {
 	Id        uuid.UUID
 	Name      string
-	Price     float64
+	Price     entities.Money
 	Seller    *SellerResult
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set if the product is soft deleted
+	DeletedAt *time.Time
+
+	// ConvertedPrice and ExchangeRate are only set when the price was requested in another currency
+	ConvertedPrice *entities.Money
+	ExchangeRate   *ExchangeRateResult
 }
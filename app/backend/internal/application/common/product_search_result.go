@@ -0,0 +1,10 @@
+package common
+
+// ProductSearchResult is a product matching a search
+type ProductSearchResult struct {
+	Product *ProductResult
+	// Rank is the relevance of the product, higher is better
+	Rank float64
+	// Highlight is the HTML escaped product name with the matching parts wrapped in <mark> tags
+	Highlight string
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"context"
+)
+
+// RequestScope holds the values identifying the request a context belongs to.
+// It is attached to the request context by the API layer, so services and repositories
+// can use it e.g. for logging without passing it explicitly.
+type RequestScope struct {
+	// RequestId correlates the log lines of a request, it is echoed in the X-Request-ID response header
+	RequestId string
+	// UserId is the authenticated user, empty for anonymous requests
+	UserId string
+	// TenantId is the tenant the request was made for, empty if the client did not name one
+	TenantId string
+}
+
+type requestScopeKey struct{}
+
+// WithRequestScope returns a copy of the context carrying the request scope
+func WithRequestScope(ctx context.Context, scope RequestScope) context.Context {
+	return context.WithValue(ctx, requestScopeKey{}, scope)
+}
+
+// RequestScopeFrom returns the request scope of the context, or an empty scope outside of requests
+func RequestScopeFrom(ctx context.Context) RequestScope {
+	scope, _ := ctx.Value(requestScopeKey{}).(RequestScope)
+	return scope
+}
+
+// WithUserId returns a copy of the context whose request scope names the authenticated user
+func WithUserId(ctx context.Context, userId string) context.Context {
+	scope := RequestScopeFrom(ctx)
+	scope.UserId = userId
+	return WithRequestScope(ctx, scope)
+}
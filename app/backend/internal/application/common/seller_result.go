@@ -8,6 +8,9 @@ import (
 type SellerResult struct {
 	Id        uuid.UUID
 	Name      string
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set if the seller is soft deleted
+	DeletedAt *time.Time
 }
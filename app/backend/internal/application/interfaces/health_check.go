@@ -0,0 +1,11 @@
+package interfaces
+
+import "context"
+
+// HealthCheck probes a dependency the server needs to handle requests, e.g. the database
+type HealthCheck interface {
+	// Name identifies the check in health reports
+	Name() string
+	// Check returns an error if the dependency is not usable
+	Check(ctx context.Context) error
+}
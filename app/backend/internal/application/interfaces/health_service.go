@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+)
+
+type HealthService interface {
+	Liveness() *common.HealthResult
+	Readiness(ctx context.Context) *common.HealthResult
+}
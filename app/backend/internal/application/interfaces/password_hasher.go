@@ -0,0 +1,11 @@
+package interfaces
+
+// PasswordHasher hashes and verifies user passwords
+type PasswordHasher interface {
+	// Hash returns an encoded, salted hash of the password
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash using a constant-time comparison
+	Verify(password, encodedHash string) (bool, error)
+	// NeedsRehash reports whether the encoded hash was created with an outdated algorithm or parameters
+	NeedsRehash(encodedHash string) bool
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+)
+
+type ProductSearchService interface {
+	SearchProducts(ctx context.Context, searchQuery *query.SearchProductsQuery) (*query.ProductSearchQueryResult, error)
+}
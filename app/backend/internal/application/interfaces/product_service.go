@@ -1,13 +1,19 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
 )
 
 type ProductService interface {
-	CreateProduct(productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error)
-	FindAllProducts() (*query.ProductQueryListResult, error)
-	FindProductById(id uuid.UUID) (*query.ProductQueryResult, error)
+	CreateProduct(ctx context.Context, productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error)
+	FindAllProducts(ctx context.Context, listQuery *query.ListProductsQuery) (*query.ProductQueryListResult, error)
+	FindProductById(ctx context.Context, id uuid.UUID, displayCurrency entities.Currency) (*query.ProductQueryResult, error)
+	UpdateProduct(ctx context.Context, updateCommand *command.UpdateProductCommand) (*command.UpdateProductCommandResult, error)
+	DeleteProduct(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error
+	RestoreProduct(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreProductCommandResult, error)
 }
@@ -1,15 +1,21 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/query"
 )
 
 type SellerService interface {
-	CreateSeller(sellerCommand *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error)
-	FindAllSellers() (*query.SellerQueryListResult, error)
-	FindSellerById(id uuid.UUID) (*query.SellerQueryResult, error)
-	UpdateSeller(updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error)
-	DeleteSeller(id uuid.UUID) error
+	CreateSeller(ctx context.Context, sellerCommand *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error)
+	FindAllSellers(ctx context.Context, listQuery *query.ListSellersQuery) (*query.SellerQueryListResult, error)
+	FindSellersByMember(ctx context.Context, userId string) (*query.SellerQueryListResult, error)
+	FindSellerById(ctx context.Context, id uuid.UUID) (*query.SellerQueryResult, error)
+	UpdateSeller(ctx context.Context, updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error)
+	DeleteSeller(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error
+	RestoreSeller(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreSellerCommandResult, error)
+	AddSellerMember(ctx context.Context, memberCommand *command.AddSellerMemberCommand) error
+	RemoveSellerMember(ctx context.Context, sellerId uuid.UUID, userId string, actor common.Actor) error
 }
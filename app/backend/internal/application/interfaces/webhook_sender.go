@@ -0,0 +1,17 @@
+package interfaces
+
+import "context"
+
+// WebhookRequest is a signed webhook delivery ready to be posted to an endpoint
+type WebhookRequest struct {
+	URL     string
+	Headers map[string]string
+	Body    []byte
+}
+
+// WebhookSender posts webhook deliveries to partner endpoints
+type WebhookSender interface {
+	// Send posts the request and returns the HTTP status of the response. An error means
+	// the endpoint could not be reached, the status is 0 then.
+	Send(ctx context.Context, request WebhookRequest) (int, error)
+}
@@ -19,7 +19,22 @@ func NewProductResultFromEntity(product *entities.Product) *common.ProductResult
 		Name:      product.Name,
 		Price:     product.Price,
 		Seller:    NewSellerResultFromEntity(&product.Seller),
+		Version:   product.Version,
 		CreatedAt: product.CreatedAt,
 		UpdatedAt: product.UpdatedAt,
+		DeletedAt: product.DeletedAt,
+	}
+}
+
+func NewExchangeRateResultFromEntity(rate *entities.ExchangeRate) *common.ExchangeRateResult {
+	if rate == nil {
+		return nil
+	}
+
+	return &common.ExchangeRateResult{
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+		Rate:          rate.Rate,
+		EffectiveDate: rate.EffectiveDate,
 	}
 }
@@ -17,7 +17,9 @@ func NewSellerResultFromEntity(seller *entities.Seller) *common.SellerResult {
 	return &common.SellerResult{
 		Id:        seller.Id,
 		Name:      seller.Name,
+		Version:   seller.Version,
 		CreatedAt: seller.CreatedAt,
 		UpdatedAt: seller.UpdatedAt,
+		DeletedAt: seller.DeletedAt,
 	}
 }
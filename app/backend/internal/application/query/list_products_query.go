@@ -0,0 +1,15 @@
+package query
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+)
+
+// ListProductsQuery selects a filtered and sorted page of products
+type ListProductsQuery struct {
+	Filter repositories.ProductFilter
+	Sort   repositories.SortOrder
+	Page   repositories.PageRequest
+	// DisplayCurrency converts prices to this currency if set
+	DisplayCurrency entities.Currency
+}
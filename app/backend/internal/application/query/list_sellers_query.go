@@ -0,0 +1,10 @@
+package query
+
+import "github.com/sklinkert/go-ddd/internal/domain/repositories"
+
+// ListSellersQuery selects a filtered and sorted page of sellers
+type ListSellersQuery struct {
+	Filter repositories.SellerFilter
+	Sort   repositories.SortOrder
+	Page   repositories.PageRequest
+}
@@ -8,4 +8,10 @@ type ProductQueryResult struct {
 
 type ProductQueryListResult struct {
 	Result []*common.ProductResult
+	Page   common.PageResult
+}
+
+type ProductSearchQueryResult struct {
+	Result []*common.ProductSearchResult
+	Page   common.PageResult
 }
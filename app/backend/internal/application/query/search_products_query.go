@@ -0,0 +1,10 @@
+package query
+
+import "github.com/sklinkert/go-ddd/internal/domain/repositories"
+
+// SearchProductsQuery selects a page of the products matching a full-text search, best matches first.
+// Search results are ranked, so they can only be paged by offset.
+type SearchProductsQuery struct {
+	Text string
+	Page repositories.PageRequest
+}
@@ -8,4 +8,5 @@ type SellerQueryResult struct {
 
 type SellerQueryListResult struct {
 	Result []*common.SellerResult
+	Page   common.PageResult
 }
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"time"
+)
+
+// AuditService records who changed which aggregate how and lists the audit trail
+type AuditService struct {
+	repository repositories.AuditRepository
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(repository repositories.AuditRepository) *AuditService {
+	return &AuditService{repository: repository}
+}
+
+// Record appends an entry with the changes between the snapshots of the aggregate before and after a command.
+// The entry is written in the transaction of the command, so that the command fails if it cannot be recorded.
+// The actor defaults to the authenticated user of the request, the request ID is taken from the request scope.
+func (s *AuditService) Record(
+	ctx context.Context,
+	tx repositories.Transaction,
+	actorId string,
+	action entities.AuditAction,
+	aggregateType entities.AuditAggregateType,
+	aggregateId string,
+	before, after entities.AuditSnapshot,
+) error {
+	scope := common.RequestScopeFrom(ctx)
+	if actorId == "" {
+		actorId = scope.UserId
+	}
+
+	entry := entities.NewAuditEntry(actorId, action, aggregateType, aggregateId, before, after, scope.RequestId)
+	if err := tx.Audit().Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry %s %s %s: %w", aggregateType, aggregateId, action, err)
+	}
+	return nil
+}
+
+// FindEntries returns one page of the audit entries matching the filter, newest first
+func (s *AuditService) FindEntries(ctx context.Context, filter repositories.AuditFilter, page repositories.PageRequest) (*repositories.AuditPage, error) {
+	return s.repository.FindPage(ctx, filter, page)
+}
+
+// productAuditSnapshot returns the audited fields of a product, nil if there is none
+func productAuditSnapshot(product *entities.Product) entities.AuditSnapshot {
+	if product == nil {
+		return nil
+	}
+	return withDeletedAt(entities.AuditSnapshot{
+		"name":      product.Name,
+		"price":     product.Price.String(),
+		"seller_id": product.Seller.Id.String(),
+	}, product.DeletedAt)
+}
+
+// sellerAuditSnapshot returns the audited fields of a seller, nil if there is none
+func sellerAuditSnapshot(seller *entities.Seller) entities.AuditSnapshot {
+	if seller == nil {
+		return nil
+	}
+	return withDeletedAt(entities.AuditSnapshot{"name": seller.Name}, seller.DeletedAt)
+}
+
+// membershipAuditSnapshot returns the role of a member as the audited field of the seller, nil if there is none
+func membershipAuditSnapshot(membership *entities.SellerMembership) entities.AuditSnapshot {
+	if membership == nil {
+		return nil
+	}
+	return entities.AuditSnapshot{"members." + membership.UserId: string(membership.Role)}
+}
+
+// userAuditSnapshot returns the audited fields of a user, nil if there is none. The password hash is only compared.
+func userAuditSnapshot(user *entities.User) entities.AuditSnapshot {
+	if user == nil {
+		return nil
+	}
+	return entities.AuditSnapshot{
+		"username":      user.Username,
+		"email":         user.Email,
+		"password":      entities.AuditSecret(user.PasswordHash),
+		"role":          string(user.Role),
+		"status":        string(user.Status),
+		"status_reason": user.StatusReason,
+	}
+}
+
+func withDeletedAt(snapshot entities.AuditSnapshot, deletedAt *time.Time) entities.AuditSnapshot {
+	if deletedAt != nil {
+		snapshot["deleted_at"] = deletedAt.UTC().Format(time.RFC3339)
+	}
+	return snapshot
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// MockAuditRepository keeps the entries in the order they were appended, or fails with err if it is set
+type MockAuditRepository struct {
+	entries []*entities.AuditEntry
+	err     error
+}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{}
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, entry *entities.AuditEntry) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *MockAuditRepository) FindPage(ctx context.Context, filter repositories.AuditFilter, page repositories.PageRequest) (*repositories.AuditPage, error) {
+	result := &repositories.AuditPage{}
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		entry := m.entries[i]
+		if filter.AggregateId != "" && entry.AggregateId != filter.AggregateId {
+			continue
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	result.TotalCount = int64(len(result.Entries))
+	return result, nil
+}
+
+func TestAuditService_Record(t *testing.T) {
+	repo := NewMockAuditRepository()
+	service := NewAuditService(repo)
+	tx := &MockUnitOfWork{audit: repo}
+	ctx := common.WithRequestScope(context.Background(), common.RequestScope{RequestId: "request-id", UserId: "user-id"})
+
+	require.NoError(t, service.Record(ctx, tx, "", entities.AuditActionUpdate, entities.AuditAggregateSeller, "seller-id",
+		entities.AuditSnapshot{"name": "Old"}, entities.AuditSnapshot{"name": "New"}))
+	require.NoError(t, service.Record(ctx, tx, "actor-id", entities.AuditActionDelete, entities.AuditAggregateSeller, "seller-id",
+		entities.AuditSnapshot{"name": "New"}, nil))
+
+	require.Len(t, repo.entries, 2)
+	assert.Equal(t, "user-id", repo.entries[0].ActorId, "The actor defaults to the user of the request")
+	assert.Equal(t, "request-id", repo.entries[0].RequestId)
+	assert.Equal(t, []entities.AuditChange{{Field: "name", Before: "Old", After: "New"}}, repo.entries[0].Changes)
+	assert.Equal(t, "actor-id", repo.entries[1].ActorId)
+	assert.Equal(t, []entities.AuditChange{{Field: "name", Before: "New"}}, repo.entries[1].Changes)
+
+	page, err := service.FindEntries(ctx, repositories.AuditFilter{AggregateId: "seller-id"}, repositories.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.TotalCount)
+	assert.Equal(t, entities.AuditActionDelete, page.Entries[0].Action, "Entries are listed newest first")
+}
+
+func TestProductService_RecordsAuditTrail(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: productRepo, sellers: sellerRepo, sellerMemberships: membershipRepo, audit: auditRepo}
+	service := NewProductService(productRepo, sellerRepo, membershipRepo, nil, unitOfWork, NewAuditService(auditRepo))
+	staff := common.Actor{UserId: "staff-id", ManagesAllSellers: true}
+
+	seller := createPersistedSeller(t, sellerRepo)
+	createCommand := getCreateProductCommand(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller))
+	createCommand.Actor = staff
+	created, err := service.CreateProduct(context.Background(), createCommand)
+	require.NoError(t, err)
+	name := "Renamed"
+	_, err = service.UpdateProduct(context.Background(), &command.UpdateProductCommand{Id: created.Result.Id, Name: &name, Actor: staff})
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteProduct(context.Background(), created.Result.Id, nil, staff))
+
+	require.Len(t, auditRepo.entries, 3)
+	for _, entry := range auditRepo.entries {
+		assert.Equal(t, "staff-id", entry.ActorId)
+		assert.Equal(t, entities.AuditAggregateProduct, entry.AggregateType)
+		assert.Equal(t, created.Result.Id.String(), entry.AggregateId)
+	}
+
+	assert.Equal(t, entities.AuditActionCreate, auditRepo.entries[0].Action)
+	assert.Len(t, auditRepo.entries[0].Changes, 3)
+
+	assert.Equal(t, entities.AuditActionUpdate, auditRepo.entries[1].Action)
+	assert.Equal(t, []entities.AuditChange{{Field: "name", Before: "Example", After: "Renamed"}}, auditRepo.entries[1].Changes)
+
+	assert.Equal(t, entities.AuditActionDelete, auditRepo.entries[2].Action)
+	require.Len(t, auditRepo.entries[2].Changes, 1, "A soft delete only sets the deletion time")
+	assert.Equal(t, "deleted_at", auditRepo.entries[2].Changes[0].Field)
+	assert.Nil(t, auditRepo.entries[2].Changes[0].Before)
+}
+
+func TestSellerService_RecordsMemberChanges(t *testing.T) {
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: &MockProductRepository{}, sellers: sellerRepo, sellerMemberships: membershipRepo, users: newMockMemberUsers(), audit: auditRepo}
+	service := NewSellerService(sellerRepo, membershipRepo, unitOfWork, NewAuditService(auditRepo))
+
+	created, err := service.CreateSeller(context.Background(), &command.CreateSellerCommand{Name: "Acme", Actor: testSellerOwner})
+	require.NoError(t, err)
+	sellerId := created.Result.Id
+	require.NoError(t, service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: sellerId, UserId: "member-id", Role: entities.SellerMemberRoleMember, Actor: testSellerOwner,
+	}))
+	require.NoError(t, service.RemoveSellerMember(context.Background(), sellerId, "member-id", testSellerOwner))
+
+	require.Len(t, auditRepo.entries, 3)
+	assert.Equal(t, entities.AuditActionCreate, auditRepo.entries[0].Action)
+	assert.Equal(t, []entities.AuditChange{{Field: "name", After: "Acme"}}, auditRepo.entries[0].Changes)
+	assert.Equal(t, entities.AuditActionAddMember, auditRepo.entries[1].Action)
+	assert.Equal(t, []entities.AuditChange{{Field: "members.member-id", After: "member"}}, auditRepo.entries[1].Changes)
+	assert.Equal(t, entities.AuditActionRemoveMember, auditRepo.entries[2].Action)
+	assert.Equal(t, []entities.AuditChange{{Field: "members.member-id", Before: "member"}}, auditRepo.entries[2].Changes)
+	for _, entry := range auditRepo.entries {
+		assert.Equal(t, testSellerOwner.UserId, entry.ActorId)
+		assert.Equal(t, sellerId.String(), entry.AggregateId)
+	}
+}
+
+func TestUserService_RecordsRedactedPasswordChange(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{users: userRepo, audit: auditRepo}
+	service := NewUserService(userRepo, NewMockLoginAttemptRepository(), newTestPasswordHasher(t), nil, unitOfWork, NewAuditService(auditRepo))
+	user, err := entities.NewUser("user-id", "testuser", "test@example.com", "old-hash")
+	require.NoError(t, err)
+	userRepo.On("FindByID", "user-id").Return(user, nil)
+	userRepo.On("Save", mock.Anything).Return(nil)
+	ctx := common.WithUserId(context.Background(), "admin-id")
+
+	password := "new-password"
+	_, err = service.UpdateUser(ctx, &command.UpdateUserCommand{Id: "user-id", Password: &password})
+	require.NoError(t, err)
+
+	require.Len(t, auditRepo.entries, 1)
+	entry := auditRepo.entries[0]
+	assert.Equal(t, "admin-id", entry.ActorId)
+	assert.Equal(t, entities.AuditAggregateUser, entry.AggregateType)
+	assert.Equal(t, []entities.AuditChange{{Field: "password", Before: entities.AuditRedacted, After: entities.AuditRedacted}}, entry.Changes)
+}
+
+func TestUserService_RecordsAutomaticLockAndUnlock(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	auditRepo := NewMockAuditRepository()
+	loginProtection := config.NewLoginProtectionConfig()
+	loginProtection.MaxFailedAttemptsPerAccount = 1
+	unitOfWork := &MockUnitOfWork{users: userRepo, audit: auditRepo}
+	service := NewUserService(userRepo, NewMockLoginAttemptRepository(), newTestPasswordHasher(t), loginProtection, unitOfWork, NewAuditService(auditRepo))
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	passwordHash, err := service.passwordHasher.Hash("password123")
+	require.NoError(t, err)
+	user, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+	userRepo.On("FindByEmail", user.Email).Return(user, nil)
+	userRepo.On("IncrementFailedLogins", user.ID).Return(user, nil)
+	userRepo.On("Save", user).Return(nil)
+
+	_, err = service.Authenticate(context.Background(), user.Email, "wrong-password", "192.0.2.1")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	now = now.Add(service.loginProtection.AccountLockDuration)
+	_, err = service.Authenticate(context.Background(), user.Email, "password123", "192.0.2.1")
+	require.NoError(t, err)
+
+	require.Len(t, auditRepo.entries, 2)
+	for _, entry := range auditRepo.entries {
+		assert.Equal(t, entities.AuditActionUpdate, entry.Action)
+		assert.Equal(t, "user-id", entry.AggregateId)
+		assert.Empty(t, entry.ActorId, "Automatic changes have no actor")
+	}
+	assert.Contains(t, auditRepo.entries[0].Changes, entities.AuditChange{Field: "status", Before: "active", After: "locked"})
+	assert.Contains(t, auditRepo.entries[1].Changes, entities.AuditChange{Field: "status", Before: "locked", After: "active"})
+}
+
+func TestAuditFailureFailsCommand(t *testing.T) {
+	failure := errors.New("audit trail unavailable")
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	auditRepo := &MockAuditRepository{err: failure}
+	unitOfWork := &MockUnitOfWork{products: &MockProductRepository{}, sellers: sellerRepo, sellerMemberships: membershipRepo, audit: auditRepo}
+	service := NewSellerService(sellerRepo, membershipRepo, unitOfWork, NewAuditService(auditRepo))
+
+	_, err := service.CreateSeller(context.Background(), &command.CreateSellerCommand{Name: "Acme", Actor: testSellerOwner})
+
+	assert.ErrorIs(t, err, failure, "The command is rolled back if it cannot be recorded")
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"time"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = entities.NewError(entities.ErrUnauthorized, "invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+	// The whole token family is revoked because the token has most likely been stolen.
+	ErrRefreshTokenReused = entities.NewError(entities.ErrUnauthorized, "refresh token reuse detected")
+	// ErrTokenRevoked is returned for access tokens which have been revoked
+	ErrTokenRevoked = entities.NewError(entities.ErrUnauthorized, "token has been revoked")
+	// ErrUserNotActive is returned when the user of a token is no longer allowed to sign in
+	ErrUserNotActive = entities.NewError(entities.ErrUnauthorized, "user account is not active")
+)
+
+// refreshTokenBytes is the amount of random bytes in a refresh token
+const refreshTokenBytes = 32
+
+// AuthService manages refresh tokens and the server-side state of issued access tokens
+type AuthService struct {
+	userRepository         repositories.UserRepository
+	refreshTokenRepository repositories.RefreshTokenRepository
+	revokedTokenRepository repositories.RevokedTokenRepository
+	config                 *config.JWTConfig
+	now                    func() time.Time
+}
+
+// NewAuthService creates a new AuthService
+func NewAuthService(
+	userRepository repositories.UserRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	revokedTokenRepository repositories.RevokedTokenRepository,
+	jwtConfig *config.JWTConfig,
+) *AuthService {
+	return &AuthService{
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		revokedTokenRepository: revokedTokenRepository,
+		config:                 jwtConfig,
+		now:                    time.Now,
+	}
+}
+
+// IssueRefreshToken creates a refresh token starting a new token family for the user
+func (s *AuthService) IssueRefreshToken(ctx context.Context, userID string) (string, error) {
+	value, _, err := s.issueRefreshToken(ctx, userID, uuid.New().String())
+	return value, err
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one and returns the user it belongs to.
+// The presented token is revoked; presenting it again revokes the whole token family, even if
+// both presentations are processed concurrently.
+func (s *AuthService) RotateRefreshToken(ctx context.Context, refreshToken string) (*entities.User, string, error) {
+	storedToken, err := s.refreshTokenRepository.FindByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, "", err
+	}
+	if storedToken == nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	now := s.now()
+	if storedToken.IsRevoked() {
+		if storedToken.ReplacedByID != "" {
+			if err := s.refreshTokenRepository.RevokeFamily(ctx, storedToken.FamilyID, now); err != nil {
+				return nil, "", err
+			}
+			return nil, "", ErrRefreshTokenReused
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if storedToken.IsExpired(now) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepository.FindByID(ctx, storedToken.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil || !user.IsActive() {
+		if err := s.refreshTokenRepository.RevokeFamily(ctx, storedToken.FamilyID, now); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrUserNotActive
+	}
+
+	value, newToken, err := s.issueRefreshToken(ctx, user.ID, storedToken.FamilyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Only one rotation of a token can revoke it, any other one is a reuse
+	replaced, err := s.refreshTokenRepository.Replace(ctx, storedToken.ID, newToken.ID, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if !replaced {
+		if err := s.refreshTokenRepository.RevokeFamily(ctx, storedToken.FamilyID, now); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	return user, value, nil
+}
+
+// Logout revokes the refresh token family and the access token of the current session
+func (s *AuthService) Logout(ctx context.Context, refreshToken, accessTokenID string, accessTokenExpiresAt time.Time) error {
+	if refreshToken != "" {
+		storedToken, err := s.refreshTokenRepository.FindByTokenHash(ctx, hashRefreshToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if storedToken != nil {
+			if err := s.refreshTokenRepository.RevokeFamily(ctx, storedToken.FamilyID, s.now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if accessTokenID == "" {
+		return nil
+	}
+
+	return s.revokedTokenRepository.Revoke(ctx, accessTokenID, accessTokenExpiresAt)
+}
+
+// RevokeAllSessions revokes every refresh token of the user
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID string) error {
+	return s.refreshTokenRepository.RevokeAllForUser(ctx, userID, s.now())
+}
+
+// AuthorizeAccessToken checks the server-side state of a cryptographically valid access token
+// and returns the user it was issued for
+func (s *AuthService) AuthorizeAccessToken(ctx context.Context, userID, tokenID string, issuedAt time.Time) (*entities.User, error) {
+	if tokenID != "" {
+		revoked, err := s.revokedTokenRepository.IsRevoked(ctx, tokenID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.AcceptsTokenIssuedAt(issuedAt) {
+		return nil, ErrUserNotActive
+	}
+
+	return user, nil
+}
+
+// PurgeExpiredTokens removes refresh tokens and revocation entries which can no longer be used
+func (s *AuthService) PurgeExpiredTokens(ctx context.Context) error {
+	now := s.now()
+	if err := s.refreshTokenRepository.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	return s.revokedTokenRepository.DeleteExpired(ctx, now)
+}
+
+// Run purges expired tokens every PurgeInterval until the context is cancelled
+func (s *AuthService) Run(ctx context.Context) {
+	runPolling(ctx, s.config.PurgeInterval, 1, "purge expired tokens", func(ctx context.Context) (int, error) {
+		return 0, s.PurgeExpiredTokens(ctx)
+	})
+}
+
+func (s *AuthService) issueRefreshToken(ctx context.Context, userID, familyID string) (string, *entities.RefreshToken, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(buf)
+
+	token, err := entities.NewRefreshToken(
+		uuid.New().String(),
+		userID,
+		familyID,
+		hashRefreshToken(value),
+		s.now().Add(s.config.RefreshTokenExpiry),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.refreshTokenRepository.Save(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	return value, token, nil
+}
+
+// hashRefreshToken hashes a refresh token for storage. Refresh tokens carry 256 bits of
+// randomness, so a fast unsalted hash is sufficient to protect them at rest.
+func hashRefreshToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
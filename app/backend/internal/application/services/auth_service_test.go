@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// MockRefreshTokenRepository is an in-memory implementation of the RefreshTokenRepository interface
+type MockRefreshTokenRepository struct {
+	tokens map[string]*entities.RefreshToken
+}
+
+func NewMockRefreshTokenRepository() *MockRefreshTokenRepository {
+	return &MockRefreshTokenRepository{tokens: make(map[string]*entities.RefreshToken)}
+}
+
+func (m *MockRefreshTokenRepository) Save(ctx context.Context, token *entities.RefreshToken) error {
+	stored := *token
+	m.tokens[token.ID] = &stored
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRefreshTokenRepository) Replace(ctx context.Context, id, replacedByID string, revokedAt time.Time) (bool, error) {
+	token, ok := m.tokens[id]
+	if !ok || token.IsRevoked() {
+		return false, nil
+	}
+	token.ReplaceWith(replacedByID, revokedAt)
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID {
+			token.Revoke(revokedAt)
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			token.Revoke(revokedAt)
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	for id, token := range m.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(m.tokens, id)
+		}
+	}
+	return nil
+}
+
+// MockRevokedTokenRepository is an in-memory implementation of the RevokedTokenRepository interface
+type MockRevokedTokenRepository struct {
+	revoked map[string]time.Time
+}
+
+func NewMockRevokedTokenRepository() *MockRevokedTokenRepository {
+	return &MockRevokedTokenRepository{revoked: make(map[string]time.Time)}
+}
+
+func (m *MockRevokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	m.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (m *MockRevokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	_, ok := m.revoked[tokenID]
+	return ok, nil
+}
+
+func (m *MockRevokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	for id, expiresAt := range m.revoked {
+		if expiresAt.Before(before) {
+			delete(m.revoked, id)
+		}
+	}
+	return nil
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *MockUserRepository, *MockRefreshTokenRepository, *entities.User) {
+	userRepo := new(MockUserRepository)
+	refreshTokenRepo := NewMockRefreshTokenRepository()
+	cfg := config.NewJWTConfig()
+	cfg.RefreshTokenExpiry = time.Hour
+	service := NewAuthService(userRepo, refreshTokenRepo, NewMockRevokedTokenRepository(), cfg)
+
+	user, err := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	require.NoError(t, err)
+	userRepo.On("FindByID", user.ID).Return(user, nil)
+
+	return service, userRepo, refreshTokenRepo, user
+}
+
+func TestAuthService_RotateRefreshToken(t *testing.T) {
+	service, _, refreshTokenRepo, user := newTestAuthService(t)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	rotatedUser, rotatedToken, err := service.RotateRefreshToken(context.Background(), refreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, rotatedUser.ID)
+	assert.NotEqual(t, refreshToken, rotatedToken)
+
+	// The rotated token can be used once more
+	_, _, err = service.RotateRefreshToken(context.Background(), rotatedToken)
+	assert.NoError(t, err)
+
+	// Only hashes are stored
+	for _, token := range refreshTokenRepo.tokens {
+		assert.NotEqual(t, refreshToken, token.TokenHash)
+	}
+}
+
+func TestAuthService_RotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	service, _, _, user := newTestAuthService(t)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	_, rotatedToken, err := service.RotateRefreshToken(context.Background(), refreshToken)
+	require.NoError(t, err)
+
+	// Presenting the already rotated token again is treated as theft
+	_, _, err = service.RotateRefreshToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// The legitimate successor has been revoked as well
+	_, _, err = service.RotateRefreshToken(context.Background(), rotatedToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+// staleRefreshTokenRepository returns a token as it was read before a concurrent rotation revoked it
+type staleRefreshTokenRepository struct {
+	*MockRefreshTokenRepository
+	stale *entities.RefreshToken
+}
+
+func (r *staleRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	if tokenHash == r.stale.TokenHash {
+		found := *r.stale
+		return &found, nil
+	}
+	return r.MockRefreshTokenRepository.FindByTokenHash(ctx, tokenHash)
+}
+
+func TestAuthService_RotateRefreshToken_ConcurrentReuse(t *testing.T) {
+	service, _, refreshTokenRepo, user := newTestAuthService(t)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+	stale, err := refreshTokenRepo.FindByTokenHash(context.Background(), hashRefreshToken(refreshToken))
+	require.NoError(t, err)
+
+	_, rotatedToken, err := service.RotateRefreshToken(context.Background(), refreshToken)
+	require.NoError(t, err)
+
+	// The second rotation read the token before the first one revoked it
+	service.refreshTokenRepository = &staleRefreshTokenRepository{MockRefreshTokenRepository: refreshTokenRepo, stale: stale}
+	_, _, err = service.RotateRefreshToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// The family is revoked, so the token of the first rotation is rejected as well
+	service.refreshTokenRepository = refreshTokenRepo
+	_, _, err = service.RotateRefreshToken(context.Background(), rotatedToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthService_RotateRefreshToken_Invalid(t *testing.T) {
+	service, _, _, user := newTestAuthService(t)
+
+	_, _, err := service.RotateRefreshToken(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, _, err = service.RotateRefreshToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthService_RotateRefreshToken_LockedUser(t *testing.T) {
+	service, _, _, user := newTestAuthService(t)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, user.UpdateStatus(entities.StatusLocked))
+
+	_, _, err = service.RotateRefreshToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrUserNotActive)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	service, _, _, user := newTestAuthService(t)
+
+	refreshToken, err := service.IssueRefreshToken(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	issuedAt := time.Now()
+	_, err = service.AuthorizeAccessToken(context.Background(), user.ID, "token-id", issuedAt)
+	require.NoError(t, err)
+
+	err = service.Logout(context.Background(), refreshToken, "token-id", issuedAt.Add(time.Minute))
+	require.NoError(t, err)
+
+	_, err = service.AuthorizeAccessToken(context.Background(), user.ID, "token-id", issuedAt)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, _, err = service.RotateRefreshToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthService_AuthorizeAccessToken_StatusChangedAfterIssue(t *testing.T) {
+	service, _, _, user := newTestAuthService(t)
+
+	issuedAt := time.Now().Add(-time.Minute)
+
+	require.NoError(t, user.UpdateStatus(entities.StatusLocked))
+	_, err := service.AuthorizeAccessToken(context.Background(), user.ID, "token-id", issuedAt)
+	assert.ErrorIs(t, err, ErrUserNotActive)
+
+	// Unlocking the user does not revive tokens issued before the lock
+	require.NoError(t, user.UpdateStatus(entities.StatusActive))
+	_, err = service.AuthorizeAccessToken(context.Background(), user.ID, "token-id", issuedAt)
+	assert.ErrorIs(t, err, ErrUserNotActive)
+
+	_, err = service.AuthorizeAccessToken(context.Background(), user.ID, "new-token-id", time.Now())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"log"
+	"sync"
+	"time"
+)
+
+// DomainEventHandler handles a delivered domain event. Events are delivered at least once,
+// an event is delivered again to all its handlers if one of them fails, so handlers must tolerate duplicates.
+type DomainEventHandler func(ctx context.Context, event entities.DomainEvent) error
+
+// EventDispatcher delivers the domain events stored in the outbox to in-process subscribers.
+// Failed deliveries are retried with exponential backoff until MaxAttempts is reached.
+type EventDispatcher struct {
+	outboxRepository repositories.OutboxRepository
+	config           *config.OutboxConfig
+	now              func() time.Time
+
+	mu sync.RWMutex
+	// handlers by event name, the handlers under "" receive every event
+	handlers map[string][]DomainEventHandler
+}
+
+// NewEventDispatcher creates a new EventDispatcher
+func NewEventDispatcher(outboxRepository repositories.OutboxRepository, outboxConfig *config.OutboxConfig) *EventDispatcher {
+	return &EventDispatcher{
+		outboxRepository: outboxRepository,
+		config:           outboxConfig,
+		now:              time.Now,
+		handlers:         map[string][]DomainEventHandler{},
+	}
+}
+
+// Subscribe registers the handler for the events with the given name, e.g. entities.EventProductCreated
+func (d *EventDispatcher) Subscribe(eventName string, handler DomainEventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventName] = append(d.handlers[eventName], handler)
+}
+
+// SubscribeAll registers the handler for every event
+func (d *EventDispatcher) SubscribeAll(handler DomainEventHandler) {
+	d.Subscribe("", handler)
+}
+
+// Run dispatches due events every PollInterval until the context is cancelled
+func (d *EventDispatcher) Run(ctx context.Context) {
+	runPolling(ctx, d.config.PollInterval, d.config.BatchSize, "dispatch domain events", d.DispatchDue)
+}
+
+// DispatchDue delivers one batch of due events and returns the number of events it tried to deliver
+func (d *EventDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	messages, err := d.outboxRepository.FindDue(ctx, d.now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		// Events which cannot be decoded will never succeed, so they are given up at once
+		event, decodeErr := entities.DecodeDomainEvent(message.EventName, message.Payload)
+		if decodeErr != nil {
+			err = d.outboxRepository.MarkFailed(ctx, message.Id, decodeErr, time.Time{})
+		} else if deliveryErr := d.deliver(ctx, event); deliveryErr != nil {
+			err = d.outboxRepository.MarkFailed(ctx, message.Id, deliveryErr, d.retryAt(message))
+		} else {
+			err = d.outboxRepository.MarkDelivered(ctx, message.Id, d.now())
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update outbox message %d: %w", message.Id, err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+// deliver calls all handlers of the event, a panicking handler counts as failed
+func (d *EventDispatcher) deliver(ctx context.Context, event entities.DomainEvent) (err error) {
+	d.mu.RLock()
+	handlers := append(append([]DomainEventHandler{}, d.handlers[event.EventName()]...), d.handlers[""]...)
+	d.mu.RUnlock()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler of %s panicked: %v", event.EventName(), recovered)
+		}
+	}()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryAt returns when to deliver the message again after it failed, or the zero time to give up
+func (d *EventDispatcher) retryAt(message *repositories.OutboxMessage) time.Time {
+	attempts := message.Attempts + 1
+	if attempts >= d.config.MaxAttempts {
+		log.Printf("Giving up on domain event %d (%s) after %d attempts", message.Id, message.EventName, attempts)
+		return time.Time{}
+	}
+
+	return d.now().Add(backoffDelay(attempts, d.config.RetryBaseDelay, d.config.MaxRetryDelay))
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"testing"
+	"time"
+)
+
+// MockOutboxRepository is an in-memory implementation of the OutboxRepository interface
+type MockOutboxRepository struct {
+	messages  []*repositories.OutboxMessage
+	delivered map[int64]bool
+	retryAt   map[int64]time.Time
+	failed    map[int64]bool
+}
+
+func NewMockOutboxRepository(events ...entities.DomainEvent) *MockOutboxRepository {
+	repo := &MockOutboxRepository{delivered: map[int64]bool{}, retryAt: map[int64]time.Time{}, failed: map[int64]bool{}}
+	for i, event := range events {
+		payload, _ := json.Marshal(event)
+		repo.messages = append(repo.messages, &repositories.OutboxMessage{
+			Id:        int64(i + 1),
+			EventName: event.EventName(),
+			Payload:   payload,
+		})
+	}
+	return repo
+}
+
+func (m *MockOutboxRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*repositories.OutboxMessage, error) {
+	var due []*repositories.OutboxMessage
+	for _, message := range m.messages {
+		if !m.delivered[message.Id] && !m.failed[message.Id] && !m.retryAt[message.Id].After(now) && len(due) < limit {
+			due = append(due, message)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockOutboxRepository) MarkDelivered(ctx context.Context, id int64, at time.Time) error {
+	m.delivered[id] = true
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, deliveryErr error, retryAt time.Time) error {
+	for _, message := range m.messages {
+		if message.Id == id {
+			message.Attempts++
+		}
+	}
+	if retryAt.IsZero() {
+		m.failed[id] = true
+	}
+	m.retryAt[id] = retryAt
+	return nil
+}
+
+func newTestEventDispatcher(repo *MockOutboxRepository, now *time.Time) *EventDispatcher {
+	dispatcher := NewEventDispatcher(repo, &config.OutboxConfig{
+		PollInterval:   time.Second,
+		BatchSize:      10,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		MaxRetryDelay:  time.Minute,
+	})
+	dispatcher.now = func() time.Time { return *now }
+	return dispatcher
+}
+
+func TestEventDispatcher_DispatchDue(t *testing.T) {
+	seller := entities.NewSeller("Seller")
+	repo := NewMockOutboxRepository(seller.PendingEvents()[0], entities.UserRegistered{UserId: "user-1"})
+	now := time.Now()
+	dispatcher := newTestEventDispatcher(repo, &now)
+
+	var sellerEvents []entities.SellerCreated
+	var allEvents []string
+	dispatcher.Subscribe(entities.EventSellerCreated, func(_ context.Context, event entities.DomainEvent) error {
+		sellerEvents = append(sellerEvents, event.(entities.SellerCreated))
+		return nil
+	})
+	dispatcher.SubscribeAll(func(_ context.Context, event entities.DomainEvent) error {
+		allEvents = append(allEvents, event.EventName())
+		return nil
+	})
+
+	dispatched, err := dispatcher.DispatchDue(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if dispatched != 2 || !repo.delivered[1] || !repo.delivered[2] {
+		t.Errorf("Expected both events to be delivered, got %d (%v)", dispatched, repo.delivered)
+	}
+	if len(sellerEvents) != 1 || sellerEvents[0].SellerId != seller.Id {
+		t.Errorf("Expected the SellerCreated event of the seller, but got %+v", sellerEvents)
+	}
+	if len(allEvents) != 2 {
+		t.Errorf("Expected the catch-all subscriber to receive both events, but got %v", allEvents)
+	}
+}
+
+func TestEventDispatcher_RetriesFailedDeliveries(t *testing.T) {
+	repo := NewMockOutboxRepository(entities.UserRegistered{UserId: "user-1"})
+	now := time.Now()
+	dispatcher := newTestEventDispatcher(repo, &now)
+
+	calls := 0
+	dispatcher.Subscribe(entities.EventUserRegistered, func(_ context.Context, event entities.DomainEvent) error {
+		calls++
+		if calls == 1 {
+			panic("subscriber crashed")
+		}
+		return errors.New("subscriber unavailable")
+	})
+
+	// The first failure is retried after the base delay
+	_, _ = dispatcher.DispatchDue(context.Background())
+	if !repo.retryAt[1].Equal(now.Add(time.Second)) {
+		t.Errorf("Expected a retry after one second, but got %s", repo.retryAt[1].Sub(now))
+	}
+
+	// Nothing is due before the retry
+	_, _ = dispatcher.DispatchDue(context.Background())
+	if calls != 1 {
+		t.Errorf("Expected no delivery before the retry, but got %d calls", calls)
+	}
+
+	// The delay doubles
+	now = now.Add(time.Second)
+	_, _ = dispatcher.DispatchDue(context.Background())
+	if !repo.retryAt[1].Equal(now.Add(2 * time.Second)) {
+		t.Errorf("Expected a retry after two seconds, but got %s", repo.retryAt[1].Sub(now))
+	}
+
+	// MaxAttempts gives up
+	now = now.Add(2 * time.Second)
+	_, _ = dispatcher.DispatchDue(context.Background())
+	if calls != 3 || !repo.failed[1] || repo.delivered[1] {
+		t.Errorf("Expected to give up after 3 attempts, got %d calls (failed %v)", calls, repo.failed[1])
+	}
+}
+
+func TestEventDispatcher_GivesUpOnUnknownEvents(t *testing.T) {
+	repo := NewMockOutboxRepository()
+	repo.messages = append(repo.messages, &repositories.OutboxMessage{Id: 1, EventName: "order.unknown", Payload: []byte("{}")})
+	now := time.Now()
+	dispatcher := newTestEventDispatcher(repo, &now)
+
+	_, _ = dispatcher.DispatchDue(context.Background())
+	if !repo.failed[1] {
+		t.Error("Expected an undecodable event to be given up at once")
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"io"
+	"strings"
+	"time"
+)
+
+// exchangeRateCSVHeader is the expected header of rate files, e.g. "2024-05-01,EUR,USD,1.0712"
+var exchangeRateCSVHeader = []string{"date", "base", "quote", "rate"}
+
+// ErrInvalidExchangeRateFile is returned when a rate file cannot be imported. Nothing is saved in that case.
+var ErrInvalidExchangeRateFile = entities.NewError(entities.ErrValidation, "invalid exchange rate file")
+
+// ExchangeRateService loads exchange rates
+type ExchangeRateService struct {
+	unitOfWork repositories.UnitOfWork
+}
+
+// NewExchangeRateService creates a new ExchangeRateService
+func NewExchangeRateService(unitOfWork repositories.UnitOfWork) *ExchangeRateService {
+	return &ExchangeRateService{unitOfWork: unitOfWork}
+}
+
+// ImportRatesCSV loads daily rates from CSV with the columns date (YYYY-MM-DD), base, quote and rate.
+// All rows are validated before any is saved and the rates are saved in one transaction,
+// so a bad file or a failing write is rejected as a whole.
+// A rate for an already loaded pair and date replaces the existing one. Returns the number of rates saved.
+func (s *ExchangeRateService) ImportRatesCSV(ctx context.Context, reader io.Reader) (int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = len(exchangeRateCSVHeader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("%w: file is empty", ErrInvalidExchangeRateFile)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidExchangeRateFile, err)
+	}
+	for i, column := range exchangeRateCSVHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), column) {
+			return 0, fmt.Errorf("%w: header must be %q", ErrInvalidExchangeRateFile, strings.Join(exchangeRateCSVHeader, ","))
+		}
+	}
+
+	var rates []*entities.ExchangeRate
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidExchangeRateFile, err)
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		rate, err := parseExchangeRateRecord(record)
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrInvalidExchangeRateFile, line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		for _, rate := range rates {
+			if err := tx.ExchangeRates().Save(ctx, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func parseExchangeRateRecord(record []string) (*entities.ExchangeRate, error) {
+	effectiveDate, err := time.Parse(time.DateOnly, strings.TrimSpace(record[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", record[0])
+	}
+
+	base, err := entities.ParseCurrency(record[1])
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := entities.ParseCurrency(record[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.NewExchangeRate(base, quote, record[3], effectiveDate)
+}
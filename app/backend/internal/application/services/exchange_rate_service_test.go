@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockExchangeRateRepository is a mock implementation of the ExchangeRateRepository interface
+type MockExchangeRateRepository struct {
+	rates []*entities.ExchangeRate
+	// saveErr fails saving rates of this quote currency
+	saveErr      error
+	saveErrQuote entities.Currency
+}
+
+func (m *MockExchangeRateRepository) Save(ctx context.Context, rate *entities.ExchangeRate) error {
+	if m.saveErr != nil && rate.QuoteCurrency == m.saveErrQuote {
+		return m.saveErr
+	}
+	for index, r := range m.rates {
+		if r.BaseCurrency == rate.BaseCurrency && r.QuoteCurrency == rate.QuoteCurrency && r.EffectiveDate.Equal(rate.EffectiveDate) {
+			m.rates[index] = rate
+			return nil
+		}
+	}
+	m.rates = append(m.rates, rate)
+	return nil
+}
+
+func (m *MockExchangeRateRepository) FindEffective(ctx context.Context, base, quote entities.Currency, at time.Time) (*entities.ExchangeRate, error) {
+	var effective *entities.ExchangeRate
+	for _, rate := range m.rates {
+		if rate.BaseCurrency != base || rate.QuoteCurrency != quote || rate.EffectiveDate.After(at) {
+			continue
+		}
+		if effective == nil || rate.EffectiveDate.After(effective.EffectiveDate) {
+			effective = rate
+		}
+	}
+	return effective, nil
+}
+
+// rollbackUnitOfWork discards the exchange rates saved in a transaction which fails
+type rollbackUnitOfWork struct {
+	*MockUnitOfWork
+}
+
+func newExchangeRateUnitOfWork(repo *MockExchangeRateRepository) *rollbackUnitOfWork {
+	return &rollbackUnitOfWork{MockUnitOfWork: &MockUnitOfWork{exchangeRates: repo}}
+}
+
+func (u *rollbackUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	committed := append([]*entities.ExchangeRate(nil), u.exchangeRates.rates...)
+	if err := fn(u.MockUnitOfWork); err != nil {
+		u.exchangeRates.rates = committed
+		return err
+	}
+	return nil
+}
+
+func TestExchangeRateService_ImportRatesCSV(t *testing.T) {
+	repo := &MockExchangeRateRepository{}
+	service := NewExchangeRateService(newExchangeRateUnitOfWork(repo))
+
+	csv := "date,base,quote,rate\n" +
+		"2024-05-01,EUR,USD,1.0712\n" +
+		"2024-05-01, usd, jpy, 151.37\n" +
+		"2024-05-02,EUR,USD,1.0725\n"
+
+	imported, err := service.ImportRatesCSV(context.Background(), strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if imported != 3 || len(repo.rates) != 3 {
+		t.Errorf("Expected 3 imported rates, but got %d (stored %d)", imported, len(repo.rates))
+	}
+
+	rate, _ := repo.FindEffective(context.Background(), entities.CurrencyUSD, entities.CurrencyJPY, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if rate == nil || rate.Rate != "151.37" {
+		t.Errorf("Expected USD/JPY rate 151.37, but got %v", rate)
+	}
+
+	// Loading the same day again replaces the rate
+	if _, err := service.ImportRatesCSV(context.Background(), strings.NewReader("date,base,quote,rate\n2024-05-02,EUR,USD,1.08\n")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rate, _ = repo.FindEffective(context.Background(), entities.CurrencyEUR, entities.CurrencyUSD, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	if len(repo.rates) != 3 || rate == nil || rate.Rate != "1.08" {
+		t.Errorf("Expected EUR/USD rate to be replaced with 1.08, but got %v", rate)
+	}
+}
+
+func TestExchangeRateService_ImportRatesCSV_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		contains string
+	}{
+		{"empty file", "", "empty"},
+		{"wrong header", "day,from,to,rate\n", "header"},
+		{"invalid date", "date,base,quote,rate\n2024-05-01,EUR,USD,1.07\n01.05.2024,EUR,USD,1.07\n", "line 3"},
+		{"unsupported currency", "date,base,quote,rate\n2024-05-01,EUR,XXX,1.07\n", "line 2"},
+		{"invalid rate", "date,base,quote,rate\n2024-05-01,EUR,USD,-1\n", "line 2"},
+		{"missing column", "date,base,quote,rate\n2024-05-01,EUR,USD\n", "wrong number of fields"},
+	}
+
+	for _, test := range tests {
+		repo := &MockExchangeRateRepository{}
+		service := NewExchangeRateService(newExchangeRateUnitOfWork(repo))
+
+		_, err := service.ImportRatesCSV(context.Background(), strings.NewReader(test.csv))
+		if !errors.Is(err, ErrInvalidExchangeRateFile) {
+			t.Errorf("%s: expected ErrInvalidExchangeRateFile, but got %v", test.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("%s: expected error to mention %q, but got %s", test.name, test.contains, err)
+		}
+		if len(repo.rates) != 0 {
+			t.Errorf("%s: expected no rates to be saved, but got %d", test.name, len(repo.rates))
+		}
+	}
+}
+
+func TestExchangeRateService_ImportRatesCSV_SaveFails(t *testing.T) {
+	saveErr := errors.New("connection lost")
+	repo := &MockExchangeRateRepository{saveErr: saveErr, saveErrQuote: entities.CurrencyJPY}
+	service := NewExchangeRateService(newExchangeRateUnitOfWork(repo))
+
+	csv := "date,base,quote,rate\n" +
+		"2024-05-01,EUR,USD,1.0712\n" +
+		"2024-05-01,USD,JPY,151.37\n"
+
+	imported, err := service.ImportRatesCSV(context.Background(), strings.NewReader(csv))
+	if !errors.Is(err, saveErr) {
+		t.Errorf("Expected the save error, but got %v", err)
+	}
+	if imported != 0 || len(repo.rates) != 0 {
+		t.Errorf("Expected no rates to be kept, but got %d (stored %d)", imported, len(repo.rates))
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"sync"
+	"time"
+)
+
+// HealthService reports whether the server is alive and whether it is ready to handle requests
+type HealthService struct {
+	checks []interfaces.HealthCheck
+	// timeout limits how long a single check may take
+	timeout time.Duration
+}
+
+// NewHealthService creates a new HealthService which runs the checks to determine readiness
+func NewHealthService(timeout time.Duration, checks ...interfaces.HealthCheck) *HealthService {
+	return &HealthService{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Liveness reports that the server is able to answer, it does not depend on other systems
+func (s *HealthService) Liveness() *common.HealthResult {
+	return &common.HealthResult{Healthy: true, Checks: []common.HealthCheckResult{}}
+}
+
+// Readiness runs all checks concurrently and reports whether every one passed
+func (s *HealthService) Readiness(ctx context.Context) *common.HealthResult {
+	result := &common.HealthResult{Healthy: true, Checks: make([]common.HealthCheckResult, len(s.checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Checks[i] = s.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range result.Checks {
+		if check.Error != "" {
+			result.Healthy = false
+		}
+	}
+	return result
+}
+
+func (s *HealthService) run(ctx context.Context, check interfaces.HealthCheck) common.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check.Check(ctx)
+	result := common.HealthCheckResult{Name: check.Name(), Duration: time.Since(started)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
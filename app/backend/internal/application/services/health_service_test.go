@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubHealthCheck fails with err, or when it takes longer than the timeout of the service
+type stubHealthCheck struct {
+	name  string
+	delay time.Duration
+	err   error
+}
+
+func (c *stubHealthCheck) Name() string {
+	return c.name
+}
+
+func (c *stubHealthCheck) Check(ctx context.Context) error {
+	select {
+	case <-time.After(c.delay):
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestHealthService_Readiness(t *testing.T) {
+	service := NewHealthService(time.Second, &stubHealthCheck{name: "database"}, &stubHealthCheck{name: "migrations"})
+
+	result := service.Readiness(context.Background())
+
+	assert.True(t, result.Healthy)
+	assert.Len(t, result.Checks, 2)
+	assert.Equal(t, "database", result.Checks[0].Name)
+	assert.Empty(t, result.Checks[0].Error)
+	assert.Equal(t, "migrations", result.Checks[1].Name)
+}
+
+func TestHealthService_ReadinessReportsFailedChecks(t *testing.T) {
+	service := NewHealthService(50*time.Millisecond,
+		&stubHealthCheck{name: "database", delay: time.Minute},
+		&stubHealthCheck{name: "migrations", err: errors.New("pending migrations of products")},
+		&stubHealthCheck{name: "cache"},
+	)
+
+	started := time.Now()
+	result := service.Readiness(context.Background())
+
+	assert.Less(t, time.Since(started), time.Second)
+	assert.False(t, result.Healthy)
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Checks[0].Error)
+	assert.Equal(t, "pending migrations of products", result.Checks[1].Error)
+	assert.Empty(t, result.Checks[2].Error)
+}
+
+func TestHealthService_Liveness(t *testing.T) {
+	service := NewHealthService(time.Second, &stubHealthCheck{name: "database", err: errors.New("connection refused")})
+
+	assert.True(t, service.Liveness().Healthy)
+}
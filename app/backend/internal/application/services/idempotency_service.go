@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with another request
+	ErrIdempotencyKeyReused = entities.NewError(entities.ErrConflict, "idempotency key was already used for another request")
+	// ErrIdempotentRequestInProgress is returned when a request is retried before the first attempt completed
+	ErrIdempotentRequestInProgress = entities.NewError(entities.ErrConflict, "a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService makes retries of requests sent with an idempotency key safe: the first request
+// reserves the key and records its response, retries get the recorded response until the key expires.
+type IdempotencyService struct {
+	repository repositories.IdempotencyRepository
+	config     *config.IdempotencyConfig
+	now        func() time.Time
+}
+
+// NewIdempotencyService creates a new IdempotencyService
+func NewIdempotencyService(repository repositories.IdempotencyRepository, idempotencyConfig *config.IdempotencyConfig) *IdempotencyService {
+	return &IdempotencyService{
+		repository: repository,
+		config:     idempotencyConfig,
+		now:        time.Now,
+	}
+}
+
+// Begin reserves the key of the user for a request to the route. If the request was already sent with the key,
+// it returns the completed record of the first attempt, whose response is replayed. Otherwise it returns a new record
+// which the caller completes with Complete, or releases with Abort if the request should be retried.
+// It returns ErrIdempotencyKeyReused if the key was used for another request and ErrIdempotentRequestInProgress
+// if the first attempt did not complete yet.
+func (s *IdempotencyService) Begin(ctx context.Context, key, userId, route, requestHash string) (*entities.IdempotencyRecord, error) {
+	record, err := entities.NewIdempotencyRecord(key, userId, route, requestHash, s.now(), s.config.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.Reserve(ctx, record)
+	if err != nil || existing == nil {
+		return record, err
+	}
+
+	if !existing.Matches(requestHash) {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.IsCompleted() {
+		return existing, nil
+	}
+	if s.now().Sub(existing.CreatedAt) < s.config.LockTimeout {
+		return nil, ErrIdempotentRequestInProgress
+	}
+
+	// The first attempt abandoned the key, e.g. because the server stopped, so this attempt takes it over
+	if err := s.repository.Delete(ctx, existing); err != nil {
+		return nil, err
+	}
+	if existing, err = s.repository.Reserve(ctx, record); err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrIdempotentRequestInProgress
+	}
+	return record, nil
+}
+
+// Complete records the response to the request, it is replayed to retries until the key expires
+func (s *IdempotencyService) Complete(ctx context.Context, record *entities.IdempotencyRecord, status int, headers map[string]string, body []byte) error {
+	if err := record.Complete(status, headers, body); err != nil {
+		return err
+	}
+	return s.repository.Save(ctx, record)
+}
+
+// Abort releases the key of a request which did not complete, so that a retry executes it again
+func (s *IdempotencyService) Abort(ctx context.Context, record *entities.IdempotencyRecord) error {
+	return s.repository.Delete(ctx, record)
+}
+
+// PurgeExpired deletes the records of keys which expired
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) error {
+	return s.repository.DeleteExpired(ctx, s.now())
+}
+
+// Run deletes expired keys every PurgeInterval until the context is cancelled
+func (s *IdempotencyService) Run(ctx context.Context) {
+	runPolling(ctx, s.config.PurgeInterval, 1, "purge expired idempotency keys", func(ctx context.Context) (int, error) {
+		return 0, s.PurgeExpired(ctx)
+	})
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// MockIdempotencyRepository is an in-memory implementation of the IdempotencyRepository interface
+type MockIdempotencyRepository struct {
+	records map[string]*entities.IdempotencyRecord
+}
+
+func NewMockIdempotencyRepository() *MockIdempotencyRepository {
+	return &MockIdempotencyRepository{records: make(map[string]*entities.IdempotencyRecord)}
+}
+
+func idempotencyRecordKey(record *entities.IdempotencyRecord) string {
+	return record.Key + "|" + record.UserId + "|" + record.Route
+}
+
+func (m *MockIdempotencyRepository) Reserve(ctx context.Context, record *entities.IdempotencyRecord) (*entities.IdempotencyRecord, error) {
+	if existing, ok := m.records[idempotencyRecordKey(record)]; ok && !existing.IsExpired(record.CreatedAt) {
+		return existing, nil
+	}
+	m.records[idempotencyRecordKey(record)] = record
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepository) Save(ctx context.Context, record *entities.IdempotencyRecord) error {
+	if existing, ok := m.records[idempotencyRecordKey(record)]; !ok || existing.Token != record.Token {
+		return repositories.ErrIdempotencyKeyReleased
+	}
+	m.records[idempotencyRecordKey(record)] = record
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Delete(ctx context.Context, record *entities.IdempotencyRecord) error {
+	if existing, ok := m.records[idempotencyRecordKey(record)]; ok && existing.Token == record.Token {
+		delete(m.records, idempotencyRecordKey(record))
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	for key, record := range m.records {
+		if record.ExpiresAt.Before(before) {
+			delete(m.records, key)
+		}
+	}
+	return nil
+}
+
+func newTestIdempotencyService(now *time.Time) *IdempotencyService {
+	service := NewIdempotencyService(NewMockIdempotencyRepository(), config.NewIdempotencyConfig())
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+func TestIdempotencyService_ReplaysCompletedRequest(t *testing.T) {
+	now := time.Now()
+	service := newTestIdempotencyService(&now)
+	ctx := context.Background()
+
+	record, err := service.Begin(ctx, "key-1", "user-1", "POST /api/v1/products", "hash")
+	assert.NoError(t, err)
+	assert.False(t, record.IsCompleted())
+	assert.NoError(t, service.Complete(ctx, record, 201, map[string]string{"Location": "/api/v1/products/1"}, []byte(`{}`)))
+
+	replayed, err := service.Begin(ctx, "key-1", "user-1", "POST /api/v1/products", "hash")
+	assert.NoError(t, err)
+	assert.True(t, replayed.IsCompleted())
+	assert.Equal(t, 201, replayed.ResponseStatus)
+	assert.Equal(t, "/api/v1/products/1", replayed.ResponseHeaders["Location"])
+
+	// Keys are scoped to the user and the route
+	other, err := service.Begin(ctx, "key-1", "user-2", "POST /api/v1/products", "hash")
+	assert.NoError(t, err)
+	assert.False(t, other.IsCompleted())
+}
+
+func TestIdempotencyService_RejectsReusedKey(t *testing.T) {
+	now := time.Now()
+	service := newTestIdempotencyService(&now)
+	ctx := context.Background()
+
+	record, _ := service.Begin(ctx, "key-1", "user-1", "POST /api/v1/sellers", "hash")
+	assert.NoError(t, service.Complete(ctx, record, 201, nil, nil))
+
+	_, err := service.Begin(ctx, "key-1", "user-1", "POST /api/v1/sellers", "other-hash")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	assert.ErrorIs(t, err, entities.ErrConflict)
+
+	// An expired key can be used for another request
+	now = now.Add(25 * time.Hour)
+	record, err = service.Begin(ctx, "key-1", "user-1", "POST /api/v1/sellers", "other-hash")
+	assert.NoError(t, err)
+	assert.False(t, record.IsCompleted())
+}
+
+func TestIdempotencyService_TakesOverAbandonedRequest(t *testing.T) {
+	now := time.Now()
+	service := newTestIdempotencyService(&now)
+	ctx := context.Background()
+
+	_, err := service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.NoError(t, err)
+
+	_, err = service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.ErrorIs(t, err, ErrIdempotentRequestInProgress)
+
+	now = now.Add(2 * time.Minute)
+	record, err := service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, now, record.CreatedAt)
+}
+
+func TestIdempotencyService_AbandonedRequestCannotTouchTakenOverKey(t *testing.T) {
+	now := time.Now()
+	service := newTestIdempotencyService(&now)
+	ctx := context.Background()
+
+	abandoned, err := service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	retry, err := service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.NoError(t, err)
+
+	// The first attempt finishes late, it neither records its response nor releases the key of the retry
+	assert.ErrorIs(t, service.Complete(ctx, abandoned, 200, nil, []byte("late")), repositories.ErrIdempotencyKeyReleased)
+	assert.NoError(t, service.Abort(ctx, abandoned))
+
+	assert.NoError(t, service.Complete(ctx, retry, 200, nil, []byte("retry")))
+	replayed, err := service.Begin(ctx, "key-1", "user-1", "PUT /api/v1/sellers", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "retry", string(replayed.ResponseBody))
+}
+
+func TestIdempotencyService_PurgeExpired(t *testing.T) {
+	now := time.Now()
+	repo := NewMockIdempotencyRepository()
+	service := NewIdempotencyService(repo, config.NewIdempotencyConfig())
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := service.Begin(ctx, "key-1", "user-1", "POST /api/v1/products", "hash")
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.PurgeExpired(ctx))
+	assert.Len(t, repo.records, 1)
+
+	now = now.Add(25 * time.Hour)
+	assert.NoError(t, service.PurgeExpired(ctx))
+	assert.Empty(t, repo.records)
+}
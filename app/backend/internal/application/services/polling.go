@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runPolling calls poll every interval until the context is cancelled. poll returns the number of
+// items it processed, a full batch is followed by the next poll right away to catch up with a backlog.
+func runPolling(ctx context.Context, interval time.Duration, batchSize int, name string, poll func(ctx context.Context) (int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := poll(ctx)
+			if err != nil {
+				log.Printf("Failed to %s: %v", name, err)
+			}
+			if err != nil || processed < batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoffDelay returns the delay before the next attempt after the given number of failed attempts,
+// starting at baseDelay and doubling with every further failure up to maxDelay
+func backoffDelay(attempts int, baseDelay, maxDelay time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/mapper"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"strings"
+)
+
+// maxSearchTerms limits the number of terms of a search query, further terms are ignored
+const maxSearchTerms = 10
+
+var (
+	// ErrEmptySearchQuery is returned when the search text contains no searchable terms
+	ErrEmptySearchQuery = entities.NewError(entities.ErrValidation, "search query contains no searchable terms")
+	// ErrProductSearchUnavailable is returned when the database does not support the search index
+	ErrProductSearchUnavailable = repositories.ErrProductSearchUnavailable
+)
+
+type ProductSearchService struct {
+	searchRepository repositories.ProductSearchRepository
+}
+
+func NewProductSearchService(searchRepository repositories.ProductSearchRepository) interfaces.ProductSearchService {
+	return &ProductSearchService{searchRepository: searchRepository}
+}
+
+// SearchProducts finds the products whose name contains all terms of the search text, best matches first.
+// Words which are not in the index are replaced by indexed words that differ by a typo.
+func (s *ProductSearchService) SearchProducts(ctx context.Context, searchQuery *query.SearchProductsQuery) (*query.ProductSearchQueryResult, error) {
+	groups := entities.ParseSearchQuery(searchQuery.Text)
+	if len(groups) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	if len(groups) > maxSearchTerms {
+		groups = groups[:maxSearchTerms]
+	}
+
+	for i := range groups {
+		if err := s.addTypoAlternatives(ctx, &groups[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	page := searchQuery.Page.WithDefaults()
+	hits, err := s.searchRepository.Search(ctx, repositories.ProductSearchQuery{
+		Groups: groups,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult := query.ProductSearchQueryResult{
+		Page: common.PageResult{
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			TotalCount: hits.TotalCount,
+		},
+	}
+	for _, hit := range hits.Hits {
+		queryResult.Result = append(queryResult.Result, &common.ProductSearchResult{
+			Product:   mapper.NewProductResultFromEntity(hit.Product),
+			Rank:      hit.Rank,
+			Highlight: entities.HighlightSearchMatches(hit.Product.Name, groups),
+		})
+	}
+
+	return &queryResult, nil
+}
+
+// addTypoAlternatives lets the group also match indexed terms which are a typo away, unless the term itself matches
+func (s *ProductSearchService) addTypoAlternatives(ctx context.Context, group *entities.SearchTermGroup) error {
+	term := group.Alternatives[0]
+	maxDistance := entities.MaxTypoDistance(term)
+	if maxDistance == 0 {
+		return nil
+	}
+
+	similarTerms, err := s.searchRepository.FindSimilarTerms(ctx, term, maxDistance)
+	if err != nil {
+		return err
+	}
+
+	for _, similarTerm := range similarTerms {
+		if similarTerm == term || (group.Prefix && strings.HasPrefix(similarTerm, term)) {
+			return nil
+		}
+	}
+	group.Alternatives = append(group.Alternatives, similarTerms...)
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"strings"
+	"testing"
+)
+
+// MockProductSearchRepository is an in-memory implementation of the ProductSearchRepository interface.
+// A product matches if every group matches one of its terms, products with more matching terms rank higher.
+type MockProductSearchRepository struct {
+	products []*entities.Product
+	queries  []repositories.ProductSearchQuery
+}
+
+func (m *MockProductSearchRepository) Search(ctx context.Context, searchQuery repositories.ProductSearchQuery) (*repositories.ProductSearchPage, error) {
+	m.queries = append(m.queries, searchQuery)
+
+	var hits []repositories.ProductSearchHit
+	for _, product := range m.products {
+		matchedTerms := 0
+		for _, group := range searchQuery.Groups {
+			for _, token := range entities.TokenizeSearchText(product.Name) {
+				if matchesGroup(token.Term, group) {
+					matchedTerms++
+				}
+			}
+		}
+		if matchedTerms >= len(searchQuery.Groups) {
+			hits = append(hits, repositories.ProductSearchHit{Product: product, Rank: float64(matchedTerms)})
+		}
+	}
+
+	return &repositories.ProductSearchPage{
+		Hits:       pageOf(hits, repositories.PageRequest{Limit: searchQuery.Limit, Offset: searchQuery.Offset}),
+		TotalCount: int64(len(hits)),
+	}, nil
+}
+
+func (m *MockProductSearchRepository) FindSimilarTerms(ctx context.Context, term string, maxDistance int) ([]string, error) {
+	var similarTerms []string
+	for _, product := range m.products {
+		for _, token := range entities.TokenizeSearchText(product.Name) {
+			if entities.EditDistance(term, token.Term) <= maxDistance {
+				similarTerms = append(similarTerms, token.Term)
+			}
+		}
+	}
+	return similarTerms, nil
+}
+
+func matchesGroup(term string, group entities.SearchTermGroup) bool {
+	for _, alternative := range group.Alternatives {
+		if term == alternative || (group.Prefix && strings.HasPrefix(term, alternative)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestProductSearchService(t *testing.T, names ...string) (*ProductSearchService, *MockProductSearchRepository) {
+	seller := createPersistedSeller(t, &MockSellerRepository{})
+
+	repo := &MockProductSearchRepository{}
+	for _, name := range names {
+		repo.products = append(repo.products, entities.NewProduct(name, entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller))
+	}
+
+	return NewProductSearchService(repo).(*ProductSearchService), repo
+}
+
+func TestProductSearchService_SearchProducts(t *testing.T) {
+	service, _ := newTestProductSearchService(t, "Red Shoes", "Blue Shoes", "Red Socks")
+
+	result, err := service.SearchProducts(context.Background(), &query.SearchProductsQuery{Text: "red shoes"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(result.Result) != 1 || result.Result[0].Product.Name != "Red Shoes" {
+		t.Fatalf("Expected only Red Shoes to match, but got %+v", result.Result)
+	}
+	if result.Result[0].Highlight != "<mark>Red</mark> <mark>Shoes</mark>" {
+		t.Errorf("Expected both words to be highlighted, but got %q", result.Result[0].Highlight)
+	}
+	if result.Page.Limit != repositories.DefaultPageSize || result.Page.TotalCount != 1 {
+		t.Errorf("Expected the default page with one result, but got %+v", result.Page)
+	}
+}
+
+func TestProductSearchService_SearchProductsWithTypo(t *testing.T) {
+	service, repo := newTestProductSearchService(t, "Leather Sneakers", "Leather Belt")
+
+	result, err := service.SearchProducts(context.Background(), &query.SearchProductsQuery{Text: "snaekers"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(result.Result) != 1 || result.Result[0].Product.Name != "Leather Sneakers" {
+		t.Fatalf("Expected the misspelled word to match Leather Sneakers, but got %+v", result.Result)
+	}
+	if result.Result[0].Highlight != "Leather <mark>Sneakers</mark>" {
+		t.Errorf("Expected the corrected word to be highlighted, but got %q", result.Result[0].Highlight)
+	}
+
+	// Correctly spelled words are not expanded
+	_, _ = service.SearchProducts(context.Background(), &query.SearchProductsQuery{Text: "belt"})
+	if groups := repo.queries[len(repo.queries)-1].Groups; len(groups[0].Alternatives) != 1 {
+		t.Errorf("Expected no alternatives for an indexed word, but got %+v", groups)
+	}
+}
+
+func TestProductSearchService_SearchProductsJapanese(t *testing.T) {
+	service, _ := newTestProductSearchService(t, "黒いスニーカー", "白いスニーカー", "黒い革靴")
+
+	result, err := service.SearchProducts(context.Background(), &query.SearchProductsQuery{Text: "すにーかー 黒"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(result.Result) != 1 || result.Result[0].Product.Name != "黒いスニーカー" {
+		t.Fatalf("Expected only 黒いスニーカー to match, but got %+v", result.Result)
+	}
+	if result.Result[0].Highlight != "<mark>黒</mark>い<mark>スニーカー</mark>" {
+		t.Errorf("Expected the matches to be highlighted, but got %q", result.Result[0].Highlight)
+	}
+}
+
+func TestProductSearchService_SearchProductsEmptyQuery(t *testing.T) {
+	service, _ := newTestProductSearchService(t, "Red Shoes")
+
+	if _, err := service.SearchProducts(context.Background(), &query.SearchProductsQuery{Text: " ?! "}); !errors.Is(err, ErrEmptySearchQuery) {
+		t.Errorf("Expected ErrEmptySearchQuery, but got %v", err)
+	}
+}
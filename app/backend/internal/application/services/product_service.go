@@ -1,88 +1,298 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
 	"github.com/sklinkert/go-ddd/internal/application/mapper"
 	"github.com/sklinkert/go-ddd/internal/application/query"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	domainservices "github.com/sklinkert/go-ddd/internal/domain/services"
+	"time"
+)
+
+var (
+	// ErrProductNotFound is returned when the product to act on does not exist
+	ErrProductNotFound = repositories.ErrProductNotFound
+	// ErrExchangeRateNotFound is returned when prices cannot be converted to the requested currency
+	ErrExchangeRateNotFound = domainservices.ErrExchangeRateNotFound
+	// ErrInvalidCursor is returned when a listing is requested with a malformed cursor
+	ErrInvalidCursor = repositories.ErrInvalidCursor
+	// ErrSellerDeleted is returned when restoring a product of a deleted seller
+	ErrSellerDeleted = entities.NewError(entities.ErrConflict, "the seller of the product is deleted, restore the seller first")
 )
 
 type ProductService struct {
-	productRepository repositories.ProductRepository
-	sellerRepository  repositories.SellerRepository
+	productRepository    repositories.ProductRepository
+	sellerRepository     repositories.SellerRepository
+	membershipRepository repositories.SellerMembershipRepository
+	currencyConverter    *domainservices.CurrencyConverter
+	unitOfWork           repositories.UnitOfWork
+	audit                *AuditService
+	now                  func() time.Time
 }
 
 func NewProductService(
 	productRepository repositories.ProductRepository,
 	sellerRepository repositories.SellerRepository,
+	membershipRepository repositories.SellerMembershipRepository,
+	currencyConverter *domainservices.CurrencyConverter,
+	unitOfWork repositories.UnitOfWork,
+	audit *AuditService,
 ) interfaces.ProductService {
-	return &ProductService{productRepository: productRepository, sellerRepository: sellerRepository}
+	return &ProductService{
+		productRepository:    productRepository,
+		sellerRepository:     sellerRepository,
+		membershipRepository: membershipRepository,
+		currencyConverter:    currencyConverter,
+		unitOfWork:           unitOfWork,
+		audit:                audit,
+		now:                  time.Now,
+	}
 }
 
-func (s *ProductService) CreateProduct(productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error) {
-	storedSeller, err := s.sellerRepository.FindById(productCommand.SellerId)
+// CreateProduct creates a product for a seller the acting user belongs to. The seller is read in the
+// same transaction the product is written in, so the product is not attached to a deleted seller.
+func (s *ProductService) CreateProduct(ctx context.Context, productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error) {
+	var validatedProduct *entities.ValidatedProduct
+
+	err := s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := authorizeSellerAccess(ctx, tx.SellerMemberships(), productCommand.SellerId, productCommand.Actor, false); err != nil {
+			return err
+		}
+
+		storedSeller, err := tx.Sellers().FindById(ctx, productCommand.SellerId)
+		if err != nil {
+			return err
+		}
+
+		if storedSeller == nil {
+			return ErrSellerNotFound
+		}
+
+		validatedSeller, err := entities.NewValidatedSeller(storedSeller)
+		if err != nil {
+			return err
+		}
+
+		var newProduct = entities.NewProduct(
+			productCommand.Name,
+			productCommand.Price,
+			*validatedSeller,
+		)
+
+		validatedProduct, err = entities.NewValidatedProduct(newProduct)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Products().Create(ctx, validatedProduct); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, productCommand.Actor.UserId, entities.AuditActionCreate, entities.AuditAggregateProduct,
+			validatedProduct.Id.String(), nil, productAuditSnapshot(&validatedProduct.Product))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if storedSeller == nil {
-		return nil, errors.New("seller not found")
+	result := command.CreateProductCommandResult{
+		Result: mapper.NewProductResultFromValidatedEntity(validatedProduct),
 	}
 
-	validatedSeller, err := entities.NewValidatedSeller(storedSeller)
+	return &result, nil
+}
+
+// FindAllProducts returns a page of the products matching the query.
+// If a display currency is given, prices are also converted to it.
+func (s *ProductService) FindAllProducts(ctx context.Context, listQuery *query.ListProductsQuery) (*query.ProductQueryListResult, error) {
+	sort := listQuery.Sort
+	if sort.Field == "" {
+		sort.Field = repositories.SortByCreatedAt
+	}
+	page := listQuery.Page.WithDefaults()
+
+	storedProducts, err := s.productRepository.FindPage(ctx, listQuery.Filter, sort, page)
 	if err != nil {
 		return nil, err
 	}
 
-	var newProduct = entities.NewProduct(
-		productCommand.Name,
-		productCommand.Price,
-		*validatedSeller,
-	)
+	queryListResult := query.ProductQueryListResult{
+		Page: common.PageResult{
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			TotalCount: storedProducts.TotalCount,
+			NextCursor: storedProducts.NextCursor,
+		},
+	}
+	for _, product := range storedProducts.Products {
+		productResult, err := s.toProductResult(ctx, product, listQuery.DisplayCurrency)
+		if err != nil {
+			return nil, err
+		}
+		queryListResult.Result = append(queryListResult.Result, productResult)
+	}
+
+	return &queryListResult, nil
+}
 
-	validatedProduct, err := entities.NewValidatedProduct(newProduct)
+// FindProductById returns a product. If a display currency is given, the price is also converted to it.
+func (s *ProductService) FindProductById(ctx context.Context, id uuid.UUID, displayCurrency entities.Currency) (*query.ProductQueryResult, error) {
+	storedProduct, err := s.productRepository.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = s.productRepository.Create(validatedProduct)
+	productResult, err := s.toProductResult(ctx, storedProduct, displayCurrency)
 	if err != nil {
 		return nil, err
 	}
 
-	result := command.CreateProductCommandResult{
-		Result: mapper.NewProductResultFromValidatedEntity(validatedProduct),
+	var queryResult query.ProductQueryResult
+	queryResult.Result = productResult
+
+	return &queryResult, nil
+}
+
+// toProductResult maps the product and converts its price if a display currency is given
+func (s *ProductService) toProductResult(ctx context.Context, product *entities.Product, displayCurrency entities.Currency) (*common.ProductResult, error) {
+	productResult := mapper.NewProductResultFromEntity(product)
+	if productResult == nil || displayCurrency == "" {
+		return productResult, nil
 	}
 
-	return &result, nil
+	convertedPrice, rate, err := s.currencyConverter.Convert(ctx, product.Price, displayCurrency, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	productResult.ConvertedPrice = &convertedPrice
+	productResult.ExchangeRate = mapper.NewExchangeRateResultFromEntity(rate)
+
+	return productResult, nil
 }
 
-func (s *ProductService) FindAllProducts() (*query.ProductQueryListResult, error) {
-	storedProducts, err := s.productRepository.FindAll()
+// UpdateProduct changes the name and/or price of a product of a seller the acting user belongs to
+func (s *ProductService) UpdateProduct(ctx context.Context, updateCommand *command.UpdateProductCommand) (*command.UpdateProductCommandResult, error) {
+	product, err := s.productRepository.FindById(ctx, updateCommand.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	var queryListResult query.ProductQueryListResult
-	for _, product := range storedProducts {
-		queryListResult.Result = append(queryListResult.Result, mapper.NewProductResultFromEntity(product))
+	if product == nil {
+		return nil, ErrProductNotFound
 	}
 
-	return &queryListResult, nil
+	if err := authorizeSellerAccess(ctx, s.membershipRepository, product.Seller.Id, updateCommand.Actor, false); err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(updateCommand.ExpectedVersion, product.Version); err != nil {
+		return nil, err
+	}
+
+	before := productAuditSnapshot(product)
+	if updateCommand.Name != nil {
+		if err := product.UpdateName(*updateCommand.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if updateCommand.Price != nil {
+		if err := product.UpdatePrice(*updateCommand.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	validatedProduct, err := entities.NewValidatedProduct(product)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedProduct *entities.Product
+	err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if updatedProduct, err = tx.Products().Update(ctx, validatedProduct); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, updateCommand.Actor.UserId, entities.AuditActionUpdate, entities.AuditAggregateProduct,
+			updatedProduct.Id.String(), before, productAuditSnapshot(updatedProduct))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := command.UpdateProductCommandResult{
+		Result: mapper.NewProductResultFromEntity(updatedProduct),
+	}
+
+	return &result, nil
 }
 
-func (s *ProductService) FindProductById(id uuid.UUID) (*query.ProductQueryResult, error) {
-	storedProduct, err := s.productRepository.FindById(id)
+// DeleteProduct soft deletes a product of a seller the acting user belongs to, it can be restored until it is purged.
+// If an expected version is given, it returns ErrVersionConflict if the product has another version.
+func (s *ProductService) DeleteProduct(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error {
+	product, err := s.productRepository.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return ErrProductNotFound
+	}
+
+	if err := authorizeSellerAccess(ctx, s.membershipRepository, product.Seller.Id, actor, false); err != nil {
+		return err
+	}
+
+	if err := checkVersion(expectedVersion, product.Version); err != nil {
+		return err
+	}
+
+	before := productAuditSnapshot(product)
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Products().Delete(ctx, id, product.Version); err != nil {
+			return err
+		}
+
+		// The deletion time is set by the repository
+		deleted, err := tx.Products().FindByIdIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, actor.UserId, entities.AuditActionDelete, entities.AuditAggregateProduct, id.String(), before, productAuditSnapshot(deleted))
+	})
+}
+
+// RestoreProduct restores a soft deleted product of a seller the acting user belongs to.
+// Restoring a product which is not deleted returns it unchanged.
+func (s *ProductService) RestoreProduct(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreProductCommandResult, error) {
+	product, err := s.productRepository.FindByIdIncludingDeleted(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	var queryResult query.ProductQueryResult
-	queryResult.Result = mapper.NewProductResultFromEntity(storedProduct)
+	if err := authorizeSellerAccess(ctx, s.membershipRepository, product.Seller.Id, actor, false); err != nil {
+		return nil, err
+	}
 
-	return &queryResult, nil
+	if product.IsDeleted() {
+		if product.Seller.IsDeleted() {
+			return nil, ErrSellerDeleted
+		}
+		before := productAuditSnapshot(product)
+		err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+			if product, err = tx.Products().Restore(ctx, id); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, tx, actor.UserId, entities.AuditActionRestore, entities.AuditAggregateProduct, id.String(), before, productAuditSnapshot(product))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &command.RestoreProductCommandResult{Result: mapper.NewProductResultFromEntity(product)}, nil
 }
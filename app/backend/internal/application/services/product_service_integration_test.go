@@ -10,6 +10,8 @@ import (
 	"github.com/sklinkert/go-ddd/internal/application/command"
 	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
@@ -63,9 +65,12 @@ func setupTestDatabase(t *testing.T) (*gorm.DB, func()) {
 		t.Fatalf("Failed to connect to database: %s", err)
 	}
 
-	// AutoMigrate our models
-	err = database.AutoMigrate(&postgres.Product{}, &postgres.Seller{})
+	// Apply the schema migrations like the server does
+	migrator, err := postgres.NewMigrator(database)
 	if err != nil {
+		t.Fatalf("Failed to load migrations: %s", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
 		t.Fatalf("Failed to migrate database: %s", err)
 	}
 
@@ -86,8 +91,9 @@ func setupTestDatabase(t *testing.T) (*gorm.DB, func()) {
 
 func createTestSeller(t *testing.T, sellerService interfaces.SellerService) *common.SellerResult {
 	sellerName := "Test Seller " + uuid.New().String()
-	result, err := sellerService.CreateSeller(&command.CreateSellerCommand{
-		Name: sellerName,
+	result, err := sellerService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  sellerName,
+		Actor: testSellerOwner,
 	})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -104,24 +110,28 @@ func TestProductService_Integration_CreateProduct(t *testing.T) {
 	// Create repositories
 	productRepo := postgres.NewGormProductRepository(db)
 	sellerRepo := postgres.NewGormSellerRepository(db)
+	membershipRepo := postgres.NewGormSellerMembershipRepository(db)
+	unitOfWork := postgres.NewGormUnitOfWork(db)
 
 	// Create services
-	productService := NewProductService(productRepo, sellerRepo)
-	sellerService := NewSellerService(sellerRepo)
+	auditService := NewAuditService(postgres.NewGormAuditRepository(db))
+	productService := NewProductService(productRepo, sellerRepo, membershipRepo, nil, unitOfWork, auditService)
+	sellerService := NewSellerService(sellerRepo, membershipRepo, unitOfWork, auditService)
 
 	// Create a seller first
 	seller := createTestSeller(t, sellerService)
 
 	// Test creating a product
 	productName := "Test Product"
-	productPrice := 99.99
+	productPrice := entities.Money{Amount: 9999, Currency: entities.CurrencyUSD}
 	createProductCmd := &command.CreateProductCommand{
 		Name:     productName,
 		Price:    productPrice,
 		SellerId: seller.Id,
+		Actor:    testSellerOwner,
 	}
 
-	result, err := productService.CreateProduct(createProductCmd)
+	result, err := productService.CreateProduct(context.Background(), createProductCmd)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotNil(t, result.Result)
@@ -138,10 +148,13 @@ func TestProductService_Integration_FindAllProducts(t *testing.T) {
 	// Create repositories
 	productRepo := postgres.NewGormProductRepository(db)
 	sellerRepo := postgres.NewGormSellerRepository(db)
+	membershipRepo := postgres.NewGormSellerMembershipRepository(db)
+	unitOfWork := postgres.NewGormUnitOfWork(db)
 
 	// Create services
-	productService := NewProductService(productRepo, sellerRepo)
-	sellerService := NewSellerService(sellerRepo)
+	auditService := NewAuditService(postgres.NewGormAuditRepository(db))
+	productService := NewProductService(productRepo, sellerRepo, membershipRepo, nil, unitOfWork, auditService)
+	sellerService := NewSellerService(sellerRepo, membershipRepo, unitOfWork, auditService)
 
 	// Create a seller first
 	seller := createTestSeller(t, sellerService)
@@ -149,19 +162,20 @@ func TestProductService_Integration_FindAllProducts(t *testing.T) {
 	// Create multiple products
 	for i := 1; i <= 3; i++ {
 		productName := fmt.Sprintf("Test Product %d", i)
-		productPrice := float64(i * 10)
+		productPrice := entities.Money{Amount: int64(i * 1000), Currency: entities.CurrencyUSD}
 		createProductCmd := &command.CreateProductCommand{
 			Name:     productName,
 			Price:    productPrice,
 			SellerId: seller.Id,
+			Actor:    testSellerOwner,
 		}
 
-		_, err := productService.CreateProduct(createProductCmd)
+		_, err := productService.CreateProduct(context.Background(), createProductCmd)
 		assert.NoError(t, err)
 	}
 
 	// Test finding all products
-	result, err := productService.FindAllProducts()
+	result, err := productService.FindAllProducts(context.Background(), &query.ListProductsQuery{})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotNil(t, result.Result)
@@ -176,29 +190,33 @@ func TestProductService_Integration_FindProductById(t *testing.T) {
 	// Create repositories
 	productRepo := postgres.NewGormProductRepository(db)
 	sellerRepo := postgres.NewGormSellerRepository(db)
+	membershipRepo := postgres.NewGormSellerMembershipRepository(db)
+	unitOfWork := postgres.NewGormUnitOfWork(db)
 
 	// Create services
-	productService := NewProductService(productRepo, sellerRepo)
-	sellerService := NewSellerService(sellerRepo)
+	auditService := NewAuditService(postgres.NewGormAuditRepository(db))
+	productService := NewProductService(productRepo, sellerRepo, membershipRepo, nil, unitOfWork, auditService)
+	sellerService := NewSellerService(sellerRepo, membershipRepo, unitOfWork, auditService)
 
 	// Create a seller first
 	seller := createTestSeller(t, sellerService)
 
 	// Create a product
 	productName := "Test Product"
-	productPrice := 99.99
+	productPrice := entities.Money{Amount: 9999, Currency: entities.CurrencyUSD}
 	createProductCmd := &command.CreateProductCommand{
 		Name:     productName,
 		Price:    productPrice,
 		SellerId: seller.Id,
+		Actor:    testSellerOwner,
 	}
 
-	createResult, err := productService.CreateProduct(createProductCmd)
+	createResult, err := productService.CreateProduct(context.Background(), createProductCmd)
 	assert.NoError(t, err)
 	productId := createResult.Result.Id
 
 	// Test finding product by ID
-	findResult, err := productService.FindProductById(productId)
+	findResult, err := productService.FindProductById(context.Background(), productId, "")
 	assert.NoError(t, err)
 	assert.NotNil(t, findResult)
 	assert.NotNil(t, findResult.Result)
@@ -207,6 +225,6 @@ func TestProductService_Integration_FindProductById(t *testing.T) {
 	assert.Equal(t, seller.Id, findResult.Result.Seller.Id)
 
 	// Test finding non-existent product
-	_, err = productService.FindProductById(uuid.New())
+	_, err = productService.FindProductById(context.Background(), uuid.New(), "")
 	assert.Error(t, err)
 }
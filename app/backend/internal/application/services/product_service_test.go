@@ -1,12 +1,19 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/query"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	domainservices "github.com/sklinkert/go-ddd/internal/domain/services"
 	"testing"
+	"time"
 )
 
 // MockProductRepository is a mock implementation of the ProductRepository interface
@@ -14,20 +21,30 @@ type MockProductRepository struct {
 	products []*entities.ValidatedProduct
 }
 
-func (m *MockProductRepository) Create(product *entities.ValidatedProduct) (*entities.Product, error) {
+func (m *MockProductRepository) Create(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error) {
 	m.products = append(m.products, product)
 	return &product.Product, nil
 }
 
-func (m *MockProductRepository) FindAll() ([]*entities.Product, error) {
+func (m *MockProductRepository) FindAll(ctx context.Context) ([]*entities.Product, error) {
 	var products []*entities.Product
 	for _, p := range m.products {
-		products = append(products, &p.Product)
+		if !p.IsDeleted() {
+			products = append(products, &p.Product)
+		}
 	}
 	return products, nil
 }
 
-func (m *MockProductRepository) Update(product *entities.ValidatedProduct) (*entities.Product, error) {
+func (m *MockProductRepository) FindPage(ctx context.Context, filter repositories.ProductFilter, sort repositories.SortOrder, page repositories.PageRequest) (*repositories.ProductPage, error) {
+	products, _ := m.FindAll(ctx)
+	return &repositories.ProductPage{
+		Products:   pageOf(products, page),
+		TotalCount: int64(len(products)),
+	}, nil
+}
+
+func (m *MockProductRepository) Update(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error) {
 	for index, p := range m.products {
 		if p.Id == product.Id {
 			m.products[index] = product
@@ -37,38 +54,102 @@ func (m *MockProductRepository) Update(product *entities.ValidatedProduct) (*ent
 	return nil, errors.New("product not found for update")
 }
 
-func (m *MockProductRepository) Delete(id uuid.UUID) error {
-	for index, p := range m.products {
-		if p.Id == id {
-			m.products = append(m.products[:index], m.products[index+1:]...)
+func (m *MockProductRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	for _, p := range m.products {
+		if p.Id == id && !p.IsDeleted() {
+			if p.Version != version {
+				return repositories.ErrVersionConflict
+			}
+			now := time.Now()
+			p.DeletedAt = &now
 			return nil
 		}
 	}
 	return errors.New("product not found for delete")
 }
 
-func (m *MockProductRepository) FindById(id uuid.UUID) (*entities.Product, error) {
+func (m *MockProductRepository) DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error {
+	now := time.Now()
+	for _, p := range m.products {
+		if p.Seller.Id == sellerId && !p.IsDeleted() {
+			p.DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockProductRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
+	product, err := m.FindByIdIncludingDeleted(ctx, id)
+	if err != nil || product.IsDeleted() {
+		return nil, repositories.ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (m *MockProductRepository) FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
 	for _, p := range m.products {
 		if p.Id == id {
 			return &p.Product, nil
 		}
 		fmt.Printf("Id: mem:%s - %s\n", p.Id, id)
 	}
-	return nil, errors.New("product not found")
+	return nil, repositories.ErrProductNotFound
+}
+
+func (m *MockProductRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
+	product, err := m.FindByIdIncludingDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	product.DeletedAt = nil
+	return product, nil
+}
+
+func (m *MockProductRepository) RestoreBySeller(ctx context.Context, sellerId uuid.UUID, deletedSince time.Time) error {
+	for _, p := range m.products {
+		if p.Seller.Id == sellerId && p.IsDeleted() && !p.DeletedAt.Before(deletedSince) {
+			p.DeletedAt = nil
+		}
+	}
+	return nil
+}
+
+func (m *MockProductRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var remaining []*entities.ValidatedProduct
+	for _, p := range m.products {
+		if !p.IsDeleted() || !p.DeletedAt.Before(deletedBefore) {
+			remaining = append(remaining, p)
+		}
+	}
+	purged := int64(len(m.products) - len(remaining))
+	m.products = remaining
+	return purged, nil
+}
+
+// newTestProductService creates a ProductService whose unit of work uses the given mock repositories
+func newTestProductService(
+	productRepo *MockProductRepository,
+	sellerRepo *MockSellerRepository,
+	membershipRepo *MockSellerMembershipRepository,
+	currencyConverter *domainservices.CurrencyConverter,
+) interfaces.ProductService {
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: productRepo, sellers: sellerRepo, sellerMemberships: membershipRepo, audit: auditRepo}
+	return NewProductService(productRepo, sellerRepo, membershipRepo, currencyConverter, unitOfWork, NewAuditService(auditRepo))
 }
 
 func TestProductService_CreateProduct(t *testing.T) {
 	productRepo := &MockProductRepository{}
 	sellerRepo := &MockSellerRepository{}
-	service := NewProductService(productRepo, sellerRepo)
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
 
 	// Create seller
 	seller := createPersistedSeller(t, sellerRepo)
 
 	// Create product
-	product := entities.NewProduct("Example", 100.0, *seller)
+	product := entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)
 	productCommand := getCreateProductCommand(product)
-	_, err := service.CreateProduct(productCommand)
+	_, err := service.CreateProduct(context.Background(), productCommand)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -81,16 +162,16 @@ func TestProductService_CreateProduct(t *testing.T) {
 func TestProductService_GetAllProducts(t *testing.T) {
 	productRepo := &MockProductRepository{}
 	sellerRepo := &MockSellerRepository{}
-	service := NewProductService(productRepo, sellerRepo)
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
 
 	// Create seller
 	seller := createPersistedSeller(t, sellerRepo)
 
 	// Add two products
-	_, _ = service.CreateProduct(getCreateProductCommand(entities.NewProduct("Example1", 100.0, *seller)))
-	_, _ = service.CreateProduct(getCreateProductCommand(entities.NewProduct("Example2", 200.0, *seller)))
+	_, _ = service.CreateProduct(context.Background(), getCreateProductCommand(entities.NewProduct("Example1", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)))
+	_, _ = service.CreateProduct(context.Background(), getCreateProductCommand(entities.NewProduct("Example2", entities.Money{Amount: 20000, Currency: entities.CurrencyUSD}, *seller)))
 
-	products, err := service.FindAllProducts()
+	products, err := service.FindAllProducts(context.Background(), &query.ListProductsQuery{})
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -103,18 +184,18 @@ func TestProductService_GetAllProducts(t *testing.T) {
 func TestProductService_FindProductById(t *testing.T) {
 	productRepo := &MockProductRepository{}
 	sellerRepo := &MockSellerRepository{}
-	service := NewProductService(productRepo, sellerRepo)
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
 
 	// Create seller
 	seller := createPersistedSeller(t, sellerRepo)
 
-	product := entities.NewProduct("Example", 100.0, *seller)
-	result, err := service.CreateProduct(getCreateProductCommand(product))
+	product := entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)
+	result, err := service.CreateProduct(context.Background(), getCreateProductCommand(product))
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
 
-	foundProduct, err := service.FindProductById(result.Result.Id)
+	foundProduct, err := service.FindProductById(context.Background(), result.Result.Id, "")
 	if err != nil {
 		t.Fatalf("Unexpected error: %s", err)
 	}
@@ -123,17 +204,180 @@ func TestProductService_FindProductById(t *testing.T) {
 		t.Errorf("Expected product name 'Example', but got %s", foundProduct.Result.Name)
 	}
 
-	_, err = service.FindProductById(uuid.New()) // some non-existent Id
+	_, err = service.FindProductById(context.Background(), uuid.New(), "") // some non-existent Id
 	if err == nil {
 		t.Error("Expected error for non-existent product, but got none")
 	}
 }
 
+func TestProductService_CreateProduct_RequiresSellerMembership(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	service := newTestProductService(productRepo, sellerRepo, membershipRepo, nil)
+
+	seller := createPersistedSeller(t, sellerRepo)
+	productCommand := getCreateProductCommand(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller))
+	productCommand.Actor = common.Actor{UserId: "member-id"}
+
+	_, err := service.CreateProduct(context.Background(), productCommand)
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember, but got %v", err)
+	}
+
+	membership, _ := entities.NewSellerMembership(seller.Id, "member-id", entities.SellerMemberRoleMember)
+	_ = membershipRepo.Save(context.Background(), membership)
+
+	if _, err := service.CreateProduct(context.Background(), productCommand); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestProductService_UpdateProduct(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
+
+	seller := createPersistedSeller(t, sellerRepo)
+	result, err := service.CreateProduct(context.Background(), getCreateProductCommand(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Only the price is changed, the name is kept
+	newPrice := entities.Money{Amount: 15000, Currency: entities.CurrencyUSD}
+	updated, err := service.UpdateProduct(context.Background(), &command.UpdateProductCommand{
+		Id:    result.Result.Id,
+		Price: &newPrice,
+		Actor: common.Actor{ManagesAllSellers: true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if updated.Result.Name != "Example" || updated.Result.Price != newPrice {
+		t.Errorf("Expected product 'Example' with price %s, but got %s with price %s", newPrice, updated.Result.Name, updated.Result.Price)
+	}
+
+	invalidPrice := entities.Money{Amount: -100, Currency: entities.CurrencyUSD}
+	_, err = service.UpdateProduct(context.Background(), &command.UpdateProductCommand{
+		Id:    result.Result.Id,
+		Price: &invalidPrice,
+		Actor: common.Actor{ManagesAllSellers: true},
+	})
+	if err == nil {
+		t.Error("Expected error for invalid price, but got none")
+	}
+
+	_, err = service.UpdateProduct(context.Background(), &command.UpdateProductCommand{
+		Id:    result.Result.Id,
+		Price: &newPrice,
+		Actor: common.Actor{UserId: "stranger-id"},
+	})
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember, but got %v", err)
+	}
+
+	_, err = service.UpdateProduct(context.Background(), &command.UpdateProductCommand{
+		Id:    uuid.New(),
+		Price: &newPrice,
+		Actor: common.Actor{ManagesAllSellers: true},
+	})
+	if !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, but got %v", err)
+	}
+}
+
+func TestProductService_DeleteProduct(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
+
+	seller := createPersistedSeller(t, sellerRepo)
+	result, err := service.CreateProduct(context.Background(), getCreateProductCommand(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	err = service.DeleteProduct(context.Background(), result.Result.Id, nil, common.Actor{UserId: "stranger-id"})
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember, but got %v", err)
+	}
+
+	if err := service.DeleteProduct(context.Background(), result.Result.Id, nil, common.Actor{ManagesAllSellers: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	_, err = service.FindProductById(context.Background(), result.Result.Id, "")
+	if !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound after delete, but got %v", err)
+	}
+}
+
+func TestProductService_RestoreProduct(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), nil)
+	staff := common.Actor{ManagesAllSellers: true}
+
+	seller := createPersistedSeller(t, sellerRepo)
+	result, err := service.CreateProduct(context.Background(), getCreateProductCommand(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := service.DeleteProduct(context.Background(), result.Result.Id, nil, staff); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	_, err = service.RestoreProduct(context.Background(), result.Result.Id, common.Actor{UserId: "stranger-id"})
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember, but got %v", err)
+	}
+
+	restored, err := service.RestoreProduct(context.Background(), result.Result.Id, staff)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if restored.Result.DeletedAt != nil {
+		t.Errorf("Expected the product to be restored, but it was deleted at %v", restored.Result.DeletedAt)
+	}
+	if _, err := service.FindProductById(context.Background(), result.Result.Id, ""); err != nil {
+		t.Errorf("Expected the restored product to be found, but got %v", err)
+	}
+
+	_, err = service.RestoreProduct(context.Background(), uuid.New(), staff)
+	if !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, but got %v", err)
+	}
+}
+
+func TestProductService_RestoreProductOfDeletedSeller(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	service := newTestProductService(productRepo, &MockSellerRepository{}, NewMockSellerMembershipRepository(), nil)
+
+	deletedAt := time.Now()
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("John Doe"))
+	product, _ := entities.NewValidatedProduct(entities.NewProduct("Example", entities.Money{Amount: 10000, Currency: entities.CurrencyUSD}, *seller))
+	product.DeletedAt = &deletedAt
+	product.Seller.DeletedAt = &deletedAt
+	_, _ = productRepo.Create(context.Background(), product)
+
+	_, err := service.RestoreProduct(context.Background(), product.Id, common.Actor{ManagesAllSellers: true})
+
+	if !errors.Is(err, ErrSellerDeleted) {
+		t.Errorf("Expected ErrSellerDeleted, but got %v", err)
+	}
+	if !product.IsDeleted() {
+		t.Error("Expected the product to stay deleted")
+	}
+}
+
 func getCreateProductCommand(product *entities.Product) *command.CreateProductCommand {
 	return &command.CreateProductCommand{
 		Name:     product.Name,
 		Price:    product.Price,
 		SellerId: product.Seller.Id,
+		Actor:    common.Actor{ManagesAllSellers: true},
 	}
 }
 
@@ -143,9 +387,55 @@ func createPersistedSeller(t *testing.T, sellerRepo *MockSellerRepository) *enti
 	if err != nil {
 		t.Fatalf("Unexpected error: %s", err)
 	}
-	_, err = sellerRepo.Create(validatedSeller)
+	_, err = sellerRepo.Create(context.Background(), validatedSeller)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s", err)
 	}
 	return validatedSeller
 }
+
+func TestProductService_FindProductsInDisplayCurrency(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	rateRepo := &MockExchangeRateRepository{}
+	service := newTestProductService(productRepo, sellerRepo, NewMockSellerMembershipRepository(), domainservices.NewCurrencyConverter(rateRepo))
+
+	rate, err := entities.NewExchangeRate(entities.CurrencyUSD, entities.CurrencyEUR, "0.9234", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_ = rateRepo.Save(context.Background(), rate)
+
+	seller := createPersistedSeller(t, sellerRepo)
+	product := entities.NewProduct("Example", entities.Money{Amount: 1099, Currency: entities.CurrencyUSD}, *seller)
+	result, err := service.CreateProduct(context.Background(), getCreateProductCommand(product))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	foundProduct, err := service.FindProductById(context.Background(), result.Result.Id, entities.CurrencyEUR)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if foundProduct.Result.Price != product.Price {
+		t.Errorf("Expected the original price %s, but got %s", product.Price, foundProduct.Result.Price)
+	}
+	if foundProduct.Result.ConvertedPrice == nil || *foundProduct.Result.ConvertedPrice != (entities.Money{Amount: 1015, Currency: entities.CurrencyEUR}) {
+		t.Errorf("Expected converted price 10.15 EUR, but got %v", foundProduct.Result.ConvertedPrice)
+	}
+	if foundProduct.Result.ExchangeRate == nil || foundProduct.Result.ExchangeRate.Rate != "0.9234" {
+		t.Errorf("Expected exchange rate 0.9234, but got %v", foundProduct.Result.ExchangeRate)
+	}
+
+	products, err := service.FindAllProducts(context.Background(), &query.ListProductsQuery{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if products.Result[0].ConvertedPrice != nil || products.Result[0].ExchangeRate != nil {
+		t.Error("Expected no conversion without display currency")
+	}
+
+	if _, err := service.FindAllProducts(context.Background(), &query.ListProductsQuery{DisplayCurrency: entities.CurrencyJPY}); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Errorf("Expected ErrExchangeRateNotFound, but got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"log"
+	"time"
+)
+
+// PurgeService permanently removes the products and sellers which were soft deleted longer than the retention period
+type PurgeService struct {
+	productRepository repositories.ProductRepository
+	sellerRepository  repositories.SellerRepository
+	config            *config.SoftDeleteConfig
+	now               func() time.Time
+}
+
+// NewPurgeService creates a new PurgeService
+func NewPurgeService(
+	productRepository repositories.ProductRepository,
+	sellerRepository repositories.SellerRepository,
+	softDeleteConfig *config.SoftDeleteConfig,
+) *PurgeService {
+	return &PurgeService{
+		productRepository: productRepository,
+		sellerRepository:  sellerRepository,
+		config:            softDeleteConfig,
+		now:               time.Now,
+	}
+}
+
+// Purge removes the products and sellers whose retention ended. Products are purged first,
+// as sellers are only purged once none of their products is left.
+func (s *PurgeService) Purge(ctx context.Context) error {
+	deletedBefore := s.now().Add(-s.config.Retention)
+
+	products, err := s.productRepository.Purge(ctx, deletedBefore)
+	if err != nil {
+		return err
+	}
+	sellers, err := s.sellerRepository.Purge(ctx, deletedBefore)
+	if err != nil {
+		return err
+	}
+
+	if products > 0 || sellers > 0 {
+		log.Printf("Purged %d products and %d sellers deleted before %s", products, sellers, deletedBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Run purges every PurgeInterval until the context is cancelled
+func (s *PurgeService) Run(ctx context.Context) {
+	runPolling(ctx, s.config.PurgeInterval, 1, "purge deleted products and sellers", func(ctx context.Context) (int, error) {
+		return 0, s.Purge(ctx)
+	})
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPurgeService_PurgesAfterRetention(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	ctx := context.Background()
+	now := time.Now()
+	service := NewPurgeService(productRepo, sellerRepo, config.NewSoftDeleteConfig())
+	service.now = func() time.Time { return now }
+
+	expired := now.Add(-config.NewSoftDeleteConfig().Retention - time.Minute)
+	recent := now.Add(-time.Hour)
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("Seller"))
+	seller.DeletedAt = &expired
+	_, _ = sellerRepo.Create(ctx, seller)
+	var products []*entities.ValidatedProduct
+	for _, deletedAt := range []*time.Time{&expired, &recent, nil} {
+		product, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller))
+		product.DeletedAt = deletedAt
+		_, _ = productRepo.Create(ctx, product)
+		products = append(products, product)
+	}
+
+	require.NoError(t, service.Purge(ctx))
+
+	_, err := productRepo.FindByIdIncludingDeleted(ctx, products[0].Id)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	for _, product := range products[1:] {
+		_, err := productRepo.FindByIdIncludingDeleted(ctx, product.Id)
+		assert.NoError(t, err, "Products deleted within the retention period must be kept")
+	}
+	_, err = sellerRepo.FindByIdIncludingDeleted(ctx, seller.Id)
+	assert.ErrorIs(t, err, ErrSellerNotFound)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+)
+
+var (
+	// ErrRoleNotFound is returned when a role does not exist
+	ErrRoleNotFound = entities.NewError(entities.ErrNotFound, "role not found")
+	// ErrRoleAlreadyExists is returned when creating a role whose name is taken
+	ErrRoleAlreadyExists = entities.NewError(entities.ErrConflict, "role already exists")
+	// ErrRoleInUse is returned when deleting a built-in role or a role assigned to users
+	ErrRoleInUse = entities.NewError(entities.ErrConflict, "role is built-in or assigned to users")
+)
+
+// RoleService manages roles and answers permission checks
+type RoleService struct {
+	roleRepository repositories.RoleRepository
+	userRepository repositories.UserRepository
+}
+
+// NewRoleService creates a new RoleService
+func NewRoleService(roleRepository repositories.RoleRepository, userRepository repositories.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepository: roleRepository,
+		userRepository: userRepository,
+	}
+}
+
+// EnsureDefaultRoles creates missing built-in roles. Existing roles keep their customized
+// permissions, except for the admin role which is always granted every permission.
+func (s *RoleService) EnsureDefaultRoles(ctx context.Context) error {
+	for _, defaultRole := range entities.DefaultRoles() {
+		role, err := s.roleRepository.FindByName(ctx, defaultRole.Name)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case role == nil:
+			role = defaultRole
+		case role.Name == entities.RoleAdmin && len(role.Permissions) != len(entities.AllPermissions()):
+			if err := role.SetPermissions(entities.AllPermissions()); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		if err := s.roleRepository.Save(ctx, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRole creates a new role
+func (s *RoleService) CreateRole(ctx context.Context, name entities.UserRole, description string, permissions []entities.Permission) (*entities.Role, error) {
+	existingRole, err := s.roleRepository.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existingRole != nil {
+		return nil, ErrRoleAlreadyExists
+	}
+
+	role, err := entities.NewRole(name, description, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepository.Save(ctx, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// GetRole retrieves a role by name
+func (s *RoleService) GetRole(ctx context.Context, name entities.UserRole) (*entities.Role, error) {
+	role, err := s.roleRepository.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// GetAllRoles retrieves all roles
+func (s *RoleService) GetAllRoles(ctx context.Context) ([]*entities.Role, error) {
+	return s.roleRepository.FindAll(ctx)
+}
+
+// UpdateRole updates the description and replaces the permissions of a role
+func (s *RoleService) UpdateRole(ctx context.Context, name entities.UserRole, description string, permissions []entities.Permission) (*entities.Role, error) {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := role.SetPermissions(permissions); err != nil {
+		return nil, err
+	}
+	role.UpdateDescription(description)
+
+	if err := s.roleRepository.Save(ctx, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteRole deletes a custom role which is not assigned to any user
+func (s *RoleService) DeleteRole(ctx context.Context, name entities.UserRole) error {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.IsBuiltIn() {
+		return ErrRoleInUse
+	}
+
+	users, err := s.userRepository.FindWithFilter(ctx, repositories.UserFilter{Role: name})
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.roleRepository.Delete(ctx, name)
+}
+
+// HasPermission reports whether the role grants the permission. Unknown roles grant nothing.
+func (s *RoleService) HasPermission(ctx context.Context, name entities.UserRole, permission entities.Permission) (bool, error) {
+	role, err := s.roleRepository.FindByName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	if role == nil {
+		return false, nil
+	}
+	return role.HasPermission(permission), nil
+}
+
+// Includes reports whether the role grants every permission of the other role.
+// Unknown roles grant no permissions.
+func (s *RoleService) Includes(ctx context.Context, name, other entities.UserRole) (bool, error) {
+	role, err := s.roleRepository.FindByName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	otherRole, err := s.roleRepository.FindByName(ctx, other)
+	if err != nil {
+		return false, err
+	}
+	if otherRole == nil {
+		return true, nil
+	}
+	if role == nil {
+		return len(otherRole.Permissions) == 0, nil
+	}
+	return role.Includes(otherRole), nil
+}
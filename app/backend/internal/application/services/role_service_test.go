@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// MockRoleRepository is an in-memory implementation of the RoleRepository interface
+type MockRoleRepository struct {
+	roles map[entities.UserRole]*entities.Role
+}
+
+func NewMockRoleRepository() *MockRoleRepository {
+	return &MockRoleRepository{roles: make(map[entities.UserRole]*entities.Role)}
+}
+
+func (m *MockRoleRepository) Save(ctx context.Context, role *entities.Role) error {
+	stored := *role
+	m.roles[role.Name] = &stored
+	return nil
+}
+
+func (m *MockRoleRepository) FindByName(ctx context.Context, name entities.UserRole) (*entities.Role, error) {
+	role, ok := m.roles[name]
+	if !ok {
+		return nil, nil
+	}
+	found := *role
+	return &found, nil
+}
+
+func (m *MockRoleRepository) FindAll(ctx context.Context) ([]*entities.Role, error) {
+	roles := make([]*entities.Role, 0, len(m.roles))
+	for _, role := range m.roles {
+		found := *role
+		roles = append(roles, &found)
+	}
+	return roles, nil
+}
+
+func (m *MockRoleRepository) Delete(ctx context.Context, name entities.UserRole) error {
+	delete(m.roles, name)
+	return nil
+}
+
+func TestRoleService_EnsureDefaultRoles(t *testing.T) {
+	roleRepo := NewMockRoleRepository()
+	service := NewRoleService(roleRepo, new(MockUserRepository))
+
+	require.NoError(t, service.EnsureDefaultRoles(context.Background()))
+	assert.Len(t, roleRepo.roles, len(entities.DefaultRoles()))
+
+	// Customized permissions of built-in roles are kept
+	_, err := service.UpdateRole(context.Background(), entities.RoleSupport, "Read only support", []entities.Permission{entities.PermissionUserRead})
+	require.NoError(t, err)
+	require.NoError(t, service.EnsureDefaultRoles(context.Background()))
+
+	granted, err := service.HasPermission(context.Background(), entities.RoleSupport, entities.PermissionUserWrite)
+	require.NoError(t, err)
+	assert.False(t, granted)
+
+	granted, err = service.HasPermission(context.Background(), entities.RoleAdmin, entities.PermissionRoleManage)
+	require.NoError(t, err)
+	assert.True(t, granted)
+}
+
+func TestRoleService_CreateAndDeleteRole(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewRoleService(NewMockRoleRepository(), userRepo)
+	require.NoError(t, service.EnsureDefaultRoles(context.Background()))
+
+	role, err := service.CreateRole(context.Background(), "editor", "Edits the catalog", []entities.Permission{entities.PermissionProductWrite})
+	require.NoError(t, err)
+	assert.True(t, role.HasPermission(entities.PermissionProductWrite))
+
+	_, err = service.CreateRole(context.Background(), "editor", "", nil)
+	assert.ErrorIs(t, err, ErrRoleAlreadyExists)
+
+	_, err = service.CreateRole(context.Background(), "broken", "", []entities.Permission{"product:fly"})
+	assert.Error(t, err)
+
+	// Built-in roles cannot be deleted
+	assert.ErrorIs(t, service.DeleteRole(context.Background(), entities.RoleSupport), ErrRoleInUse)
+
+	// Roles assigned to users cannot be deleted
+	editor, _ := entities.NewUser("user-id", "editor", "editor@example.com", "hashed-password")
+	userRepo.On("FindWithFilter", repositories.UserFilter{Role: "editor"}).Return([]*entities.User{editor}, nil).Once()
+	assert.ErrorIs(t, service.DeleteRole(context.Background(), "editor"), ErrRoleInUse)
+
+	userRepo.On("FindWithFilter", repositories.UserFilter{Role: "editor"}).Return([]*entities.User{}, nil).Once()
+	require.NoError(t, service.DeleteRole(context.Background(), "editor"))
+
+	_, err = service.GetRole(context.Background(), "editor")
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+
+	// Unknown roles grant nothing
+	granted, err := service.HasPermission(context.Background(), "editor", entities.PermissionProductWrite)
+	require.NoError(t, err)
+	assert.False(t, granted)
+}
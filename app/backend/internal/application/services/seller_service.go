@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
 	"github.com/sklinkert/go-ddd/internal/application/mapper"
 	"github.com/sklinkert/go-ddd/internal/application/query"
@@ -11,17 +13,63 @@ import (
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 )
 
+var (
+	// ErrNotSellerMember is returned when the acting user does not belong to the seller it acts on
+	ErrNotSellerMember = entities.NewError(entities.ErrForbidden, "user is not a member of the seller")
+	// ErrSellerNotFound is returned when the seller to act on does not exist
+	ErrSellerNotFound = repositories.ErrSellerNotFound
+	// ErrSellerMemberNotFound is returned when removing a user who is not a member of the seller
+	ErrSellerMemberNotFound = entities.NewError(entities.ErrNotFound, "seller member not found")
+	// ErrLastSellerOwner is returned when removing or demoting the only owner of a seller
+	ErrLastSellerOwner = entities.NewError(entities.ErrConflict, "the last owner of a seller cannot be removed or demoted")
+)
+
 type SellerService struct {
-	repo repositories.SellerRepository
+	repo                 repositories.SellerRepository
+	membershipRepository repositories.SellerMembershipRepository
+	unitOfWork           repositories.UnitOfWork
+	audit                *AuditService
 }
 
 // NewSellerService - Constructor for the service
-func NewSellerService(repo repositories.SellerRepository) interfaces.SellerService {
-	return &SellerService{repo: repo}
+func NewSellerService(
+	repo repositories.SellerRepository,
+	membershipRepository repositories.SellerMembershipRepository,
+	unitOfWork repositories.UnitOfWork,
+	audit *AuditService,
+) interfaces.SellerService {
+	return &SellerService{repo: repo, membershipRepository: membershipRepository, unitOfWork: unitOfWork, audit: audit}
 }
 
-// CreateSeller saves a new seller
-func (s *SellerService) CreateSeller(sellerCommand *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error) {
+// authorizeSellerAccess returns ErrNotSellerMember unless the actor may act on behalf of the seller.
+// If requireOwner is set, plain members are rejected as well.
+func authorizeSellerAccess(
+	ctx context.Context,
+	membershipRepository repositories.SellerMembershipRepository,
+	sellerId uuid.UUID,
+	actor common.Actor,
+	requireOwner bool,
+) error {
+	if actor.ManagesAllSellers {
+		return nil
+	}
+	if actor.UserId == "" {
+		return ErrNotSellerMember
+	}
+
+	membership, err := membershipRepository.Find(ctx, sellerId, actor.UserId)
+	if err != nil {
+		return err
+	}
+	if membership == nil || (requireOwner && !membership.IsOwner()) {
+		return ErrNotSellerMember
+	}
+
+	return nil
+}
+
+// CreateSeller saves a new seller together with the ownership of the creating user
+func (s *SellerService) CreateSeller(ctx context.Context, sellerCommand *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error) {
 	var newSeller = entities.NewSeller(sellerCommand.Name)
 
 	validatedSeller, err := entities.NewValidatedSeller(newSeller)
@@ -29,7 +77,25 @@ func (s *SellerService) CreateSeller(sellerCommand *command.CreateSellerCommand)
 		return nil, err
 	}
 
-	_, err = s.repo.Create(validatedSeller)
+	err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if _, err := tx.Sellers().Create(ctx, validatedSeller); err != nil {
+			return err
+		}
+
+		// The creating user owns the new seller
+		if sellerCommand.Actor.UserId != "" {
+			membership, err := entities.NewSellerMembership(validatedSeller.Id, sellerCommand.Actor.UserId, entities.SellerMemberRoleOwner)
+			if err != nil {
+				return err
+			}
+			if err := tx.SellerMemberships().Save(ctx, membership); err != nil {
+				return err
+			}
+		}
+
+		return s.audit.Record(ctx, tx, sellerCommand.Actor.UserId, entities.AuditActionCreate, entities.AuditAggregateSeller,
+			validatedSeller.Id.String(), nil, sellerAuditSnapshot(&validatedSeller.Seller))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -41,24 +107,61 @@ func (s *SellerService) CreateSeller(sellerCommand *command.CreateSellerCommand)
 	return &result, nil
 }
 
-// FindAllSellers fetches all sellers
-func (s *SellerService) FindAllSellers() (*query.SellerQueryListResult, error) {
-	storedSellers, err := s.repo.FindAll()
+// FindAllSellers fetches a page of the sellers matching the query
+func (s *SellerService) FindAllSellers(ctx context.Context, listQuery *query.ListSellersQuery) (*query.SellerQueryListResult, error) {
+	sort := listQuery.Sort
+	if sort.Field == "" {
+		sort.Field = repositories.SortByCreatedAt
+	}
+	page := listQuery.Page.WithDefaults()
+
+	storedSellers, err := s.repo.FindPage(ctx, listQuery.Filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult := query.SellerQueryListResult{
+		Page: common.PageResult{
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			TotalCount: storedSellers.TotalCount,
+			NextCursor: storedSellers.NextCursor,
+		},
+	}
+	for _, seller := range storedSellers.Sellers {
+		queryResult.Result = append(queryResult.Result, mapper.NewSellerResultFromEntity(seller))
+	}
+
+	return &queryResult, nil
+}
+
+// FindSellersByMember fetches all sellers the user owns or is a member of
+func (s *SellerService) FindSellersByMember(ctx context.Context, userId string) (*query.SellerQueryListResult, error) {
+	memberships, err := s.membershipRepository.FindByUser(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
 
 	var queryResult query.SellerQueryListResult
-	for _, seller := range storedSellers {
+	for _, membership := range memberships {
+		seller, err := s.repo.FindById(ctx, membership.SellerId)
+		if errors.Is(err, repositories.ErrSellerNotFound) {
+			// The memberships of deleted sellers are kept until the seller is purged
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
 		queryResult.Result = append(queryResult.Result, mapper.NewSellerResultFromEntity(seller))
 	}
+	queryResult.Page = common.PageResult{Limit: len(queryResult.Result), TotalCount: int64(len(queryResult.Result))}
 
 	return &queryResult, nil
 }
 
 // FindSellerById fetches a specific seller by Id
-func (s *SellerService) FindSellerById(id uuid.UUID) (*query.SellerQueryResult, error) {
-	storedSeller, err := s.repo.FindById(id)
+func (s *SellerService) FindSellerById(ctx context.Context, id uuid.UUID) (*query.SellerQueryResult, error) {
+	storedSeller, err := s.repo.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -70,16 +173,25 @@ func (s *SellerService) FindSellerById(id uuid.UUID) (*query.SellerQueryResult,
 }
 
 // UpdateSeller updates a seller
-func (s *SellerService) UpdateSeller(updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error) {
-	seller, err := s.repo.FindById(updateCommand.Id)
+func (s *SellerService) UpdateSeller(ctx context.Context, updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error) {
+	if err := authorizeSellerAccess(ctx, s.membershipRepository, updateCommand.Id, updateCommand.Actor, false); err != nil {
+		return nil, err
+	}
+
+	seller, err := s.repo.FindById(ctx, updateCommand.Id)
 	if err != nil {
 		return nil, err
 	}
 
 	if seller == nil {
-		return nil, errors.New("seller not found")
+		return nil, ErrSellerNotFound
 	}
 
+	if err := checkVersion(updateCommand.ExpectedVersion, seller.Version); err != nil {
+		return nil, err
+	}
+
+	before := sellerAuditSnapshot(seller)
 	if err := seller.UpdateName(updateCommand.Name); err != nil {
 		return nil, err
 	}
@@ -89,18 +201,198 @@ func (s *SellerService) UpdateSeller(updateCommand *command.UpdateSellerCommand)
 		return nil, err
 	}
 
-	_, err = s.repo.Update(validatedUpdatedSeller)
+	var updatedSeller *entities.Seller
+	err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if updatedSeller, err = tx.Sellers().Update(ctx, validatedUpdatedSeller); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, updateCommand.Actor.UserId, entities.AuditActionUpdate, entities.AuditAggregateSeller,
+			updatedSeller.Id.String(), before, sellerAuditSnapshot(updatedSeller))
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	result := command.UpdateSellerCommandResult{
-		Result: mapper.NewSellerResultFromEntity(seller),
+		Result: mapper.NewSellerResultFromEntity(updatedSeller),
 	}
 
 	return &result, nil
 }
 
-func (s *SellerService) DeleteSeller(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// DeleteSeller soft deletes a seller together with its products, the memberships are kept so that the owners
+// can restore it until it is purged. Only owners may delete a seller.
+// If an expected version is given, it returns ErrVersionConflict if the seller has another version.
+func (s *SellerService) DeleteSeller(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error {
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := authorizeSellerAccess(ctx, tx.SellerMemberships(), id, actor, true); err != nil {
+			return err
+		}
+
+		seller, err := tx.Sellers().FindById(ctx, id)
+		if errors.Is(err, repositories.ErrSellerNotFound) && expectedVersion == nil {
+			// The seller is deleted already
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(expectedVersion, seller.Version); err != nil {
+			return err
+		}
+		before := sellerAuditSnapshot(seller)
+
+		// The seller is deleted first, RestoreSeller identifies the products deleted with it by their deletion time
+		if err := tx.Sellers().Delete(ctx, id, seller.Version); err != nil {
+			return err
+		}
+		if err := tx.Products().DeleteBySeller(ctx, id); err != nil {
+			return err
+		}
+
+		deleted, err := tx.Sellers().FindByIdIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, actor.UserId, entities.AuditActionDelete, entities.AuditAggregateSeller, id.String(), before, sellerAuditSnapshot(deleted))
+	})
+}
+
+// RestoreSeller restores a soft deleted seller together with the products deleted with it, products which
+// were deleted before stay deleted. Only owners may restore a seller. Restoring a seller which is not deleted
+// returns it unchanged.
+func (s *SellerService) RestoreSeller(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreSellerCommandResult, error) {
+	var restored *entities.Seller
+	err := s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := authorizeSellerAccess(ctx, tx.SellerMemberships(), id, actor, true); err != nil {
+			return err
+		}
+
+		seller, err := tx.Sellers().FindByIdIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !seller.IsDeleted() {
+			restored = seller
+			return nil
+		}
+
+		before := sellerAuditSnapshot(seller)
+		deletedAt := *seller.DeletedAt
+		if restored, err = tx.Sellers().Restore(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.Products().RestoreBySeller(ctx, id, deletedAt); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, actor.UserId, entities.AuditActionRestore, entities.AuditAggregateSeller, id.String(), before, sellerAuditSnapshot(restored))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &command.RestoreSellerCommandResult{Result: mapper.NewSellerResultFromEntity(restored)}, nil
+}
+
+// AddSellerMember adds a user to a seller or changes the role of an existing member.
+// Only owners may manage the members of a seller, and the last owner cannot be demoted.
+func (s *SellerService) AddSellerMember(ctx context.Context, memberCommand *command.AddSellerMemberCommand) error {
+	membership, err := entities.NewSellerMembership(memberCommand.SellerId, memberCommand.UserId, memberCommand.Role)
+	if err != nil {
+		return err
+	}
+
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := lockSellerMembers(ctx, tx, memberCommand.SellerId); err != nil {
+			return err
+		}
+		if err := authorizeSellerAccess(ctx, tx.SellerMemberships(), memberCommand.SellerId, memberCommand.Actor, true); err != nil {
+			return err
+		}
+
+		user, err := tx.Users().FindByID(ctx, memberCommand.UserId)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+
+		existing, err := tx.SellerMemberships().Find(ctx, memberCommand.SellerId, memberCommand.UserId)
+		if err != nil {
+			return err
+		}
+
+		// Re-adding an owner with a lower role demotes them, which must not leave the seller without an owner
+		if existing != nil && existing.IsOwner() && !membership.IsOwner() {
+			if err := checkRemainingOwners(ctx, tx.SellerMemberships(), memberCommand.SellerId); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.SellerMemberships().Save(ctx, membership); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, memberCommand.Actor.UserId, entities.AuditActionAddMember, entities.AuditAggregateSeller,
+			memberCommand.SellerId.String(), membershipAuditSnapshot(existing), membershipAuditSnapshot(membership))
+	})
+}
+
+// RemoveSellerMember removes a user from a seller. Only owners may manage the members of a seller,
+// and the last owner cannot be removed.
+func (s *SellerService) RemoveSellerMember(ctx context.Context, sellerId uuid.UUID, userId string, actor common.Actor) error {
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := lockSellerMembers(ctx, tx, sellerId); err != nil {
+			return err
+		}
+		if err := authorizeSellerAccess(ctx, tx.SellerMemberships(), sellerId, actor, true); err != nil {
+			return err
+		}
+
+		removed, err := tx.SellerMemberships().Find(ctx, sellerId, userId)
+		if err != nil {
+			return err
+		}
+		if removed == nil {
+			return ErrSellerMemberNotFound
+		}
+
+		if removed.IsOwner() {
+			if err := checkRemainingOwners(ctx, tx.SellerMemberships(), sellerId); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.SellerMemberships().Delete(ctx, sellerId, userId); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, actor.UserId, entities.AuditActionRemoveMember, entities.AuditAggregateSeller,
+			sellerId.String(), membershipAuditSnapshot(removed), nil)
+	})
+}
+
+// lockSellerMembers locks the seller until the transaction ends, so that concurrent changes of its members
+// run one after another and cannot both remove the last owners. It returns ErrSellerNotFound if there is no seller.
+func lockSellerMembers(ctx context.Context, tx repositories.Transaction, sellerId uuid.UUID) error {
+	_, err := tx.Sellers().FindByIdForUpdate(ctx, sellerId)
+	return err
+}
+
+// checkRemainingOwners returns ErrLastSellerOwner unless the seller has another owner besides the one being removed
+func checkRemainingOwners(ctx context.Context, membershipRepository repositories.SellerMembershipRepository, sellerId uuid.UUID) error {
+	memberships, err := membershipRepository.FindBySeller(ctx, sellerId)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	for _, membership := range memberships {
+		if membership.IsOwner() {
+			owners++
+		}
+	}
+	if owners <= 1 {
+		return ErrLastSellerOwner
+	}
+	return nil
 }
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/query"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,15 +24,16 @@ func TestSellerService_Integration_CreateSeller(t *testing.T) {
 	sellerRepo := postgres.NewGormSellerRepository(db)
 
 	// Create service
-	sellerService := NewSellerService(sellerRepo)
+	sellerService := NewSellerService(sellerRepo, postgres.NewGormSellerMembershipRepository(db), postgres.NewGormUnitOfWork(db), NewAuditService(postgres.NewGormAuditRepository(db)))
 
 	// Test creating a seller
 	sellerName := "Test Seller"
 	createSellerCmd := &command.CreateSellerCommand{
-		Name: sellerName,
+		Name:  sellerName,
+		Actor: testSellerOwner,
 	}
 
-	result, err := sellerService.CreateSeller(createSellerCmd)
+	result, err := sellerService.CreateSeller(context.Background(), createSellerCmd)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotNil(t, result.Result)
@@ -47,21 +50,22 @@ func TestSellerService_Integration_FindAllSellers(t *testing.T) {
 	sellerRepo := postgres.NewGormSellerRepository(db)
 
 	// Create service
-	sellerService := NewSellerService(sellerRepo)
+	sellerService := NewSellerService(sellerRepo, postgres.NewGormSellerMembershipRepository(db), postgres.NewGormUnitOfWork(db), NewAuditService(postgres.NewGormAuditRepository(db)))
 
 	// Create multiple sellers
 	for i := 1; i <= 3; i++ {
 		sellerName := fmt.Sprintf("Test Seller %d", i)
 		createSellerCmd := &command.CreateSellerCommand{
-			Name: sellerName,
+			Name:  sellerName,
+			Actor: testSellerOwner,
 		}
 
-		_, err := sellerService.CreateSeller(createSellerCmd)
+		_, err := sellerService.CreateSeller(context.Background(), createSellerCmd)
 		assert.NoError(t, err)
 	}
 
 	// Test finding all sellers
-	result, err := sellerService.FindAllSellers()
+	result, err := sellerService.FindAllSellers(context.Background(), &query.ListSellersQuery{})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotNil(t, result.Result)
@@ -77,20 +81,21 @@ func TestSellerService_Integration_FindSellerById(t *testing.T) {
 	sellerRepo := postgres.NewGormSellerRepository(db)
 
 	// Create service
-	sellerService := NewSellerService(sellerRepo)
+	sellerService := NewSellerService(sellerRepo, postgres.NewGormSellerMembershipRepository(db), postgres.NewGormUnitOfWork(db), NewAuditService(postgres.NewGormAuditRepository(db)))
 
 	// Create a seller
 	sellerName := "Test Seller"
 	createSellerCmd := &command.CreateSellerCommand{
-		Name: sellerName,
+		Name:  sellerName,
+		Actor: testSellerOwner,
 	}
 
-	createResult, err := sellerService.CreateSeller(createSellerCmd)
+	createResult, err := sellerService.CreateSeller(context.Background(), createSellerCmd)
 	assert.NoError(t, err)
 	sellerId := createResult.Result.Id
 
 	// Test finding seller by ID
-	findResult, err := sellerService.FindSellerById(sellerId)
+	findResult, err := sellerService.FindSellerById(context.Background(), sellerId)
 	assert.NoError(t, err)
 	assert.NotNil(t, findResult)
 	assert.NotNil(t, findResult.Result)
@@ -98,7 +103,7 @@ func TestSellerService_Integration_FindSellerById(t *testing.T) {
 	assert.Equal(t, sellerId, findResult.Result.Id)
 
 	// Test finding non-existent seller
-	_, err = sellerService.FindSellerById(uuid.New())
+	_, err = sellerService.FindSellerById(context.Background(), uuid.New())
 	assert.Error(t, err)
 }
 
@@ -111,26 +116,28 @@ func TestSellerService_Integration_UpdateSeller(t *testing.T) {
 	sellerRepo := postgres.NewGormSellerRepository(db)
 
 	// Create service
-	sellerService := NewSellerService(sellerRepo)
+	sellerService := NewSellerService(sellerRepo, postgres.NewGormSellerMembershipRepository(db), postgres.NewGormUnitOfWork(db), NewAuditService(postgres.NewGormAuditRepository(db)))
 
 	// Create a seller
 	sellerName := "Test Seller"
 	createSellerCmd := &command.CreateSellerCommand{
-		Name: sellerName,
+		Name:  sellerName,
+		Actor: testSellerOwner,
 	}
 
-	createResult, err := sellerService.CreateSeller(createSellerCmd)
+	createResult, err := sellerService.CreateSeller(context.Background(), createSellerCmd)
 	assert.NoError(t, err)
 	sellerId := createResult.Result.Id
 
 	// Test updating seller
 	updatedName := "Updated Seller"
 	updateSellerCmd := &command.UpdateSellerCommand{
-		Id:   sellerId,
-		Name: updatedName,
+		Id:    sellerId,
+		Name:  updatedName,
+		Actor: testSellerOwner,
 	}
 
-	updateResult, err := sellerService.UpdateSeller(updateSellerCmd)
+	updateResult, err := sellerService.UpdateSeller(context.Background(), updateSellerCmd)
 	assert.NoError(t, err)
 	assert.NotNil(t, updateResult)
 	assert.NotNil(t, updateResult.Result)
@@ -138,7 +145,7 @@ func TestSellerService_Integration_UpdateSeller(t *testing.T) {
 	assert.Equal(t, sellerId, updateResult.Result.Id)
 
 	// Verify the update by finding the seller
-	findResult, err := sellerService.FindSellerById(sellerId)
+	findResult, err := sellerService.FindSellerById(context.Background(), sellerId)
 	assert.NoError(t, err)
 	assert.Equal(t, updatedName, findResult.Result.Name)
 }
@@ -152,23 +159,24 @@ func TestSellerService_Integration_DeleteSeller(t *testing.T) {
 	sellerRepo := postgres.NewGormSellerRepository(db)
 
 	// Create service
-	sellerService := NewSellerService(sellerRepo)
+	sellerService := NewSellerService(sellerRepo, postgres.NewGormSellerMembershipRepository(db), postgres.NewGormUnitOfWork(db), NewAuditService(postgres.NewGormAuditRepository(db)))
 
 	// Create a seller
 	sellerName := "Test Seller"
 	createSellerCmd := &command.CreateSellerCommand{
-		Name: sellerName,
+		Name:  sellerName,
+		Actor: testSellerOwner,
 	}
 
-	createResult, err := sellerService.CreateSeller(createSellerCmd)
+	createResult, err := sellerService.CreateSeller(context.Background(), createSellerCmd)
 	assert.NoError(t, err)
 	sellerId := createResult.Result.Id
 
 	// Test deleting seller
-	err = sellerService.DeleteSeller(sellerId)
+	err = sellerService.DeleteSeller(context.Background(), sellerId, nil, testSellerOwner)
 	assert.NoError(t, err)
 
 	// Verify the deletion by trying to find the seller
-	_, err = sellerService.FindSellerById(sellerId)
+	_, err = sellerService.FindSellerById(context.Background(), sellerId)
 	assert.Error(t, err)
 }
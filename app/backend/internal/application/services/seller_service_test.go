@@ -1,12 +1,19 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/query"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 // MockSellerRepository is a mock implementation of the SellerRepository interface
@@ -14,20 +21,49 @@ type MockSellerRepository struct {
 	sellers []*entities.ValidatedSeller
 }
 
-func (m *MockSellerRepository) Create(seller *entities.ValidatedSeller) (*entities.Seller, error) {
+func (m *MockSellerRepository) Create(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error) {
 	m.sellers = append(m.sellers, seller)
 	return &seller.Seller, nil
 }
 
-func (m *MockSellerRepository) FindAll() ([]*entities.Seller, error) {
+func (m *MockSellerRepository) FindAll(ctx context.Context) ([]*entities.Seller, error) {
 	var sellers []*entities.Seller
 	for _, s := range m.sellers {
-		sellers = append(sellers, &s.Seller)
+		if !s.IsDeleted() {
+			sellers = append(sellers, &s.Seller)
+		}
 	}
 	return sellers, nil
 }
 
-func (m *MockSellerRepository) FindById(id uuid.UUID) (*entities.Seller, error) {
+func (m *MockSellerRepository) FindPage(ctx context.Context, filter repositories.SellerFilter, sort repositories.SortOrder, page repositories.PageRequest) (*repositories.SellerPage, error) {
+	sellers, _ := m.FindAll(ctx)
+	return &repositories.SellerPage{
+		Sellers:    pageOf(sellers, page),
+		TotalCount: int64(len(sellers)),
+	}, nil
+}
+
+// pageOf returns the items of the page. Mock repositories ignore filters and sort order.
+func pageOf[T any](items []T, page repositories.PageRequest) []T {
+	start := min(page.Offset, len(items))
+	end := min(start+page.Limit, len(items))
+	return items[start:end]
+}
+
+func (m *MockSellerRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	seller, err := m.FindByIdIncludingDeleted(ctx, id)
+	if err != nil || seller.IsDeleted() {
+		return nil, repositories.ErrSellerNotFound
+	}
+	return seller, nil
+}
+
+func (m *MockSellerRepository) FindByIdForUpdate(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	return m.FindById(ctx, id)
+}
+
+func (m *MockSellerRepository) FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
 	for _, s := range m.sellers {
 		if s.Id == id {
 			return &s.Seller, nil
@@ -35,20 +71,46 @@ func (m *MockSellerRepository) FindById(id uuid.UUID) (*entities.Seller, error)
 			fmt.Printf("Id: %s - %s\n", s.Id, id)
 		}
 	}
-	return nil, errors.New("seller not found")
+	return nil, repositories.ErrSellerNotFound
 }
 
-func (m *MockSellerRepository) Delete(id uuid.UUID) error {
-	for index, s := range m.sellers {
-		if s.Id == id {
-			m.sellers = append(m.sellers[:index], m.sellers[index+1:]...)
+func (m *MockSellerRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	for _, s := range m.sellers {
+		if s.Id == id && !s.IsDeleted() {
+			if s.Version != version {
+				return repositories.ErrVersionConflict
+			}
+			now := time.Now()
+			s.DeletedAt = &now
 			return nil
 		}
 	}
 	return errors.New("seller not found for deletion")
 }
 
-func (m *MockSellerRepository) Update(seller *entities.ValidatedSeller) (*entities.Seller, error) {
+func (m *MockSellerRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	seller, err := m.FindByIdIncludingDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	seller.DeletedAt = nil
+	return seller, nil
+}
+
+// Purge removes the sellers deleted before the given time, the mock does not check for remaining products
+func (m *MockSellerRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var remaining []*entities.ValidatedSeller
+	for _, s := range m.sellers {
+		if !s.IsDeleted() || !s.DeletedAt.Before(deletedBefore) {
+			remaining = append(remaining, s)
+		}
+	}
+	purged := int64(len(m.sellers) - len(remaining))
+	m.sellers = remaining
+	return purged, nil
+}
+
+func (m *MockSellerRepository) Update(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error) {
 	for index, s := range m.sellers {
 		if s.Id == seller.Id {
 			m.sellers[index] = seller
@@ -58,11 +120,132 @@ func (m *MockSellerRepository) Update(seller *entities.ValidatedSeller) (*entiti
 	return nil, errors.New("seller not found for update")
 }
 
+// MockSellerMembershipRepository is an in-memory implementation of the SellerMembershipRepository interface
+type MockSellerMembershipRepository struct {
+	memberships []*entities.SellerMembership
+}
+
+func NewMockSellerMembershipRepository() *MockSellerMembershipRepository {
+	return &MockSellerMembershipRepository{}
+}
+
+func (m *MockSellerMembershipRepository) Save(ctx context.Context, membership *entities.SellerMembership) error {
+	_ = m.Delete(ctx, membership.SellerId, membership.UserId)
+	m.memberships = append(m.memberships, membership)
+	return nil
+}
+
+func (m *MockSellerMembershipRepository) Find(ctx context.Context, sellerId uuid.UUID, userId string) (*entities.SellerMembership, error) {
+	for _, membership := range m.memberships {
+		if membership.SellerId == sellerId && membership.UserId == userId {
+			return membership, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockSellerMembershipRepository) FindBySeller(ctx context.Context, sellerId uuid.UUID) ([]*entities.SellerMembership, error) {
+	var memberships []*entities.SellerMembership
+	for _, membership := range m.memberships {
+		if membership.SellerId == sellerId {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+func (m *MockSellerMembershipRepository) FindByUser(ctx context.Context, userId string) ([]*entities.SellerMembership, error) {
+	var memberships []*entities.SellerMembership
+	for _, membership := range m.memberships {
+		if membership.UserId == userId {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+func (m *MockSellerMembershipRepository) Delete(ctx context.Context, sellerId uuid.UUID, userId string) error {
+	for index, membership := range m.memberships {
+		if membership.SellerId == sellerId && membership.UserId == userId {
+			m.memberships = append(m.memberships[:index], m.memberships[index+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockSellerMembershipRepository) DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error {
+	var remaining []*entities.SellerMembership
+	for _, membership := range m.memberships {
+		if membership.SellerId != sellerId {
+			remaining = append(remaining, membership)
+		}
+	}
+	m.memberships = remaining
+	return nil
+}
+
+// MockUnitOfWork runs the work on the mock repositories, it cannot roll back
+type MockUnitOfWork struct {
+	products          *MockProductRepository
+	sellers           *MockSellerRepository
+	sellerMemberships *MockSellerMembershipRepository
+	users             repositories.UserRepository
+	exchangeRates     *MockExchangeRateRepository
+	audit             repositories.AuditRepository
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	return fn(m)
+}
+
+func (m *MockUnitOfWork) Products() repositories.ProductRepository {
+	return m.products
+}
+
+func (m *MockUnitOfWork) Sellers() repositories.SellerRepository {
+	return m.sellers
+}
+
+func (m *MockUnitOfWork) SellerMemberships() repositories.SellerMembershipRepository {
+	return m.sellerMemberships
+}
+
+func (m *MockUnitOfWork) Users() repositories.UserRepository {
+	return m.users
+}
+
+func (m *MockUnitOfWork) ExchangeRates() repositories.ExchangeRateRepository {
+	return m.exchangeRates
+}
+
+func (m *MockUnitOfWork) Audit() repositories.AuditRepository {
+	return m.audit
+}
+
+// unknownUserId is the only user which newMockMemberUsers does not find
+const unknownUserId = "unknown-user-id"
+
+// newMockMemberUsers returns a user repository which finds every user except unknownUserId
+func newMockMemberUsers() *MockUserRepository {
+	users := new(MockUserRepository)
+	users.On("FindByID", unknownUserId).Return(nil, nil)
+	users.On("FindByID", mock.Anything).Return(&entities.User{Status: entities.StatusActive}, nil)
+	return users
+}
+
+// newTestSellerService creates a SellerService whose unit of work uses the given mock repositories
+func newTestSellerService(repo *MockSellerRepository, membershipRepo *MockSellerMembershipRepository) interfaces.SellerService {
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: &MockProductRepository{}, sellers: repo, sellerMemberships: membershipRepo, users: newMockMemberUsers(), audit: auditRepo}
+	return NewSellerService(repo, membershipRepo, unitOfWork, NewAuditService(auditRepo))
+}
+
 func TestSellerService_CreateSeller(t *testing.T) {
 	repo := &MockSellerRepository{}
-	service := NewSellerService(repo)
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
 
-	_, err := service.CreateSeller(getCreateSellerCommand("John Doe"))
+	_, err := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -74,13 +257,13 @@ func TestSellerService_CreateSeller(t *testing.T) {
 
 func TestSellerService_GetAllSellers(t *testing.T) {
 	repo := &MockSellerRepository{}
-	service := NewSellerService(repo)
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
 
 	// Add two sellers
-	_, _ = service.CreateSeller(getCreateSellerCommand("John Doe"))
-	_, _ = service.CreateSeller(getCreateSellerCommand("Jane Doe"))
+	_, _ = service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
+	_, _ = service.CreateSeller(context.Background(), getCreateSellerCommand("Jane Doe"))
 
-	sellers, err := service.FindAllSellers()
+	sellers, err := service.FindAllSellers(context.Background(), &query.ListSellersQuery{})
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -92,12 +275,12 @@ func TestSellerService_GetAllSellers(t *testing.T) {
 
 func TestSellerService_GetSellerById(t *testing.T) {
 	repo := &MockSellerRepository{}
-	service := NewSellerService(repo)
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
 
-	createdSellerResult, _ := service.CreateSeller(getCreateSellerCommand("John Doe"))
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
 	sellerID := createdSellerResult.Result.Id
 
-	foundSeller, err := service.FindSellerById(sellerID)
+	foundSeller, err := service.FindSellerById(context.Background(), sellerID)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -106,7 +289,7 @@ func TestSellerService_GetSellerById(t *testing.T) {
 		t.Errorf("Expected seller name 'John Doe', but got %s", foundSeller.Result.Name)
 	}
 
-	_, err = service.FindSellerById(uuid.New()) // some non-existent Id
+	_, err = service.FindSellerById(context.Background(), uuid.New()) // some non-existent Id
 	if err == nil {
 		t.Error("Expected error for non-existent seller, but got none")
 	}
@@ -114,9 +297,9 @@ func TestSellerService_GetSellerById(t *testing.T) {
 
 func TestSellerService_UpdateSeller(t *testing.T) {
 	repo := &MockSellerRepository{}
-	service := NewSellerService(repo)
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
 
-	createdSellerResult, _ := service.CreateSeller(getCreateSellerCommand("John Doe"))
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
 	sellerId := createdSellerResult.Result.Id
 
 	var updatableSeller = entities.Seller{
@@ -124,22 +307,251 @@ func TestSellerService_UpdateSeller(t *testing.T) {
 		Name: "Doe Johnny",
 	}
 
-	_, err := service.UpdateSeller(&command.UpdateSellerCommand{
-		Id:   sellerId,
-		Name: updatableSeller.Name,
+	_, err := service.UpdateSeller(context.Background(), &command.UpdateSellerCommand{
+		Id:    sellerId,
+		Name:  updatableSeller.Name,
+		Actor: testSellerOwner,
 	})
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
 
-	updatedSeller, _ := service.FindSellerById(sellerId)
+	updatedSeller, _ := service.FindSellerById(context.Background(), sellerId)
 	if updatedSeller.Result.Name != "Doe Johnny" {
 		t.Errorf("Expected seller name 'Johnny Doe', but got %s", updatedSeller.Result.Name)
 	}
 }
 
+func TestSellerService_UpdateSellerChecksExpectedVersion(t *testing.T) {
+	repo := &MockSellerRepository{}
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
+
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
+	sellerId := createdSellerResult.Result.Id
+
+	staleVersion := createdSellerResult.Result.Version + 1
+	_, err := service.UpdateSeller(context.Background(), &command.UpdateSellerCommand{
+		Id:              sellerId,
+		Name:            "Doe Johnny",
+		ExpectedVersion: &staleVersion,
+		Actor:           testSellerOwner,
+	})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, but got %v", err)
+	}
+
+	if err := service.DeleteSeller(context.Background(), sellerId, &staleVersion, testSellerOwner); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, but got %v", err)
+	}
+
+	currentVersion := createdSellerResult.Result.Version
+	if err := service.DeleteSeller(context.Background(), sellerId, &currentVersion, testSellerOwner); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestSellerService_MembershipIsEnforced(t *testing.T) {
+	repo := &MockSellerRepository{}
+	service := newTestSellerService(repo, NewMockSellerMembershipRepository())
+
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
+	sellerId := createdSellerResult.Result.Id
+
+	stranger := common.Actor{UserId: "stranger-id"}
+	member := common.Actor{UserId: "member-id"}
+
+	_, err := service.UpdateSeller(context.Background(), &command.UpdateSellerCommand{Id: sellerId, Name: "Hijacked", Actor: stranger})
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember for a stranger, but got %v", err)
+	}
+
+	// Only owners manage members
+	err = service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: sellerId, UserId: member.UserId, Role: entities.SellerMemberRoleMember, Actor: stranger,
+	})
+	if !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember when a stranger adds members, but got %v", err)
+	}
+
+	err = service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: sellerId, UserId: member.UserId, Role: entities.SellerMemberRoleMember, Actor: testSellerOwner,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Members may update, but not delete the seller
+	if _, err := service.UpdateSeller(context.Background(), &command.UpdateSellerCommand{Id: sellerId, Name: "Jane Doe", Actor: member}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if err := service.DeleteSeller(context.Background(), sellerId, nil, member); !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember when a member deletes the seller, but got %v", err)
+	}
+
+	mySellers, err := service.FindSellersByMember(context.Background(), member.UserId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(mySellers.Result) != 1 || mySellers.Result[0].Id != sellerId {
+		t.Errorf("Expected the member to see its seller, but got %v", mySellers.Result)
+	}
+
+	// The last owner cannot be removed
+	if err := service.RemoveSellerMember(context.Background(), sellerId, testSellerOwner.UserId, testSellerOwner); err == nil {
+		t.Error("Expected error when removing the last owner, but got none")
+	}
+
+	// Staff may act on behalf of any seller
+	if _, err := service.UpdateSeller(context.Background(), &command.UpdateSellerCommand{
+		Id: sellerId, Name: "Moderated", Actor: common.Actor{UserId: "staff-id", ManagesAllSellers: true},
+	}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if err := service.DeleteSeller(context.Background(), sellerId, nil, testSellerOwner); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	mySellers, _ = service.FindSellersByMember(context.Background(), member.UserId)
+	if len(mySellers.Result) != 0 {
+		t.Errorf("Expected deleted sellers to be left out, but got %v", mySellers.Result)
+	}
+}
+
+func TestSellerService_LastOwnerCannotBeDemoted(t *testing.T) {
+	service := newTestSellerService(&MockSellerRepository{}, NewMockSellerMembershipRepository())
+
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
+	sellerId := createdSellerResult.Result.Id
+
+	demote := &command.AddSellerMemberCommand{
+		SellerId: sellerId, UserId: testSellerOwner.UserId, Role: entities.SellerMemberRoleMember, Actor: testSellerOwner,
+	}
+	if err := service.AddSellerMember(context.Background(), demote); !errors.Is(err, ErrLastSellerOwner) {
+		t.Errorf("Expected ErrLastSellerOwner when demoting the last owner, but got %v", err)
+	}
+
+	// Once there is another owner, the former owner may step down
+	err := service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: sellerId, UserId: "co-owner-id", Role: entities.SellerMemberRoleOwner, Actor: testSellerOwner,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := service.AddSellerMember(context.Background(), demote); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if err := service.RemoveSellerMember(context.Background(), sellerId, "co-owner-id", common.Actor{UserId: "co-owner-id"}); !errors.Is(err, ErrLastSellerOwner) {
+		t.Errorf("Expected ErrLastSellerOwner when removing the remaining owner, but got %v", err)
+	}
+}
+
+func TestSellerService_AddSellerMemberRequiresExistingUserAndSeller(t *testing.T) {
+	service := newTestSellerService(&MockSellerRepository{}, NewMockSellerMembershipRepository())
+	staff := common.Actor{UserId: "staff-id", ManagesAllSellers: true}
+
+	createdSellerResult, _ := service.CreateSeller(context.Background(), getCreateSellerCommand("John Doe"))
+	err := service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: createdSellerResult.Result.Id, UserId: unknownUserId, Role: entities.SellerMemberRoleMember, Actor: testSellerOwner,
+	})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound when adding an unknown user, but got %v", err)
+	}
+
+	err = service.AddSellerMember(context.Background(), &command.AddSellerMemberCommand{
+		SellerId: uuid.New(), UserId: "member-id", Role: entities.SellerMemberRoleMember, Actor: staff,
+	})
+	if !errors.Is(err, repositories.ErrSellerNotFound) {
+		t.Errorf("Expected ErrSellerNotFound when adding a member to an unknown seller, but got %v", err)
+	}
+}
+
+func TestSellerService_DeleteSellerDeletesProducts(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: productRepo, sellers: sellerRepo, sellerMemberships: membershipRepo, users: newMockMemberUsers(), audit: auditRepo}
+	service := NewSellerService(sellerRepo, membershipRepo, unitOfWork, NewAuditService(auditRepo))
+
+	created, err := service.CreateSeller(context.Background(), getCreateSellerCommand("Seller"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	seller, _ := entities.NewValidatedSeller(&entities.Seller{Id: created.Result.Id, Name: created.Result.Name})
+	otherSeller, _ := entities.NewValidatedSeller(entities.NewSeller("Other"))
+	for _, owner := range []*entities.ValidatedSeller{seller, otherSeller} {
+		product, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *owner))
+		_, _ = productRepo.Create(context.Background(), product)
+	}
+
+	if err := service.DeleteSeller(context.Background(), seller.Id, nil, testSellerOwner); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	remaining, _ := productRepo.FindAll(context.Background())
+	if len(remaining) != 1 || remaining[0].Seller.Id != otherSeller.Id {
+		t.Errorf("Expected only the products of the other seller to remain, but got %d products", len(remaining))
+	}
+	if _, err := sellerRepo.FindById(context.Background(), seller.Id); !errors.Is(err, repositories.ErrSellerNotFound) {
+		t.Errorf("Expected the seller to be deleted, but got %v", err)
+	}
+}
+
+func TestSellerService_RestoreSeller(t *testing.T) {
+	productRepo := &MockProductRepository{}
+	sellerRepo := &MockSellerRepository{}
+	membershipRepo := NewMockSellerMembershipRepository()
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{products: productRepo, sellers: sellerRepo, sellerMemberships: membershipRepo, users: newMockMemberUsers(), audit: auditRepo}
+	service := NewSellerService(sellerRepo, membershipRepo, unitOfWork, NewAuditService(auditRepo))
+
+	created, err := service.CreateSeller(context.Background(), getCreateSellerCommand("Seller"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	seller, _ := entities.NewValidatedSeller(&entities.Seller{Id: created.Result.Id, Name: created.Result.Name})
+	deletedBefore, _ := entities.NewValidatedProduct(entities.NewProduct("Old Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller))
+	deletedWithSeller, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller))
+	_, _ = productRepo.Create(context.Background(), deletedBefore)
+	_, _ = productRepo.Create(context.Background(), deletedWithSeller)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	deletedBefore.DeletedAt = &yesterday
+
+	if err := service.DeleteSeller(context.Background(), seller.Id, nil, testSellerOwner); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := service.RestoreSeller(context.Background(), seller.Id, common.Actor{UserId: "stranger-id"}); !errors.Is(err, ErrNotSellerMember) {
+		t.Errorf("Expected ErrNotSellerMember, but got %v", err)
+	}
+
+	result, err := service.RestoreSeller(context.Background(), seller.Id, testSellerOwner)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Result.DeletedAt != nil {
+		t.Errorf("Expected the seller to be restored, but it was deleted at %v", result.Result.DeletedAt)
+	}
+	if _, err := productRepo.FindById(context.Background(), deletedWithSeller.Id); err != nil {
+		t.Errorf("Expected the product deleted with the seller to be restored, but got %v", err)
+	}
+	if _, err := productRepo.FindById(context.Background(), deletedBefore.Id); !errors.Is(err, repositories.ErrProductNotFound) {
+		t.Errorf("Expected the product deleted before the seller to stay deleted, but got %v", err)
+	}
+
+	// Restoring a seller which is not deleted changes nothing
+	if _, err := service.RestoreSeller(context.Background(), seller.Id, testSellerOwner); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+// testSellerOwner is the actor creating sellers in tests
+var testSellerOwner = common.Actor{UserId: "owner-id"}
+
 func getCreateSellerCommand(name string) *command.CreateSellerCommand {
 	return &command.CreateSellerCommand{
-		Name: name,
+		Name:  name,
+		Actor: testSellerOwner,
 	}
 }
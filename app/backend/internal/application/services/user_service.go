@@ -1,64 +1,136 @@
 package services
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUserNotFound is returned when the user to act on does not exist
+	ErrUserNotFound = entities.NewError(entities.ErrNotFound, "user not found")
+	// ErrEmailTaken is returned when the email is already used by another user
+	ErrEmailTaken = entities.NewError(entities.ErrConflict, "user with this email already exists")
+	// ErrUsernameTaken is returned when the username is already used by another user
+	ErrUsernameTaken = entities.NewError(entities.ErrConflict, "user with this username already exists")
+	// ErrInvalidCredentials is returned when logging in with an unknown email or a wrong password
+	ErrInvalidCredentials = entities.NewError(entities.ErrUnauthorized, "invalid email or password")
+	// ErrAccountLocked is returned when logging into a locked account
+	ErrAccountLocked = entities.NewError(entities.ErrForbidden, "user account is locked")
+	// ErrTooManyLoginAttempts is returned while logins from a client IP are blocked
+	ErrTooManyLoginAttempts = entities.NewError(entities.ErrTooManyRequests, "too many failed login attempts, try again later")
 )
 
 // UserService handles user-related operations
 type UserService struct {
-	userRepository repositories.UserRepository
+	userRepository         repositories.UserRepository
+	loginAttemptRepository repositories.LoginAttemptRepository
+	passwordHasher         interfaces.PasswordHasher
+	loginProtection        *config.LoginProtectionConfig
+	unitOfWork             repositories.UnitOfWork
+	audit                  *AuditService
+	now                    func() time.Time
+
+	// dummyHash is verified for unknown users so that response times
+	// do not reveal whether an email address is registered
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewUserService creates a new UserService
-func NewUserService(userRepository repositories.UserRepository) *UserService {
+func NewUserService(
+	userRepository repositories.UserRepository,
+	loginAttemptRepository repositories.LoginAttemptRepository,
+	passwordHasher interfaces.PasswordHasher,
+	loginProtection *config.LoginProtectionConfig,
+	unitOfWork repositories.UnitOfWork,
+	audit *AuditService,
+) *UserService {
 	return &UserService{
-		userRepository: userRepository,
+		userRepository:         userRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		passwordHasher:         passwordHasher,
+		loginProtection:        loginProtection,
+		unitOfWork:             unitOfWork,
+		audit:                  audit,
+		now:                    time.Now,
 	}
 }
 
-// RegisterUser registers a new user
-func (s *UserService) RegisterUser(username, email, password string) (*entities.User, error) {
+// RegisterUser registers a new user with the default role and status
+func (s *UserService) RegisterUser(ctx context.Context, username, email, password string) (*entities.User, error) {
+	return s.CreateUser(ctx, &command.CreateUserCommand{Username: username, Email: email, Password: password})
+}
+
+// CreateUser creates a user with the given role and status. The user is saved and recorded in one transaction,
+// so that it is never stored with the default role or status instead.
+func (s *UserService) CreateUser(ctx context.Context, createCommand *command.CreateUserCommand) (*entities.User, error) {
 	// Check if user already exists with this email
-	existingUser, err := s.userRepository.FindByEmail(email)
+	existingUser, err := s.userRepository.FindByEmail(ctx, createCommand.Email)
 	if err != nil {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrEmailTaken
 	}
 
 	// Check if user already exists with this username
-	existingUser, err = s.userRepository.FindByUsername(username)
+	existingUser, err = s.userRepository.FindByUsername(ctx, createCommand.Username)
 	if err != nil {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this username already exists")
+		return nil, ErrUsernameTaken
 	}
 
 	// Hash the password
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
+	hashedPassword, err := s.passwordHasher.Hash(createCommand.Password)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a new user
 	user, err := entities.NewUser(
 		uuid.New().String(),
-		username,
-		email,
-		string(hashedPassword),
+		createCommand.Username,
+		createCommand.Email,
+		hashedPassword,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if createCommand.Role != "" {
+		if err := user.UpdateRole(createCommand.Role); err != nil {
+			return nil, err
+		}
+	}
+	if createCommand.Status != "" && createCommand.Status != user.Status {
+		if createCommand.Status == entities.StatusLocked {
+			err = user.Lock("", time.Time{})
+		} else {
+			err = user.ChangeStatus(createCommand.Status, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Save the user
-	err = s.userRepository.Save(user)
+	err = s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, "", entities.AuditActionCreate, entities.AuditAggregateUser, user.ID, nil, userAuditSnapshot(user))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -66,173 +138,343 @@ func (s *UserService) RegisterUser(username, email, password string) (*entities.
 	return user, nil
 }
 
-// Authenticate authenticates a user with email and password
-func (s *UserService) Authenticate(email, password string) (*entities.User, error) {
+// Authenticate authenticates a user with email and password.
+// Consecutive failures lock the account and failures from the same client IP block further attempts.
+func (s *UserService) Authenticate(ctx context.Context, email, password, clientIP string) (*entities.User, error) {
+	now := s.now()
+
+	// Reject attempts from blocked client IPs before doing any work
+	attempt, err := s.findLoginAttempt(ctx, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if attempt != nil && attempt.IsBlocked(now) {
+		return nil, ErrTooManyLoginAttempts
+	}
+
 	// Find the user by email
-	user, err := s.userRepository.FindByEmail(email)
+	user, err := s.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		s.verifyDummyPassword(password)
+		s.recordFailedAttempt(ctx, clientIP, now)
+		return nil, ErrInvalidCredentials
+	}
+
+	// Unlock accounts whose lock has run out
+	if user.IsLockExpired(now) {
+		if user, err = s.unlockExpiredUser(ctx, user); err != nil {
+			return nil, err
+		}
 	}
 
 	// Check if user is active
-	if user.Status != entities.StatusActive {
-		return nil, errors.New("user account is not active")
+	if user.Status == entities.StatusLocked {
+		s.recordFailedAttempt(ctx, clientIP, now)
+		return nil, ErrAccountLocked
+	}
+	if !user.IsActive() {
+		return nil, ErrUserNotActive
 	}
 
 	// Verify the password
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
+	valid, err := s.passwordHasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		s.recordFailedAttempt(ctx, clientIP, now)
+		if err := s.recordFailedLogin(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if attempt != nil {
+		if err := s.loginAttemptRepository.Delete(ctx, clientIP); err != nil {
+			log.Printf("Failed to reset login attempts of %s: %v", clientIP, err)
+		}
+	}
+
+	changed := false
+	if user.FailedLoginAttempts > 0 {
+		user.ResetFailedLogins()
+		changed = true
+	}
+
+	// Upgrade legacy or outdated hashes now that the plain password is known
+	if s.passwordHasher.NeedsRehash(user.PasswordHash) {
+		if err := s.rehashPassword(user, password); err != nil {
+			log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		} else {
+			changed = true
+		}
+	}
 
-	if user.PasswordHash != hashedPassword {
-		return nil, errors.New("invalid password")
+	if changed {
+		if err := s.userRepository.Save(ctx, user); err != nil {
+			log.Printf("Failed to save user %s after login: %v", user.ID, err)
+		}
 	}
 
 	return user, nil
 }
 
+// findLoginAttempt returns the failed logins of the client IP, or nil if there are none
+func (s *UserService) findLoginAttempt(ctx context.Context, clientIP string) (*entities.LoginAttempt, error) {
+	if clientIP == "" {
+		return nil, nil
+	}
+	return s.loginAttemptRepository.FindByClientIP(ctx, clientIP)
+}
+
+// recordFailedAttempt counts a failed login of the client IP and blocks it once the threshold is reached
+func (s *UserService) recordFailedAttempt(ctx context.Context, clientIP string, now time.Time) {
+	if clientIP == "" {
+		return
+	}
+
+	attempt, err := s.loginAttemptRepository.RecordFailure(ctx, clientIP, now, s.loginProtection.FailedAttemptWindow)
+	if err != nil {
+		log.Printf("Failed to record login attempt of %s: %v", clientIP, err)
+		return
+	}
+	if !attempt.ReachedThreshold(s.loginProtection.MaxFailedAttemptsPerIP) {
+		return
+	}
+	if err := s.loginAttemptRepository.Block(ctx, clientIP, now.Add(s.loginProtection.IPBlockDuration)); err != nil {
+		log.Printf("Failed to block login attempts of %s: %v", clientIP, err)
+	}
+}
+
+// recordFailedLogin counts a failed login of the user atomically and locks the account once the threshold is reached
+func (s *UserService) recordFailedLogin(ctx context.Context, userId string, now time.Time) error {
+	user, err := s.userRepository.IncrementFailedLogins(ctx, userId)
+	if err != nil || user == nil {
+		return err
+	}
+
+	before := userAuditSnapshot(user)
+	locked, err := user.LockAfterFailedLogins(now, s.loginProtection.MaxFailedAttemptsPerAccount, s.loginProtection.AccountLockDuration)
+	if err != nil || !locked {
+		return err
+	}
+	// A concurrent failure changed the user in between; it counted the next attempt and locks the account itself
+	if err := s.saveChangedUser(ctx, user, before); err != nil && !errors.Is(err, repositories.ErrVersionConflict) {
+		return err
+	}
+	return nil
+}
+
+// unlockExpiredUser activates a user whose lock has run out. If a concurrent login already changed the user,
+// the stored user is returned instead.
+func (s *UserService) unlockExpiredUser(ctx context.Context, user *entities.User) (*entities.User, error) {
+	before := userAuditSnapshot(user)
+	if err := user.ChangeStatus(entities.StatusActive, "lock expired"); err != nil {
+		return nil, err
+	}
+	err := s.saveChangedUser(ctx, user, before)
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		if user, err = s.userRepository.FindByID(ctx, user.ID); err == nil && user == nil {
+			err = ErrInvalidCredentials
+		}
+		return user, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// rehashPassword replaces the stored hash of the user with a hash of the preferred algorithm
+func (s *UserService) rehashPassword(user *entities.User, password string) error {
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return user.UpdatePassword(hashedPassword)
+}
+
+// verifyDummyPassword spends the same effort as a real verification
+func (s *UserService) verifyDummyPassword(password string) {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.passwordHasher.Hash(uuid.New().String())
+	})
+	if s.dummyHash != "" {
+		_, _ = s.passwordHasher.Verify(password, s.dummyHash)
+	}
+}
+
 // GetUserByID retrieves a user by ID
-func (s *UserService) GetUserByID(id string) (*entities.User, error) {
-	return s.userRepository.FindByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id string) (*entities.User, error) {
+	return s.userRepository.FindByID(ctx, id)
 }
 
 // GetUserByEmail retrieves a user by email
-func (s *UserService) GetUserByEmail(email string) (*entities.User, error) {
-	return s.userRepository.FindByEmail(email)
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	return s.userRepository.FindByEmail(ctx, email)
 }
 
 // GetUserByUsername retrieves a user by username
-func (s *UserService) GetUserByUsername(username string) (*entities.User, error) {
-	return s.userRepository.FindByUsername(username)
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*entities.User, error) {
+	return s.userRepository.FindByUsername(ctx, username)
 }
 
 // GetAllUsers retrieves all users
-func (s *UserService) GetAllUsers() ([]*entities.User, error) {
-	return s.userRepository.FindAll()
+func (s *UserService) GetAllUsers(ctx context.Context) ([]*entities.User, error) {
+	return s.userRepository.FindAll(ctx)
 }
 
 // FindUsers retrieves users matching the given filter
-func (s *UserService) FindUsers(filter repositories.UserFilter) ([]*entities.User, error) {
-	return s.userRepository.FindWithFilter(filter)
+func (s *UserService) FindUsers(ctx context.Context, filter repositories.UserFilter) ([]*entities.User, error) {
+	return s.userRepository.FindWithFilter(ctx, filter)
 }
 
-// UpdateUserUsername updates a user's username
-func (s *UserService) UpdateUserUsername(id, username string) error {
-	// Check if username is already taken
-	existingUser, err := s.userRepository.FindByUsername(username)
+// UpdateUser changes the username, email and/or password of a user, all of them are saved together
+func (s *UserService) UpdateUser(ctx context.Context, updateCommand *command.UpdateUserCommand) (*entities.User, error) {
+	user, err := s.findUserForUpdate(ctx, updateCommand.Id, updateCommand.ExpectedVersion)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if existingUser != nil && existingUser.ID != id {
-		return errors.New("username already taken")
+	before := userAuditSnapshot(user)
+
+	if updateCommand.Username != nil {
+		// Check if username is already taken
+		existingUser, err := s.userRepository.FindByUsername(ctx, *updateCommand.Username)
+		if err != nil {
+			return nil, err
+		}
+		if existingUser != nil && existingUser.ID != user.ID {
+			return nil, ErrUsernameTaken
+		}
+		if err := user.UpdateUsername(*updateCommand.Username); err != nil {
+			return nil, err
+		}
 	}
 
-	user, err := s.userRepository.FindByID(id)
-	if err != nil {
-		return err
-	}
-	if user == nil {
-		return errors.New("user not found")
+	if updateCommand.Email != nil {
+		// Check if email is already taken
+		existingUser, err := s.userRepository.FindByEmail(ctx, *updateCommand.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existingUser != nil && existingUser.ID != user.ID {
+			return nil, ErrEmailTaken
+		}
+		if err := user.UpdateEmail(*updateCommand.Email); err != nil {
+			return nil, err
+		}
 	}
 
-	err = user.UpdateUsername(username)
-	if err != nil {
-		return err
+	if updateCommand.Password != nil {
+		hashedPassword, err := s.passwordHasher.Hash(*updateCommand.Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := user.UpdatePassword(hashedPassword); err != nil {
+			return nil, err
+		}
 	}
 
-	return s.userRepository.Save(user)
+	if err := s.saveChangedUser(ctx, user, before); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// UpdateUserEmail updates a user's email
-func (s *UserService) UpdateUserEmail(id, email string) error {
-	// Check if email is already taken
-	existingUser, err := s.userRepository.FindByEmail(email)
+// UpdateUserRole updates a user's role
+func (s *UserService) UpdateUserRole(ctx context.Context, roleCommand *command.UpdateUserRoleCommand) (*entities.User, error) {
+	user, err := s.findUserForUpdate(ctx, roleCommand.Id, roleCommand.ExpectedVersion)
 	if err != nil {
-		return err
-	}
-	if existingUser != nil && existingUser.ID != id {
-		return errors.New("email already taken")
+		return nil, err
 	}
 
-	user, err := s.userRepository.FindByID(id)
-	if err != nil {
-		return err
-	}
-	if user == nil {
-		return errors.New("user not found")
+	before := userAuditSnapshot(user)
+	if err := user.UpdateRole(roleCommand.Role); err != nil {
+		return nil, err
 	}
 
-	err = user.UpdateEmail(email)
-	if err != nil {
-		return err
+	if err := s.saveChangedUser(ctx, user, before); err != nil {
+		return nil, err
 	}
-
-	return s.userRepository.Save(user)
+	return user, nil
 }
 
-// UpdateUserPassword updates a user's password
-func (s *UserService) UpdateUserPassword(id, password string) error {
-	user, err := s.userRepository.FindByID(id)
+// UpdateUserStatus updates a user's status and records the reason for the change.
+// Locking an account this way keeps it locked until its status is changed again.
+func (s *UserService) UpdateUserStatus(ctx context.Context, statusCommand *command.UpdateUserStatusCommand) (*entities.User, error) {
+	user, err := s.findUserForUpdate(ctx, statusCommand.Id, statusCommand.ExpectedVersion)
 	if err != nil {
-		return err
-	}
-	if user == nil {
-		return errors.New("user not found")
+		return nil, err
 	}
 
-	// Hash the new password
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
-
-	err = user.UpdatePassword(string(hashedPassword))
+	before := userAuditSnapshot(user)
+	if statusCommand.Status == entities.StatusLocked {
+		err = user.Lock(statusCommand.Reason, time.Time{})
+	} else {
+		err = user.ChangeStatus(statusCommand.Status, statusCommand.Reason)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.userRepository.Save(user)
+	if err := s.saveChangedUser(ctx, user, before); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// UpdateUserRole updates a user's role
-func (s *UserService) UpdateUserRole(id string, role entities.UserRole) error {
-	user, err := s.userRepository.FindByID(id)
+// findUserForUpdate loads the user changed by a command, unless it has another version than the client expects.
+// The user is saved with the version read here, so that changes made in between are not overwritten.
+func (s *UserService) findUserForUpdate(ctx context.Context, id string, expectedVersion *int) (*entities.User, error) {
+	user, err := s.userRepository.FindByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if user == nil {
-		return errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
-
-	err = user.UpdateRole(role)
-	if err != nil {
-		return err
+	if err := checkVersion(expectedVersion, user.Version); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	return s.userRepository.Save(user)
+// saveChangedUser saves a changed user and records the change in the same transaction.
+// Changes made while logging in, like locking an account, have no actor.
+func (s *UserService) saveChangedUser(ctx context.Context, user *entities.User, before entities.AuditSnapshot) error {
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, "", entities.AuditActionUpdate, entities.AuditAggregateUser, user.ID, before, userAuditSnapshot(user))
+	})
 }
 
-// UpdateUserStatus updates a user's status
-func (s *UserService) UpdateUserStatus(id string, status entities.UserStatus) error {
-	user, err := s.userRepository.FindByID(id)
+// DeleteUser deletes a user unless it has another version than the client expects.
+// Deleting a user which does not exist succeeds if no version is expected.
+func (s *UserService) DeleteUser(ctx context.Context, id string, expectedVersion *int) error {
+	user, err := s.userRepository.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 	if user == nil {
-		return errors.New("user not found")
+		if expectedVersion != nil {
+			return ErrUserNotFound
+		}
+		return nil
 	}
-
-	err = user.UpdateStatus(status)
-	if err != nil {
+	if err := checkVersion(expectedVersion, user.Version); err != nil {
 		return err
 	}
 
-	return s.userRepository.Save(user)
-}
-
-// DeleteUser deletes a user
-func (s *UserService) DeleteUser(id string) error {
-	return s.userRepository.Delete(id)
+	return s.unitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users().Delete(ctx, id, user.Version); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, "", entities.AuditActionDelete, entities.AuditAggregateUser, id, userAuditSnapshot(user), nil)
+	})
 }
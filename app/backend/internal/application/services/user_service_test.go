@@ -1,25 +1,60 @@
 package services
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
+	"time"
 )
 
+// newTestPasswordHasher returns an argon2id hasher with cheap parameters to keep tests fast
+func newTestPasswordHasher(t *testing.T) interfaces.PasswordHasher {
+	cfg := config.NewPasswordConfig()
+	cfg.Argon2Memory = 1024
+	cfg.Argon2Iterations = 1
+	cfg.Argon2Parallelism = 1
+
+	hasher, err := auth.NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create password hasher: %s", err)
+	}
+	return hasher
+}
+
 // MockUserRepository is a mock implementation of the UserRepository interface
 type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Save(user *entities.User) error {
+func (m *MockUserRepository) Save(ctx context.Context, user *entities.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) FindByID(id string) (*entities.User, error) {
+// IncrementFailedLogins counts the failed login on the returned user like the database would
+func (m *MockUserRepository) IncrementFailedLogins(ctx context.Context, id string) (*entities.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	user := args.Get(0).(*entities.User)
+	user.FailedLoginAttempts++
+	user.Version++
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -27,7 +62,7 @@ func (m *MockUserRepository) FindByID(id string) (*entities.User, error) {
 	return args.Get(0).(*entities.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (*entities.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -35,42 +70,121 @@ func (m *MockUserRepository) FindByEmail(email string) (*entities.User, error) {
 	return args.Get(0).(*entities.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockUserRepository) FindByUsername(ctx context.Context, username string) (*entities.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAll(ctx context.Context) ([]*entities.User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindWithFilter(ctx context.Context, filter repositories.UserFilter) ([]*entities.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+// MockLoginAttemptRepository is an in-memory implementation of the LoginAttemptRepository interface
+type MockLoginAttemptRepository struct {
+	attempts map[string]*entities.LoginAttempt
+}
+
+func NewMockLoginAttemptRepository() *MockLoginAttemptRepository {
+	return &MockLoginAttemptRepository{attempts: make(map[string]*entities.LoginAttempt)}
+}
+
+func (m *MockLoginAttemptRepository) FindByClientIP(ctx context.Context, clientIP string) (*entities.LoginAttempt, error) {
+	attempt, ok := m.attempts[clientIP]
+	if !ok {
+		return nil, nil
+	}
+	found := *attempt
+	return &found, nil
+}
+
+func (m *MockLoginAttemptRepository) RecordFailure(ctx context.Context, clientIP string, now time.Time, window time.Duration) (*entities.LoginAttempt, error) {
+	attempt, ok := m.attempts[clientIP]
+	if !ok {
+		attempt = &entities.LoginAttempt{ClientIP: clientIP}
+		m.attempts[clientIP] = attempt
+	}
+	if attempt.LastFailedAt.Before(now.Add(-window)) {
+		attempt.FailedAttempts = 0
+	}
+	attempt.FailedAttempts++
+	attempt.LastFailedAt = now
+	recorded := *attempt
+	return &recorded, nil
+}
+
+func (m *MockLoginAttemptRepository) Block(ctx context.Context, clientIP string, until time.Time) error {
+	if attempt, ok := m.attempts[clientIP]; ok {
+		attempt.BlockedUntil = until
+		attempt.FailedAttempts = 0
+	}
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) Delete(ctx context.Context, clientIP string) error {
+	delete(m.attempts, clientIP)
+	return nil
+}
+
+// newTestUserService returns a UserService using the given mock repository and the default login protection
+func newTestUserService(t *testing.T, userRepo *MockUserRepository) *UserService {
+	auditRepo := NewMockAuditRepository()
+	unitOfWork := &MockUnitOfWork{users: userRepo, audit: auditRepo}
+	return NewUserService(userRepo, NewMockLoginAttemptRepository(), newTestPasswordHasher(t), config.NewLoginProtectionConfig(), unitOfWork, NewAuditService(auditRepo))
+}
+
 func TestUserService_RegisterUser(t *testing.T) {
 	// Create a mock repository
 	mockRepo := new(MockUserRepository)
 
 	// Create a user service with the mock repository
-	userService := NewUserService(mockRepo)
+	userService := newTestUserService(t, mockRepo)
 
 	// Test case: Register a new user
 	t.Run("Register a new user", func(t *testing.T) {
 		// Setup mock expectations
 		mockRepo.On("FindByEmail", "test@example.com").Return(nil, nil)
+		mockRepo.On("FindByUsername", "testuser").Return(nil, nil)
 		mockRepo.On("Save", mock.AnythingOfType("*entities.User")).Return(nil)
 
 		// Call the method being tested
-		user, err := userService.RegisterUser("test@example.com", "password123")
+		user, err := userService.RegisterUser(context.Background(), "testuser", "test@example.com", "password123")
 
 		// Assert expectations
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, "test@example.com", user.Email)
+		assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
 		mockRepo.AssertExpectations(t)
 	})
 
 	// Test case: Register a user with an existing email
 	t.Run("Register a user with an existing email", func(t *testing.T) {
 		// Setup mock expectations
-		existingUser, _ := entities.NewUser("user-id", "existing@example.com", "hashed-password")
+		existingUser, _ := entities.NewUser("user-id", "existing", "existing@example.com", "hashed-password")
 		mockRepo.On("FindByEmail", "existing@example.com").Return(existingUser, nil)
 
 		// Call the method being tested
-		user, err := userService.RegisterUser("existing@example.com", "password123")
+		user, err := userService.RegisterUser(context.Background(), "newuser", "existing@example.com", "password123")
 
 		// Assert expectations
 		assert.Error(t, err)
@@ -85,46 +199,62 @@ func TestUserService_Authenticate(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 
 	// Create a user service with the mock repository
-	userService := NewUserService(mockRepo)
+	userService := newTestUserService(t, mockRepo)
 
 	// Create a test user with a known password
 	testEmail := "test@example.com"
 	testPassword := "password123"
 
-	// Hash the password
+	// Hash the password the way earlier versions did (unsalted SHA-256)
 	hasher := sha256.New()
 	hasher.Write([]byte(testPassword))
 	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
 
-	testUser, _ := entities.NewUser("user-id", testEmail, hashedPassword)
+	testUser, _ := entities.NewUser("user-id", "testuser", testEmail, hashedPassword)
 
-	// Test case: Authenticate with valid credentials
+	// Test case: Authenticate with valid credentials upgrades the legacy hash
 	t.Run("Authenticate with valid credentials", func(t *testing.T) {
 		// Setup mock expectations
 		mockRepo.On("FindByEmail", testEmail).Return(testUser, nil)
+		mockRepo.On("Save", testUser).Return(nil).Once()
 
 		// Call the method being tested
-		user, err := userService.Authenticate(testEmail, testPassword)
+		user, err := userService.Authenticate(context.Background(), testEmail, testPassword, "192.0.2.1")
 
 		// Assert expectations
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, testEmail, user.Email)
+		assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
 		mockRepo.AssertExpectations(t)
 	})
 
+	// Test case: Authenticate again with the upgraded hash does not rehash
+	t.Run("Authenticate with upgraded hash", func(t *testing.T) {
+		upgradedHash := testUser.PasswordHash
+
+		// Call the method being tested
+		user, err := userService.Authenticate(context.Background(), testEmail, testPassword, "192.0.2.1")
+
+		// Assert expectations
+		assert.NoError(t, err)
+		assert.NotNil(t, user)
+		assert.Equal(t, upgradedHash, user.PasswordHash)
+		mockRepo.AssertNumberOfCalls(t, "Save", 1)
+	})
+
 	// Test case: Authenticate with invalid email
 	t.Run("Authenticate with invalid email", func(t *testing.T) {
 		// Setup mock expectations
 		mockRepo.On("FindByEmail", "invalid@example.com").Return(nil, nil)
 
 		// Call the method being tested
-		user, err := userService.Authenticate("invalid@example.com", testPassword)
+		user, err := userService.Authenticate(context.Background(), "invalid@example.com", testPassword, "192.0.2.1")
 
 		// Assert expectations
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Contains(t, err.Error(), "not found")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -132,14 +262,204 @@ func TestUserService_Authenticate(t *testing.T) {
 	t.Run("Authenticate with invalid password", func(t *testing.T) {
 		// Setup mock expectations
 		mockRepo.On("FindByEmail", testEmail).Return(testUser, nil)
+		mockRepo.On("IncrementFailedLogins", testUser.ID).Return(testUser, nil).Once()
 
 		// Call the method being tested
-		user, err := userService.Authenticate(testEmail, "wrong-password")
+		user, err := userService.Authenticate(context.Background(), testEmail, "wrong-password", "192.0.2.1")
 
 		// Assert expectations
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Contains(t, err.Error(), "invalid password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Equal(t, 1, testUser.FailedLoginAttempts)
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestUserService_Authenticate_LocksAccount(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := newTestUserService(t, mockRepo)
+	userService.loginProtection.MaxFailedAttemptsPerAccount = 3
+
+	now := time.Now()
+	userService.now = func() time.Time { return now }
+
+	passwordHash, err := userService.passwordHasher.Hash("password123")
+	require.NoError(t, err)
+	testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+	mockRepo.On("FindByEmail", testUser.Email).Return(testUser, nil)
+	mockRepo.On("IncrementFailedLogins", testUser.ID).Return(testUser, nil)
+	mockRepo.On("Save", testUser).Return(nil)
+
+	// Failures from different client IPs all count towards the account
+	for i, clientIP := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		_, err := userService.Authenticate(context.Background(), testUser.Email, "wrong-password", clientIP)
+		assert.Error(t, err)
+		assert.Equal(t, i == 2, testUser.Status == entities.StatusLocked)
+	}
+	assert.Equal(t, "too many failed login attempts", testUser.StatusReason)
+
+	// The correct password is rejected while the account is locked
+	_, err = userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.4")
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	// The account is unlocked automatically after the cooldown
+	now = now.Add(userService.loginProtection.AccountLockDuration)
+	user, err := userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.4")
+	require.NoError(t, err)
+	assert.True(t, user.IsActive())
+	assert.Equal(t, 0, user.FailedLoginAttempts)
+	assert.True(t, user.LockedUntil.IsZero())
+}
+
+func TestUserService_Authenticate_SuccessResetsFailedAttempts(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := newTestUserService(t, mockRepo)
+	userService.loginProtection.MaxFailedAttemptsPerAccount = 2
+
+	passwordHash, err := userService.passwordHasher.Hash("password123")
+	require.NoError(t, err)
+	testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+	mockRepo.On("FindByEmail", testUser.Email).Return(testUser, nil)
+	mockRepo.On("IncrementFailedLogins", testUser.ID).Return(testUser, nil)
+	mockRepo.On("Save", testUser).Return(nil)
+
+	for i := 0; i < 3; i++ {
+		_, err = userService.Authenticate(context.Background(), testUser.Email, "wrong-password", "192.0.2.1")
+		assert.Error(t, err)
+
+		_, err = userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, testUser.FailedLoginAttempts)
+	}
+	assert.True(t, testUser.IsActive())
+}
+
+func TestUserService_Authenticate_ConcurrentChanges(t *testing.T) {
+	passwordHash, err := newTestPasswordHasher(t).Hash("password123")
+	require.NoError(t, err)
+
+	t.Run("Locking a user changed by a concurrent failure", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		userService := newTestUserService(t, mockRepo)
+		userService.loginProtection.MaxFailedAttemptsPerAccount = 1
+		testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+		mockRepo.On("FindByEmail", testUser.Email).Return(testUser, nil)
+		mockRepo.On("IncrementFailedLogins", testUser.ID).Return(testUser, nil)
+		mockRepo.On("Save", testUser).Return(repositories.ErrVersionConflict)
+
+		_, err := userService.Authenticate(context.Background(), testUser.Email, "wrong-password", "192.0.2.1")
+		assert.ErrorIs(t, err, ErrInvalidCredentials, "The lock is left to the concurrent failure instead of reporting a conflict")
+	})
+
+	t.Run("Unlocking a user changed by a concurrent login", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		userService := newTestUserService(t, mockRepo)
+		lockedUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+		_, err := lockedUser.RecordFailedLogin(time.Now().Add(-time.Hour), 1, time.Minute)
+		require.NoError(t, err)
+		unlockedUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+		mockRepo.On("FindByEmail", lockedUser.Email).Return(lockedUser, nil)
+		mockRepo.On("Save", lockedUser).Return(repositories.ErrVersionConflict)
+		mockRepo.On("FindByID", lockedUser.ID).Return(unlockedUser, nil)
+
+		user, err := userService.Authenticate(context.Background(), lockedUser.Email, "password123", "192.0.2.1")
+		require.NoError(t, err)
+		assert.Same(t, unlockedUser, user)
+	})
+}
+
+func TestUserService_Authenticate_BlocksClientIP(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := newTestUserService(t, mockRepo)
+	userService.loginProtection.MaxFailedAttemptsPerIP = 3
+
+	now := time.Now()
+	userService.now = func() time.Time { return now }
+
+	passwordHash, err := userService.passwordHasher.Hash("password123")
+	require.NoError(t, err)
+	testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", passwordHash)
+	mockRepo.On("FindByEmail", testUser.Email).Return(testUser, nil)
+	mockRepo.On("FindByEmail", mock.AnythingOfType("string")).Return(nil, nil)
+
+	// Guessing unknown accounts counts towards the client IP
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := userService.Authenticate(context.Background(), email, "password123", "192.0.2.1")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrTooManyLoginAttempts)
+	}
+
+	// Even valid credentials are rejected from the blocked client IP
+	_, err = userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.1")
+	assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+
+	// Other client IPs are not affected
+	_, err = userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.2")
+	assert.NoError(t, err)
+
+	// The block is lifted after the cooldown
+	now = now.Add(userService.loginProtection.IPBlockDuration)
+	_, err = userService.Authenticate(context.Background(), testUser.Email, "password123", "192.0.2.1")
+	assert.NoError(t, err)
+}
+
+func TestUserService_UpdateUserStatus(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := newTestUserService(t, mockRepo)
+
+	testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	mockRepo.On("FindByID", testUser.ID).Return(testUser, nil)
+	mockRepo.On("Save", testUser).Return(nil)
+
+	// Locking manually keeps the account locked until an admin unlocks it
+	_, err := userService.UpdateUserStatus(context.Background(), &command.UpdateUserStatusCommand{Id: testUser.ID, Status: entities.StatusLocked, Reason: "suspicious activity"})
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusLocked, testUser.Status)
+	assert.Equal(t, "suspicious activity", testUser.StatusReason)
+	assert.False(t, testUser.IsLockExpired(time.Now().Add(24*time.Hour)))
+
+	_, err = userService.UpdateUserStatus(context.Background(), &command.UpdateUserStatusCommand{Id: testUser.ID, Status: entities.StatusActive, Reason: "verified by support"})
+	require.NoError(t, err)
+	assert.True(t, testUser.IsActive())
+	assert.Equal(t, "verified by support", testUser.StatusReason)
+
+	_, err = userService.UpdateUserStatus(context.Background(), &command.UpdateUserStatusCommand{Id: testUser.ID, Status: entities.UserStatus("unknown")})
+	assert.Error(t, err)
+}
+
+func TestUserService_UpdateUserChecksExpectedVersion(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := newTestUserService(t, mockRepo)
+
+	testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	testUser.Version = 3
+	mockRepo.On("FindByID", testUser.ID).Return(testUser, nil)
+	mockRepo.On("FindByUsername", "renamed").Return(nil, nil)
+	mockRepo.On("FindByEmail", "renamed@example.com").Return(nil, nil)
+	mockRepo.On("Save", testUser).Return(nil)
+	mockRepo.On("Delete", testUser.ID, 3).Return(nil)
+
+	staleVersion := 2
+	username, email, password := "renamed", "renamed@example.com", "new-password"
+	updateCommand := &command.UpdateUserCommand{Id: testUser.ID, Username: &username, Email: &email, Password: &password, ExpectedVersion: &staleVersion}
+	_, err := userService.UpdateUser(context.Background(), updateCommand)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = userService.UpdateUserRole(context.Background(), &command.UpdateUserRoleCommand{Id: testUser.ID, Role: entities.RoleAdmin, ExpectedVersion: &staleVersion})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.ErrorIs(t, userService.DeleteUser(context.Background(), testUser.ID, &staleVersion), ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+	assert.Equal(t, "testuser", testUser.Username)
+
+	// All fields are saved together with the version that was checked
+	currentVersion := 3
+	updateCommand.ExpectedVersion = &currentVersion
+	user, err := userService.UpdateUser(context.Background(), updateCommand)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", user.Username)
+	assert.Equal(t, "renamed@example.com", user.Email)
+	mockRepo.AssertNumberOfCalls(t, "Save", 1)
+
+	require.NoError(t, userService.DeleteUser(context.Background(), testUser.ID, &currentVersion))
+	mockRepo.AssertCalled(t, "Delete", testUser.ID, 3)
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+)
+
+// ErrVersionConflict is returned when an aggregate was changed since the client read it
+var ErrVersionConflict = repositories.ErrVersionConflict
+
+// checkVersion returns ErrVersionConflict unless the aggregate still has the version the client expects.
+// A nil expected version skips the check.
+func checkVersion(expected *int, actual int) error {
+	if expected != nil && *expected != actual {
+		return ErrVersionConflict
+	}
+	return nil
+}
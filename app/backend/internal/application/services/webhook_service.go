@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+var (
+	// ErrWebhookSubscriptionNotFound is returned when a webhook subscription does not exist
+	ErrWebhookSubscriptionNotFound = entities.NewError(entities.ErrNotFound, "webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned when a delivery does not exist or belongs to another subscription
+	ErrWebhookDeliveryNotFound = entities.NewError(entities.ErrNotFound, "webhook delivery not found")
+	// ErrInvalidWebhookSubscription is returned when the URL or the event types of a subscription are invalid
+	ErrInvalidWebhookSubscription = entities.NewError(entities.ErrValidation, "invalid webhook subscription")
+)
+
+// webhookEventNamespace derives the ids of webhook events from the domain events
+var webhookEventNamespace = uuid.MustParse("5b0d6c2e-8f4a-4f3e-9a51-6f1c0e7d2b94")
+
+// WebhookEnvelope is the JSON body of a webhook delivery
+type WebhookEnvelope struct {
+	// Id identifies the event, receivers use it to ignore retried and replayed deliveries
+	Id         uuid.UUID
+	Type       string
+	OccurredAt time.Time
+	// Data is the domain event, e.g. entities.ProductCreated
+	Data entities.DomainEvent
+}
+
+// WebhookService manages webhook subscriptions and delivers the domain events partners subscribed to.
+// Domain events are turned into deliveries by HandleDomainEvent, which is subscribed to the EventDispatcher,
+// so every successful product and seller command results in a delivery to each matching subscription.
+type WebhookService struct {
+	subscriptionRepository repositories.WebhookSubscriptionRepository
+	deliveryRepository     repositories.WebhookDeliveryRepository
+	sender                 interfaces.WebhookSender
+	config                 *config.WebhookConfig
+	now                    func() time.Time
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService(
+	subscriptionRepository repositories.WebhookSubscriptionRepository,
+	deliveryRepository repositories.WebhookDeliveryRepository,
+	sender interfaces.WebhookSender,
+	webhookConfig *config.WebhookConfig,
+) *WebhookService {
+	return &WebhookService{
+		subscriptionRepository: subscriptionRepository,
+		deliveryRepository:     deliveryRepository,
+		sender:                 sender,
+		config:                 webhookConfig,
+		now:                    time.Now,
+	}
+}
+
+// CreateSubscription registers the endpoint for the event types and generates the secret signing its deliveries
+func (s *WebhookService) CreateSubscription(ctx context.Context, endpoint string, eventTypes []string) (*entities.WebhookSubscription, error) {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := entities.NewWebhookSubscription(endpoint, eventTypes, secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWebhookSubscription, err)
+	}
+
+	if err := s.subscriptionRepository.Save(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetSubscriptions returns all subscriptions
+func (s *WebhookService) GetSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	return s.subscriptionRepository.FindAll(ctx)
+}
+
+// GetSubscription returns the subscription with the given id
+func (s *WebhookService) GetSubscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	subscription, err := s.subscriptionRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes the subscription and its delivery log, pending deliveries are not sent anymore
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.subscriptionRepository.Delete(ctx, id)
+}
+
+// GetDeliveries returns one page of the delivery log of the subscription, newest first
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionId uuid.UUID, page repositories.PageRequest) (*repositories.WebhookDeliveryPage, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionId); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepository.FindBySubscription(ctx, subscriptionId, page)
+}
+
+// ReplayDelivery sends the event of a delivery again. The replay is a new delivery with the same event id,
+// it is sent with the next batch regardless of whether the original delivery succeeded.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, subscriptionId, deliveryId uuid.UUID) (*entities.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveryRepository.FindById(ctx, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.SubscriptionId != subscriptionId {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	replay := delivery.Replay()
+	if err := s.deliveryRepository.Save(ctx, replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// HandleDomainEvent creates a pending delivery of the event for every subscription of its type.
+// The event dispatcher delivers events at least once, so subscriptions which already have a
+// delivery of the event are skipped.
+func (s *WebhookService) HandleDomainEvent(ctx context.Context, event entities.DomainEvent) error {
+	if !entities.IsWebhookEventType(event.EventName()) {
+		return nil
+	}
+
+	subscriptions, err := s.subscriptionRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	envelope := WebhookEnvelope{
+		Id:         webhookEventId(event),
+		Type:       event.EventName(),
+		OccurredAt: event.OccurredAt(),
+		Data:       event,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload of %s: %w", event.EventName(), err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event.EventName()) {
+			continue
+		}
+
+		exists, err := s.deliveryRepository.ExistsForEvent(ctx, subscription.Id, envelope.Id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		delivery := entities.NewWebhookDelivery(subscription.Id, envelope.Id, event.EventName(), payload)
+		if err := s.deliveryRepository.Save(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run sends due deliveries every PollInterval until the context is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	runPolling(ctx, s.config.PollInterval, s.config.BatchSize, "deliver webhooks", s.DeliverDue)
+}
+
+// DeliverDue sends one batch of due deliveries and returns the number of deliveries it attempted.
+// Endpoints answering with a 2xx status accepted the delivery, anything else is retried with exponential backoff.
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.deliveryRepository.FindDue(ctx, s.now(), s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if err := s.deliver(ctx, delivery); err != nil {
+			return 0, fmt.Errorf("failed to update webhook delivery %s: %w", delivery.Id, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	subscription, err := s.subscriptionRepository.FindById(ctx, delivery.SubscriptionId)
+	if err != nil {
+		return err
+	}
+	if subscription == nil {
+		delivery.RecordFailure(0, "subscription was deleted", time.Time{})
+		return s.deliveryRepository.Save(ctx, delivery)
+	}
+
+	timestamp := s.now().Unix()
+	status, sendErr := s.sender.Send(ctx, interfaces.WebhookRequest{
+		URL: subscription.URL,
+		Headers: map[string]string{
+			WebhookIdHeader:        delivery.EventId.String(),
+			WebhookEventHeader:     delivery.EventType,
+			WebhookTimestampHeader: strconv.FormatInt(timestamp, 10),
+			WebhookSignatureHeader: entities.SignWebhookPayload(subscription.Secret, timestamp, delivery.Payload),
+		},
+		Body: delivery.Payload,
+	})
+
+	switch {
+	case sendErr != nil:
+		delivery.RecordFailure(0, sendErr.Error(), s.retryAt(delivery))
+	case status < 200 || status > 299:
+		delivery.RecordFailure(status, fmt.Sprintf("endpoint responded with status %d", status), s.retryAt(delivery))
+	default:
+		delivery.RecordSuccess(status, s.now())
+	}
+	return s.deliveryRepository.Save(ctx, delivery)
+}
+
+// retryAt returns when to send the delivery again after the current attempt failed, or the zero time to give up
+func (s *WebhookService) retryAt(delivery *entities.WebhookDelivery) time.Time {
+	attempts := delivery.Attempts + 1
+	if attempts >= s.config.MaxAttempts {
+		log.Printf("Giving up on webhook delivery %s (%s) after %d attempts", delivery.Id, delivery.EventType, attempts)
+		return time.Time{}
+	}
+	return s.now().Add(backoffDelay(attempts, s.config.RetryBaseDelay, s.config.MaxRetryDelay))
+}
+
+// webhookEventId derives a stable id from the event, so redelivered domain events get the same id
+func webhookEventId(event entities.DomainEvent) uuid.UUID {
+	name := event.EventName() + "/" + event.AggregateId() + "/" + event.OccurredAt().UTC().Format(time.RFC3339Nano)
+	return uuid.NewSHA1(webhookEventNamespace, []byte(name))
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MockWebhookSubscriptionRepository is an in-memory implementation of the WebhookSubscriptionRepository interface
+type MockWebhookSubscriptionRepository struct {
+	subscriptions []*entities.WebhookSubscription
+}
+
+func (m *MockWebhookSubscriptionRepository) Save(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	for i, existing := range m.subscriptions {
+		if existing.Id == subscription.Id {
+			m.subscriptions[i] = subscription
+			return nil
+		}
+	}
+	m.subscriptions = append(m.subscriptions, subscription)
+	return nil
+}
+
+func (m *MockWebhookSubscriptionRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	for _, subscription := range m.subscriptions {
+		if subscription.Id == id {
+			return subscription, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockWebhookSubscriptionRepository) FindAll(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	return m.subscriptions, nil
+}
+
+func (m *MockWebhookSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	for i, subscription := range m.subscriptions {
+		if subscription.Id == id {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// MockWebhookDeliveryRepository is an in-memory implementation of the WebhookDeliveryRepository interface
+type MockWebhookDeliveryRepository struct {
+	deliveries []*entities.WebhookDelivery
+}
+
+func (m *MockWebhookDeliveryRepository) Save(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	for i, existing := range m.deliveries {
+		if existing.Id == delivery.Id {
+			m.deliveries[i] = delivery
+			return nil
+		}
+	}
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *MockWebhookDeliveryRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.Id == id {
+			return delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockWebhookDeliveryRepository) ExistsForEvent(ctx context.Context, subscriptionId, eventId uuid.UUID) (bool, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.SubscriptionId == subscriptionId && delivery.EventId == eventId && delivery.ReplayOf == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockWebhookDeliveryRepository) FindBySubscription(ctx context.Context, subscriptionId uuid.UUID, page repositories.PageRequest) (*repositories.WebhookDeliveryPage, error) {
+	result := &repositories.WebhookDeliveryPage{}
+	for i := len(m.deliveries) - 1; i >= 0; i-- {
+		if m.deliveries[i].SubscriptionId == subscriptionId {
+			result.Deliveries = append(result.Deliveries, m.deliveries[i])
+		}
+	}
+	result.TotalCount = int64(len(result.Deliveries))
+	return result, nil
+}
+
+func (m *MockWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.WebhookDelivery, error) {
+	var due []*entities.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == entities.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+// webhookReceiver is an httptest endpoint recording the deliveries it received
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, body)
+		w.WriteHeader(receiver.status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// newTestWebhookService creates a service with a fake clock, it must run ahead of time.Now so new deliveries are due
+func newTestWebhookService(now *time.Time) (*WebhookService, *MockWebhookSubscriptionRepository, *MockWebhookDeliveryRepository) {
+	subscriptionRepo := &MockWebhookSubscriptionRepository{}
+	deliveryRepo := &MockWebhookDeliveryRepository{}
+	service := NewWebhookService(subscriptionRepo, deliveryRepo, webhook.NewHTTPSender(time.Second), &config.WebhookConfig{
+		PollInterval:   time.Second,
+		BatchSize:      10,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		MaxRetryDelay:  time.Minute,
+		Timeout:        time.Second,
+	})
+	service.now = func() time.Time { return *now }
+	return service, subscriptionRepo, deliveryRepo
+}
+
+func TestWebhookService_DeliversSignedEvents(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	service, _, deliveryRepo := newTestWebhookService(&now)
+	receiver := newWebhookReceiver(t)
+
+	subscription, err := service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventSellerCreated})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if subscription.Secret == "" {
+		t.Fatal("Expected a generated secret")
+	}
+	other, _ := service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventProductCreated})
+
+	seller := entities.NewSeller("Seller")
+	event := seller.PendingEvents()[0]
+	if err := service.HandleDomainEvent(context.Background(), event); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// The event dispatcher may deliver an event again, it must not be sent twice
+	if err := service.HandleDomainEvent(context.Background(), event); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// Events partners cannot subscribe to are ignored
+	if err := service.HandleDomainEvent(context.Background(), entities.UserRegistered{UserId: "user-1"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(deliveryRepo.deliveries) != 1 || deliveryRepo.deliveries[0].SubscriptionId != subscription.Id {
+		t.Fatalf("Expected one delivery to the seller subscription, but got %+v (other %s)", deliveryRepo.deliveries, other.Id)
+	}
+
+	delivered, err := service.DeliverDue(context.Background())
+	if err != nil || delivered != 1 {
+		t.Fatalf("Expected one delivery, but got %d (%v)", delivered, err)
+	}
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("Expected the receiver to get one request, but got %d", len(receiver.requests))
+	}
+	request, body := receiver.requests[0], receiver.bodies[0]
+	timestamp, _ := strconv.ParseInt(request.Header.Get(WebhookTimestampHeader), 10, 64)
+	if !entities.VerifyWebhookSignature(subscription.Secret, timestamp, body, request.Header.Get(WebhookSignatureHeader)) {
+		t.Errorf("Expected a valid signature, but got %q", request.Header.Get(WebhookSignatureHeader))
+	}
+	if request.Header.Get(WebhookEventHeader) != entities.EventSellerCreated {
+		t.Errorf("Expected the event type header, but got %q", request.Header.Get(WebhookEventHeader))
+	}
+
+	var envelope struct {
+		Id   uuid.UUID
+		Type string
+		Data entities.SellerCreated
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("Unexpected payload %s: %s", body, err)
+	}
+	if envelope.Type != entities.EventSellerCreated || envelope.Data.SellerId != seller.Id || envelope.Id.String() != request.Header.Get(WebhookIdHeader) {
+		t.Errorf("Unexpected payload %+v", envelope)
+	}
+
+	delivery := deliveryRepo.deliveries[0]
+	if delivery.Status != entities.WebhookDeliverySucceeded || delivery.ResponseStatus != http.StatusOK || delivery.Attempts != 1 {
+		t.Errorf("Expected the delivery to be recorded as succeeded, but got %+v", delivery)
+	}
+}
+
+func TestWebhookService_RetriesFailedDeliveries(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	service, _, deliveryRepo := newTestWebhookService(&now)
+	receiver := newWebhookReceiver(t)
+	receiver.status = http.StatusServiceUnavailable
+
+	_, _ = service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventSellerCreated})
+	_ = service.HandleDomainEvent(context.Background(), entities.NewSeller("Seller").PendingEvents()[0])
+	delivery := deliveryRepo.deliveries[0]
+
+	// The first failure is retried after the base delay
+	_, _ = service.DeliverDue(context.Background())
+	if delivery.Status != entities.WebhookDeliveryPending || delivery.ResponseStatus != http.StatusServiceUnavailable || !delivery.NextAttemptAt.Equal(now.Add(time.Second)) {
+		t.Errorf("Expected a retry after one second, but got %+v", delivery)
+	}
+
+	// Nothing is due before the retry
+	if delivered, _ := service.DeliverDue(context.Background()); delivered != 0 {
+		t.Errorf("Expected no delivery before the retry, but got %d", delivered)
+	}
+
+	// The delay doubles
+	now = now.Add(time.Second)
+	_, _ = service.DeliverDue(context.Background())
+	if !delivery.NextAttemptAt.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("Expected a retry after two seconds, but got %s", delivery.NextAttemptAt.Sub(now))
+	}
+
+	// MaxAttempts gives up
+	now = now.Add(2 * time.Second)
+	_, _ = service.DeliverDue(context.Background())
+	if delivery.Status != entities.WebhookDeliveryFailed || delivery.Attempts != 3 || len(receiver.requests) != 3 {
+		t.Errorf("Expected the delivery to be given up after 3 attempts, but got %+v", delivery)
+	}
+}
+
+func TestWebhookService_UnreachableEndpoint(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	service, _, deliveryRepo := newTestWebhookService(&now)
+	receiver := newWebhookReceiver(t)
+	receiver.Close()
+
+	_, _ = service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventSellerCreated})
+	_ = service.HandleDomainEvent(context.Background(), entities.NewSeller("Seller").PendingEvents()[0])
+	_, _ = service.DeliverDue(context.Background())
+
+	delivery := deliveryRepo.deliveries[0]
+	if delivery.Status != entities.WebhookDeliveryPending || delivery.ResponseStatus != 0 || delivery.LastError == "" {
+		t.Errorf("Expected a retry without response status, but got %+v", delivery)
+	}
+}
+
+func TestWebhookService_ReplayDelivery(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	service, _, deliveryRepo := newTestWebhookService(&now)
+	receiver := newWebhookReceiver(t)
+
+	subscription, _ := service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventSellerCreated})
+	_ = service.HandleDomainEvent(context.Background(), entities.NewSeller("Seller").PendingEvents()[0])
+	_, _ = service.DeliverDue(context.Background())
+	original := deliveryRepo.deliveries[0]
+
+	replay, err := service.ReplayDelivery(context.Background(), subscription.Id, original.Id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_, _ = service.DeliverDue(context.Background())
+
+	if replay.Status != entities.WebhookDeliverySucceeded || len(receiver.requests) != 2 {
+		t.Errorf("Expected the replay to be delivered, but got %+v", replay)
+	}
+	if receiver.requests[1].Header.Get(WebhookIdHeader) != original.EventId.String() {
+		t.Errorf("Expected the replay to keep the event id, but got %q", receiver.requests[1].Header.Get(WebhookIdHeader))
+	}
+
+	page, err := service.GetDeliveries(context.Background(), subscription.Id, repositories.PageRequest{})
+	if err != nil || page.TotalCount != 2 || page.Deliveries[0].Id != replay.Id {
+		t.Errorf("Expected the replay first in the delivery log, but got %+v (%v)", page, err)
+	}
+
+	other, _ := service.CreateSubscription(context.Background(), receiver.URL, []string{entities.EventSellerCreated})
+	if _, err := service.ReplayDelivery(context.Background(), other.Id, original.Id); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+		t.Errorf("Expected ErrWebhookDeliveryNotFound for the delivery of another subscription, but got %v", err)
+	}
+	if _, err := service.ReplayDelivery(context.Background(), uuid.New(), original.Id); !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+		t.Errorf("Expected ErrWebhookSubscriptionNotFound, but got %v", err)
+	}
+}
+
+func TestWebhookService_SubscriptionManagement(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	service, subscriptionRepo, _ := newTestWebhookService(&now)
+
+	if _, err := service.CreateSubscription(context.Background(), "not a url", []string{entities.EventSellerCreated}); !errors.Is(err, ErrInvalidWebhookSubscription) {
+		t.Errorf("Expected ErrInvalidWebhookSubscription, but got %v", err)
+	}
+
+	first, _ := service.CreateSubscription(context.Background(), "https://a.example.com", []string{entities.EventProductCreated})
+	second, _ := service.CreateSubscription(context.Background(), "https://b.example.com", []string{entities.EventProductCreated})
+	if first.Secret == second.Secret {
+		t.Error("Expected every subscription to get its own secret")
+	}
+
+	if err := service.DeleteSubscription(context.Background(), first.Id); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := service.DeleteSubscription(context.Background(), first.Id); !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+		t.Errorf("Expected ErrWebhookSubscriptionNotFound, but got %v", err)
+	}
+
+	var urls []string
+	for _, subscription := range subscriptionRepo.subscriptions {
+		urls = append(urls, subscription.URL)
+	}
+	sort.Strings(urls)
+	if len(urls) != 1 || urls[0] != "https://b.example.com" {
+		t.Errorf("Expected only the second subscription to remain, but got %v", urls)
+	}
+}
+
+func TestWebhookEventId_IsStable(t *testing.T) {
+	event := entities.SellerDeleted{SellerId: uuid.New(), At: time.Now()}
+	if webhookEventId(event) != webhookEventId(event) {
+		t.Error("Expected the same event to get the same id")
+	}
+	if webhookEventId(event) == webhookEventId(entities.SellerDeleted{SellerId: event.SellerId, At: event.At.Add(time.Nanosecond)}) {
+		t.Error("Expected different events to get different ids")
+	}
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvironmentDevelopment is the only environment in which the insecure defaults are accepted
+	EnvironmentDevelopment = "development"
+	// EnvPrefix is the prefix of the environment variables of all settings
+	EnvPrefix = "MARKETPLACE_"
+	// FileEnv is the environment variable naming the configuration file, unless the flag -config is given
+	FileEnv = EnvPrefix + "CONFIG"
+)
+
+// redacted replaces secrets in printed configurations
+const redacted = "*****"
+
+// Config is the configuration shared by the marketplace commands
+type Config struct {
+	// Environment is the deployment environment, e.g. "development" or "production"
+	Environment     string                 `yaml:"environment"`
+	Server          *ServerConfig          `yaml:"server"`
+	Database        *DatabaseConfig        `yaml:"database"`
+	JWT             *JWTConfig             `yaml:"jwt"`
+	Password        *PasswordConfig        `yaml:"password"`
+	LoginProtection *LoginProtectionConfig `yaml:"login_protection"`
+	Request         *RequestConfig         `yaml:"request"`
+	Outbox          *OutboxConfig          `yaml:"outbox"`
+	Webhook         *WebhookConfig         `yaml:"webhook"`
+	Idempotency     *IdempotencyConfig     `yaml:"idempotency"`
+	SoftDelete      *SoftDeleteConfig      `yaml:"soft_delete"`
+}
+
+// NewConfig creates a new configuration with default values
+func NewConfig() *Config {
+	return &Config{
+		Environment:     EnvironmentDevelopment,
+		Server:          NewServerConfig(),
+		Database:        NewDatabaseConfig(),
+		JWT:             NewJWTConfig(),
+		Password:        NewPasswordConfig(),
+		LoginProtection: NewLoginProtectionConfig(),
+		Request:         NewRequestConfig(),
+		Outbox:          NewOutboxConfig(),
+		Webhook:         NewWebhookConfig(),
+		Idempotency:     NewIdempotencyConfig(),
+		SoftDelete:      NewSoftDeleteConfig(),
+	}
+}
+
+// Load loads the configuration of the command name from, in increasing precedence, the defaults,
+// the YAML file named by the flag -config or FileEnv, environment variables and the flags in args.
+// Every setting has a key, which is its path in the file, e.g. "database.ssl_mode" is set by
+// MARKETPLACE_DATABASE_SSL_MODE and -database.ssl-mode. The loaded configuration is validated.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg, operands, err := LoadWithOperands(name, args, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if len(operands) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(operands, " "))
+	}
+	return cfg, nil
+}
+
+// LoadWithOperands loads the configuration like Load and returns the arguments following the flags,
+// for commands which take operands like "migrate up".
+func LoadWithOperands(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	// The flags are parsed first to find the file, but applied last
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := flags.String("config", "", "path of the YAML configuration file (env "+FileEnv+")")
+	for _, s := range NewConfig().settings() {
+		flags.Var(s, s.flagName(), "(env "+s.envName()+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := NewConfig()
+	if *path == "" {
+		*path, _ = lookupEnv(FileEnv)
+	}
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byFlag := make(map[string]setting)
+	for _, s := range cfg.settings() {
+		byFlag[s.flagName()] = s
+		if value, ok := lookupEnv(s.envName()); ok {
+			if err := s.Set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid value %q for %s: %w", value, s.envName(), err)
+			}
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if s, ok := byFlag[f.Name]; ok && err == nil {
+			err = s.Set(f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, flags.Args(), nil
+}
+
+// loadFile overrides the settings which are present in the YAML file
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// Misspelled keys would silently keep the default
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to read configuration file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Environment == "" {
+		errs = append(errs, errors.New("environment is required"))
+	}
+	if c.Server.Address == "" {
+		errs = append(errs, errors.New("server.address is required"))
+	}
+	if c.Database.Host == "" || c.Database.Name == "" || c.Database.User == "" {
+		errs = append(errs, errors.New("database.host, database.name and database.user are required"))
+	}
+	if c.Database.Port > 65535 {
+		errs = append(errs, errors.New("database.port must be at most 65535"))
+	}
+	if c.Password.Algorithm != "argon2id" && c.Password.Algorithm != "bcrypt" {
+		errs = append(errs, errors.New(`password.algorithm must be "argon2id" or "bcrypt"`))
+	}
+	if c.Environment != EnvironmentDevelopment && c.JWT.UsesDefaultSecret() {
+		errs = append(errs, fmt.Errorf("jwt.secret_key must not be the default secret outside of %s", EnvironmentDevelopment))
+	}
+
+	// Counts, sizes and durations of zero would disable a feature or stall a background job
+	for _, s := range c.settings() {
+		if s.value.CanInt() && s.value.Int() <= 0 || s.value.CanUint() && s.value.Uint() == 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", s.key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration whose secrets are masked
+func (c *Config) Redacted() *Config {
+	copied := *c
+
+	database := *c.Database
+	database.Password = redact(database.Password)
+	copied.Database = &database
+
+	jwt := *c.JWT
+	jwt.SecretKey = redact(jwt.SecretKey)
+	jwt.Keys = make([]JWTKey, len(c.JWT.Keys))
+	for i, key := range c.JWT.Keys {
+		key.Secret = redact(key.Secret)
+		key.PrivateKeyPEM = redact(key.PrivateKeyPEM)
+		jwt.Keys[i] = key
+	}
+	copied.JWT = &jwt
+
+	return &copied
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// String returns the configuration as YAML with masked secrets, so that it can be logged
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("unprintable configuration: %v", err)
+	}
+	return string(out)
+}
+
+// setting is a single value of the configuration which can be set from the environment or a flag
+type setting struct {
+	// key is the path of the value in the configuration file, e.g. "database.ssl_mode"
+	key   string
+	value reflect.Value
+}
+
+// settings returns the scalar values of the configuration, lists like the JWT keys are only read from the file
+func (c *Config) settings() []setting {
+	return collectSettings("", reflect.ValueOf(c).Elem())
+}
+
+func collectSettings(prefix string, section reflect.Value) []setting {
+	var settings []setting
+	for i := 0; i < section.NumField(); i++ {
+		key, _, _ := strings.Cut(section.Type().Field(i).Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		value := section.Field(i)
+		switch {
+		case value.Kind() == reflect.Pointer && value.Elem().Kind() == reflect.Struct:
+			settings = append(settings, collectSettings(prefix+key+".", value.Elem())...)
+		case value.Kind() == reflect.String || value.Kind() == reflect.Bool || value.CanInt() || value.CanUint():
+			settings = append(settings, setting{key: prefix + key, value: value})
+		}
+	}
+	return settings
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+func (s setting) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+// String implements flag.Value
+func (s setting) String() string {
+	if !s.value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(s.value.Interface())
+}
+
+// IsBoolFlag lets boolean flags be given without a value, e.g. -database.auto-migrate
+func (s setting) IsBoolFlag() bool {
+	return s.value.IsValid() && s.value.Kind() == reflect.Bool
+}
+
+// Set implements flag.Value, durations are written like "15m"
+func (s setting) Set(text string) error {
+	if s.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch {
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(text)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		s.value.SetBool(b)
+	case s.value.CanInt():
+		n, err := strconv.ParseInt(text, 10, s.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(n)
+	case s.value.CanUint():
+		n, err := strconv.ParseUint(text, 10, s.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		s.value.SetUint(n)
+	}
+	return nil
+}
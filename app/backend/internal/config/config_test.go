@@ -0,0 +1,149 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func envOf(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "marketplace.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load("marketplace", nil, envOf(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, NewConfig(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+database:
+  host: file-host
+  name: file-db
+  user: file-user
+jwt:
+  token_expiry: 5m
+outbox:
+  batch_size: 10
+`)
+	env := envOf(map[string]string{
+		FileEnv:                     path,
+		"MARKETPLACE_DATABASE_NAME": "env-db",
+		"MARKETPLACE_DATABASE_USER": "env-user",
+	})
+
+	cfg, err := Load("marketplace", []string{"-database.user=flag-user", "-password.argon2-iterations", "4"}, env)
+
+	require.NoError(t, err)
+	assert.Equal(t, "file-host", cfg.Database.Host)
+	assert.Equal(t, "env-db", cfg.Database.Name)
+	assert.Equal(t, "flag-user", cfg.Database.User)
+	assert.Equal(t, 5*time.Minute, cfg.JWT.TokenExpiry)
+	assert.Equal(t, 10, cfg.Outbox.BatchSize)
+	assert.Equal(t, uint32(4), cfg.Password.Argon2Iterations)
+	// Settings missing from the file keep their defaults
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, time.Second, cfg.Outbox.PollInterval)
+}
+
+func TestLoadConfigFileFlag(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  address: \":8080\"\n")
+
+	cfg, err := Load("marketplace", []string{"-config", path}, envOf(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.Server.Address)
+}
+
+func TestLoadRejectsInvalidInput(t *testing.T) {
+	_, err := Load("marketplace", nil, envOf(map[string]string{"MARKETPLACE_REQUEST_TIMEOUT": "soon"}))
+	assert.ErrorContains(t, err, "MARKETPLACE_REQUEST_TIMEOUT")
+
+	_, err = Load("marketplace", []string{"-database.port=http"}, envOf(nil))
+	assert.Error(t, err)
+
+	// Misspelled keys are reported instead of being ignored
+	path := writeConfigFile(t, "database:\n  hots: db\n")
+	_, err = Load("marketplace", nil, envOf(map[string]string{FileEnv: path}))
+	assert.ErrorContains(t, err, "hots")
+}
+
+func TestLoadWithOperands(t *testing.T) {
+	cfg, operands, err := LoadWithOperands("migrate", []string{"-database.auto-migrate=false", "down", "2"}, envOf(nil))
+
+	require.NoError(t, err)
+	assert.False(t, cfg.Database.AutoMigrate)
+	assert.Equal(t, []string{"down", "2"}, operands)
+
+	_, err = Load("marketplace", []string{"serve"}, envOf(nil))
+	assert.ErrorContains(t, err, "unexpected arguments: serve")
+}
+
+func TestValidateDefaultSecret(t *testing.T) {
+	production := envOf(map[string]string{"MARKETPLACE_ENVIRONMENT": "production"})
+
+	_, err := Load("marketplace", nil, production)
+	assert.ErrorContains(t, err, "jwt.secret_key")
+
+	cfg, err := Load("marketplace", []string{"-jwt.secret-key", "a-real-secret"}, production)
+	require.NoError(t, err)
+	assert.Equal(t, "production", cfg.Environment)
+}
+
+func TestValidateReportsEveryInvalidSetting(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Server.Address = ""
+	cfg.Outbox.PollInterval = 0
+	cfg.Password.Argon2Parallelism = 0
+
+	err := cfg.Validate()
+
+	assert.ErrorContains(t, err, "server.address is required")
+	assert.ErrorContains(t, err, "outbox.poll_interval must be positive")
+	assert.ErrorContains(t, err, "password.argon2_parallelism must be positive")
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Database.Password = "db-secret"
+	cfg.JWT.Keys = []JWTKey{{ID: "2024", Secret: "key-secret"}}
+
+	printed := cfg.String()
+
+	assert.NotContains(t, printed, "db-secret")
+	assert.NotContains(t, printed, "key-secret")
+	assert.NotContains(t, printed, DefaultJWTSecretKey)
+	assert.Contains(t, printed, "host: localhost")
+	assert.Contains(t, printed, "token_expiry: 15m0s")
+	// The configuration itself is unchanged
+	assert.Equal(t, "db-secret", cfg.Database.Password)
+	assert.Equal(t, "key-secret", cfg.JWT.Keys[0].Secret)
+}
+
+func TestDatabaseDSNQuotesValues(t *testing.T) {
+	database := NewDatabaseConfig()
+	database.Password = `it's secret`
+
+	assert.Equal(t, `host='localhost' user='root' password='it\'s secret' dbname='mydb' port=5432 sslmode='disable' TimeZone='UTC'`, database.DSN())
+}
+
+func TestLoadExampleConfigFile(t *testing.T) {
+	cfg, err := Load("marketplace", []string{"-config", "../../config.example.yaml"}, envOf(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, NewConfig(), cfg)
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DatabaseConfig contains configuration for the connection to PostgreSQL
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"ssl_mode"`
+	// TimeZone is the time zone of the database session
+	TimeZone string `yaml:"time_zone"`
+	// AutoMigrate applies pending schema migrations when the server starts
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+// NewDatabaseConfig creates a new database configuration with default values
+func NewDatabaseConfig() *DatabaseConfig {
+	return &DatabaseConfig{
+		Host:        "localhost",
+		Port:        5432,
+		User:        "root",
+		Password:    "password", // Matches the database of docker-compose.yml
+		Name:        "mydb",
+		SSLMode:     "disable",
+		TimeZone:    "UTC",
+		AutoMigrate: true,
+	}
+}
+
+// DSN returns the connection string of the database
+func (c *DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		dsnValue(c.Host), dsnValue(c.User), dsnValue(c.Password), dsnValue(c.Name), c.Port, dsnValue(c.SSLMode), dsnValue(c.TimeZone))
+}
+
+// dsnValue quotes a value of a connection string, so that it may contain spaces and quotes
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+)
+
+// IdempotencyConfig contains configuration for replaying the responses to requests sent with an idempotency key
+type IdempotencyConfig struct {
+	// TTL is how long the response to a request is replayed to retries, afterwards the key may be used again
+	TTL time.Duration `yaml:"ttl"`
+	// LockTimeout is how long a request may hold its key without completing, afterwards it is considered
+	// abandoned, e.g. because the server stopped, and a retry executes the command again
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+	// PurgeInterval is how often expired keys are deleted
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// NewIdempotencyConfig creates a new idempotency configuration with default values
+func NewIdempotencyConfig() *IdempotencyConfig {
+	return &IdempotencyConfig{
+		TTL:           24 * time.Hour,
+		LockTimeout:   time.Minute,
+		PurgeInterval: time.Hour,
+	}
+}
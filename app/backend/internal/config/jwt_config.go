@@ -4,18 +4,65 @@ import (
 	"time"
 )
 
+// DefaultJWTSecretKey is the secret of the default configuration, it is only accepted in development
+const DefaultJWTSecretKey = "your-secret-key"
+
+// JWTKey describes a single key used to sign or verify tokens.
+// Symmetric methods (HS256) use Secret, asymmetric methods (RS256, EdDSA)
+// use a PEM encoded private key from which the public key is derived.
+type JWTKey struct {
+	// ID is published as the "kid" header of every token signed with this key
+	ID string `yaml:"id"`
+	// SigningMethod overrides JWTConfig.SigningMethod for this key
+	SigningMethod string `yaml:"signing_method"`
+	Secret        string `yaml:"secret"`
+	PrivateKeyPEM string `yaml:"private_key_pem"`
+	// RetireAt is the moment after which tokens signed with this key are rejected.
+	// A zero value keeps the key valid until it is removed from the configuration.
+	RetireAt time.Time `yaml:"retire_at"`
+}
+
 // JWTConfig contains configuration for JWT authentication
 type JWTConfig struct {
-	SecretKey     string
-	TokenExpiry   time.Duration
-	SigningMethod string
+	SecretKey string `yaml:"secret_key"`
+	// TokenExpiry is the lifetime of access tokens
+	TokenExpiry time.Duration `yaml:"token_expiry"`
+	// RefreshTokenExpiry is the lifetime of refresh tokens
+	RefreshTokenExpiry time.Duration `yaml:"refresh_token_expiry"`
+	// PurgeInterval is how often expired refresh tokens and revocations of expired access tokens are deleted
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+	SigningMethod string        `yaml:"signing_method"`
+	Issuer        string        `yaml:"issuer"`
+	Audience      string        `yaml:"audience"`
+	// ActiveKeyID selects the key used to sign new tokens
+	ActiveKeyID string `yaml:"active_key_id"`
+	// Keys holds the active key and previous keys which are still accepted during rotation.
+	// When empty, a single key is derived from SecretKey and SigningMethod.
+	Keys []JWTKey `yaml:"keys"`
 }
 
 // NewJWTConfig creates a new JWT configuration with default values
 func NewJWTConfig() *JWTConfig {
 	return &JWTConfig{
-		SecretKey:     "your-secret-key", // In production, this should be loaded from environment variables
-		TokenExpiry:   24 * time.Hour,    // 24 hours
-		SigningMethod: "HS256",           // HMAC with SHA-256
+		SecretKey:          DefaultJWTSecretKey, // Set MARKETPLACE_JWT_SECRET_KEY outside of development
+		TokenExpiry:        15 * time.Minute,    // Short-lived access tokens
+		RefreshTokenExpiry: 7 * 24 * time.Hour,  // 7 days
+		PurgeInterval:      time.Hour,
+		SigningMethod:      "HS256", // HMAC with SHA-256
+		Issuer:             "marketplace",
+		Audience:           "marketplace-api",
+	}
+}
+
+// UsesDefaultSecret reports whether tokens are signed or verified with DefaultJWTSecretKey
+func (c *JWTConfig) UsesDefaultSecret() bool {
+	if len(c.Keys) == 0 {
+		return c.SecretKey == DefaultJWTSecretKey
+	}
+	for _, key := range c.Keys {
+		if key.Secret == DefaultJWTSecretKey {
+			return true
+		}
 	}
+	return false
 }
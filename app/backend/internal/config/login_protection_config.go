@@ -0,0 +1,32 @@
+package config
+
+import (
+	"time"
+)
+
+// LoginProtectionConfig contains configuration for login brute-force protection
+type LoginProtectionConfig struct {
+	// MaxFailedAttemptsPerAccount is the number of consecutive failed logins after which an account is locked
+	MaxFailedAttemptsPerAccount int `yaml:"max_failed_attempts_per_account"`
+	// AccountLockDuration is how long an automatically locked account stays locked
+	AccountLockDuration time.Duration `yaml:"account_lock_duration"`
+
+	// MaxFailedAttemptsPerIP is the number of failed logins within FailedAttemptWindow
+	// after which a client IP is blocked
+	MaxFailedAttemptsPerIP int `yaml:"max_failed_attempts_per_ip"`
+	// IPBlockDuration is how long a client IP stays blocked
+	IPBlockDuration time.Duration `yaml:"ip_block_duration"`
+	// FailedAttemptWindow is the period after which failed logins of a client IP are forgotten
+	FailedAttemptWindow time.Duration `yaml:"failed_attempt_window"`
+}
+
+// NewLoginProtectionConfig creates a new login protection configuration with default values
+func NewLoginProtectionConfig() *LoginProtectionConfig {
+	return &LoginProtectionConfig{
+		MaxFailedAttemptsPerAccount: 5,
+		AccountLockDuration:         15 * time.Minute,
+		MaxFailedAttemptsPerIP:      20,
+		IPBlockDuration:             15 * time.Minute,
+		FailedAttemptWindow:         15 * time.Minute,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+)
+
+// OutboxConfig contains configuration for delivering domain events from the outbox
+type OutboxConfig struct {
+	// PollInterval is how often the dispatcher looks for due events
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BatchSize is the maximum number of events delivered per poll
+	BatchSize int `yaml:"batch_size"`
+	// MaxAttempts is the number of failed deliveries after which an event is given up
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryBaseDelay is the delay before the first retry, it doubles with every further failure
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	// MaxRetryDelay caps the delay between retries
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay"`
+}
+
+// NewOutboxConfig creates a new outbox configuration with default values
+func NewOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		MaxAttempts:    10,
+		RetryBaseDelay: 5 * time.Second,
+		MaxRetryDelay:  time.Hour,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+// PasswordConfig contains configuration for password hashing
+type PasswordConfig struct {
+	// Algorithm used for new hashes: "argon2id" or "bcrypt"
+	Algorithm string `yaml:"algorithm"`
+
+	// Argon2id parameters, see RFC 9106
+	Argon2Memory      uint32 `yaml:"argon2_memory"` // in KiB
+	Argon2Iterations  uint32 `yaml:"argon2_iterations"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism"`
+	Argon2SaltLength  uint32 `yaml:"argon2_salt_length"`
+	Argon2KeyLength   uint32 `yaml:"argon2_key_length"`
+
+	// BcryptCost is the cost factor used for bcrypt hashes
+	BcryptCost int `yaml:"bcrypt_cost"`
+}
+
+// NewPasswordConfig creates a new password configuration with default values
+func NewPasswordConfig() *PasswordConfig {
+	return &PasswordConfig{
+		Algorithm:         "argon2id",
+		Argon2Memory:      64 * 1024, // 64 MiB
+		Argon2Iterations:  3,
+		Argon2Parallelism: 2,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+		BcryptCost:        12,
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"time"
+)
+
+// RequestConfig contains configuration for handling API requests
+type RequestConfig struct {
+	// Timeout is the deadline of the context of every request, queries still running when it passes are cancelled
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxBodySize is the size in bytes up to which request bodies are read into memory, e.g. to hash the payload
+	// of requests sent with an idempotency key
+	MaxBodySize int64 `yaml:"max_body_size"`
+}
+
+// NewRequestConfig creates a new request configuration with default values
+func NewRequestConfig() *RequestConfig {
+	return &RequestConfig{
+		Timeout:     30 * time.Second,
+		MaxBodySize: 1 << 20,
+	}
+}
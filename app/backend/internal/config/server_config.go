@@ -0,0 +1,24 @@
+package config
+
+import (
+	"time"
+)
+
+// ServerConfig contains configuration for the HTTP server
+type ServerConfig struct {
+	// Address is the host and port the server listens on, e.g. ":9090"
+	Address string `yaml:"address"`
+	// ShutdownTimeout limits how long requests in flight and background jobs may take to finish on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// HealthCheckTimeout limits how long a single readiness check may take
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout"`
+}
+
+// NewServerConfig creates a new server configuration with default values
+func NewServerConfig() *ServerConfig {
+	return &ServerConfig{
+		Address:            ":9090",
+		ShutdownTimeout:    30 * time.Second,
+		HealthCheckTimeout: 2 * time.Second,
+	}
+}
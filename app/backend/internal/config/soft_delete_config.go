@@ -0,0 +1,21 @@
+package config
+
+import (
+	"time"
+)
+
+// SoftDeleteConfig contains configuration for purging soft deleted products and sellers
+type SoftDeleteConfig struct {
+	// Retention is how long deleted products and sellers can be restored, afterwards they are purged
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval is how often products and sellers whose retention ended are purged
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// NewSoftDeleteConfig creates a new soft delete configuration with default values
+func NewSoftDeleteConfig() *SoftDeleteConfig {
+	return &SoftDeleteConfig{
+		Retention:     30 * 24 * time.Hour,
+		PurgeInterval: time.Hour,
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"time"
+)
+
+// WebhookConfig contains configuration for delivering webhooks to partner endpoints
+type WebhookConfig struct {
+	// PollInterval is how often pending deliveries are sent
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BatchSize is the maximum number of deliveries sent per poll
+	BatchSize int `yaml:"batch_size"`
+	// MaxAttempts is the number of failed attempts after which a delivery is given up
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryBaseDelay is the delay before the first retry, it doubles with every further failure
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	// MaxRetryDelay caps the delay between retries
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay"`
+	// Timeout limits how long an endpoint may take to answer a delivery
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewWebhookConfig creates a new webhook configuration with default values
+func NewWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		PollInterval:   time.Second,
+		BatchSize:      50,
+		MaxAttempts:    8,
+		RetryBaseDelay: 10 * time.Second,
+		MaxRetryDelay:  6 * time.Hour,
+		Timeout:        10 * time.Second,
+	}
+}
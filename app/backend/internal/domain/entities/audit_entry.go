@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// AuditAction names what a command did to an aggregate
+type AuditAction string
+
+const (
+	AuditActionCreate       AuditAction = "create"
+	AuditActionUpdate       AuditAction = "update"
+	AuditActionDelete       AuditAction = "delete"
+	AuditActionRestore      AuditAction = "restore"
+	AuditActionAddMember    AuditAction = "add_member"
+	AuditActionRemoveMember AuditAction = "remove_member"
+)
+
+// AuditAggregateType names the kind of aggregate an audit entry is about
+type AuditAggregateType string
+
+const (
+	AuditAggregateProduct AuditAggregateType = "product"
+	AuditAggregateSeller  AuditAggregateType = "seller"
+	AuditAggregateUser    AuditAggregateType = "user"
+)
+
+// AuditChange is the value of one field before and after a command, nil if the field was not set
+type AuditChange struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
+
+// AuditEntry records who changed which aggregate how. Entries are append-only, they are never changed or removed.
+type AuditEntry struct {
+	Id uuid.UUID
+	// ActorId is the user who executed the command, empty for background jobs and anonymous requests like registrations
+	ActorId       string
+	Action        AuditAction
+	AggregateType AuditAggregateType
+	AggregateId   string
+	// Changes lists the fields whose value differs before and after the command, ordered by field
+	Changes []AuditChange
+	// RequestId is the X-Request-ID of the request which executed the command, empty outside of requests
+	RequestId  string
+	OccurredAt time.Time
+}
+
+// AuditSnapshot holds the audited fields of an aggregate by name, a nil snapshot means the aggregate does not exist
+type AuditSnapshot map[string]interface{}
+
+// AuditSecret is a snapshot value which is compared but never recorded, e.g. a password hash
+type AuditSecret string
+
+// AuditRedacted replaces the values of secrets in the changes
+const AuditRedacted = "[redacted]"
+
+// NewAuditEntry creates an entry with the changes between the snapshots of the aggregate before and after the command
+func NewAuditEntry(
+	actorId string,
+	action AuditAction,
+	aggregateType AuditAggregateType,
+	aggregateId string,
+	before, after AuditSnapshot,
+	requestId string,
+) *AuditEntry {
+	return &AuditEntry{
+		Id:            uuid.New(),
+		ActorId:       actorId,
+		Action:        action,
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Changes:       DiffAuditSnapshots(before, after),
+		RequestId:     requestId,
+		OccurredAt:    time.Now(),
+	}
+}
+
+// DiffAuditSnapshots returns the fields whose value differs between the snapshots, ordered by field.
+// Secrets which changed are recorded as AuditRedacted.
+func DiffAuditSnapshots(before, after AuditSnapshot) []AuditChange {
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	changes := make([]AuditChange, 0, len(fields))
+	for field := range fields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changes = append(changes, AuditChange{Field: field, Before: redactAuditSecret(before[field]), After: redactAuditSecret(after[field])})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func redactAuditSecret(value interface{}) interface{} {
+	if _, ok := value.(AuditSecret); ok {
+		return AuditRedacted
+	}
+	return value
+}
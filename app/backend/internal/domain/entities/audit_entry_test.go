@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffAuditSnapshots(t *testing.T) {
+	tests := []struct {
+		name   string
+		before AuditSnapshot
+		after  AuditSnapshot
+		want   []AuditChange
+	}{
+		{"unchanged", AuditSnapshot{"name": "A"}, AuditSnapshot{"name": "A"}, []AuditChange{}},
+		{"created", nil, AuditSnapshot{"name": "A", "email": "a@example.com"}, []AuditChange{
+			{Field: "email", After: "a@example.com"},
+			{Field: "name", After: "A"},
+		}},
+		{"changed", AuditSnapshot{"name": "A", "role": "user"}, AuditSnapshot{"name": "B", "role": "user"}, []AuditChange{
+			{Field: "name", Before: "A", After: "B"},
+		}},
+		{"removed", AuditSnapshot{"name": "A"}, nil, []AuditChange{{Field: "name", Before: "A"}}},
+		{"secret changed", AuditSnapshot{"password": AuditSecret("old")}, AuditSnapshot{"password": AuditSecret("new")}, []AuditChange{
+			{Field: "password", Before: AuditRedacted, After: AuditRedacted},
+		}},
+		{"secret unchanged", AuditSnapshot{"password": AuditSecret("old")}, AuditSnapshot{"password": AuditSecret("old")}, []AuditChange{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffAuditSnapshots(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffAuditSnapshots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	entry := NewAuditEntry("user-id", AuditActionUpdate, AuditAggregateUser, "other-id",
+		AuditSnapshot{"role": "user"}, AuditSnapshot{"role": "admin"}, "request-id")
+
+	if entry.ActorId != "user-id" || entry.AggregateId != "other-id" || entry.RequestId != "request-id" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if entry.OccurredAt.IsZero() {
+		t.Error("Expected the time of the entry to be set")
+	}
+	if len(entry.Changes) != 1 || entry.Changes[0].Field != "role" {
+		t.Errorf("Expected the role change, got %v", entry.Changes)
+	}
+}
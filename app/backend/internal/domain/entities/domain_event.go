@@ -0,0 +1,227 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// DomainEvent is something that happened to an aggregate. Events are persisted together
+// with the aggregate and delivered to subscribers afterwards.
+type DomainEvent interface {
+	// EventName identifies the type of the event, e.g. "product.created"
+	EventName() string
+	// AggregateId is the id of the aggregate which raised the event
+	AggregateId() string
+	// OccurredAt is the time the event was raised
+	OccurredAt() time.Time
+}
+
+// DomainEvents collects the events an aggregate raised until a repository persists them
+type DomainEvents struct {
+	pending []DomainEvent
+}
+
+func (e *DomainEvents) raise(event DomainEvent) {
+	e.pending = append(e.pending, event)
+}
+
+// PendingEvents returns the events raised since the aggregate was created, loaded or last saved
+func (e *DomainEvents) PendingEvents() []DomainEvent {
+	return e.pending
+}
+
+// ClearEvents forgets the raised events once they are persisted
+func (e *DomainEvents) ClearEvents() {
+	e.pending = nil
+}
+
+const (
+	EventProductCreated      = "product.created"
+	EventProductRenamed      = "product.renamed"
+	EventProductPriceChanged = "product.price_changed"
+	EventProductDeleted      = "product.deleted"
+	EventProductRestored     = "product.restored"
+	EventSellerCreated       = "seller.created"
+	EventSellerRenamed       = "seller.renamed"
+	EventSellerDeleted       = "seller.deleted"
+	EventSellerRestored      = "seller.restored"
+	EventUserRegistered      = "user.registered"
+	EventUserStatusChanged   = "user.status_changed"
+	EventUserLocked          = "user.locked"
+)
+
+// ProductCreated is raised when a new product is listed
+type ProductCreated struct {
+	ProductId uuid.UUID
+	SellerId  uuid.UUID
+	Name      string
+	Price     Money
+	At        time.Time
+}
+
+func (e ProductCreated) EventName() string     { return EventProductCreated }
+func (e ProductCreated) AggregateId() string   { return e.ProductId.String() }
+func (e ProductCreated) OccurredAt() time.Time { return e.At }
+
+// ProductRenamed is raised when the name of a product changes
+type ProductRenamed struct {
+	ProductId uuid.UUID
+	OldName   string
+	NewName   string
+	At        time.Time
+}
+
+func (e ProductRenamed) EventName() string     { return EventProductRenamed }
+func (e ProductRenamed) AggregateId() string   { return e.ProductId.String() }
+func (e ProductRenamed) OccurredAt() time.Time { return e.At }
+
+// ProductPriceChanged is raised when the price of a product changes
+type ProductPriceChanged struct {
+	ProductId uuid.UUID
+	OldPrice  Money
+	NewPrice  Money
+	At        time.Time
+}
+
+func (e ProductPriceChanged) EventName() string     { return EventProductPriceChanged }
+func (e ProductPriceChanged) AggregateId() string   { return e.ProductId.String() }
+func (e ProductPriceChanged) OccurredAt() time.Time { return e.At }
+
+// ProductDeleted is raised when a product is removed. Products are deleted by id without
+// loading them, so the repository raises it.
+type ProductDeleted struct {
+	ProductId uuid.UUID
+	At        time.Time
+}
+
+func (e ProductDeleted) EventName() string     { return EventProductDeleted }
+func (e ProductDeleted) AggregateId() string   { return e.ProductId.String() }
+func (e ProductDeleted) OccurredAt() time.Time { return e.At }
+
+// ProductRestored is raised by the repository when a soft deleted product is restored
+type ProductRestored struct {
+	ProductId uuid.UUID
+	At        time.Time
+}
+
+func (e ProductRestored) EventName() string     { return EventProductRestored }
+func (e ProductRestored) AggregateId() string   { return e.ProductId.String() }
+func (e ProductRestored) OccurredAt() time.Time { return e.At }
+
+// SellerCreated is raised when a new seller is registered
+type SellerCreated struct {
+	SellerId uuid.UUID
+	Name     string
+	At       time.Time
+}
+
+func (e SellerCreated) EventName() string     { return EventSellerCreated }
+func (e SellerCreated) AggregateId() string   { return e.SellerId.String() }
+func (e SellerCreated) OccurredAt() time.Time { return e.At }
+
+// SellerRenamed is raised when the name of a seller changes
+type SellerRenamed struct {
+	SellerId uuid.UUID
+	OldName  string
+	NewName  string
+	At       time.Time
+}
+
+func (e SellerRenamed) EventName() string     { return EventSellerRenamed }
+func (e SellerRenamed) AggregateId() string   { return e.SellerId.String() }
+func (e SellerRenamed) OccurredAt() time.Time { return e.At }
+
+// SellerDeleted is raised by the repository when a seller is removed
+type SellerDeleted struct {
+	SellerId uuid.UUID
+	At       time.Time
+}
+
+func (e SellerDeleted) EventName() string     { return EventSellerDeleted }
+func (e SellerDeleted) AggregateId() string   { return e.SellerId.String() }
+func (e SellerDeleted) OccurredAt() time.Time { return e.At }
+
+// SellerRestored is raised by the repository when a soft deleted seller is restored
+type SellerRestored struct {
+	SellerId uuid.UUID
+	At       time.Time
+}
+
+func (e SellerRestored) EventName() string     { return EventSellerRestored }
+func (e SellerRestored) AggregateId() string   { return e.SellerId.String() }
+func (e SellerRestored) OccurredAt() time.Time { return e.At }
+
+// UserRegistered is raised when a new user account is created
+type UserRegistered struct {
+	UserId   string
+	Username string
+	At       time.Time
+}
+
+func (e UserRegistered) EventName() string     { return EventUserRegistered }
+func (e UserRegistered) AggregateId() string   { return e.UserId }
+func (e UserRegistered) OccurredAt() time.Time { return e.At }
+
+// UserStatusChanged is raised when a user is activated or deactivated
+type UserStatusChanged struct {
+	UserId    string
+	OldStatus UserStatus
+	NewStatus UserStatus
+	Reason    string
+	At        time.Time
+}
+
+func (e UserStatusChanged) EventName() string     { return EventUserStatusChanged }
+func (e UserStatusChanged) AggregateId() string   { return e.UserId }
+func (e UserStatusChanged) OccurredAt() time.Time { return e.At }
+
+// UserLocked is raised when a user account is locked, by an admin or after too many failed logins
+type UserLocked struct {
+	UserId string
+	Reason string
+	// LockedUntil is the time the lock expires, zero if an admin has to unlock the account
+	LockedUntil time.Time
+	At          time.Time
+}
+
+func (e UserLocked) EventName() string     { return EventUserLocked }
+func (e UserLocked) AggregateId() string   { return e.UserId }
+func (e UserLocked) OccurredAt() time.Time { return e.At }
+
+// domainEventDecoders restore the stored events by their name
+var domainEventDecoders = map[string]func(payload []byte) (DomainEvent, error){
+	EventProductCreated:      decodeDomainEvent[ProductCreated],
+	EventProductRenamed:      decodeDomainEvent[ProductRenamed],
+	EventProductPriceChanged: decodeDomainEvent[ProductPriceChanged],
+	EventProductDeleted:      decodeDomainEvent[ProductDeleted],
+	EventProductRestored:     decodeDomainEvent[ProductRestored],
+	EventSellerCreated:       decodeDomainEvent[SellerCreated],
+	EventSellerRenamed:       decodeDomainEvent[SellerRenamed],
+	EventSellerDeleted:       decodeDomainEvent[SellerDeleted],
+	EventSellerRestored:      decodeDomainEvent[SellerRestored],
+	EventUserRegistered:      decodeDomainEvent[UserRegistered],
+	EventUserStatusChanged:   decodeDomainEvent[UserStatusChanged],
+	EventUserLocked:          decodeDomainEvent[UserLocked],
+}
+
+func decodeDomainEvent[T DomainEvent](payload []byte) (DomainEvent, error) {
+	var event T
+	err := json.Unmarshal(payload, &event)
+	return event, err
+}
+
+// DecodeDomainEvent restores an event from its name and JSON payload
+func DecodeDomainEvent(name string, payload []byte) (DomainEvent, error) {
+	decode, ok := domainEventDecoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown domain event %q", name)
+	}
+
+	event, err := decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload of domain event %q: %w", name, err)
+	}
+	return event, nil
+}
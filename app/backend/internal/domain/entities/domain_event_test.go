@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func eventNames(events []DomainEvent) []string {
+	var names []string
+	for _, event := range events {
+		names = append(names, event.EventName())
+	}
+	return names
+}
+
+func TestProduct_RaisesEvents(t *testing.T) {
+	seller, _ := NewValidatedSeller(NewSeller("Seller"))
+	product := NewProduct("Shoe", Money{Amount: 1000, Currency: CurrencyUSD}, *seller)
+
+	_ = product.UpdateName("Shoe")
+	_ = product.UpdateName("Red Shoe")
+	_ = product.UpdatePrice(Money{Amount: 1000, Currency: CurrencyUSD})
+	_ = product.UpdatePrice(Money{Amount: 1200, Currency: CurrencyUSD})
+	if err := product.UpdateName(""); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+
+	expected := []string{EventProductCreated, EventProductRenamed, EventProductPriceChanged}
+	if names := eventNames(product.PendingEvents()); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, names)
+	}
+
+	priceChanged := product.PendingEvents()[2].(ProductPriceChanged)
+	if priceChanged.OldPrice.Amount != 1000 || priceChanged.NewPrice.Amount != 1200 || priceChanged.AggregateId() != product.Id.String() {
+		t.Errorf("Unexpected price change %+v", priceChanged)
+	}
+
+	product.ClearEvents()
+	if len(product.PendingEvents()) != 0 {
+		t.Error("Expected no events after clearing them")
+	}
+}
+
+func TestSeller_RaisesEvents(t *testing.T) {
+	seller := NewSeller("Seller")
+	_ = seller.UpdateName("Renamed Seller")
+
+	expected := []string{EventSellerCreated, EventSellerRenamed}
+	if names := eventNames(seller.PendingEvents()); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected events %v, but got %v", expected, names)
+	}
+}
+
+func TestUser_RaisesEvents(t *testing.T) {
+	user, _ := NewUser("user-1", "alice", "alice@example.com", "hash")
+	now := time.Now()
+
+	_, _ = user.RecordFailedLogin(now, 1, time.Minute)
+	_ = user.ChangeStatus(StatusActive, "lock expired")
+	_ = user.ChangeStatus(StatusActive, "unchanged")
+	_ = user.ChangeStatus("unknown", "invalid")
+
+	expected := []string{EventUserRegistered, EventUserLocked, EventUserStatusChanged}
+	if names := eventNames(user.PendingEvents()); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, names)
+	}
+
+	locked := user.PendingEvents()[1].(UserLocked)
+	if locked.Reason != "too many failed login attempts" || !locked.LockedUntil.Equal(now.Add(time.Minute)) {
+		t.Errorf("Unexpected lock %+v", locked)
+	}
+	statusChanged := user.PendingEvents()[2].(UserStatusChanged)
+	if statusChanged.OldStatus != StatusLocked || statusChanged.NewStatus != StatusActive || statusChanged.Reason != "lock expired" {
+		t.Errorf("Unexpected status change %+v", statusChanged)
+	}
+}
+
+func TestDecodeDomainEvent(t *testing.T) {
+	event := ProductPriceChanged{
+		ProductId: NewSeller("Seller").Id,
+		OldPrice:  Money{Amount: 1000, Currency: CurrencyUSD},
+		NewPrice:  Money{Amount: 999, Currency: CurrencyJPY},
+		At:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	decoded, err := DecodeDomainEvent(event.EventName(), payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, event) {
+		t.Errorf("Expected %+v, but got %+v", event, decoded)
+	}
+
+	if _, err := DecodeDomainEvent("product.unknown", payload); err == nil {
+		t.Error("Expected an error for an unknown event")
+	}
+	if _, err := DecodeDomainEvent(EventProductCreated, []byte("{")); err == nil {
+		t.Error("Expected an error for an invalid payload")
+	}
+}
@@ -0,0 +1,101 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of domain and application errors. Specific errors wrap one of them, callers which only care
+// about the kind test for it with errors.Is, e.g. errors.Is(err, ErrNotFound) for ErrProductNotFound.
+var (
+	// ErrNotFound is the kind of errors returned when the entity to act on does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrValidation is the kind of errors returned for invalid input, see ValidationError for the field details
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict is the kind of errors returned when an action conflicts with the current state, e.g. a taken name
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is the kind of errors returned when the acting user may not perform an action
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized is the kind of errors returned when credentials or tokens are missing or invalid
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrTooManyRequests is the kind of errors returned while a client is throttled, e.g. after failed logins
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error is an error of one of the kinds above
+type Error struct {
+	Kind    error
+	Message string
+}
+
+// NewError creates an error of the given kind, e.g. NewError(ErrNotFound, "product not found")
+func NewError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind of the error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Codes of field errors, clients can rely on them while messages may change
+const (
+	ValidationCodeRequired   = "required"
+	ValidationCodeInvalid    = "invalid"
+	ValidationCodeTooShort   = "too_short"
+	ValidationCodeTooLong    = "too_long"
+	ValidationCodeTooSmall   = "too_small"
+	ValidationCodeTooLarge   = "too_large"
+	ValidationCodeNotAllowed = "not_allowed"
+)
+
+// FieldError describes why the value of a field is invalid
+type FieldError struct {
+	Field string
+	// Code is one of the ValidationCode constants, e.g. ValidationCodeRequired
+	Code    string
+	Message string
+}
+
+// ValidationError lists the invalid fields of an entity or a request, it is of the kind ErrValidation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError creates a validation error for a single field,
+// e.g. NewValidationError("name", ValidationCodeRequired, "must not be empty")
+func NewValidationError(field, code, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Code: code, Message: message}}}
+}
+
+// Add records another invalid field
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns the validation error if any field was added, nil otherwise.
+// Validations collect all violations with Add and finish with return errs.Err().
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error joins the fields, e.g. "name must not be empty; price must be greater than 0"
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns ErrValidation
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestErrorIsOfItsKind(t *testing.T) {
+	err := fmt.Errorf("loading product: %w", NewError(ErrNotFound, "product not found"))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error to be of kind ErrNotFound, but got %v", err)
+	}
+	if errors.Is(err, ErrConflict) {
+		t.Errorf("Expected error not to be of kind ErrConflict, but got %v", err)
+	}
+	if err.Error() != "loading product: product not found" {
+		t.Errorf("Expected message 'loading product: product not found', but got %s", err.Error())
+	}
+}
+
+func TestValidationErrorListsFields(t *testing.T) {
+	validationErr := NewValidationError("name", ValidationCodeRequired, "cannot be empty")
+	validationErr.Add("price", ValidationCodeTooSmall, "must be greater than 0")
+
+	var err error = validationErr
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected error to be of kind ErrValidation, but got %v", err)
+	}
+	if err.Error() != "name cannot be empty; price must be greater than 0" {
+		t.Errorf("Expected the fields to be joined, but got %s", err.Error())
+	}
+
+	var target *ValidationError
+	if !errors.As(fmt.Errorf("creating product: %w", err), &target) || len(target.Fields) != 2 {
+		t.Errorf("Expected to find the validation error with 2 fields in the chain, but got %v", target)
+	}
+}
+
+func TestValidationErrorWithoutFieldsIsNil(t *testing.T) {
+	errs := &ValidationError{}
+	if err := errs.Err(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+}
+
+func TestProductValidateReturnsAllViolations(t *testing.T) {
+	product := &Product{Name: "", Price: Money{Amount: 0, Currency: CurrencyUSD}}
+
+	var validationErr *ValidationError
+	if err := product.validate(); !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, but got %v", err)
+	}
+
+	expected := []FieldError{
+		{Field: "name", Code: ValidationCodeRequired, Message: "must not be empty"},
+		{Field: "price", Code: ValidationCodeTooSmall, Message: "must be greater than 0"},
+	}
+	if !reflect.DeepEqual(validationErr.Fields, expected) {
+		t.Errorf("Expected fields %v, but got %v", expected, validationErr.Fields)
+	}
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ExchangeRate states how many units of the quote currency one unit of the base currency is worth,
+// starting at the effective date. A rate stays in effect until a rate with a later date is loaded.
+type ExchangeRate struct {
+	BaseCurrency  Currency
+	QuoteCurrency Currency
+	// Rate is the exact decimal as published, e.g. "0.9234"
+	Rate          string
+	EffectiveDate time.Time
+	CreatedAt     time.Time
+}
+
+// NewExchangeRate creates an exchange rate. The effective date is truncated to the day in UTC.
+func NewExchangeRate(base, quote Currency, rate string, effectiveDate time.Time) (*ExchangeRate, error) {
+	errs := &ValidationError{}
+	if !base.IsValid() {
+		errs.Add("base_currency", ValidationCodeNotAllowed, fmt.Sprintf("%q is not supported", base))
+	}
+	if !quote.IsValid() {
+		errs.Add("quote_currency", ValidationCodeNotAllowed, fmt.Sprintf("%q is not supported", quote))
+	} else if base == quote {
+		errs.Add("quote_currency", ValidationCodeInvalid, "must differ from the base currency")
+	}
+	if effectiveDate.IsZero() {
+		errs.Add("effective_date", ValidationCodeRequired, "cannot be empty")
+	}
+
+	rate = strings.TrimSpace(rate)
+	factor, ok := new(big.Rat).SetString(rate)
+	if !ok || strings.ContainsAny(rate, "eE/") {
+		errs.Add("rate", ValidationCodeInvalid, fmt.Sprintf("%q is not a decimal number", rate))
+	} else if factor.Sign() <= 0 {
+		errs.Add("rate", ValidationCodeTooSmall, fmt.Sprintf("must be greater than 0, got %q", rate))
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          rate,
+		EffectiveDate: effectiveDate.UTC().Truncate(24 * time.Hour),
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// Factor returns the rate as exact rational number
+func (r *ExchangeRate) Factor() *big.Rat {
+	factor, _ := new(big.Rat).SetString(r.Rate)
+	return factor
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewExchangeRate(t *testing.T) {
+	rate, err := NewExchangeRate(CurrencyEUR, CurrencyUSD, " 1.0712 ", time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if rate.Rate != "1.0712" {
+		t.Errorf("Expected rate 1.0712, but got %s", rate.Rate)
+	}
+	if !rate.EffectiveDate.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the effective date to be truncated to the day, but got %s", rate.EffectiveDate)
+	}
+	if rate.Factor().Cmp(big.NewRat(10712, 10000)) != 0 {
+		t.Errorf("Expected factor 10712/10000, but got %s", rate.Factor())
+	}
+}
+
+func TestNewExchangeRate_Invalid(t *testing.T) {
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		base  Currency
+		quote Currency
+		rate  string
+		date  time.Time
+	}{
+		{"unsupported base", "XXX", CurrencyUSD, "1", date},
+		{"unsupported quote", CurrencyEUR, "XXX", "1", date},
+		{"same currency", CurrencyEUR, CurrencyEUR, "1", date},
+		{"missing date", CurrencyEUR, CurrencyUSD, "1", time.Time{}},
+		{"not a number", CurrencyEUR, CurrencyUSD, "abc", date},
+		{"exponent", CurrencyEUR, CurrencyUSD, "1e2", date},
+		{"fraction", CurrencyEUR, CurrencyUSD, "1/2", date},
+		{"zero", CurrencyEUR, CurrencyUSD, "0", date},
+		{"negative", CurrencyEUR, CurrencyUSD, "-1.2", date},
+	}
+
+	for _, test := range tests {
+		if _, err := NewExchangeRate(test.base, test.quote, test.rate, test.date); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the maximum length of the idempotency keys clients send
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord remembers the response to a request sent with an idempotency key, so that a retried
+// request is answered with the same response instead of executing its command again.
+// Keys are scoped to the user and the route, the request hash detects keys reused for another request.
+type IdempotencyRecord struct {
+	Key         string
+	UserId      string
+	Route       string
+	RequestHash string
+	// Token identifies the request which reserved the key, so that a request whose key was taken over
+	// by a retry cannot record its response or release the key of the retry
+	Token string
+	// ResponseStatus is 0 while the request is still being processed
+	ResponseStatus  int
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
+
+// NewIdempotencyRecord reserves the key for the request until the record expires after ttl
+func NewIdempotencyRecord(key, userId, route, requestHash string, now time.Time, ttl time.Duration) (*IdempotencyRecord, error) {
+	if key == "" {
+		return nil, errors.New("idempotency key cannot be empty")
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, errors.New("idempotency key is too long")
+	}
+	if userId == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	if route == "" {
+		return nil, errors.New("route cannot be empty")
+	}
+	if requestHash == "" {
+		return nil, errors.New("request hash cannot be empty")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+
+	return &IdempotencyRecord{
+		Key:         key,
+		UserId:      userId,
+		Route:       route,
+		RequestHash: requestHash,
+		Token:       uuid.New().String(),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}, nil
+}
+
+// Matches reports whether the record was created for the request with the given hash
+func (r *IdempotencyRecord) Matches(requestHash string) bool {
+	return r.RequestHash == requestHash
+}
+
+// IsCompleted reports whether the response of the request was recorded
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.ResponseStatus != 0
+}
+
+// IsExpired reports whether the key may be used for another request at the given time
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Complete records the response which is replayed to retries of the request
+func (r *IdempotencyRecord) Complete(status int, headers map[string]string, body []byte) error {
+	if status <= 0 {
+		return errors.New("response status must be positive")
+	}
+	if r.IsCompleted() {
+		return errors.New("idempotency record is already completed")
+	}
+
+	r.ResponseStatus = status
+	r.ResponseHeaders = headers
+	r.ResponseBody = body
+	return nil
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// LoginAttempt tracks consecutive failed logins from a single client IP
+type LoginAttempt struct {
+	ClientIP       string
+	FailedAttempts int
+	LastFailedAt   time.Time
+	BlockedUntil   time.Time
+}
+
+// NewLoginAttempt creates an empty login attempt record for the given client IP
+func NewLoginAttempt(clientIP string) (*LoginAttempt, error) {
+	if clientIP == "" {
+		return nil, errors.New("client IP cannot be empty")
+	}
+
+	return &LoginAttempt{
+		ClientIP: clientIP,
+	}, nil
+}
+
+// IsBlocked reports whether logins from the client IP are currently rejected
+func (a *LoginAttempt) IsBlocked(now time.Time) bool {
+	return now.Before(a.BlockedUntil)
+}
+
+// ReachedThreshold reports whether enough failed logins were counted to block the client IP
+func (a *LoginAttempt) ReachedThreshold(threshold int) bool {
+	return threshold > 0 && a.FailedAttempts >= threshold
+}
@@ -0,0 +1,291 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyCHF Currency = "CHF"
+	CurrencyJPY Currency = "JPY"
+	CurrencyCNY Currency = "CNY"
+	CurrencyKRW Currency = "KRW"
+	CurrencyAUD Currency = "AUD"
+	CurrencyCAD Currency = "CAD"
+	CurrencySEK Currency = "SEK"
+	CurrencyKWD Currency = "KWD"
+)
+
+// DefaultCurrency is used for prices that were stored before prices carried a currency
+const DefaultCurrency = CurrencyUSD
+
+// currencyMinorUnits holds the number of decimal places of the supported currencies
+var currencyMinorUnits = map[Currency]int{
+	CurrencyUSD: 2,
+	CurrencyEUR: 2,
+	CurrencyGBP: 2,
+	CurrencyCHF: 2,
+	CurrencyJPY: 0,
+	CurrencyCNY: 2,
+	CurrencyKRW: 0,
+	CurrencyAUD: 2,
+	CurrencyCAD: 2,
+	CurrencySEK: 2,
+	CurrencyKWD: 3,
+}
+
+var (
+	ErrUnsupportedCurrency = NewError(ErrValidation, "unsupported currency")
+	ErrCurrencyMismatch    = NewError(ErrValidation, "currencies do not match")
+	ErrInvalidAmount       = NewError(ErrValidation, "invalid amount")
+)
+
+// ParseCurrency returns the currency for an ISO 4217 code
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !currency.IsValid() {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return currency, nil
+}
+
+// IsValid reports whether the currency is supported
+func (c Currency) IsValid() bool {
+	_, ok := currencyMinorUnits[c]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places of the currency, e.g. 2 for cents
+func (c Currency) MinorUnits() int {
+	return currencyMinorUnits[c]
+}
+
+// Money is an amount in the minor units of a currency, e.g. 1099 USD is $10.99.
+// Amounts are integers so that arithmetic never loses precision.
+// In JSON the amount is an exact decimal string in major units, see MarshalJSON.
+type Money struct {
+	Amount   int64    `json:"Amount" swaggertype:"string" example:"10.99"`
+	Currency Currency `json:"Currency" swaggertype:"string" example:"USD"`
+}
+
+// NewMoney creates money from an amount in minor units
+func NewMoney(amount int64, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney creates money from a decimal string such as "10.99".
+// More decimal places than the currency has are rejected instead of rounded.
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok || strings.ContainsAny(amount, "eE/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	minor := value.Mul(value, new(big.Rat).SetInt(pow10(currency.MinorUnits())))
+	if !minor.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, amount, currency.MinorUnits())
+	}
+	if !minor.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, amount)
+	}
+
+	return Money{Amount: minor.Num().Int64(), Currency: currency}, nil
+}
+
+// NewMoneyFromFloat creates money from a float, rounding half away from zero to the minor unit.
+// It exists for legacy data only; use ParseMoney for user input.
+func NewMoneyFromFloat(amount float64, currency Currency) (Money, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+
+	// Use the shortest decimal representation so that 1.005 is not read as 1.00499999...
+	value, _ := new(big.Rat).SetString(strconv.FormatFloat(amount, 'f', -1, 64))
+	minor, err := roundHalfAwayFromZero(value.Mul(value, new(big.Rat).SetInt(pow10(currency.MinorUnits()))))
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Validate checks that the money has a supported currency
+func (m Money) Validate() error {
+	if !m.Currency.IsValid() {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCurrency, m.Currency)
+	}
+	return nil
+}
+
+// Add returns the sum of both amounts. Both must have the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidAmount)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Subtract returns the difference of both amounts. Both must have the same currency.
+func (m Money) Subtract(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidAmount)
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Multiply returns the amount multiplied by a whole quantity
+func (m Money) Multiply(quantity int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(quantity))
+	if !product.IsInt64() {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidAmount)
+	}
+	return Money{Amount: product.Int64(), Currency: m.Currency}, nil
+}
+
+// Scale returns the amount multiplied by an exact factor such as a discount or an exchange rate,
+// rounded half away from zero to the minor unit of the currency
+func (m Money) Scale(factor *big.Rat) (Money, error) {
+	scaled := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+	amount, err := roundHalfAwayFromZero(scaled)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// ConvertTo returns the amount in the target currency, given how many units of the target
+// currency one unit of the money's currency is worth. The result is rounded half away from zero.
+func (m Money) ConvertTo(target Currency, rate *big.Rat) (Money, error) {
+	if !target.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, target)
+	}
+
+	// Account for currencies with a different number of minor units, e.g. USD cents to whole JPY
+	factor := new(big.Rat).Mul(rate, new(big.Rat).SetFrac(pow10(target.MinorUnits()), pow10(m.Currency.MinorUnits())))
+
+	converted, err := m.Scale(factor)
+	if err != nil {
+		return Money{}, err
+	}
+	converted.Currency = target
+	return converted, nil
+}
+
+// Decimal returns the amount as exact decimal string in major units, e.g. "10.99"
+func (m Money) Decimal() string {
+	digits := m.Currency.MinorUnits()
+	if digits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	abs := new(big.Int).Abs(big.NewInt(m.Amount)).String()
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	if len(abs) <= digits {
+		abs = strings.Repeat("0", digits-len(abs)+1) + abs
+	}
+
+	return sign + abs[:len(abs)-digits] + "." + abs[len(abs)-digits:]
+}
+
+// String returns the amount with its currency, e.g. "10.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+type moneyJSON struct {
+	Amount   json.Number
+	Currency string
+}
+
+// MarshalJSON encodes the amount as decimal string so that clients never see float rounding,
+// e.g. {"Amount":"10.99","Currency":"USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string
+		Currency Currency
+	}{
+		Amount:   m.Decimal(),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON accepts the amount as decimal string or JSON number. The literal is parsed exactly.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	currency, err := ParseCurrency(raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(raw.Amount.String(), currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// roundHalfAwayFromZero rounds to the nearest integer, ties away from zero (commercial rounding)
+func roundHalfAwayFromZero(value *big.Rat) (int64, error) {
+	numerator := new(big.Int).Abs(value.Num())
+	denominator := value.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+
+	if !quotient.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+	return quotient.Int64(), nil
+}
@@ -0,0 +1,185 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency Currency
+		expected int64
+		wantErr  bool
+	}{
+		{amount: "10.99", currency: CurrencyUSD, expected: 1099},
+		{amount: "10.9", currency: CurrencyUSD, expected: 1090},
+		{amount: "0.1", currency: CurrencyUSD, expected: 10},
+		{amount: "-3.50", currency: CurrencyEUR, expected: -350},
+		{amount: "1500", currency: CurrencyJPY, expected: 1500},
+		{amount: "1.234", currency: CurrencyKWD, expected: 1234},
+		{amount: "10.999", currency: CurrencyUSD, wantErr: true},
+		{amount: "1.5", currency: CurrencyJPY, wantErr: true},
+		{amount: "1e2", currency: CurrencyUSD, wantErr: true},
+		{amount: "abc", currency: CurrencyUSD, wantErr: true},
+		{amount: "", currency: CurrencyUSD, wantErr: true},
+		{amount: "1.00", currency: Currency("XXX"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		money, err := ParseMoney(tc.amount, tc.currency)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %s): expected error, but got %s", tc.amount, tc.currency, money)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s): unexpected error: %s", tc.amount, tc.currency, err)
+			continue
+		}
+		if money.Amount != tc.expected {
+			t.Errorf("ParseMoney(%q, %s): expected %d minor units, but got %d", tc.amount, tc.currency, tc.expected, money.Amount)
+		}
+	}
+}
+
+func TestNewMoneyFromFloatRoundsHalfAwayFromZero(t *testing.T) {
+	tests := map[float64]int64{
+		10.99:  1099,
+		1.005:  101,
+		2.675:  268,
+		-1.005: -101,
+		0.004:  0,
+	}
+
+	for value, expected := range tests {
+		money, err := NewMoneyFromFloat(value, CurrencyUSD)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if money.Amount != expected {
+			t.Errorf("NewMoneyFromFloat(%v): expected %d minor units, but got %d", value, expected, money.Amount)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	price := Money{Amount: 1099, Currency: CurrencyUSD}
+
+	sum, err := price.Add(Money{Amount: 1, Currency: CurrencyUSD})
+	if err != nil || sum.Amount != 1100 {
+		t.Errorf("Expected 1100, but got %d (%v)", sum.Amount, err)
+	}
+
+	difference, err := price.Subtract(Money{Amount: 1100, Currency: CurrencyUSD})
+	if err != nil || difference.Amount != -1 {
+		t.Errorf("Expected -1, but got %d (%v)", difference.Amount, err)
+	}
+
+	if _, err := price.Add(Money{Amount: 1, Currency: CurrencyEUR}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, but got %v", err)
+	}
+
+	total, err := price.Multiply(3)
+	if err != nil || total.Amount != 3297 {
+		t.Errorf("Expected 3297, but got %d (%v)", total.Amount, err)
+	}
+
+	// 10.99 * 15% = 1.6485 -> 1.65
+	discount, err := price.Scale(big.NewRat(15, 100))
+	if err != nil || discount.Amount != 165 {
+		t.Errorf("Expected 165, but got %d (%v)", discount.Amount, err)
+	}
+
+	// 0.05 / 2 = 0.025 -> 0.03
+	half, err := Money{Amount: 5, Currency: CurrencyUSD}.Scale(big.NewRat(1, 2))
+	if err != nil || half.Amount != 3 {
+		t.Errorf("Expected 3, but got %d (%v)", half.Amount, err)
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := map[Money]string{
+		{Amount: 1099, Currency: CurrencyUSD}: "10.99",
+		{Amount: 5, Currency: CurrencyUSD}:    "0.05",
+		{Amount: -5, Currency: CurrencyUSD}:   "-0.05",
+		{Amount: 1500, Currency: CurrencyJPY}: "1500",
+		{Amount: 1, Currency: CurrencyKWD}:    "0.001",
+	}
+
+	for money, expected := range tests {
+		if money.Decimal() != expected {
+			t.Errorf("Expected %s, but got %s", expected, money.Decimal())
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	money := Money{Amount: 1099, Currency: CurrencyUSD}
+
+	encoded, err := json.Marshal(money)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(encoded) != `{"Amount":"10.99","Currency":"USD"}` {
+		t.Errorf("Unexpected JSON: %s", encoded)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if decoded != money {
+		t.Errorf("Expected %s, but got %s", money, decoded)
+	}
+
+	// JSON numbers are parsed from their literal, not via float64
+	if err := json.Unmarshal([]byte(`{"Amount":0.29,"Currency":"usd"}`), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if decoded != (Money{Amount: 29, Currency: CurrencyUSD}) {
+		t.Errorf("Expected 0.29 USD, but got %s", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Amount":"1.999","Currency":"USD"}`), &decoded); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, but got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"Amount":"1.99","Currency":"XXX"}`), &decoded); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, but got %v", err)
+	}
+}
+
+func TestMoney_ConvertTo(t *testing.T) {
+	tests := []struct {
+		money    Money
+		target   Currency
+		rate     string
+		expected Money
+	}{
+		{Money{Amount: 1099, Currency: CurrencyUSD}, CurrencyEUR, "0.9234", Money{Amount: 1015, Currency: CurrencyEUR}},
+		// USD cents to whole yen
+		{Money{Amount: 1099, Currency: CurrencyUSD}, CurrencyJPY, "151.37", Money{Amount: 1664, Currency: CurrencyJPY}},
+		// whole yen to USD cents
+		{Money{Amount: 1000, Currency: CurrencyJPY}, CurrencyUSD, "0.0066", Money{Amount: 660, Currency: CurrencyUSD}},
+		// 0.5 cents are rounded away from zero
+		{Money{Amount: 1, Currency: CurrencyUSD}, CurrencyEUR, "0.5", Money{Amount: 1, Currency: CurrencyEUR}},
+	}
+
+	for _, test := range tests {
+		rate, _ := new(big.Rat).SetString(test.rate)
+		converted, err := test.money.ConvertTo(test.target, rate)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if converted != test.expected {
+			t.Errorf("Expected %s at rate %s to be %s, but got %s", test.money, test.rate, test.expected, converted)
+		}
+	}
+
+	if _, err := (Money{Amount: 1, Currency: CurrencyUSD}).ConvertTo("XXX", big.NewRat(1, 1)); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, but got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package entities
+
+// Permission grants access to a single kind of operation, formatted as "<resource>:<action>"
+type Permission string
+
+const (
+	// PermissionProductWrite allows creating, updating and deleting products
+	PermissionProductWrite Permission = "product:write"
+	// PermissionSellerWrite allows creating sellers and managing the sellers the user is a member of
+	PermissionSellerWrite Permission = "seller:write"
+	// PermissionSellerManageAny allows managing every seller and its products without being a member
+	PermissionSellerManageAny Permission = "seller:manage-any"
+	// PermissionUserRead allows viewing user accounts
+	PermissionUserRead Permission = "user:read"
+	// PermissionUserWrite allows creating, updating and deleting user accounts
+	PermissionUserWrite Permission = "user:write"
+	// PermissionRoleManage allows managing roles and their permissions
+	PermissionRoleManage Permission = "role:manage"
+	// PermissionAuditRead allows viewing the audit trail
+	PermissionAuditRead Permission = "audit:read"
+	// PermissionExchangeRateManage allows loading exchange rates
+	PermissionExchangeRateManage Permission = "exchange-rate:manage"
+	// PermissionWebhookManage allows managing webhook subscriptions and their deliveries
+	PermissionWebhookManage Permission = "webhook:manage"
+)
+
+// AllPermissions returns every permission known to the system
+func AllPermissions() []Permission {
+	return []Permission{
+		PermissionProductWrite,
+		PermissionSellerWrite,
+		PermissionSellerManageAny,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionRoleManage,
+		PermissionAuditRead,
+		PermissionExchangeRateManage,
+		PermissionWebhookManage,
+	}
+}
+
+// IsValid reports whether the permission is known to the system
+func (p Permission) IsValid() bool {
+	for _, permission := range AllPermissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,6 @@
 package entities
 
 import (
-	"errors"
 	"github.com/google/uuid"
 	"time"
 )
@@ -11,26 +10,35 @@ type Product struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
-	Price     float64
+	Price     Money
 	Seller    Seller
+	// Version counts the persisted changes, it is 0 until the product is created.
+	// Repositories only update the version that was read to detect concurrent changes.
+	Version int
+	// DeletedAt is set while the product is soft deleted, it can be restored until it is purged
+	DeletedAt *time.Time
+	DomainEvents
 }
 
 func (p *Product) validate() error {
+	errs := &ValidationError{}
 	if p.Name == "" {
-		return errors.New("name must not be empty")
+		errs.Add("name", ValidationCodeRequired, "must not be empty")
 	}
-	if p.Price <= 0 {
-		return errors.New("price must be greater than 0")
+	if err := p.Price.Validate(); err != nil {
+		errs.Add("price", ValidationCodeInvalid, err.Error())
+	} else if !p.Price.IsPositive() {
+		errs.Add("price", ValidationCodeTooSmall, "must be greater than 0")
 	}
 	if p.CreatedAt.After(p.UpdatedAt) {
-		return errors.New("created_at must be before updated_at")
+		errs.Add("created_at", ValidationCodeInvalid, "must be before updated_at")
 	}
 
-	return nil
+	return errs.Err()
 }
 
-func NewProduct(name string, price float64, seller ValidatedSeller) *Product {
-	return &Product{
+func NewProduct(name string, price Money, seller ValidatedSeller) *Product {
+	product := &Product{
 		Id:        uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -38,18 +46,48 @@ func NewProduct(name string, price float64, seller ValidatedSeller) *Product {
 		Price:     price,
 		Seller:    seller.Seller,
 	}
+	product.raise(ProductCreated{
+		ProductId: product.Id,
+		SellerId:  seller.Id,
+		Name:      name,
+		Price:     price,
+		At:        product.CreatedAt,
+	})
+
+	return product
+}
+
+// IsDeleted reports whether the product is soft deleted
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt != nil
 }
 
 func (p *Product) UpdateName(name string) error {
+	oldName := p.Name
 	p.Name = name
 	p.UpdatedAt = time.Now()
 
-	return p.validate()
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if name != oldName {
+		p.raise(ProductRenamed{ProductId: p.Id, OldName: oldName, NewName: name, At: p.UpdatedAt})
+	}
+
+	return nil
 }
 
-func (p *Product) UpdatePrice(price float64) error {
+func (p *Product) UpdatePrice(price Money) error {
+	oldPrice := p.Price
 	p.Price = price
 	p.UpdatedAt = time.Now()
 
-	return p.validate()
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if price != oldPrice {
+		p.raise(ProductPriceChanged{ProductId: p.Id, OldPrice: oldPrice, NewPrice: price, At: p.UpdatedAt})
+	}
+
+	return nil
 }
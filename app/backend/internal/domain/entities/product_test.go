@@ -12,14 +12,14 @@ func TestNewProduct(t *testing.T) {
 		t.Fatalf("Expected no error, but got %s", err.Error())
 	}
 
-	product := NewProduct("Example Product", 10.0, *validatedSeller)
+	product := NewProduct("Example Product", Money{Amount: 1000, Currency: CurrencyUSD}, *validatedSeller)
 
 	if product.Name != "Example Product" {
 		t.Errorf("Expected product name to be 'Example Product', but got %s", product.Name)
 	}
 
-	if product.Price != 10.0 {
-		t.Errorf("Expected product price to be 10.0, but got %f", product.Price)
+	if product.Price != (Money{Amount: 1000, Currency: CurrencyUSD}) {
+		t.Errorf("Expected product price to be 10.00 USD, but got %s", product.Price)
 	}
 
 	if product.Id == (uuid.UUID{}) {
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// RefreshToken represents a long-lived token which can be exchanged for a new access token.
+// Tokens are rotated on every use; all tokens descending from the same login share a family ID
+// so that the whole chain can be revoked when a rotated token is presented again.
+type RefreshToken struct {
+	ID           string
+	UserID       string
+	FamilyID     string
+	TokenHash    string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+	RevokedAt    *time.Time
+	ReplacedByID string
+}
+
+// NewRefreshToken creates a new refresh token. Only the hash of the token value is stored.
+func NewRefreshToken(id, userID, familyID, tokenHash string, expiresAt time.Time) (*RefreshToken, error) {
+	if id == "" {
+		return nil, errors.New("refresh token ID cannot be empty")
+	}
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	if familyID == "" {
+		return nil, errors.New("family ID cannot be empty")
+	}
+	if tokenHash == "" {
+		return nil, errors.New("token hash cannot be empty")
+	}
+
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	return &RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}, nil
+}
+
+// IsExpired reports whether the token has expired at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token has been revoked or rotated
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// Revoke marks the token as revoked
+func (t *RefreshToken) Revoke(now time.Time) {
+	if t.RevokedAt == nil {
+		t.RevokedAt = &now
+	}
+}
+
+// ReplaceWith revokes the token because it has been rotated into the token with the given ID
+func (t *RefreshToken) ReplaceWith(id string, now time.Time) {
+	t.Revoke(now)
+	t.ReplacedByID = id
+}
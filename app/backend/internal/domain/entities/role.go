@@ -0,0 +1,130 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// RoleSellerOwner is the role for users running a seller
+	RoleSellerOwner UserRole = "seller-owner"
+	// RoleCatalogManager is the role for users maintaining the product catalog
+	RoleCatalogManager UserRole = "catalog-manager"
+	// RoleAuditor is the role for users reviewing accounts and the audit trail
+	RoleAuditor UserRole = "auditor"
+	// RoleSupport is the role for users helping customers with their accounts
+	RoleSupport UserRole = "support"
+)
+
+// Role is a named set of permissions which can be assigned to users
+type Role struct {
+	Name        UserRole
+	Description string
+	Permissions []Permission
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewRole creates a new role with the given name, description and permissions
+func NewRole(name UserRole, description string, permissions []Permission) (*Role, error) {
+	if name == "" {
+		return nil, NewValidationError("name", ValidationCodeRequired, "cannot be empty")
+	}
+
+	now := time.Now()
+	role := &Role{
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := role.SetPermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DefaultRoles returns the built-in roles and their default permissions
+func DefaultRoles() []*Role {
+	definitions := []struct {
+		name        UserRole
+		description string
+		permissions []Permission
+	}{
+		{RoleAdmin, "Full access to the marketplace", AllPermissions()},
+		{RoleUser, "Regular marketplace customer", nil},
+		{RoleSellerOwner, "Runs a seller and its products", []Permission{PermissionProductWrite, PermissionSellerWrite}},
+		{RoleCatalogManager, "Maintains the product catalog", []Permission{PermissionProductWrite, PermissionSellerManageAny}},
+		{RoleAuditor, "Reviews accounts and the audit trail", []Permission{PermissionUserRead, PermissionAuditRead}},
+		{RoleSupport, "Helps customers with their accounts", []Permission{PermissionUserRead, PermissionUserWrite}},
+	}
+
+	roles := make([]*Role, 0, len(definitions))
+	for _, definition := range definitions {
+		role, _ := NewRole(definition.name, definition.description, definition.permissions)
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// SetPermissions replaces the permissions of the role
+func (r *Role) SetPermissions(permissions []Permission) error {
+	unique := make([]Permission, 0, len(permissions))
+	seen := make(map[Permission]bool, len(permissions))
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return NewValidationError("permissions", ValidationCodeNotAllowed, fmt.Sprintf("contain the unknown permission %q", permission))
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		unique = append(unique, permission)
+	}
+
+	// The admin role must never lose access, otherwise nobody can manage roles anymore
+	if r.Name == RoleAdmin && len(unique) != len(AllPermissions()) {
+		return NewValidationError("permissions", ValidationCodeInvalid, "must all be granted to the admin role")
+	}
+
+	r.Permissions = unique
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateDescription updates the description of the role
+func (r *Role) UpdateDescription(description string) {
+	r.Description = description
+	r.UpdatedAt = time.Now()
+}
+
+// HasPermission reports whether the role grants the permission
+func (r *Role) HasPermission(permission Permission) bool {
+	for _, granted := range r.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes reports whether the role grants every permission of the other role
+func (r *Role) Includes(other *Role) bool {
+	for _, permission := range other.Permissions {
+		if !r.HasPermission(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBuiltIn reports whether the role is one of the default roles, which cannot be deleted
+func (r *Role) IsBuiltIn() bool {
+	for _, role := range DefaultRoles() {
+		if role.Name == r.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"testing"
+)
+
+func TestNewRole(t *testing.T) {
+	role, err := NewRole("editor", "Edits products", []Permission{PermissionProductWrite, PermissionProductWrite})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if len(role.Permissions) != 1 {
+		t.Errorf("Expected duplicate permissions to be removed, but got %v", role.Permissions)
+	}
+
+	if !role.HasPermission(PermissionProductWrite) {
+		t.Error("Expected role to have permission product:write")
+	}
+
+	if role.HasPermission(PermissionUserWrite) {
+		t.Error("Expected role not to have permission user:write")
+	}
+
+	if _, err := NewRole("editor", "", []Permission{"product:fly"}); err == nil {
+		t.Error("Expected an error for an unknown permission")
+	}
+}
+
+func TestRole_AdminKeepsAllPermissions(t *testing.T) {
+	for _, role := range DefaultRoles() {
+		if role.Name != RoleAdmin {
+			continue
+		}
+
+		if err := role.SetPermissions([]Permission{PermissionUserRead}); err == nil {
+			t.Error("Expected an error when removing permissions from the admin role")
+		}
+
+		for _, permission := range AllPermissions() {
+			if !role.HasPermission(permission) {
+				t.Errorf("Expected admin role to have permission %s", permission)
+			}
+		}
+		return
+	}
+
+	t.Fatal("Expected the admin role to be a default role")
+}
+
+func TestRole_Includes(t *testing.T) {
+	roles := make(map[UserRole]*Role)
+	for _, role := range DefaultRoles() {
+		roles[role.Name] = role
+	}
+
+	if !roles[RoleAdmin].Includes(roles[RoleSupport]) {
+		t.Error("Expected the admin role to include the support role")
+	}
+
+	if roles[RoleSupport].Includes(roles[RoleAdmin]) {
+		t.Error("Expected the support role not to include the admin role")
+	}
+
+	if !roles[RoleSupport].Includes(roles[RoleUser]) {
+		t.Error("Expected every role to include the user role without permissions")
+	}
+}
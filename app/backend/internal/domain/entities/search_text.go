@@ -0,0 +1,221 @@
+package entities
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SearchToken is a normalized term of a text and the runes of the original text it was made of
+type SearchToken struct {
+	Term string
+	// Start and End are the rune offsets of the term in the original text, End is exclusive
+	Start int
+	End   int
+	// Word is true for terms of space separated scripts, false for CJK bigrams and unigrams
+	Word bool
+}
+
+// SearchTermGroup is one term of a search query together with the terms it also matches, e.g. to tolerate typos
+type SearchTermGroup struct {
+	Alternatives []string
+	// Prefix matches indexed terms which start with one of the alternatives
+	Prefix bool
+}
+
+// minPrefixLength is the length from which query words also match longer words, e.g. "sho" matches "shoes"
+const minPrefixLength = 3
+
+// TokenizeSearchText splits text into normalized search terms.
+// Text is normalized with NFKC, lowercased and katakana are folded to hiragana.
+// Words of space separated scripts become one term each. Chinese, Japanese and Korean text
+// has no spaces, so runs of it become overlapping bigrams plus the last character as unigram,
+// e.g. "革靴下" becomes "革靴", "靴下" and "下".
+func TokenizeSearchText(text string) []SearchToken {
+	var tokens []SearchToken
+	runes, origins := normalizeSearchText(text)
+	// end returns the exclusive end offset in the original text of the normalized rune
+	end := func(i int) int { return origins[i].end }
+
+	for i := 0; i < len(runes); {
+		switch {
+		case isCJK(runes[i]):
+			start := i
+			for i < len(runes) && isCJK(runes[i]) {
+				i++
+			}
+			for j := start; j+1 < i; j++ {
+				tokens = append(tokens, SearchToken{Term: string(runes[j : j+2]), Start: origins[j].start, End: end(j + 1)})
+			}
+			tokens = append(tokens, SearchToken{Term: string(runes[i-1]), Start: origins[i-1].start, End: end(i - 1)})
+		case unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]):
+			start := i
+			for i < len(runes) && !isCJK(runes[i]) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || unicode.Is(unicode.Mn, runes[i])) {
+				i++
+			}
+			tokens = append(tokens, SearchToken{Term: string(runes[start:i]), Start: origins[start].start, End: end(i - 1), Word: true})
+		default:
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// ParseSearchQuery turns the text of a search query into term groups which all have to match.
+// Words of at least three letters and single CJK characters also match as prefix.
+// Duplicate terms are removed.
+func ParseSearchQuery(text string) []SearchTermGroup {
+	tokens := TokenizeSearchText(text)
+
+	var groups []SearchTermGroup
+	seen := map[string]bool{}
+	for i, token := range tokens {
+		isUnigram := !token.Word && len([]rune(token.Term)) == 1
+		// The unigram of a CJK run is only needed if the run is a single character without bigrams
+		if isUnigram && i > 0 && !tokens[i-1].Word && tokens[i-1].End == token.End {
+			continue
+		}
+		if seen[token.Term] {
+			continue
+		}
+		seen[token.Term] = true
+
+		groups = append(groups, SearchTermGroup{
+			Alternatives: []string{token.Term},
+			Prefix:       isUnigram || (token.Word && len([]rune(token.Term)) >= minPrefixLength),
+		})
+	}
+
+	return groups
+}
+
+// MaxTypoDistance returns how many edits a query word may be away from an indexed word.
+// Short words and CJK terms must match exactly.
+func MaxTypoDistance(term string) int {
+	length := len([]rune(term))
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// EditDistance returns the Levenshtein distance of both terms in runes
+func EditDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+// HighlightSearchMatches returns the HTML escaped text with the parts matching the query wrapped in <mark> tags
+func HighlightSearchMatches(text string, groups []SearchTermGroup) string {
+	original := []rune(text)
+	marked := make([]bool, len(original))
+	tokens := TokenizeSearchText(text)
+	for index, token := range tokens {
+		matched, exact := matchesAnyGroup(token.Term, groups)
+		if !matched {
+			continue
+		}
+		end := token.End
+		// A CJK character matching a bigram as prefix only marks its own character, which ends where the next bigram starts
+		if !exact && !token.Word && index+1 < len(tokens) && !tokens[index+1].Word && tokens[index+1].Start < end {
+			end = tokens[index+1].Start
+		}
+		for i := token.Start; i < end; i++ {
+			marked[i] = true
+		}
+	}
+
+	var highlighted strings.Builder
+	for i := 0; i < len(original); {
+		j := i
+		for j < len(original) && marked[j] == marked[i] {
+			j++
+		}
+		part := html.EscapeString(string(original[i:j]))
+		if marked[i] {
+			part = "<mark>" + part + "</mark>"
+		}
+		highlighted.WriteString(part)
+		i = j
+	}
+
+	return highlighted.String()
+}
+
+// matchesAnyGroup reports whether the term matches one of the groups and whether one of them matches it exactly
+func matchesAnyGroup(term string, groups []SearchTermGroup) (matched, exact bool) {
+	for _, group := range groups {
+		for _, alternative := range group.Alternatives {
+			if term == alternative {
+				return true, true
+			}
+			if group.Prefix && strings.HasPrefix(term, alternative) {
+				matched = true
+			}
+		}
+	}
+	return matched, false
+}
+
+// runeSpan is the range of original runes a normalized rune was made of
+type runeSpan struct {
+	start, end int
+}
+
+// normalizeSearchText normalizes the text rune by rune so that every normalized rune can be traced back
+// to the original runes it came from
+func normalizeSearchText(text string) ([]rune, []runeSpan) {
+	var runes []rune
+	var origins []runeSpan
+	for offset, r := range []rune(text) {
+		for _, normalized := range strings.ToLower(norm.NFKC.String(string(r))) {
+			// Half-width kana carry their voicing mark as separate rune, e.g. "ｶﾞ" is "ガ"
+			if last := len(runes) - 1; last >= 0 && unicode.Is(unicode.Mn, normalized) {
+				if composed := []rune(norm.NFC.String(string(runes[last]) + string(normalized))); len(composed) == 1 {
+					runes[last] = foldKatakana(composed[0])
+					origins[last].end = offset + 1
+					continue
+				}
+			}
+			runes = append(runes, foldKatakana(normalized))
+			origins = append(origins, runeSpan{start: offset, end: offset + 1})
+		}
+	}
+	return runes, origins
+}
+
+// foldKatakana maps katakana to the hiragana with the same sound so that both spellings match
+func foldKatakana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー' || r == '々'
+}
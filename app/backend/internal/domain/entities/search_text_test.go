@@ -0,0 +1,118 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func searchTerms(tokens []SearchToken) []string {
+	var terms []string
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+	}
+	return terms
+}
+
+func TestTokenizeSearchText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+	}{
+		{"words", "Red Running-Shoes, size 42", []string{"red", "running", "shoes", "size", "42"}},
+		{"accents", "Café Crème", []string{"café", "crème"}},
+		{"full-width", "ＡＢＣ　１２３", []string{"abc", "123"}},
+		{"japanese", "革靴下", []string{"革靴", "靴下", "下"}},
+		{"katakana", "スニーカー", []string{"すに", "にー", "ーか", "かー", "ー"}},
+		{"half-width katakana", "ｶﾞﾑ", []string{"がむ", "む"}},
+		{"mixed", "Nike スニーカー 黒", []string{"nike", "すに", "にー", "ーか", "かー", "ー", "黒"}},
+	}
+
+	for _, test := range tests {
+		if terms := searchTerms(TokenizeSearchText(test.text)); !reflect.DeepEqual(terms, test.terms) {
+			t.Errorf("%s: expected terms %q, but got %q", test.name, test.terms, terms)
+		}
+	}
+}
+
+func TestTokenizeSearchText_Offsets(t *testing.T) {
+	tokens := TokenizeSearchText("ｶﾞﾑ Gum")
+
+	expected := []SearchToken{
+		{Term: "がむ", Start: 0, End: 3},
+		{Term: "む", Start: 2, End: 3},
+		{Term: "gum", Start: 4, End: 7, Word: true},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected tokens %+v, but got %+v", expected, tokens)
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	groups := ParseSearchQuery("red Shoes RED 靴 革靴")
+
+	expected := []SearchTermGroup{
+		{Alternatives: []string{"red"}, Prefix: true},
+		{Alternatives: []string{"shoes"}, Prefix: true},
+		{Alternatives: []string{"靴"}, Prefix: true},
+		{Alternatives: []string{"革靴"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected groups %+v, but got %+v", expected, groups)
+	}
+
+	if groups := ParseSearchQuery(" -- !! "); len(groups) != 0 {
+		t.Errorf("Expected no groups for text without words, but got %+v", groups)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"shoes", "shoes", 0},
+		{"shoes", "shoe", 1},
+		{"shoes", "shose", 2},
+		{"sneaker", "snaeker", 2},
+		{"", "abc", 3},
+		{"すにーかー", "すにーか", 1},
+	}
+
+	for _, test := range tests {
+		if distance := EditDistance(test.a, test.b); distance != test.distance {
+			t.Errorf("Expected distance %d between %q and %q, but got %d", test.distance, test.a, test.b, distance)
+		}
+	}
+}
+
+func TestMaxTypoDistance(t *testing.T) {
+	if MaxTypoDistance("red") != 0 || MaxTypoDistance("shoe") != 1 || MaxTypoDistance("sneakers") != 2 {
+		t.Error("Expected no typos for short words, one from four and two from eight letters")
+	}
+}
+
+func TestHighlightSearchMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		query       string
+		highlighted string
+	}{
+		{"word", "Red Shoes", "shoes", "Red <mark>Shoes</mark>"},
+		{"prefix", "Red Shoes", "sho", "Red <mark>Shoes</mark>"},
+		{"adjacent words", "Red Shoes", "red shoes", "<mark>Red</mark> <mark>Shoes</mark>"},
+		{"escaped", "<Shoes> & Socks", "socks", "&lt;Shoes&gt; &amp; <mark>Socks</mark>"},
+		{"japanese", "黒い革靴です", "革靴", "黒い<mark>革靴</mark>です"},
+		{"single japanese character", "黒いスニーカー", "黒", "<mark>黒</mark>いスニーカー"},
+		{"katakana query", "すにーかー 黒", "スニーカー", "<mark>すにーかー</mark> 黒"},
+		{"half-width text", "ｶﾞﾑ", "ガム", "<mark>ｶﾞﾑ</mark>"},
+	}
+
+	for _, test := range tests {
+		highlighted := HighlightSearchMatches(test.text, ParseSearchQuery(test.query))
+		if highlighted != test.highlighted {
+			t.Errorf("%s: expected %q, but got %q", test.name, test.highlighted, highlighted)
+		}
+	}
+}
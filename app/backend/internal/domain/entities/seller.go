@@ -1,7 +1,6 @@
 package entities
 
 import (
-	"errors"
 	"github.com/google/uuid"
 	"time"
 )
@@ -11,31 +10,54 @@ type Seller struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
+	// Version counts the persisted changes, it is 0 until the seller is created.
+	// Repositories only update the version that was read to detect concurrent changes.
+	Version int
+	// DeletedAt is set while the seller is soft deleted, it can be restored until it is purged
+	DeletedAt *time.Time
+	DomainEvents
 }
 
 func NewSeller(name string) *Seller {
-	return &Seller{
+	seller := &Seller{
 		Id:        uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Name:      name,
 	}
+	seller.raise(SellerCreated{SellerId: seller.Id, Name: name, At: seller.CreatedAt})
+
+	return seller
 }
 
 func (s *Seller) validate() error {
+	errs := &ValidationError{}
 	if s.Name == "" {
-		return errors.New("name must not be empty")
+		errs.Add("name", ValidationCodeRequired, "must not be empty")
 	}
 	if s.CreatedAt.After(s.UpdatedAt) {
-		return errors.New("created_at must be before updated_at")
+		errs.Add("created_at", ValidationCodeInvalid, "must be before updated_at")
 	}
 
-	return nil
+	return errs.Err()
+}
+
+// IsDeleted reports whether the seller is soft deleted
+func (s *Seller) IsDeleted() bool {
+	return s.DeletedAt != nil
 }
 
 func (s *Seller) UpdateName(name string) error {
+	oldName := s.Name
 	s.Name = name
 	s.UpdatedAt = time.Now()
 
-	return s.validate()
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if name != oldName {
+		s.raise(SellerRenamed{SellerId: s.Id, OldName: oldName, NewName: name, At: s.UpdatedAt})
+	}
+
+	return nil
 }
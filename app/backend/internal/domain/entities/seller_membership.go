@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// SellerMemberRole represents the role of a user within a seller
+type SellerMemberRole string
+
+const (
+	// SellerMemberRoleOwner may manage the seller, its members and its products and delete the seller
+	SellerMemberRoleOwner SellerMemberRole = "owner"
+	// SellerMemberRoleMember may manage the seller and its products
+	SellerMemberRoleMember SellerMemberRole = "member"
+)
+
+// SellerMembership links a user account to a seller it owns or belongs to
+type SellerMembership struct {
+	SellerId  uuid.UUID
+	UserId    string
+	Role      SellerMemberRole
+	CreatedAt time.Time
+}
+
+// NewSellerMembership creates a new membership of the user in the seller
+func NewSellerMembership(sellerId uuid.UUID, userId string, role SellerMemberRole) (*SellerMembership, error) {
+	if sellerId == uuid.Nil {
+		return nil, errors.New("seller ID cannot be empty")
+	}
+	if userId == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	if role != SellerMemberRoleOwner && role != SellerMemberRoleMember {
+		return nil, NewValidationError("role", ValidationCodeNotAllowed, "is invalid")
+	}
+
+	return &SellerMembership{
+		SellerId:  sellerId,
+		UserId:    userId,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// IsOwner reports whether the member owns the seller
+func (m *SellerMembership) IsOwner() bool {
+	return m.Role == SellerMemberRoleOwner
+}
@@ -37,6 +37,20 @@ type User struct {
 	Status       UserStatus
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	// StatusChangedAt is the last time the status changed. Tokens issued before
+	// this moment are no longer accepted. A zero value means it never changed.
+	StatusChangedAt time.Time
+	// StatusReason records why the status was last changed
+	StatusReason string
+	// FailedLoginAttempts counts consecutive failed logins since the last successful one
+	FailedLoginAttempts int
+	// LockedUntil is the time a locked account is unlocked automatically.
+	// A zero value means the account stays locked until an admin unlocks it.
+	LockedUntil time.Time
+	// Version counts the persisted changes, it is 0 until the user is saved the first time.
+	// Repositories only update the version that was read to detect concurrent changes.
+	Version int
+	DomainEvents
 }
 
 // NewUser creates a new user with the given ID, username, email, and password hash
@@ -44,18 +58,22 @@ func NewUser(id, username, email, passwordHash string) (*User, error) {
 	if id == "" {
 		return nil, errors.New("user ID cannot be empty")
 	}
+	errs := &ValidationError{}
 	if username == "" {
-		return nil, errors.New("username cannot be empty")
+		errs.Add("username", ValidationCodeRequired, "cannot be empty")
 	}
 	if email == "" {
-		return nil, errors.New("email cannot be empty")
+		errs.Add("email", ValidationCodeRequired, "cannot be empty")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
 	}
 	if passwordHash == "" {
 		return nil, errors.New("password hash cannot be empty")
 	}
 
 	now := time.Now()
-	return &User{
+	user := &User{
 		ID:           id,
 		Username:     username,
 		Email:        email,
@@ -64,13 +82,16 @@ func NewUser(id, username, email, passwordHash string) (*User, error) {
 		Status:       StatusActive,
 		CreatedAt:    now,
 		UpdatedAt:    now,
-	}, nil
+	}
+	user.raise(UserRegistered{UserId: id, Username: username, At: now})
+
+	return user, nil
 }
 
 // UpdateUsername updates the user's username
 func (u *User) UpdateUsername(username string) error {
 	if username == "" {
-		return errors.New("username cannot be empty")
+		return NewValidationError("username", ValidationCodeRequired, "cannot be empty")
 	}
 	u.Username = username
 	u.UpdatedAt = time.Now()
@@ -80,7 +101,7 @@ func (u *User) UpdateUsername(username string) error {
 // UpdateEmail updates the user's email
 func (u *User) UpdateEmail(email string) error {
 	if email == "" {
-		return errors.New("email cannot be empty")
+		return NewValidationError("email", ValidationCodeRequired, "cannot be empty")
 	}
 	u.Email = email
 	u.UpdatedAt = time.Now()
@@ -106,7 +127,94 @@ func (u *User) UpdateRole(role UserRole) error {
 
 // UpdateStatus updates the user's status
 func (u *User) UpdateStatus(status UserStatus) error {
+	return u.changeStatus(status, u.StatusReason, u.LockedUntil)
+}
+
+// ChangeStatus updates the user's status and records the reason for the change
+func (u *User) ChangeStatus(status UserStatus, reason string) error {
+	return u.changeStatus(status, reason, u.LockedUntil)
+}
+
+// Lock locks the account until the given time. A zero time locks it until an admin unlocks it.
+func (u *User) Lock(reason string, until time.Time) error {
+	return u.changeStatus(StatusLocked, reason, until)
+}
+
+// changeStatus sets the status and raises UserLocked for every lock and UserStatusChanged for other changes
+func (u *User) changeStatus(status UserStatus, reason string, lockedUntil time.Time) error {
+	switch status {
+	case StatusActive, StatusInactive, StatusLocked:
+	default:
+		return NewValidationError("status", ValidationCodeNotAllowed, "is invalid")
+	}
+
+	now := time.Now()
+	oldStatus := u.Status
+	if oldStatus != status {
+		u.StatusChangedAt = now
+	}
+	if status == StatusLocked {
+		u.LockedUntil = lockedUntil
+	} else {
+		u.LockedUntil = time.Time{}
+		u.FailedLoginAttempts = 0
+	}
 	u.Status = status
-	u.UpdatedAt = time.Now()
+	u.StatusReason = reason
+	u.UpdatedAt = now
+
+	switch {
+	case status == StatusLocked:
+		u.raise(UserLocked{UserId: u.ID, Reason: reason, LockedUntil: u.LockedUntil, At: now})
+	case status != oldStatus:
+		u.raise(UserStatusChanged{UserId: u.ID, OldStatus: oldStatus, NewStatus: status, Reason: reason, At: now})
+	}
 	return nil
 }
+
+// IsLockExpired reports whether the account is locked and its lock has run out
+func (u *User) IsLockExpired(now time.Time) bool {
+	return u.Status == StatusLocked && !u.LockedUntil.IsZero() && !now.Before(u.LockedUntil)
+}
+
+// RecordFailedLogin counts a failed login and locks the account for lockDuration
+// once threshold consecutive failures are reached. It reports whether the account was locked.
+func (u *User) RecordFailedLogin(now time.Time, threshold int, lockDuration time.Duration) (bool, error) {
+	u.FailedLoginAttempts++
+	u.UpdatedAt = time.Now()
+
+	return u.LockAfterFailedLogins(now, threshold, lockDuration)
+}
+
+// LockAfterFailedLogins locks an active account for lockDuration once threshold consecutive failures
+// are counted, e.g. by UserRepository.IncrementFailedLogins. It reports whether the account was locked.
+func (u *User) LockAfterFailedLogins(now time.Time, threshold int, lockDuration time.Duration) (bool, error) {
+	if threshold <= 0 || u.FailedLoginAttempts < threshold || u.Status != StatusActive {
+		return false, nil
+	}
+
+	if err := u.Lock("too many failed login attempts", now.Add(lockDuration)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResetFailedLogins clears the failed login counter after a successful login
+func (u *User) ResetFailedLogins() {
+	u.FailedLoginAttempts = 0
+	u.UpdatedAt = time.Now()
+}
+
+// IsActive reports whether the user is allowed to sign in
+func (u *User) IsActive() bool {
+	return u.Status == StatusActive
+}
+
+// AcceptsTokenIssuedAt reports whether a token issued at the given time is still
+// valid for this user, i.e. the user is active and its status did not change since.
+func (u *User) AcceptsTokenIssuedAt(issuedAt time.Time) bool {
+	if !u.IsActive() {
+		return false
+	}
+	return !issuedAt.Before(u.StatusChangedAt.Truncate(time.Second))
+}
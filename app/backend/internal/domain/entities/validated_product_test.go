@@ -6,22 +6,28 @@ import (
 
 func TestProductValidation(t *testing.T) {
 	// Test valid product
-	validProduct := &Product{Name: "Valid Product", Price: 10.0}
+	validProduct := &Product{Name: "Valid Product", Price: Money{Amount: 1000, Currency: CurrencyUSD}}
 	if err := validProduct.validate(); err != nil {
 		t.Errorf("Expected product to be valid, but got error: %s", err)
 	}
 
 	// Test product with empty name
-	invalidProduct1 := &Product{Name: "", Price: 10.0}
+	invalidProduct1 := &Product{Name: "", Price: Money{Amount: 1000, Currency: CurrencyUSD}}
 	if err := invalidProduct1.validate(); err == nil {
 		t.Error("Expected product with empty name to be invalid, but got no error")
 	}
 
 	// Test product with non-positive price
-	invalidProduct2 := &Product{Name: "Product", Price: -5.0}
+	invalidProduct2 := &Product{Name: "Product", Price: Money{Amount: -500, Currency: CurrencyUSD}}
 	if err := invalidProduct2.validate(); err == nil {
 		t.Error("Expected product with negative price to be invalid, but got no error")
 	}
+
+	// Test product without currency
+	invalidProduct3 := &Product{Name: "Product", Price: Money{Amount: 1000}}
+	if err := invalidProduct3.validate(); err == nil {
+		t.Error("Expected product without currency to be invalid, but got no error")
+	}
 }
 
 func TestNewValidatedProduct(t *testing.T) {
@@ -31,7 +37,7 @@ func TestNewValidatedProduct(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Expected no error, but got %s", err.Error())
 	}
-	product := NewProduct("Example Product", 10.0, *validatedSeller)
+	product := NewProduct("Example Product", Money{Amount: 1000, Currency: CurrencyUSD}, *validatedSeller)
 	validatedProduct, err := NewValidatedProduct(product)
 	if err != nil {
 		t.Errorf("Expected product to be valid, but got error: %s", err)
@@ -41,7 +47,7 @@ func TestNewValidatedProduct(t *testing.T) {
 	}
 
 	// Test invalid product
-	invalidProduct := NewProduct("", -10.0, *validatedSeller)
+	invalidProduct := NewProduct("", Money{Amount: -1000, Currency: CurrencyUSD}, *validatedSeller)
 	validatedProduct, err = NewValidatedProduct(invalidProduct)
 	if err == nil {
 		t.Error("Expected error when validating invalid product, but got none")
@@ -0,0 +1,214 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// WebhookEventTypes returns the domain events partners can subscribe to
+func WebhookEventTypes() []string {
+	return []string{
+		EventProductCreated,
+		EventProductRenamed,
+		EventProductPriceChanged,
+		EventProductDeleted,
+		EventProductRestored,
+		EventSellerCreated,
+		EventSellerRenamed,
+		EventSellerDeleted,
+		EventSellerRestored,
+	}
+}
+
+// WebhookSubscription is an endpoint of a partner which is notified about the subscribed events
+type WebhookSubscription struct {
+	Id         uuid.UUID
+	URL        string
+	EventTypes []string
+	// Secret signs the deliveries, see SignWebhookPayload
+	Secret    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewWebhookSubscription creates a subscription of the http(s) URL to the given event types
+func NewWebhookSubscription(endpoint string, eventTypes []string, secret string) (*WebhookSubscription, error) {
+	if secret == "" {
+		return nil, errors.New("webhook secret cannot be empty")
+	}
+
+	errs := &ValidationError{}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		errs.Add("url", ValidationCodeInvalid, "must be an absolute http or https URL")
+	}
+
+	subscription := &WebhookSubscription{
+		Id:        uuid.New(),
+		URL:       endpoint,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	var eventTypeErrs *ValidationError
+	if err := subscription.SetEventTypes(eventTypes); errors.As(err, &eventTypeErrs) {
+		errs.Fields = append(errs.Fields, eventTypeErrs.Fields...)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// SetEventTypes replaces the subscribed event types, duplicates are removed
+func (s *WebhookSubscription) SetEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return NewValidationError("event_types", ValidationCodeRequired, "must contain at least one event type")
+	}
+
+	errs := &ValidationError{}
+	unique := make([]string, 0, len(eventTypes))
+	seen := map[string]bool{}
+	for _, eventType := range eventTypes {
+		if !IsWebhookEventType(eventType) {
+			errs.Add("event_types", ValidationCodeNotAllowed, fmt.Sprintf("contain the unknown event type %q", eventType))
+			continue
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	s.EventTypes = unique
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Subscribes reports whether the subscription wants to be notified about the event type
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, subscribed := range s.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWebhookEventType reports whether partners can subscribe to the event type
+func IsWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes() {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their first attempt or a retry
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded deliveries were answered with a 2xx status
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries were given up after too many attempts
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one subscription, together with the outcome of the last attempt
+type WebhookDelivery struct {
+	Id             uuid.UUID
+	SubscriptionId uuid.UUID
+	// EventId identifies the event, it stays the same for retries and replays so receivers can ignore duplicates
+	EventId   uuid.UUID
+	EventType string
+	// Payload is the JSON body sent to the endpoint
+	Payload       []byte
+	Status        WebhookDeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	// ResponseStatus is the HTTP status of the last attempt, 0 if the endpoint could not be reached
+	ResponseStatus int
+	LastError      string
+	// ReplayOf is the delivery this delivery replays, if any
+	ReplayOf    *uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeliveredAt time.Time
+}
+
+// NewWebhookDelivery creates a pending delivery of the event payload to the subscription
+func NewWebhookDelivery(subscriptionId, eventId uuid.UUID, eventType string, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		Id:             uuid.New(),
+		SubscriptionId: subscriptionId,
+		EventId:        eventId,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// RecordSuccess records an attempt the endpoint accepted
+func (d *WebhookDelivery) RecordSuccess(responseStatus int, at time.Time) {
+	d.Attempts++
+	d.Status = WebhookDeliverySucceeded
+	d.ResponseStatus = responseStatus
+	d.LastError = ""
+	d.DeliveredAt = at
+	d.UpdatedAt = at
+}
+
+// RecordFailure records a failed attempt and schedules a retry. A zero retryAt gives up on the delivery.
+func (d *WebhookDelivery) RecordFailure(responseStatus int, reason string, retryAt time.Time) {
+	d.Attempts++
+	d.ResponseStatus = responseStatus
+	d.LastError = reason
+	d.UpdatedAt = time.Now()
+	if retryAt.IsZero() {
+		d.Status = WebhookDeliveryFailed
+		return
+	}
+	d.NextAttemptAt = retryAt
+}
+
+// Replay creates a new pending delivery of the same event to the same subscription
+func (d *WebhookDelivery) Replay() *WebhookDelivery {
+	replay := NewWebhookDelivery(d.SubscriptionId, d.EventId, d.EventType, d.Payload)
+	replayOf := d.Id
+	replay.ReplayOf = &replayOf
+	return replay
+}
+
+// SignWebhookPayload returns the signature of a delivery, the hex encoded HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the subscription secret and prefixed with "sha256=".
+// Receivers recompute it to verify that a delivery is authentic and reject old timestamps to prevent replay attacks.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether the signature matches the payload, in constant time
+func VerifyWebhookSignature(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, payload)), []byte(signature))
+}
@@ -0,0 +1,98 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewWebhookSubscription_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		secret     string
+		wantErr    bool
+	}{
+		{"valid", "https://partner.example.com/hooks", []string{EventProductCreated}, "secret", false},
+		{"relative URL", "/hooks", []string{EventProductCreated}, "secret", true},
+		{"unsupported scheme", "ftp://partner.example.com/hooks", []string{EventProductCreated}, "secret", true},
+		{"no event types", "https://partner.example.com/hooks", nil, "secret", true},
+		{"unknown event type", "https://partner.example.com/hooks", []string{"product.exploded"}, "secret", true},
+		{"user events are internal", "https://partner.example.com/hooks", []string{EventUserRegistered}, "secret", true},
+		{"empty secret", "https://partner.example.com/hooks", []string{EventProductCreated}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookSubscription(tt.url, tt.eventTypes, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWebhookSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookSubscription_Subscribes(t *testing.T) {
+	subscription, err := NewWebhookSubscription("http://localhost:8080", []string{EventSellerCreated, EventSellerDeleted, EventSellerCreated}, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if expected := []string{EventSellerCreated, EventSellerDeleted}; !reflect.DeepEqual(subscription.EventTypes, expected) {
+		t.Errorf("Expected duplicate event types to be removed, but got %v", subscription.EventTypes)
+	}
+	if !subscription.Subscribes(EventSellerDeleted) || subscription.Subscribes(EventProductCreated) {
+		t.Errorf("Unexpected subscriptions %v", subscription.EventTypes)
+	}
+}
+
+func TestWebhookDelivery_Lifecycle(t *testing.T) {
+	subscription, _ := NewWebhookSubscription("http://localhost:8080", []string{EventSellerCreated}, "secret")
+	original := NewWebhookDelivery(subscription.Id, NewSeller("Seller").Id, EventSellerCreated, []byte(`{}`))
+
+	retryAt := time.Now().Add(time.Minute)
+	original.RecordFailure(500, "endpoint responded with status 500", retryAt)
+	if original.Status != WebhookDeliveryPending || original.Attempts != 1 || !original.NextAttemptAt.Equal(retryAt) {
+		t.Errorf("Expected a pending retry, but got %+v", original)
+	}
+
+	original.RecordFailure(0, "connection refused", time.Time{})
+	if original.Status != WebhookDeliveryFailed || original.ResponseStatus != 0 || original.LastError != "connection refused" {
+		t.Errorf("Expected the delivery to be given up, but got %+v", original)
+	}
+
+	replay := original.Replay()
+	if replay.Id == original.Id || replay.EventId != original.EventId || replay.ReplayOf == nil || *replay.ReplayOf != original.Id {
+		t.Errorf("Expected a new delivery of the same event, but got %+v", replay)
+	}
+	if replay.Status != WebhookDeliveryPending || replay.Attempts != 0 {
+		t.Errorf("Expected the replay to be pending, but got %+v", replay)
+	}
+
+	replay.RecordSuccess(204, time.Now())
+	if replay.Status != WebhookDeliverySucceeded || replay.ResponseStatus != 204 || replay.DeliveredAt.IsZero() {
+		t.Errorf("Expected the replay to succeed, but got %+v", replay)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"Type":"seller.created"}`)
+	signature := SignWebhookPayload("secret", 1714557600, payload)
+
+	if signature != SignWebhookPayload("secret", 1714557600, payload) || signature[:7] != "sha256=" {
+		t.Fatalf("Expected a stable sha256 signature, but got %q", signature)
+	}
+	if !VerifyWebhookSignature("secret", 1714557600, payload, signature) {
+		t.Error("Expected the signature to be valid")
+	}
+	if VerifyWebhookSignature("other", 1714557600, payload, signature) {
+		t.Error("Expected the signature to be invalid for another secret")
+	}
+	if VerifyWebhookSignature("secret", 1714557601, payload, signature) {
+		t.Error("Expected the signature to be invalid for another timestamp")
+	}
+	if VerifyWebhookSignature("secret", 1714557600, []byte(`{"Type":"seller.deleted"}`), signature) {
+		t.Error("Expected the signature to be invalid for a tampered payload")
+	}
+}
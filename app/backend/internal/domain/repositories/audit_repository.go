@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// AuditFilter narrows the audit trail down, zero values match every entry
+type AuditFilter struct {
+	ActorId       string
+	Action        entities.AuditAction
+	AggregateType entities.AuditAggregateType
+	AggregateId   string
+	RequestId     string
+	// OccurredAfter and OccurredBefore limit the time of the entries, inclusive and exclusive
+	OccurredAfter  *time.Time
+	OccurredBefore *time.Time
+}
+
+// AuditPage is one page of the audit trail
+type AuditPage struct {
+	Entries    []*entities.AuditEntry
+	TotalCount int64
+}
+
+// AuditRepository defines the interface for the append-only audit trail
+type AuditRepository interface {
+	// Append stores a new entry, entries cannot be changed or removed afterwards
+	Append(ctx context.Context, entry *entities.AuditEntry) error
+
+	// FindPage retrieves one page of the entries matching the filter, newest first.
+	// Only Limit and Offset of the page are used.
+	FindPage(ctx context.Context, filter AuditFilter, page PageRequest) (*AuditPage, error)
+}
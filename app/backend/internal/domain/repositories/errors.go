@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// ErrAlreadyExists is returned when a write violates a unique constraint, e.g. a second user with the same email
+var ErrAlreadyExists = entities.NewError(entities.ErrConflict, "already exists")
+
+// ErrVersionConflict is returned when an aggregate was changed since it was read, or when its
+// version differs from the version a client expects, so that concurrent updates are not lost
+var ErrVersionConflict = entities.NewError(entities.ErrConflict, "version conflict")
+
+// ErrIdempotencyKeyReleased is returned when the response of a request is saved after its idempotency key
+// was released or taken over by a retry
+var ErrIdempotencyKeyReleased = entities.NewError(entities.ErrConflict, "idempotency key is no longer reserved for the request")
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// ExchangeRateRepository defines the interface for persisting exchange rates
+type ExchangeRateRepository interface {
+	// Save creates or replaces the rate of the currency pair for its effective date
+	Save(ctx context.Context, rate *entities.ExchangeRate) error
+
+	// FindEffective retrieves the rate of the currency pair in effect at the given time, or nil if there is none
+	FindEffective(ctx context.Context, base, quote entities.Currency, at time.Time) (*entities.ExchangeRate, error)
+}
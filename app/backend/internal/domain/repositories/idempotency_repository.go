@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// IdempotencyRepository stores the responses to requests sent with an idempotency key
+type IdempotencyRepository interface {
+	// Reserve stores the record unless a record with the same key, user and route exists which has not expired.
+	// It returns that record instead, or nil if the record was stored.
+	Reserve(ctx context.Context, record *entities.IdempotencyRecord) (*entities.IdempotencyRecord, error)
+
+	// Save stores the response of a reserved record. It returns ErrIdempotencyKeyReleased if the key
+	// is no longer reserved with the token of the record.
+	Save(ctx context.Context, record *entities.IdempotencyRecord) error
+
+	// Delete removes a record, so that its key can be used again. Records which reserve the key
+	// with another token are kept.
+	Delete(ctx context.Context, record *entities.IdempotencyRecord) error
+
+	// DeleteExpired removes records which expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// LoginAttemptRepository keeps track of failed logins per client IP
+type LoginAttemptRepository interface {
+	// FindByClientIP returns the login attempts of the client IP, or nil if there are none
+	FindByClientIP(ctx context.Context, clientIP string) (*entities.LoginAttempt, error)
+
+	// RecordFailure atomically counts a failed login of the client IP and returns the updated attempts.
+	// Failures before now minus window are forgotten.
+	RecordFailure(ctx context.Context, clientIP string, now time.Time, window time.Duration) (*entities.LoginAttempt, error)
+
+	// Block rejects logins from the client IP until the given time and resets its failure count
+	Block(ctx context.Context, clientIP string, until time.Time) error
+
+	// Delete forgets the login attempts of the client IP
+	Delete(ctx context.Context, clientIP string) error
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxMessage is a domain event stored in the outbox until it is delivered to its subscribers
+type OutboxMessage struct {
+	// Id increases in the order the messages were stored
+	Id          int64
+	EventName   string
+	AggregateId string
+	// Payload is the JSON encoded event, see entities.DecodeDomainEvent
+	Payload    []byte
+	OccurredAt time.Time
+	// Attempts counts the failed deliveries so far
+	Attempts int
+}
+
+// OutboxRepository defines the interface for delivering the domain events which repositories
+// stored in the outbox. Repositories store the pending events of an aggregate in the same
+// transaction as the aggregate, so an event is stored if and only if its change is.
+type OutboxRepository interface {
+	// FindDue retrieves up to limit undelivered messages which are due at the given time, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*OutboxMessage, error)
+
+	// MarkDelivered records that all subscribers handled the message
+	MarkDelivered(ctx context.Context, id int64, at time.Time) error
+
+	// MarkFailed records a failed delivery and schedules the next attempt.
+	// A zero retryAt gives up on the message, it stays in the outbox for inspection.
+	MarkFailed(ctx context.Context, id int64, deliveryErr error, retryAt time.Time) error
+}
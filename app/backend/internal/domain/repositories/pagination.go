@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a listing does not ask for a page size
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page a listing returns
+	MaxPageSize = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or belongs to another sort order
+var ErrInvalidCursor = entities.NewError(entities.ErrValidation, "invalid cursor")
+
+// SortField names the attribute a listing is ordered by
+type SortField string
+
+const (
+	SortByName      SortField = "name"
+	SortByPrice     SortField = "price"
+	SortByCreatedAt SortField = "created_at"
+)
+
+// SortOrder orders a listing. Rows with equal values are ordered by id so that pages are stable.
+type SortOrder struct {
+	Field      SortField
+	Descending bool
+}
+
+// PageRequest selects a page either by offset or, for stable paging through changing data,
+// by the opaque cursor returned with the previous page. A cursor takes precedence over the offset.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// WithDefaults returns the page request with the page size set to the default if missing and capped at the maximum
+func (p PageRequest) WithDefaults() PageRequest {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageSize
+	}
+	if p.Limit > MaxPageSize {
+		p.Limit = MaxPageSize
+	}
+	if p.Offset < 0 || p.Cursor != "" {
+		p.Offset = 0
+	}
+	return p
+}
+
+// ProductFilter narrows a product listing. Empty fields do not filter.
+type ProductFilter struct {
+	// NameContains matches product names case-insensitively
+	NameContains string
+	SellerId     *uuid.UUID
+	// MinPrice and MaxPrice are inclusive and only match products priced in their currency
+	MinPrice      *entities.Money
+	MaxPrice      *entities.Money
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// IncludeDeleted also lists soft deleted products
+	IncludeDeleted bool
+}
+
+// ProductPage is a page of products
+type ProductPage struct {
+	Products []*entities.Product
+	// TotalCount is the number of products matching the filter across all pages
+	TotalCount int64
+	// NextCursor selects the following page, it is empty on the last page
+	NextCursor string
+}
+
+// SellerFilter narrows a seller listing. Empty fields do not filter.
+type SellerFilter struct {
+	// NameContains matches seller names case-insensitively
+	NameContains  string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// IncludeDeleted also lists soft deleted sellers
+	IncludeDeleted bool
+}
+
+// SellerPage is a page of sellers
+type SellerPage struct {
+	Sellers []*entities.Seller
+	// TotalCount is the number of sellers matching the filter across all pages
+	TotalCount int64
+	// NextCursor selects the following page, it is empty on the last page
+	NextCursor string
+}
@@ -1,14 +1,40 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
 )
 
+// ErrProductNotFound is returned by FindById when no product has the given id
+var ErrProductNotFound = entities.NewError(entities.ErrNotFound, "product not found")
+
+// ProductRepository persists products. Create and Update also store the pending domain events
+// of the aggregate in the outbox, in the same transaction. Delete stores a ProductDeleted event.
+// Deleted products are kept until they are purged, the finders skip them unless stated otherwise.
 type ProductRepository interface {
-	Create(product *entities.ValidatedProduct) (*entities.Product, error)
-	FindById(id uuid.UUID) (*entities.Product, error)
-	FindAll() ([]*entities.Product, error)
-	Update(product *entities.ValidatedProduct) (*entities.Product, error)
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error)
+	FindById(ctx context.Context, id uuid.UUID) (*entities.Product, error)
+	// FindByIdIncludingDeleted also finds a soft deleted product
+	FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Product, error)
+	FindAll(ctx context.Context) ([]*entities.Product, error)
+	// FindPage returns one page of the products matching the filter
+	FindPage(ctx context.Context, filter ProductFilter, sort SortOrder, page PageRequest) (*ProductPage, error)
+	// Update only updates the product if it still has the version that was read,
+	// otherwise it returns ErrVersionConflict
+	Update(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error)
+	// Delete soft deletes a product if it still has the version that was read. It returns ErrVersionConflict
+	// if the product was changed since and ErrProductNotFound if it does not exist anymore.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	// DeleteBySeller soft deletes all products of the seller, storing a ProductDeleted event for each
+	DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error
+	// Restore restores a soft deleted product and stores a ProductRestored event.
+	// It returns ErrProductNotFound if there is no deleted product with the id.
+	Restore(ctx context.Context, id uuid.UUID) (*entities.Product, error)
+	// RestoreBySeller restores the products of the seller which were deleted at or after the given time,
+	// storing a ProductRestored event for each
+	RestoreBySeller(ctx context.Context, sellerId uuid.UUID, deletedSince time.Time) error
+	// Purge permanently removes the products which were soft deleted before the given time and returns their number
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// ErrProductSearchUnavailable is returned when the database does not support the search index
+var ErrProductSearchUnavailable = errors.New("product search is unavailable")
+
+// ProductSearchQuery selects a page of the products matching all term groups, best matches first
+type ProductSearchQuery struct {
+	Groups []entities.SearchTermGroup
+	Limit  int
+	Offset int
+}
+
+// ProductSearchHit is a product matching a search together with its relevance, higher is better
+type ProductSearchHit struct {
+	Product *entities.Product
+	Rank    float64
+}
+
+// ProductSearchPage is a page of search hits
+type ProductSearchPage struct {
+	Hits []ProductSearchHit
+	// TotalCount is the number of matching products across all pages
+	TotalCount int64
+}
+
+// ProductSearchRepository searches the full-text index of products.
+// The index is maintained by the ProductRepository whenever it persists a product.
+type ProductSearchRepository interface {
+	Search(ctx context.Context, query ProductSearchQuery) (*ProductSearchPage, error)
+	// FindSimilarTerms returns indexed terms which are at most maxDistance edits away from the term
+	FindSimilarTerms(ctx context.Context, term string, maxDistance int) ([]string, error)
+}
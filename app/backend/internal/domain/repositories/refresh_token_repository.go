@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// RefreshTokenRepository defines the interface for refresh token persistence operations
+type RefreshTokenRepository interface {
+	// Save persists a refresh token to the repository
+	Save(ctx context.Context, token *entities.RefreshToken) error
+
+	// FindByTokenHash retrieves a refresh token by the hash of its value
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+
+	// Replace revokes the token because it has been rotated into the token with the given ID, unless
+	// it has been revoked already. It reports whether the token was revoked by this call.
+	Replace(ctx context.Context, id, replacedByID string, revokedAt time.Time) (bool, error)
+
+	// RevokeFamily revokes all tokens which descend from the same login
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+
+	// RevokeAllForUser revokes every refresh token of a user
+	RevokeAllForUser(ctx context.Context, userID string, revokedAt time.Time) error
+
+	// DeleteExpired removes tokens which expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
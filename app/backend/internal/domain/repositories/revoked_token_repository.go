@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedTokenRepository keeps track of access tokens which were revoked before their expiry
+type RevokedTokenRepository interface {
+	// Revoke records the token ID as revoked until the token would have expired
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+
+	// IsRevoked reports whether the token ID has been revoked
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+
+	// DeleteExpired removes entries for tokens which expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// RoleRepository defines the interface for role persistence operations
+type RoleRepository interface {
+	// Save persists a role together with its permissions
+	Save(ctx context.Context, role *entities.Role) error
+
+	// FindByName retrieves a role by name, or nil if it does not exist
+	FindByName(ctx context.Context, name entities.UserRole) (*entities.Role, error)
+
+	// FindAll retrieves all roles
+	FindAll(ctx context.Context) ([]*entities.Role, error)
+
+	// Delete removes a role and its permissions
+	Delete(ctx context.Context, name entities.UserRole) error
+}
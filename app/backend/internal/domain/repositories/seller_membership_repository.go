@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// SellerMembershipRepository defines the interface for persisting which users belong to which sellers
+type SellerMembershipRepository interface {
+	// Save creates or updates a membership
+	Save(ctx context.Context, membership *entities.SellerMembership) error
+
+	// Find retrieves the membership of the user in the seller, or nil if the user is not a member
+	Find(ctx context.Context, sellerId uuid.UUID, userId string) (*entities.SellerMembership, error)
+
+	// FindBySeller retrieves all memberships of the seller
+	FindBySeller(ctx context.Context, sellerId uuid.UUID) ([]*entities.SellerMembership, error)
+
+	// FindByUser retrieves all memberships of the user
+	FindByUser(ctx context.Context, userId string) ([]*entities.SellerMembership, error)
+
+	// Delete removes the membership of the user in the seller
+	Delete(ctx context.Context, sellerId uuid.UUID, userId string) error
+
+	// DeleteBySeller removes all memberships of the seller
+	DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error
+}
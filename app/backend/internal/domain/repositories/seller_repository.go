@@ -1,14 +1,39 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
 )
 
+// ErrSellerNotFound is returned by FindById when no seller has the given id
+var ErrSellerNotFound = entities.NewError(entities.ErrNotFound, "seller not found")
+
+// SellerRepository persists sellers. Create and Update also store the pending domain events
+// of the aggregate in the outbox, in the same transaction. Delete stores a SellerDeleted event.
+// Deleted sellers are kept until they are purged, the finders skip them unless stated otherwise.
 type SellerRepository interface {
-	Create(seller *entities.ValidatedSeller) (*entities.Seller, error)
-	FindById(id uuid.UUID) (*entities.Seller, error)
-	FindAll() ([]*entities.Seller, error)
-	Update(seller *entities.ValidatedSeller) (*entities.Seller, error)
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error)
+	FindById(ctx context.Context, id uuid.UUID) (*entities.Seller, error)
+	// FindByIdForUpdate finds a seller like FindById and locks it until the transaction ends,
+	// so that concurrent commands on the seller run one after another
+	FindByIdForUpdate(ctx context.Context, id uuid.UUID) (*entities.Seller, error)
+	// FindByIdIncludingDeleted also finds a soft deleted seller
+	FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Seller, error)
+	FindAll(ctx context.Context) ([]*entities.Seller, error)
+	// FindPage returns one page of the sellers matching the filter. Sellers cannot be sorted by price.
+	FindPage(ctx context.Context, filter SellerFilter, sort SortOrder, page PageRequest) (*SellerPage, error)
+	// Update only updates the seller if it still has the version that was read,
+	// otherwise it returns ErrVersionConflict
+	Update(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error)
+	// Delete soft deletes a seller if it still has the version that was read. It returns ErrVersionConflict
+	// if the seller was changed since and ErrSellerNotFound if it does not exist anymore.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	// Restore restores a soft deleted seller and stores a SellerRestored event.
+	// It returns ErrSellerNotFound if there is no deleted seller with the id.
+	Restore(ctx context.Context, id uuid.UUID) (*entities.Seller, error)
+	// Purge permanently removes the sellers which were soft deleted before the given time together with
+	// their memberships and returns their number. Sellers which still have products are skipped.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
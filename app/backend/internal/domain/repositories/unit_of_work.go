@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+)
+
+// Transaction gives access to repositories which share one database transaction
+type Transaction interface {
+	Products() ProductRepository
+	Sellers() SellerRepository
+	SellerMemberships() SellerMembershipRepository
+	Users() UserRepository
+	ExchangeRates() ExchangeRateRepository
+	// Audit appends the audit entries of the commands, they are only kept if the transaction is committed
+	Audit() AuditRepository
+}
+
+// UnitOfWork runs commands touching several aggregates atomically
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a new transaction. The transaction is committed
+	// if fn returns nil and rolled back otherwise, the error of fn is returned as is.
+	// Domain events stored within fn are only delivered if the transaction is committed.
+	// Cancelling the context rolls the transaction back.
+	Do(ctx context.Context, fn func(tx Transaction) error) error
+}
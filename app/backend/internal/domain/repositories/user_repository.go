@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 )
 
@@ -14,24 +15,30 @@ type UserFilter struct {
 
 // UserRepository defines the interface for user persistence operations
 type UserRepository interface {
-	// Save persists a user to the repository
-	Save(user *entities.User) error
+	// Save persists a user to the repository together with its pending domain events.
+	// Existing users are only updated if they still have the version that was read, otherwise it returns ErrVersionConflict.
+	Save(ctx context.Context, user *entities.User) error
+
+	// IncrementFailedLogins atomically counts a failed login of the user and bumps its version.
+	// It returns the updated user, or nil if there is no user with the ID.
+	IncrementFailedLogins(ctx context.Context, id string) (*entities.User, error)
 
 	// FindByID retrieves a user by ID
-	FindByID(id string) (*entities.User, error)
+	FindByID(ctx context.Context, id string) (*entities.User, error)
 
 	// FindByEmail retrieves a user by email
-	FindByEmail(email string) (*entities.User, error)
+	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 
 	// FindByUsername retrieves a user by username
-	FindByUsername(username string) (*entities.User, error)
+	FindByUsername(ctx context.Context, username string) (*entities.User, error)
 
 	// FindAll retrieves all users
-	FindAll() ([]*entities.User, error)
+	FindAll(ctx context.Context) ([]*entities.User, error)
 
 	// FindWithFilter retrieves users matching the given filter
-	FindWithFilter(filter UserFilter) ([]*entities.User, error)
+	FindWithFilter(ctx context.Context, filter UserFilter) ([]*entities.User, error)
 
-	// Delete removes a user from the repository
-	Delete(id string) error
+	// Delete removes a user from the repository if it still has the version that was read,
+	// otherwise it returns ErrVersionConflict. Deleting a user which does not exist anymore succeeds.
+	Delete(ctx context.Context, id string, version int) error
 }
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
+
+// WebhookSubscriptionRepository defines the interface for webhook subscription persistence operations
+type WebhookSubscriptionRepository interface {
+	// Save creates or replaces a subscription
+	Save(ctx context.Context, subscription *entities.WebhookSubscription) error
+
+	// FindById retrieves a subscription by id, or nil if it does not exist
+	FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error)
+
+	// FindAll retrieves all subscriptions, oldest first
+	FindAll(ctx context.Context) ([]*entities.WebhookSubscription, error)
+
+	// Delete removes a subscription together with its deliveries
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// WebhookDeliveryPage is one page of the delivery log of a subscription
+type WebhookDeliveryPage struct {
+	Deliveries []*entities.WebhookDelivery
+	TotalCount int64
+}
+
+// WebhookDeliveryRepository defines the interface for the webhook delivery log
+type WebhookDeliveryRepository interface {
+	// Save creates or replaces a delivery
+	Save(ctx context.Context, delivery *entities.WebhookDelivery) error
+
+	// FindById retrieves a delivery by id, or nil if it does not exist
+	FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookDelivery, error)
+
+	// ExistsForEvent reports whether the event was already delivered to the subscription, replays aside
+	ExistsForEvent(ctx context.Context, subscriptionId, eventId uuid.UUID) (bool, error)
+
+	// FindBySubscription retrieves one page of the deliveries of a subscription, newest first.
+	// Only Limit and Offset of the page are used.
+	FindBySubscription(ctx context.Context, subscriptionId uuid.UUID, page PageRequest) (*WebhookDeliveryPage, error)
+
+	// FindDue retrieves up to limit pending deliveries which are due at the given time, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.WebhookDelivery, error)
+}
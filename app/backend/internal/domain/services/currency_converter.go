@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"math/big"
+	"time"
+)
+
+// ErrExchangeRateNotFound is returned when no rate is in effect for a currency pair
+var ErrExchangeRateNotFound = entities.NewError(entities.ErrNotFound, "exchange rate not found")
+
+// CurrencyConverter converts money between currencies using the exchange rates in effect at a given time
+type CurrencyConverter struct {
+	rates repositories.ExchangeRateRepository
+}
+
+func NewCurrencyConverter(rates repositories.ExchangeRateRepository) *CurrencyConverter {
+	return &CurrencyConverter{rates: rates}
+}
+
+// Convert returns the money in the target currency together with the rate that was used.
+// A rate of the reverse currency pair is inverted when there is no rate for the pair itself.
+// Money already in the target currency is returned as is, without a rate.
+func (c *CurrencyConverter) Convert(ctx context.Context, money entities.Money, target entities.Currency, at time.Time) (entities.Money, *entities.ExchangeRate, error) {
+	if money.Currency == target {
+		return money, nil, nil
+	}
+
+	rate, err := c.rates.FindEffective(ctx, money.Currency, target, at)
+	if err != nil {
+		return entities.Money{}, nil, err
+	}
+	if rate != nil {
+		converted, err := money.ConvertTo(target, rate.Factor())
+		return converted, rate, err
+	}
+
+	inverseRate, err := c.rates.FindEffective(ctx, target, money.Currency, at)
+	if err != nil {
+		return entities.Money{}, nil, err
+	}
+	if inverseRate != nil {
+		converted, err := money.ConvertTo(target, new(big.Rat).Inv(inverseRate.Factor()))
+		return converted, inverseRate, err
+	}
+
+	return entities.Money{}, nil, fmt.Errorf("%w: %s/%s", ErrExchangeRateNotFound, money.Currency, target)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"testing"
+	"time"
+)
+
+// inMemoryExchangeRateRepository is an in-memory implementation of the ExchangeRateRepository interface
+type inMemoryExchangeRateRepository struct {
+	rates []*entities.ExchangeRate
+}
+
+func (r *inMemoryExchangeRateRepository) Save(ctx context.Context, rate *entities.ExchangeRate) error {
+	r.rates = append(r.rates, rate)
+	return nil
+}
+
+func (r *inMemoryExchangeRateRepository) FindEffective(ctx context.Context, base, quote entities.Currency, at time.Time) (*entities.ExchangeRate, error) {
+	var effective *entities.ExchangeRate
+	for _, rate := range r.rates {
+		if rate.BaseCurrency != base || rate.QuoteCurrency != quote || rate.EffectiveDate.After(at) {
+			continue
+		}
+		if effective == nil || rate.EffectiveDate.After(effective.EffectiveDate) {
+			effective = rate
+		}
+	}
+	return effective, nil
+}
+
+func saveRate(t *testing.T, repo *inMemoryExchangeRateRepository, base, quote entities.Currency, rate string, date time.Time) {
+	exchangeRate, err := entities.NewExchangeRate(base, quote, rate, date)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_ = repo.Save(context.Background(), exchangeRate)
+}
+
+func TestCurrencyConverter_Convert(t *testing.T) {
+	repo := &inMemoryExchangeRateRepository{}
+	saveRate(t, repo, entities.CurrencyUSD, entities.CurrencyEUR, "0.90", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	saveRate(t, repo, entities.CurrencyUSD, entities.CurrencyEUR, "0.95", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC))
+	converter := NewCurrencyConverter(repo)
+
+	price := entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}
+
+	// The latest rate that took effect at the given time is used
+	converted, rate, err := converter.Convert(context.Background(), price, entities.CurrencyEUR, time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if converted != (entities.Money{Amount: 900, Currency: entities.CurrencyEUR}) {
+		t.Errorf("Expected 9.00 EUR, but got %s", converted)
+	}
+	if rate == nil || rate.Rate != "0.90" {
+		t.Errorf("Expected rate 0.90 to be used, but got %v", rate)
+	}
+
+	converted, _, err = converter.Convert(context.Background(), price, entities.CurrencyEUR, time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if converted != (entities.Money{Amount: 950, Currency: entities.CurrencyEUR}) {
+		t.Errorf("Expected 9.50 EUR, but got %s", converted)
+	}
+}
+
+func TestCurrencyConverter_ConvertWithInverseRate(t *testing.T) {
+	repo := &inMemoryExchangeRateRepository{}
+	saveRate(t, repo, entities.CurrencyUSD, entities.CurrencyEUR, "0.8", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	converter := NewCurrencyConverter(repo)
+
+	converted, rate, err := converter.Convert(context.Background(), entities.Money{Amount: 800, Currency: entities.CurrencyEUR}, entities.CurrencyUSD, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if converted != (entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}) {
+		t.Errorf("Expected 10.00 USD, but got %s", converted)
+	}
+	if rate == nil || rate.BaseCurrency != entities.CurrencyUSD {
+		t.Errorf("Expected the USD/EUR rate to be used, but got %v", rate)
+	}
+}
+
+func TestCurrencyConverter_ConvertSameCurrency(t *testing.T) {
+	converter := NewCurrencyConverter(&inMemoryExchangeRateRepository{})
+	price := entities.Money{Amount: 1099, Currency: entities.CurrencyUSD}
+
+	converted, rate, err := converter.Convert(context.Background(), price, entities.CurrencyUSD, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if converted != price || rate != nil {
+		t.Errorf("Expected %s without rate, but got %s with %v", price, converted, rate)
+	}
+}
+
+func TestCurrencyConverter_ConvertWithoutRate(t *testing.T) {
+	repo := &inMemoryExchangeRateRepository{}
+	saveRate(t, repo, entities.CurrencyUSD, entities.CurrencyEUR, "0.9", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	converter := NewCurrencyConverter(repo)
+	price := entities.Money{Amount: 1099, Currency: entities.CurrencyUSD}
+
+	if _, _, err := converter.Convert(context.Background(), price, entities.CurrencyJPY, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Errorf("Expected ErrExchangeRateNotFound, but got %v", err)
+	}
+
+	// Rates do not apply before their effective date
+	if _, _, err := converter.Convert(context.Background(), price, entities.CurrencyEUR, time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Errorf("Expected ErrExchangeRateNotFound, but got %v", err)
+	}
+}
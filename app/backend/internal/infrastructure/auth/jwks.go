@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"encoding/base64"
+)
+
+// JWK is a single public key as described in RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served by the JWKS endpoint
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// bigEndianExponent encodes an RSA public exponent without leading zero bytes
+func bigEndianExponent(e int) []byte {
+	var out []byte
+	for e > 0 {
+		out = append([]byte{byte(e & 0xff)}, out...)
+		e >>= 8
+	}
+	return out
+}
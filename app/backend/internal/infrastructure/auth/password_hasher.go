@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/config"
+)
+
+// passwordScheme is a single password hashing algorithm
+type passwordScheme interface {
+	hash(password string) (string, error)
+	verify(password, encodedHash string) (bool, error)
+	// recognizes reports whether the encoded hash was produced by this scheme
+	recognizes(encodedHash string) bool
+	// isCurrent reports whether the encoded hash uses the parameters of this scheme
+	isCurrent(encodedHash string) bool
+}
+
+// PasswordHasher hashes new passwords with the preferred scheme and verifies
+// hashes produced by any known scheme, so users can be migrated transparently.
+type PasswordHasher struct {
+	preferred passwordScheme
+	schemes   []passwordScheme
+}
+
+// NewPasswordHasher creates a PasswordHasher from the given configuration.
+// Argon2id, bcrypt and legacy unsalted SHA-256 hashes are accepted for verification.
+func NewPasswordHasher(cfg *config.PasswordConfig) (interfaces.PasswordHasher, error) {
+	argon2id := &argon2idScheme{
+		memory:      cfg.Argon2Memory,
+		iterations:  cfg.Argon2Iterations,
+		parallelism: cfg.Argon2Parallelism,
+		saltLength:  cfg.Argon2SaltLength,
+		keyLength:   cfg.Argon2KeyLength,
+	}
+	bcrypt := &bcryptScheme{cost: cfg.BcryptCost}
+
+	var preferred passwordScheme
+	switch cfg.Algorithm {
+	case "", "argon2id":
+		preferred = argon2id
+	case "bcrypt":
+		preferred = bcrypt
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", cfg.Algorithm)
+	}
+
+	return &PasswordHasher{
+		preferred: preferred,
+		schemes:   []passwordScheme{argon2id, bcrypt, &legacySHA256Scheme{}},
+	}, nil
+}
+
+// Hash returns an encoded, salted hash of the password
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.preferred.hash(password)
+}
+
+// Verify reports whether the password matches the encoded hash
+func (h *PasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	scheme := h.schemeFor(encodedHash)
+	if scheme == nil {
+		return false, fmt.Errorf("unknown password hash format")
+	}
+	return scheme.verify(password, encodedHash)
+}
+
+// NeedsRehash reports whether the encoded hash should be replaced by a hash of the preferred scheme
+func (h *PasswordHasher) NeedsRehash(encodedHash string) bool {
+	if !h.preferred.recognizes(encodedHash) {
+		return true
+	}
+	return !h.preferred.isCurrent(encodedHash)
+}
+
+func (h *PasswordHasher) schemeFor(encodedHash string) passwordScheme {
+	for _, scheme := range h.schemes {
+		if scheme.recognizes(encodedHash) {
+			return scheme
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// fastPasswordConfig keeps the cost of hashing low in tests
+func fastPasswordConfig() *config.PasswordConfig {
+	cfg := config.NewPasswordConfig()
+	cfg.Argon2Memory = 1024
+	cfg.Argon2Iterations = 1
+	cfg.Argon2Parallelism = 1
+	cfg.BcryptCost = 4
+	return cfg
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher, err := NewPasswordHasher(fastPasswordConfig())
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.Len(t, strings.Split(hash, "$"), 6)
+
+	// Hashes are salted
+	other, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	valid, err := hasher.Verify("password123", hash)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hasher.Verify("wrong-password", hash)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	cfg := fastPasswordConfig()
+	cfg.Algorithm = "bcrypt"
+	hasher, err := NewPasswordHasher(cfg)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$04$"))
+
+	valid, err := hasher.Verify("password123", hash)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hasher.Verify("wrong-password", hash)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+func TestPasswordHasher_LegacySHA256(t *testing.T) {
+	hasher, err := NewPasswordHasher(fastPasswordConfig())
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("password123"))
+	legacyHash := hex.EncodeToString(sum[:])
+
+	valid, err := hasher.Verify("password123", legacyHash)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hasher.Verify("wrong-password", legacyHash)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	assert.True(t, hasher.NeedsRehash(legacyHash))
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	weak, err := NewPasswordHasher(fastPasswordConfig())
+	require.NoError(t, err)
+	weakHash, err := weak.Hash("password123")
+	require.NoError(t, err)
+
+	strongConfig := fastPasswordConfig()
+	strongConfig.Argon2Iterations = 2
+	strong, err := NewPasswordHasher(strongConfig)
+	require.NoError(t, err)
+
+	// Hashes with weaker parameters are upgraded but still verify
+	assert.True(t, strong.NeedsRehash(weakHash))
+	valid, err := strong.Verify("password123", weakHash)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// Switching the algorithm upgrades existing hashes
+	bcryptConfig := fastPasswordConfig()
+	bcryptConfig.Algorithm = "bcrypt"
+	bcryptHasher, err := NewPasswordHasher(bcryptConfig)
+	require.NoError(t, err)
+	assert.True(t, bcryptHasher.NeedsRehash(weakHash))
+}
+
+func TestPasswordHasher_InvalidInput(t *testing.T) {
+	hasher, err := NewPasswordHasher(fastPasswordConfig())
+	require.NoError(t, err)
+
+	_, err = hasher.Verify("password123", "not-a-hash")
+	assert.Error(t, err)
+
+	_, err = hasher.Verify("password123", "$argon2id$v=19$m=1024,t=1,p=1$invalid")
+	assert.Error(t, err)
+
+	cfg := fastPasswordConfig()
+	cfg.Algorithm = "md5"
+	_, err = NewPasswordHasher(cfg)
+	assert.Error(t, err)
+}
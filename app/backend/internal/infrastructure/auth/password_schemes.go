@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// argon2idScheme produces PHC formatted argon2id hashes:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type argon2idScheme struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// argon2idHash is a decoded argon2id PHC string
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (s *argon2idScheme) hash(password string) (string, error) {
+	salt := make([]byte, s.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, s.iterations, s.memory, s.parallelism, s.keyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		s.memory,
+		s.iterations,
+		s.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (s *argon2idScheme) verify(password, encodedHash string) (bool, error) {
+	decoded, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey(
+		[]byte(password),
+		decoded.salt,
+		decoded.iterations,
+		decoded.memory,
+		decoded.parallelism,
+		uint32(len(decoded.key)),
+	)
+
+	return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+}
+
+func (s *argon2idScheme) recognizes(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$argon2id$")
+}
+
+func (s *argon2idScheme) isCurrent(encodedHash string) bool {
+	decoded, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return false
+	}
+	return decoded.memory >= s.memory &&
+		decoded.iterations >= s.iterations &&
+		decoded.parallelism >= s.parallelism &&
+		uint32(len(decoded.key)) >= s.keyLength
+}
+
+func decodeArgon2idHash(encodedHash string) (*argon2idHash, error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	decoded := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &decoded.memory, &decoded.iterations, &decoded.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// bcryptScheme produces modular crypt formatted bcrypt hashes ($2a$, $2b$, $2y$)
+type bcryptScheme struct {
+	cost int
+}
+
+func (s *bcryptScheme) hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (s *bcryptScheme) verify(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *bcryptScheme) recognizes(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func (s *bcryptScheme) isCurrent(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return false
+	}
+	return cost >= s.cost
+}
+
+// legacySHA256Scheme verifies the unsalted hex encoded SHA-256 digests stored by earlier versions.
+// It never produces new hashes; matching users are rehashed on their next login.
+type legacySHA256Scheme struct{}
+
+func (s *legacySHA256Scheme) hash(string) (string, error) {
+	return "", errors.New("legacy SHA-256 hashes must not be created")
+}
+
+func (s *legacySHA256Scheme) verify(password, encodedHash string) (bool, error) {
+	expected, err := hex.DecodeString(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(sum[:], expected) == 1, nil
+}
+
+func (s *legacySHA256Scheme) recognizes(encodedHash string) bool {
+	if len(encodedHash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encodedHash)
+	return err == nil
+}
+
+func (s *legacySHA256Scheme) isCurrent(string) bool {
+	return false
+}
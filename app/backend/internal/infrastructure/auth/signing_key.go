@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"time"
+)
+
+// signingKey is a parsed key which can sign and verify tokens
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	// publicKey is nil for symmetric keys which must never be published
+	publicKey crypto.PublicKey
+	retireAt  time.Time
+}
+
+// isRetired reports whether tokens signed with this key must be rejected at the given time
+func (k *signingKey) isRetired(now time.Time) bool {
+	return !k.retireAt.IsZero() && now.After(k.retireAt)
+}
+
+// newSigningKey parses a configured key for the given signing method
+func newSigningKey(keyConfig config.JWTKey, defaultMethod string) (*signingKey, error) {
+	if keyConfig.ID == "" {
+		return nil, errors.New("key ID must not be empty")
+	}
+
+	methodName := keyConfig.SigningMethod
+	if methodName == "" {
+		methodName = defaultMethod
+	}
+
+	key := &signingKey{
+		id:       keyConfig.ID,
+		retireAt: keyConfig.RetireAt,
+	}
+
+	switch methodName {
+	case "HS256", "HS384", "HS512":
+		if keyConfig.Secret == "" {
+			return nil, fmt.Errorf("key %s: secret must not be empty for %s", keyConfig.ID, methodName)
+		}
+		key.method = jwt.GetSigningMethod(methodName)
+		key.signKey = []byte(keyConfig.Secret)
+		key.verifyKey = []byte(keyConfig.Secret)
+	case "RS256", "RS384", "RS512":
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(keyConfig.PrivateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyConfig.ID, err)
+		}
+		key.method = jwt.GetSigningMethod(methodName)
+		key.signKey = privateKey
+		key.verifyKey = &privateKey.PublicKey
+		key.publicKey = &privateKey.PublicKey
+	case "EdDSA":
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM([]byte(keyConfig.PrivateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyConfig.ID, err)
+		}
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key %s: not an Ed25519 private key", keyConfig.ID)
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.signKey = edKey
+		key.verifyKey = edKey.Public()
+		key.publicKey = edKey.Public()
+	default:
+		return nil, fmt.Errorf("key %s: unsupported signing method %q", keyConfig.ID, methodName)
+	}
+
+	return key, nil
+}
+
+// toJWK converts the public part of the key to its JWK representation
+func (k *signingKey) toJWK() (JWK, bool) {
+	switch publicKey := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.id,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			N:   encodeSegment(publicKey.N.Bytes()),
+			E:   encodeSegment(bigEndianExponent(publicKey.E)),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.id,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Crv: "Ed25519",
+			X:   encodeSegment(publicKey),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"sort"
+	"time"
+)
+
+// defaultKeyID is used when the configuration only provides a secret key
+const defaultKeyID = "default"
+
+// Claims are the JWT claims issued for an authenticated user
+type Claims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the ID of the user the token was issued for
+func (c *Claims) UserID() string {
+	return c.Subject
+}
+
+// TokenManager issues and validates RFC 7519 tokens.
+// It keeps previous keys around for verification so keys can be rotated
+// without invalidating tokens which have already been issued: a new key is
+// added to the configuration as active key, and the previous one gets a
+// retire_at at least one token expiry later. The keys are not changed after
+// construction, so they are read without locking.
+type TokenManager struct {
+	keys        map[string]*signingKey
+	activeKeyID string
+	issuer      string
+	audience    string
+	expiry      time.Duration
+	now         func() time.Time
+}
+
+// NewTokenManager creates a TokenManager from the given configuration
+func NewTokenManager(cfg *config.JWTConfig) (*TokenManager, error) {
+	keyConfigs := cfg.Keys
+	activeKeyID := cfg.ActiveKeyID
+	if len(keyConfigs) == 0 {
+		keyConfigs = []config.JWTKey{{ID: defaultKeyID, Secret: cfg.SecretKey}}
+		activeKeyID = defaultKeyID
+	}
+
+	manager := &TokenManager{
+		keys:     make(map[string]*signingKey, len(keyConfigs)),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		expiry:   cfg.TokenExpiry,
+		now:      time.Now,
+	}
+
+	for _, keyConfig := range keyConfigs {
+		key, err := newSigningKey(keyConfig, cfg.SigningMethod)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := manager.keys[key.id]; exists {
+			return nil, fmt.Errorf("duplicate key ID %s", key.id)
+		}
+		manager.keys[key.id] = key
+	}
+
+	if activeKeyID == "" {
+		activeKeyID = keyConfigs[len(keyConfigs)-1].ID
+	}
+	if _, ok := manager.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %s is not configured", activeKeyID)
+	}
+	manager.activeKeyID = activeKeyID
+
+	return manager, nil
+}
+
+// GenerateToken issues a signed token for the given user
+func (m *TokenManager) GenerateToken(userID, email string) (string, error) {
+	key := m.keys[m.activeKeyID]
+
+	now := m.now()
+	claims := &Claims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   userID,
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.expiry)),
+		},
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	return token.SignedString(key.signKey)
+}
+
+// TokenExpiry returns the lifetime of issued tokens
+func (m *TokenManager) TokenExpiry() time.Duration {
+	return m.expiry
+}
+
+// ValidateToken verifies the signature and registered claims of a token and returns its claims
+func (m *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(m.validMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(m.now),
+	}
+	if m.issuer != "" {
+		options = append(options, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		options = append(options, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return claims, nil
+}
+
+// keyFunc selects the verification key by the "kid" header of the token
+func (m *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token has no key ID")
+	}
+
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %s", kid)
+	}
+
+	// Prevent algorithm confusion, e.g. an RSA public key used as HMAC secret
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+
+	if key.isRetired(m.now()) {
+		return nil, fmt.Errorf("key %s has been retired", kid)
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys which are currently accepted for verification.
+// Symmetric keys are never published.
+func (m *TokenManager) JWKS() JWKSet {
+	now := m.now()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if key.isRetired(now) {
+			continue
+		}
+		if jwk, ok := key.toJWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+// validMethods lists the algorithms of all configured keys
+func (m *TokenManager) validMethods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range m.keys {
+		alg := key.method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
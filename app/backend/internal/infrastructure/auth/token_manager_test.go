@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rsaPrivateKeyPEM(t *testing.T) string {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	}))
+}
+
+func ed25519PrivateKeyPEM(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestTokenManager_SigningMethods(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		key    config.JWTKey
+	}{
+		{name: "HS256", method: "HS256", key: config.JWTKey{ID: "hs", Secret: "secret"}},
+		{name: "RS256", method: "RS256", key: config.JWTKey{ID: "rs", PrivateKeyPEM: rsaPrivateKeyPEM(t)}},
+		{name: "EdDSA", method: "EdDSA", key: config.JWTKey{ID: "ed", PrivateKeyPEM: ed25519PrivateKeyPEM(t)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.NewJWTConfig()
+			cfg.SigningMethod = tc.method
+			cfg.Keys = []config.JWTKey{tc.key}
+
+			manager, err := NewTokenManager(cfg)
+			require.NoError(t, err)
+
+			token, err := manager.GenerateToken("user-id", "test@example.com")
+			require.NoError(t, err)
+			assert.Len(t, strings.Split(token, "."), 3)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.method, parsed.Header["alg"])
+			assert.Equal(t, tc.key.ID, parsed.Header["kid"])
+
+			claims, err := manager.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-id", claims.UserID())
+			assert.Equal(t, "test@example.com", claims.Email)
+			assert.Equal(t, "marketplace", claims.Issuer)
+		})
+	}
+}
+
+func TestTokenManager_DefaultsToSecretKey(t *testing.T) {
+	manager, err := NewTokenManager(config.NewJWTConfig())
+	require.NoError(t, err)
+
+	token, err := manager.GenerateToken("user-id", "test@example.com")
+	require.NoError(t, err)
+
+	_, err = manager.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Empty(t, manager.JWKS().Keys, "symmetric keys must not be published")
+}
+
+func TestTokenManager_RejectsInvalidTokens(t *testing.T) {
+	manager, err := NewTokenManager(config.NewJWTConfig())
+	require.NoError(t, err)
+
+	token, err := manager.GenerateToken("user-id", "test@example.com")
+	require.NoError(t, err)
+
+	t.Run("tampered signature", func(t *testing.T) {
+		_, err := manager.ValidateToken(token + "x")
+		assert.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		manager.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+		defer func() { manager.now = time.Now }()
+
+		_, err := manager.ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		cfg := config.NewJWTConfig()
+		cfg.SecretKey = "another-secret"
+		other, err := NewTokenManager(cfg)
+		require.NoError(t, err)
+
+		_, err = other.ValidateToken(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		cfg := config.NewJWTConfig()
+		cfg.Audience = "another-api"
+		other, err := NewTokenManager(cfg)
+		require.NoError(t, err)
+
+		_, err = other.ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+	})
+}
+
+func TestTokenManager_RejectsAlgorithmConfusion(t *testing.T) {
+	cfg := config.NewJWTConfig()
+	cfg.SigningMethod = "RS256"
+	cfg.Keys = []config.JWTKey{{ID: "rs", PrivateKeyPEM: rsaPrivateKeyPEM(t)}}
+	manager, err := NewTokenManager(cfg)
+	require.NoError(t, err)
+
+	// Sign an HS256 token using the published public key as HMAC secret
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(manager.keys["rs"].publicKey)
+	require.NoError(t, err)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "attacker",
+			Issuer:    cfg.Issuer,
+			Audience:  jwt.ClaimStrings{cfg.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forged.Header["kid"] = "rs"
+	forgedToken, err := forged.SignedString(publicKeyDER)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateToken(forgedToken)
+	assert.Error(t, err)
+}
+
+func TestTokenManager_Rotate(t *testing.T) {
+	cfg := config.NewJWTConfig()
+	cfg.SigningMethod = "RS256"
+	cfg.Keys = []config.JWTKey{{ID: "key-1", PrivateKeyPEM: rsaPrivateKeyPEM(t)}}
+	manager, err := NewTokenManager(cfg)
+	require.NoError(t, err)
+
+	oldToken, err := manager.GenerateToken("user-id", "test@example.com")
+	require.NoError(t, err)
+
+	// A new key is configured as active key, the previous one is retired after the overlap
+	cfg.Keys = append(cfg.Keys, config.JWTKey{ID: "key-2", PrivateKeyPEM: rsaPrivateKeyPEM(t)})
+	cfg.Keys[0].RetireAt = time.Now().Add(time.Hour)
+	cfg.ActiveKeyID = "key-2"
+	manager, err = NewTokenManager(cfg)
+	require.NoError(t, err)
+
+	newToken, err := manager.GenerateToken("user-id", "test@example.com")
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", parsed.Header["kid"])
+
+	// Both keys are accepted and published during the overlap period
+	_, err = manager.ValidateToken(oldToken)
+	assert.NoError(t, err)
+	_, err = manager.ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Len(t, manager.JWKS().Keys, 2)
+
+	// After the overlap the previous key is retired
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = manager.ValidateToken(oldToken)
+	assert.Error(t, err)
+
+	jwks := manager.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "key-2", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+}
+
+func TestNewTokenManager_InvalidConfiguration(t *testing.T) {
+	cfg := config.NewJWTConfig()
+	cfg.SigningMethod = "none"
+	_, err := NewTokenManager(cfg)
+	assert.Error(t, err)
+
+	cfg = config.NewJWTConfig()
+	cfg.Keys = []config.JWTKey{{ID: "a", Secret: "secret"}}
+	cfg.ActiveKeyID = "missing"
+	_, err = NewTokenManager(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// invalidNameChars are replaced in the names of new migrations
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes empty up and down scripts for a new migration to the directory and returns their paths.
+// The version follows the highest version of the existing migrations, including those written in Go.
+func Create(dir, name string, existing []Migration) (string, string, error) {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	var version int64
+	for _, migration := range existing {
+		version = max(version, migration.Version)
+	}
+	version++
+
+	upPath := filepath.Join(dir, fmt.Sprintf("%04d_%s.up.sql", version, name))
+	downPath := filepath.Join(dir, fmt.Sprintf("%04d_%s.down.sql", version, name))
+	if err := writeNewFile(upPath, fmt.Sprintf("-- %04d_%s: describe the change\n", version, name)); err != nil {
+		return "", "", err
+	}
+	if err := writeNewFile(downPath, fmt.Sprintf("-- Reverts %04d_%s\n", version, name)); err != nil {
+		return "", "", err
+	}
+	return upPath, downPath, nil
+}
+
+func writeNewFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	return err
+}
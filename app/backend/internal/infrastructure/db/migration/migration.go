@@ -0,0 +1,116 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileName matches migration scripts like "0001_initial_schema.up.sql"
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration changes the schema from the previous version to Version. Up and Down run in a
+// transaction together with the bookkeeping in the migrations table.
+type Migration struct {
+	Version int64
+	Name    string
+	// Checksum identifies the applied change, a migration must not be edited once it was applied
+	Checksum string
+	Up       func(tx *gorm.DB) error
+	// Down reverts Up, it is nil if the migration cannot be reverted
+	Down func(tx *gorm.DB) error
+}
+
+// ID returns the version and name as in the file names, e.g. "0001_initial_schema"
+func (m Migration) ID() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// NewGoMigration creates a migration written in Go, for data conversions which must behave exactly like the
+// domain. Its checksum is derived from the version and the name.
+func NewGoMigration(version int64, name string, up, down func(tx *gorm.DB) error) Migration {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("go:%d_%s", version, name)))
+	return Migration{Version: version, Name: name, Checksum: hex.EncodeToString(sum[:]), Up: up, Down: down}
+}
+
+// LoadSQL reads the SQL migrations of the directory. Every version needs an up script, the down script
+// is optional. The scripts run as a whole, so statements which cannot run in a transaction are not supported.
+func LoadSQL(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	scripts := make(map[int64]map[string]string)
+	names := make(map[int64]string)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		if name, ok := names[version]; ok && name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, name, match[2])
+		}
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		names[version] = match[2]
+		if scripts[version] == nil {
+			scripts[version] = make(map[string]string)
+		}
+		scripts[version][match[3]] = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(scripts))
+	for version, script := range scripts {
+		up, ok := script["up"]
+		if !ok {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", version, names[version])
+		}
+		sum := sha256.Sum256([]byte(up))
+		migration := Migration{
+			Version:  version,
+			Name:     names[version],
+			Checksum: hex.EncodeToString(sum[:]),
+			Up:       execScript(up),
+		}
+		if down, ok := script["down"]; ok {
+			migration.Down = execScript(down)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// execScript runs the statements of a script. They are sent to the driver as is, GORM would treat
+// question marks as placeholders.
+func execScript(script string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if strings.TrimSpace(script) == "" {
+			return nil
+		}
+		_, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, script)
+		return err
+	}
+}
+
+// sortMigrations orders the migrations by version and rejects duplicate versions
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", sorted[i].Version, sorted[i-1].Name, sorted[i].Name)
+		}
+	}
+	return sorted, nil
+}
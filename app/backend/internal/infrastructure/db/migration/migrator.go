@@ -0,0 +1,238 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// lockKey identifies the PostgreSQL advisory lock held while migrating
+const lockKey int64 = 7300512084431163
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration was edited afterwards
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	// ErrUnknownMigration is returned when the database has a migration applied which this build does not know
+	ErrUnknownMigration = errors.New("applied migration is unknown")
+	// ErrIrreversible is returned when a migration without down script should be reverted
+	ErrIrreversible = errors.New("migration cannot be reverted")
+)
+
+// State describes whether a migration was applied
+type State string
+
+const (
+	StatePending State = "pending"
+	StateApplied State = "applied"
+	// StateModified means the migration was edited after it was applied
+	StateModified State = "modified"
+	// StateUnknown means the migration was applied by another build which had a migration this build lacks
+	StateUnknown State = "unknown"
+)
+
+// Status describes a migration and whether it was applied
+type Status struct {
+	Version   int64
+	Name      string
+	State     State
+	AppliedAt *time.Time
+}
+
+// appliedMigration records an applied migration in the migrations table
+type appliedMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// TableName specifies the table name for appliedMigration
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and reverts migrations and records them in the schema_migrations table
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a new Migrator for the migrations, their versions must be unique
+func NewMigrator(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Migrations returns the known migrations ordered by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies all pending migrations in order and returns them. It refuses to run if an applied migration
+// was modified. Concurrent runs, e.g. of several instances starting at once, wait for each other.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var migrated []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			record, ok := applied[migration.Version]
+			if ok && record.Checksum != migration.Checksum {
+				return fmt.Errorf("%w: %s", ErrChecksumMismatch, migration.ID())
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&appliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now().UTC(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.ID(), err)
+			}
+			migrated = append(migrated, migration)
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// Down reverts the given number of most recently applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		var records []appliedMigration
+		if err := db.Order("version DESC").Limit(steps).Find(&records).Error; err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			migration, ok := byVersion[record.Version]
+			if !ok {
+				return fmt.Errorf("%w: %04d_%s", ErrUnknownMigration, record.Version, record.Name)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %s", ErrIrreversible, migration.ID())
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&appliedMigration{}, record.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %s: %w", migration.ID(), err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists the known migrations and the applied migrations this build does not know, ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	applied := map[int64]appliedMigration{}
+	if db.Migrator().HasTable(&appliedMigration{}) {
+		var err error
+		if applied, err = m.applied(db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if record, ok := applied[migration.Version]; ok {
+			status.State = StateApplied
+			if record.Checksum != migration.Checksum {
+				status.State = StateModified
+			}
+			status.AppliedAt = &record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, State: StateUnknown, AppliedAt: &record.AppliedAt})
+	}
+
+	sortStatuses(statuses)
+	return statuses, nil
+}
+
+func sortStatuses(statuses []Status) {
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]appliedMigration, error) {
+	var records []appliedMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[int64]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// withLock creates the migrations table if needed and runs fn while holding the migration lock.
+// PostgreSQL uses a session advisory lock, other databases are expected to be used by a single process.
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+
+	if db.Dialector.Name() == "postgres" {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		// The lock belongs to the session, so it is taken and released on the same connection
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey)
+	}
+
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	return fn(db)
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// AuditEntryModel is the GORM model for the audit trail
+type AuditEntryModel struct {
+	ID            uuid.UUID `gorm:"primaryKey"`
+	ActorID       string    `gorm:"index"`
+	Action        string
+	AggregateType string `gorm:"index:idx_audit_entries_aggregate"`
+	AggregateID   string `gorm:"index:idx_audit_entries_aggregate"`
+	// Changes is the JSON encoded list of changed fields
+	Changes    string
+	RequestID  string    `gorm:"index"`
+	OccurredAt time.Time `gorm:"index"`
+}
+
+// TableName specifies the table name for AuditEntryModel
+func (AuditEntryModel) TableName() string {
+	return "audit_entries"
+}
+
+// auditChangeModel is the JSON encoding of an entities.AuditChange
+type auditChangeModel struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// GormAuditRepository implements the AuditRepository interface using GORM v2
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuditRepository creates a new GormAuditRepository
+func NewGormAuditRepository(db *gorm.DB) repositories.AuditRepository {
+	return &GormAuditRepository{db: db}
+}
+
+func toAuditEntryModel(entry *entities.AuditEntry) (*AuditEntryModel, error) {
+	changes := make([]auditChangeModel, len(entry.Changes))
+	for i, change := range entry.Changes {
+		changes[i] = auditChangeModel{Field: change.Field, Before: change.Before, After: change.After}
+	}
+	encodedChanges, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	return &AuditEntryModel{
+		ID:            entry.Id,
+		ActorID:       entry.ActorId,
+		Action:        string(entry.Action),
+		AggregateType: string(entry.AggregateType),
+		AggregateID:   entry.AggregateId,
+		Changes:       string(encodedChanges),
+		RequestID:     entry.RequestId,
+		OccurredAt:    entry.OccurredAt,
+	}, nil
+}
+
+func fromAuditEntryModel(model *AuditEntryModel) (*entities.AuditEntry, error) {
+	var changes []auditChangeModel
+	if err := json.Unmarshal([]byte(model.Changes), &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode changes of audit entry %s: %w", model.ID, err)
+	}
+
+	entry := &entities.AuditEntry{
+		Id:            model.ID,
+		ActorId:       model.ActorID,
+		Action:        entities.AuditAction(model.Action),
+		AggregateType: entities.AuditAggregateType(model.AggregateType),
+		AggregateId:   model.AggregateID,
+		Changes:       make([]entities.AuditChange, len(changes)),
+		RequestId:     model.RequestID,
+		OccurredAt:    model.OccurredAt,
+	}
+	for i, change := range changes {
+		entry.Changes[i] = entities.AuditChange{Field: change.Field, Before: change.Before, After: change.After}
+	}
+	return entry, nil
+}
+
+// Append stores a new entry
+func (repo *GormAuditRepository) Append(ctx context.Context, entry *entities.AuditEntry) error {
+	model, err := toAuditEntryModel(entry)
+	if err != nil {
+		return err
+	}
+	return repo.db.WithContext(ctx).Create(model).Error
+}
+
+// FindPage retrieves one page of the entries matching the filter, newest first
+func (repo *GormAuditRepository) FindPage(ctx context.Context, filter repositories.AuditFilter, page repositories.PageRequest) (*repositories.AuditPage, error) {
+	page = page.WithDefaults()
+	query := repo.db.WithContext(ctx).Model(&AuditEntryModel{}).Scopes(auditFilterScope(filter))
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var models []AuditEntryModel
+	if err := query.Order("occurred_at DESC, id DESC").Limit(page.Limit).Offset(page.Offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entities.AuditEntry, len(models))
+	for i := range models {
+		entry, err := fromAuditEntryModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+
+	return &repositories.AuditPage{Entries: entries, TotalCount: totalCount}, nil
+}
+
+func auditFilterScope(filter repositories.AuditFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.ActorId != "" {
+			db = db.Where("actor_id = ?", filter.ActorId)
+		}
+		if filter.Action != "" {
+			db = db.Where("action = ?", string(filter.Action))
+		}
+		if filter.AggregateType != "" {
+			db = db.Where("aggregate_type = ?", string(filter.AggregateType))
+		}
+		if filter.AggregateId != "" {
+			db = db.Where("aggregate_id = ?", filter.AggregateId)
+		}
+		if filter.RequestId != "" {
+			db = db.Where("request_id = ?", filter.RequestId)
+		}
+		if filter.OccurredAfter != nil {
+			db = db.Where("occurred_at >= ?", *filter.OccurredAfter)
+		}
+		if filter.OccurredBefore != nil {
+			db = db.Where("occurred_at < ?", *filter.OccurredBefore)
+		}
+		return db
+	}
+}
@@ -1,12 +1,12 @@
 package postgres
 
 import (
+	"github.com/sklinkert/go-ddd/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 // NewConnection returns a new GORM v2 database connection
-func NewConnection() (*gorm.DB, error) {
-	dsn := "host=localhost user=root password=password dbname=mydb port=5432 sslmode=disable TimeZone=Asia/Tokyo"
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func NewConnection(databaseConfig *config.DatabaseConfig) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(databaseConfig.DSN()), &gorm.Config{})
 }
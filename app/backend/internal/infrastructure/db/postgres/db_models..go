@@ -2,22 +2,29 @@ package postgres
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Product struct {
-	Id        uuid.UUID `gorm:"primaryKey"`
-	Name      string
-	Price     float64
-	SellerId  uuid.UUID `gorm:"index"`
-	Seller    Seller    `gorm:"foreignKey:SellerId"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	Id            uuid.UUID `gorm:"primaryKey"`
+	Name          string
+	PriceAmount   int64     `gorm:"not null;default:0"`
+	PriceCurrency string    `gorm:"size:3"`
+	SellerId      uuid.UUID `gorm:"index"`
+	Seller        Seller    `gorm:"foreignKey:SellerId"`
+	Version       int       `gorm:"not null;default:1"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// DeletedAt makes GORM soft delete products, queries skip them unless they are Unscoped
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 type Seller struct {
 	Id        uuid.UUID `gorm:"primaryKey"`
 	Name      string
+	Version   int `gorm:"not null;default:1"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// translateError maps unique constraint violations reported by the database to repositories.ErrAlreadyExists,
+// so services and the API can tell conflicting writes from failures of the database itself
+func translateError(db *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return repositories.ErrAlreadyExists
+	}
+	return err
+}
+
+// versionConflictError explains why an update of the row with the id and the version that was read matched nothing:
+// it returns notFound if the row was deleted and repositories.ErrVersionConflict if another write changed it
+func versionConflictError(tx *gorm.DB, model interface{}, id interface{}, notFound error) error {
+	var count int64
+	if err := tx.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return repositories.ErrVersionConflict
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ExchangeRateModel is the GORM model for exchange rates. Rates are stored as decimal strings to stay exact.
+type ExchangeRateModel struct {
+	BaseCurrency  string    `gorm:"primaryKey;size:3"`
+	QuoteCurrency string    `gorm:"primaryKey;size:3"`
+	EffectiveDate time.Time `gorm:"primaryKey"`
+	Rate          string    `gorm:"not null"`
+	CreatedAt     time.Time
+}
+
+// TableName specifies the table name for ExchangeRateModel
+func (ExchangeRateModel) TableName() string {
+	return "exchange_rates"
+}
+
+// GormExchangeRateRepository is a PostgreSQL implementation of the ExchangeRateRepository interface
+type GormExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewGormExchangeRateRepository creates a new GormExchangeRateRepository
+func NewGormExchangeRateRepository(db *gorm.DB) repositories.ExchangeRateRepository {
+	return &GormExchangeRateRepository{db: db}
+}
+
+// Save creates or replaces the rate of the currency pair for its effective date
+func (r *GormExchangeRateRepository) Save(ctx context.Context, rate *entities.ExchangeRate) error {
+	model := &ExchangeRateModel{
+		BaseCurrency:  string(rate.BaseCurrency),
+		QuoteCurrency: string(rate.QuoteCurrency),
+		EffectiveDate: rate.EffectiveDate.UTC(),
+		Rate:          rate.Rate,
+		CreatedAt:     rate.CreatedAt,
+	}
+	return r.db.WithContext(ctx).Save(model).Error
+}
+
+// FindEffective retrieves the latest rate of the currency pair that took effect at or before the given time
+func (r *GormExchangeRateRepository) FindEffective(ctx context.Context, base, quote entities.Currency, at time.Time) (*entities.ExchangeRate, error) {
+	var model ExchangeRateModel
+	err := r.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_date <= ?", string(base), string(quote), at.UTC()).
+		Order("effective_date DESC").
+		First(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.ExchangeRate{
+		BaseCurrency:  entities.Currency(model.BaseCurrency),
+		QuoteCurrency: entities.Currency(model.QuoteCurrency),
+		Rate:          model.Rate,
+		EffectiveDate: model.EffectiveDate.UTC(),
+		CreatedAt:     model.CreatedAt,
+	}, nil
+}
@@ -15,14 +15,16 @@ const TableNameProduct = "products"
 
 // Product mapped from table <products>
 type Product struct {
-	Id        uuid.UUID      `gorm:"column:id;type:uuid;primaryKey" json:"id"`
-	Name      string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
-	Price     float64        `gorm:"column:price;type:float;not null" json:"price"`
-	SellerId  uuid.UUID      `gorm:"column:seller_id;type:uuid;index" json:"seller_id"`
-	Seller    Seller         `gorm:"foreignKey:SellerId" json:"seller"`
-	CreatedAt time.Time      `gorm:"column:created_at;type:timestamp;not null" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"column:updated_at;type:timestamp;not null" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;type:timestamp" json:"deleted_at"`
+	Id            uuid.UUID      `gorm:"column:id;type:uuid;primaryKey" json:"id"`
+	Name          string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	PriceAmount   int64          `gorm:"column:price_amount;type:bigint;not null;default:0" json:"price_amount"`
+	PriceCurrency string         `gorm:"column:price_currency;type:varchar(3)" json:"price_currency"`
+	SellerId      uuid.UUID      `gorm:"column:seller_id;type:uuid;index" json:"seller_id"`
+	Seller        Seller         `gorm:"foreignKey:SellerId" json:"seller"`
+	Version       int32          `gorm:"column:version;type:integer;not null;default:1" json:"version"`
+	CreatedAt     time.Time      `gorm:"column:created_at;type:timestamp;not null" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at;type:timestamp;not null" json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"column:deleted_at;type:timestamp" json:"deleted_at"`
 }
 
 // TableName Product's table name
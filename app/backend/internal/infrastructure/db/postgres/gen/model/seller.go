@@ -17,6 +17,7 @@ const TableNameSeller = "sellers"
 type Seller struct {
 	Id        uuid.UUID      `gorm:"column:id;type:uuid;primaryKey" json:"id"`
 	Name      string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Version   int32          `gorm:"column:version;type:integer;not null;default:1" json:"version"`
 	CreatedAt time.Time      `gorm:"column:created_at;type:timestamp;not null" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"column:updated_at;type:timestamp;not null" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;type:timestamp" json:"deleted_at"`
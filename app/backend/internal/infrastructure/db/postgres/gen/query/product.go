@@ -28,8 +28,10 @@ func newProduct(db *gorm.DB, opts ...gen.DOOption) product {
 	_product.ALL = field.NewAsterisk(tableName)
 	_product.Id = field.NewField(tableName, "id")
 	_product.Name = field.NewField(tableName, "name")
-	_product.Price = field.NewField(tableName, "price")
+	_product.PriceAmount = field.NewField(tableName, "price_amount")
+	_product.PriceCurrency = field.NewField(tableName, "price_currency")
 	_product.SellerId = field.NewField(tableName, "seller_id")
+	_product.Version = field.NewField(tableName, "version")
 	_product.CreatedAt = field.NewField(tableName, "created_at")
 	_product.UpdatedAt = field.NewField(tableName, "updated_at")
 	_product.DeletedAt = field.NewField(tableName, "deleted_at")
@@ -42,14 +44,16 @@ func newProduct(db *gorm.DB, opts ...gen.DOOption) product {
 type product struct {
 	productDo
 
-	ALL       field.Asterisk
-	Id        field.Field
-	Name      field.Field
-	Price     field.Field
-	SellerId  field.Field
-	CreatedAt field.Field
-	UpdatedAt field.Field
-	DeletedAt field.Field
+	ALL           field.Asterisk
+	Id            field.Field
+	Name          field.Field
+	PriceAmount   field.Field
+	PriceCurrency field.Field
+	SellerId      field.Field
+	Version       field.Field
+	CreatedAt     field.Field
+	UpdatedAt     field.Field
+	DeletedAt     field.Field
 
 	fieldMap map[string]field.Expr
 }
@@ -68,8 +72,10 @@ func (p *product) updateTableName(table string) *product {
 	p.ALL = field.NewAsterisk(table)
 	p.Id = field.NewField(table, "id")
 	p.Name = field.NewField(table, "name")
-	p.Price = field.NewField(table, "price")
+	p.PriceAmount = field.NewField(table, "price_amount")
+	p.PriceCurrency = field.NewField(table, "price_currency")
 	p.SellerId = field.NewField(table, "seller_id")
+	p.Version = field.NewField(table, "version")
 	p.CreatedAt = field.NewField(table, "created_at")
 	p.UpdatedAt = field.NewField(table, "updated_at")
 	p.DeletedAt = field.NewField(table, "deleted_at")
@@ -89,11 +95,13 @@ func (p *product) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (p *product) fillFieldMap() {
-	p.fieldMap = make(map[string]field.Expr, 7)
+	p.fieldMap = make(map[string]field.Expr, 9)
 	p.fieldMap["id"] = p.Id
 	p.fieldMap["name"] = p.Name
-	p.fieldMap["price"] = p.Price
+	p.fieldMap["price_amount"] = p.PriceAmount
+	p.fieldMap["price_currency"] = p.PriceCurrency
 	p.fieldMap["seller_id"] = p.SellerId
+	p.fieldMap["version"] = p.Version
 	p.fieldMap["created_at"] = p.CreatedAt
 	p.fieldMap["updated_at"] = p.UpdatedAt
 	p.fieldMap["deleted_at"] = p.DeletedAt
@@ -339,4 +347,4 @@ func (p productDo) Delete(models ...*model.Product) (result gen.ResultInfo, err
 func (p *productDo) withDO(do gen.Dao) *productDo {
 	p.DO = *do.(*gen.DO)
 	return p
-}
\ No newline at end of file
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/migration"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+)
+
+// DatabaseHealthCheck checks that the database answers
+type DatabaseHealthCheck struct {
+	db *gorm.DB
+}
+
+// NewDatabaseHealthCheck creates a new DatabaseHealthCheck
+func NewDatabaseHealthCheck(db *gorm.DB) interfaces.HealthCheck {
+	return &DatabaseHealthCheck{db: db}
+}
+
+func (c *DatabaseHealthCheck) Name() string {
+	return "database"
+}
+
+// Check pings the database through the connection pool
+func (c *DatabaseHealthCheck) Check(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// MigrationHealthCheck checks that every schema migration of this build was applied
+type MigrationHealthCheck struct {
+	migrator *migration.Migrator
+}
+
+// NewMigrationHealthCheck creates a new MigrationHealthCheck
+func NewMigrationHealthCheck(migrator *migration.Migrator) interfaces.HealthCheck {
+	return &MigrationHealthCheck{migrator: migrator}
+}
+
+func (c *MigrationHealthCheck) Name() string {
+	return "migrations"
+}
+
+// Check reports the pending migrations, as well as applied migrations which were modified or are
+// unknown to this build, e.g. because a newer version of the service migrated the database
+func (c *MigrationHealthCheck) Check(ctx context.Context) error {
+	statuses, err := c.migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	problems := make(map[migration.State][]string)
+	for _, status := range statuses {
+		if status.State != migration.StateApplied {
+			problems[status.State] = append(problems[status.State], fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+
+	var errs []error
+	for _, state := range []migration.State{migration.StatePending, migration.StateModified, migration.StateUnknown} {
+		if len(problems[state]) > 0 {
+			errs = append(errs, fmt.Errorf("%s migrations %s", state, strings.Join(problems[state], ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SearchIndexHealthCheck checks that the product search is available. The first time it is, the products
+// stored while it was not, e.g. before pending migrations created the search tables, are indexed.
+type SearchIndexHealthCheck struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	indexed bool
+}
+
+// NewSearchIndexHealthCheck creates a new SearchIndexHealthCheck
+func NewSearchIndexHealthCheck(db *gorm.DB) interfaces.HealthCheck {
+	return &SearchIndexHealthCheck{db: db}
+}
+
+func (c *SearchIndexHealthCheck) Name() string {
+	return "search_index"
+}
+
+// Check reports why the product search is unavailable, or whether indexing the missing products failed
+func (c *SearchIndexHealthCheck) Check(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexed {
+		return nil
+	}
+	if err := IndexProductsForSearch(c.db.WithContext(ctx)); err != nil {
+		return err
+	}
+	c.indexed = true
+	return nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// IdempotencyRecordModel is the GORM model for the responses to requests sent with an idempotency key
+type IdempotencyRecordModel struct {
+	IdempotencyKey string `gorm:"primaryKey"`
+	UserID         string `gorm:"primaryKey"`
+	Route          string `gorm:"primaryKey"`
+	RequestHash    string
+	Token          string
+	ResponseStatus int
+	// ResponseHeaders is the JSON object of the replayed response headers
+	ResponseHeaders string
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time `gorm:"index"`
+}
+
+// TableName specifies the table name for IdempotencyRecordModel
+func (IdempotencyRecordModel) TableName() string {
+	return "idempotency_records"
+}
+
+// GormIdempotencyRepository implements the IdempotencyRepository interface using GORM v2
+type GormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyRepository creates a new GormIdempotencyRepository
+func NewGormIdempotencyRepository(db *gorm.DB) repositories.IdempotencyRepository {
+	return &GormIdempotencyRepository{db: db}
+}
+
+func toIdempotencyRecordModel(record *entities.IdempotencyRecord) (*IdempotencyRecordModel, error) {
+	headers, err := json.Marshal(record.ResponseHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IdempotencyRecordModel{
+		IdempotencyKey:  record.Key,
+		UserID:          record.UserId,
+		Route:           record.Route,
+		RequestHash:     record.RequestHash,
+		Token:           record.Token,
+		ResponseStatus:  record.ResponseStatus,
+		ResponseHeaders: string(headers),
+		ResponseBody:    record.ResponseBody,
+		CreatedAt:       record.CreatedAt,
+		ExpiresAt:       record.ExpiresAt,
+	}, nil
+}
+
+func fromIdempotencyRecordModel(model *IdempotencyRecordModel) (*entities.IdempotencyRecord, error) {
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(model.ResponseHeaders), &headers); err != nil {
+		return nil, err
+	}
+
+	return &entities.IdempotencyRecord{
+		Key:             model.IdempotencyKey,
+		UserId:          model.UserID,
+		Route:           model.Route,
+		RequestHash:     model.RequestHash,
+		Token:           model.Token,
+		ResponseStatus:  model.ResponseStatus,
+		ResponseHeaders: headers,
+		ResponseBody:    model.ResponseBody,
+		CreatedAt:       model.CreatedAt,
+		ExpiresAt:       model.ExpiresAt,
+	}, nil
+}
+
+// idempotencyKeyScope selects the record of the key, user and route
+func idempotencyKeyScope(record *entities.IdempotencyRecord) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("idempotency_key = ? AND user_id = ? AND route = ?", record.Key, record.UserId, record.Route)
+	}
+}
+
+// reservationScope selects the record of the key, user and route if the key is still reserved with the token of the record
+func reservationScope(record *entities.IdempotencyRecord) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(idempotencyKeyScope(record)).Where("token = ?", record.Token)
+	}
+}
+
+// Reserve stores the record unless a record with the same key, user and route exists which has not expired.
+// It returns that record instead, or nil if the record was stored.
+func (r *GormIdempotencyRepository) Reserve(ctx context.Context, record *entities.IdempotencyRecord) (*entities.IdempotencyRecord, error) {
+	model, err := toIdempotencyRecordModel(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *entities.IdempotencyRecord
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// An expired record no longer holds its key
+		if err := tx.Scopes(idempotencyKeyScope(record)).Where("expires_at <= ?", record.CreatedAt).Delete(&IdempotencyRecordModel{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(model)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		var existingModel IdempotencyRecordModel
+		if err := tx.Scopes(idempotencyKeyScope(record)).First(&existingModel).Error; err != nil {
+			return err
+		}
+		existing, err = fromIdempotencyRecordModel(&existingModel)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Save stores the response of a reserved record. It returns repositories.ErrIdempotencyKeyReleased if the key
+// is no longer reserved with the token of the record.
+func (r *GormIdempotencyRepository) Save(ctx context.Context, record *entities.IdempotencyRecord) error {
+	model, err := toIdempotencyRecordModel(record)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Model(&IdempotencyRecordModel{}).Scopes(reservationScope(record)).
+		Select("ResponseStatus", "ResponseHeaders", "ResponseBody").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrIdempotencyKeyReleased
+	}
+	return nil
+}
+
+// Delete removes a record, so that its key can be used again. Records which reserve the key
+// with another token are kept.
+func (r *GormIdempotencyRepository) Delete(ctx context.Context, record *entities.IdempotencyRecord) error {
+	return r.db.WithContext(ctx).Scopes(reservationScope(record)).Delete(&IdempotencyRecordModel{}).Error
+}
+
+// DeleteExpired removes records which expired before the given time
+func (r *GormIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&IdempotencyRecordModel{}).Error
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// LoginAttemptModel is the GORM model for failed logins per client IP
+type LoginAttemptModel struct {
+	ClientIP       string `gorm:"primaryKey"`
+	FailedAttempts int
+	LastFailedAt   time.Time
+	BlockedUntil   time.Time
+}
+
+// TableName specifies the table name for LoginAttemptModel
+func (LoginAttemptModel) TableName() string {
+	return "login_attempts"
+}
+
+// GormLoginAttemptRepository is a PostgreSQL implementation of the LoginAttemptRepository interface
+type GormLoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewGormLoginAttemptRepository creates a new GormLoginAttemptRepository
+func NewGormLoginAttemptRepository(db *gorm.DB) repositories.LoginAttemptRepository {
+	return &GormLoginAttemptRepository{db: db}
+}
+
+// FindByClientIP returns the login attempts of the client IP, or nil if there are none
+func (r *GormLoginAttemptRepository) FindByClientIP(ctx context.Context, clientIP string) (*entities.LoginAttempt, error) {
+	var model LoginAttemptModel
+	if err := r.db.WithContext(ctx).Where("client_ip = ?", clientIP).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toLoginAttemptEntity(&model), nil
+}
+
+// RecordFailure atomically counts a failed login of the client IP and returns the updated attempts.
+// The count restarts at one if the previous failure is before now minus window.
+func (r *GormLoginAttemptRepository) RecordFailure(ctx context.Context, clientIP string, now time.Time, window time.Duration) (*entities.LoginAttempt, error) {
+	model := &LoginAttemptModel{ClientIP: clientIP, FailedAttempts: 1, LastFailedAt: now}
+	err := r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "client_ip"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_attempts": gorm.Expr(
+					"CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failed_attempts + 1 END",
+					now.Add(-window),
+				),
+				"last_failed_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(model).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toLoginAttemptEntity(model), nil
+}
+
+// Block rejects logins from the client IP until the given time and resets its failure count
+func (r *GormLoginAttemptRepository) Block(ctx context.Context, clientIP string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&LoginAttemptModel{}).Where("client_ip = ?", clientIP).Updates(map[string]interface{}{
+		"blocked_until":   until,
+		"failed_attempts": 0,
+	}).Error
+}
+
+func toLoginAttemptEntity(model *LoginAttemptModel) *entities.LoginAttempt {
+	return &entities.LoginAttempt{
+		ClientIP:       model.ClientIP,
+		FailedAttempts: model.FailedAttempts,
+		LastFailedAt:   model.LastFailedAt,
+		BlockedUntil:   model.BlockedUntil,
+	}
+}
+
+// Delete forgets the login attempts of the client IP
+func (r *GormLoginAttemptRepository) Delete(ctx context.Context, clientIP string) error {
+	return r.db.WithContext(ctx).Where("client_ip = ?", clientIP).Delete(&LoginAttemptModel{}).Error
+}
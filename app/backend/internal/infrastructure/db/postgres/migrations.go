@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"embed"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/migration"
+	"gorm.io/gorm"
+	"io/fs"
+)
+
+// MigrationsDir is the directory of the SQL migrations relative to the backend module, new migrations are created there
+const MigrationsDir = "internal/infrastructure/db/postgres/migrations"
+
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// Migrations returns the schema migrations of the PostgreSQL database
+func Migrations() ([]migration.Migration, error) {
+	source, err := fs.Sub(sqlMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := migration.LoadSQL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(migrations,
+		// Reverting leaves the converted prices in place, the float prices cannot be restored exactly
+		migration.NewGoMigration(2, "convert_legacy_prices", ConvertLegacyPrices, func(tx *gorm.DB) error { return nil }),
+	), nil
+}
+
+// NewMigrator creates a new Migrator for the schema migrations of the PostgreSQL database
+func NewMigrator(db *gorm.DB) (*migration.Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return migration.NewMigrator(db, migrations)
+}
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS product_search_documents;
+DROP TABLE IF EXISTS "product_search_terms";
+DROP TABLE IF EXISTS "idempotency_records";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_subscriptions";
+DROP TABLE IF EXISTS "outbox_messages";
+DROP TABLE IF EXISTS "exchange_rates";
+DROP TABLE IF EXISTS "seller_memberships";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "login_attempts";
+DROP TABLE IF EXISTS "revoked_tokens";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "products";
+DROP TABLE IF EXISTS "sellers";
@@ -0,0 +1,197 @@
+-- Schema of the marketplace as previously created by GORM AutoMigrate on startup.
+-- Databases of earlier releases already have the sellers, products and users tables, but without the
+-- columns added since. Those are added with defaults for the existing rows.
+
+CREATE TABLE IF NOT EXISTS "sellers" (
+	"id" text,
+	"name" text,
+	"version" bigint NOT NULL DEFAULT 1,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+ALTER TABLE "sellers" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS "products" (
+	"id" text,
+	"name" text,
+	"price_amount" bigint NOT NULL DEFAULT 0,
+	"price_currency" varchar(3),
+	"seller_id" text,
+	"version" bigint NOT NULL DEFAULT 1,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_products_seller" FOREIGN KEY ("seller_id") REFERENCES "sellers"("id")
+);
+-- Earlier releases stored the price as float, 0002_convert_legacy_prices converts it
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "price_amount" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "price_currency" varchar(3);
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS "idx_products_seller_id" ON "products" ("seller_id");
+
+CREATE TABLE IF NOT EXISTS "users" (
+	"id" text,
+	"username" text,
+	"email" text,
+	"password_hash" text,
+	"role" text,
+	"status" text,
+	"created_at" bigint,
+	"updated_at" bigint,
+	"status_changed_at" bigint,
+	"status_reason" text,
+	"failed_login_attempts" bigint,
+	"locked_until" bigint,
+	"version" bigint NOT NULL DEFAULT 1,
+	PRIMARY KEY ("id")
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "status_changed_at" bigint DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "status_reason" text DEFAULT '';
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "failed_login_attempts" bigint DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "locked_until" bigint DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+	"id" text,
+	"user_id" text,
+	"family_id" text,
+	"token_hash" text,
+	"expires_at" timestamptz,
+	"created_at" timestamptz,
+	"revoked_at" timestamptz,
+	"replaced_by_id" text,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+	"token_id" text,
+	"expires_at" timestamptz,
+	"created_at" timestamptz,
+	PRIMARY KEY ("token_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+	"client_ip" text,
+	"failed_attempts" bigint,
+	"last_failed_at" timestamptz,
+	"blocked_until" timestamptz,
+	PRIMARY KEY ("client_ip")
+);
+
+CREATE TABLE IF NOT EXISTS "roles" (
+	"name" text,
+	"description" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+	"role_name" text,
+	"permission" text,
+	PRIMARY KEY ("role_name", "permission"),
+	CONSTRAINT "fk_roles_permissions" FOREIGN KEY ("role_name") REFERENCES "roles"("name") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "seller_memberships" (
+	"seller_id" text,
+	"user_id" text,
+	"role" text,
+	"created_at" timestamptz,
+	PRIMARY KEY ("seller_id", "user_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_seller_memberships_user_id" ON "seller_memberships" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+	"base_currency" varchar(3),
+	"quote_currency" varchar(3),
+	"effective_date" timestamptz,
+	"rate" text NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("base_currency", "quote_currency", "effective_date")
+);
+
+CREATE TABLE IF NOT EXISTS "outbox_messages" (
+	"id" bigserial,
+	"event_name" text,
+	"aggregate_id" text,
+	"payload" text,
+	"occurred_at" timestamptz,
+	"created_at" timestamptz,
+	"attempts" bigint,
+	"next_attempt_at" timestamptz,
+	"last_error" text,
+	"delivered_at" timestamptz,
+	"failed_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_delivered_at" ON "outbox_messages" ("delivered_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_next_attempt_at" ON "outbox_messages" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_aggregate_id" ON "outbox_messages" ("aggregate_id");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_event_name" ON "outbox_messages" ("event_name");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+	"id" text,
+	"url" text,
+	"event_types" text,
+	"secret" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+	"id" text,
+	"subscription_id" text,
+	"event_id" text,
+	"event_type" text,
+	"payload" text,
+	"status" text,
+	"attempts" bigint,
+	"next_attempt_at" timestamptz,
+	"response_status" bigint,
+	"last_error" text,
+	"replay_of" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"delivered_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status" ON "webhook_deliveries" ("status");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_event" ON "webhook_deliveries" ("subscription_id", "event_id");
+
+CREATE TABLE IF NOT EXISTS "idempotency_records" (
+	"idempotency_key" text,
+	"user_id" text,
+	"route" text,
+	"request_hash" text,
+	"response_status" bigint,
+	"response_headers" text,
+	"response_body" bytea,
+	"created_at" timestamptz,
+	"expires_at" timestamptz,
+	PRIMARY KEY ("idempotency_key", "user_id", "route")
+);
+CREATE INDEX IF NOT EXISTS "idx_idempotency_records_expires_at" ON "idempotency_records" ("expires_at");
+
+-- Full-text search, the terms are tokenized by the application
+CREATE TABLE IF NOT EXISTS "product_search_terms" (
+	"term" text,
+	"product_id" text,
+	PRIMARY KEY ("term", "product_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_search_terms_product_id" ON "product_search_terms" ("product_id");
+
+CREATE TABLE IF NOT EXISTS product_search_documents (
+	product_id uuid PRIMARY KEY,
+	terms text NOT NULL,
+	search_vector tsvector GENERATED ALWAYS AS (array_to_tsvector(string_to_array(terms, ' '))) STORED
+);
+CREATE INDEX IF NOT EXISTS idx_product_search_documents_vector ON product_search_documents USING GIN (search_vector);
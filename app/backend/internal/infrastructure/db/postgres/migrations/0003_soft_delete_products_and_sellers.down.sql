@@ -0,0 +1,10 @@
+-- Soft deleted rows would reappear once the column is gone, so they are removed for good
+DELETE FROM "products" WHERE "deleted_at" IS NOT NULL OR "seller_id" IN (SELECT "id" FROM "sellers" WHERE "deleted_at" IS NOT NULL);
+DELETE FROM "seller_memberships" WHERE "seller_id" IN (SELECT "id" FROM "sellers" WHERE "deleted_at" IS NOT NULL);
+DELETE FROM "sellers" WHERE "deleted_at" IS NOT NULL;
+
+DROP INDEX IF EXISTS "idx_products_deleted_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "deleted_at";
+
+DROP INDEX IF EXISTS "idx_sellers_deleted_at";
+ALTER TABLE "sellers" DROP COLUMN IF EXISTS "deleted_at";
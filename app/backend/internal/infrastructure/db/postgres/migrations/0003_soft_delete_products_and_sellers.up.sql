@@ -0,0 +1,5 @@
+ALTER TABLE "sellers" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_sellers_deleted_at" ON "sellers" ("deleted_at");
+
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
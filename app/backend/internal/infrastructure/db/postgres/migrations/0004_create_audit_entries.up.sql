@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "audit_entries" (
+	"id" text,
+	"actor_id" text,
+	"action" text,
+	"aggregate_type" text,
+	"aggregate_id" text,
+	"changes" text,
+	"request_id" text,
+	"occurred_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_actor_id" ON "audit_entries" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_aggregate" ON "audit_entries" ("aggregate_type", "aggregate_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_request_id" ON "audit_entries" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_occurred_at" ON "audit_entries" ("occurred_at");
+
+-- The audit trail is append-only, the database refuses to change or remove entries
+CREATE OR REPLACE FUNCTION "reject_audit_entry_change"() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_entries_append_only"
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON "audit_entries"
+	FOR EACH STATEMENT EXECUTE FUNCTION "reject_audit_entry_change"();
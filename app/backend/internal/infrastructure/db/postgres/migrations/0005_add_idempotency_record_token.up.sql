@@ -0,0 +1,2 @@
+-- The token identifies the request which reserved a key, existing reservations get an empty token
+ALTER TABLE "idempotency_records" ADD COLUMN IF NOT EXISTS "token" text NOT NULL DEFAULT '';
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// OutboxMessageModel is the GORM model for domain events waiting to be delivered
+type OutboxMessageModel struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement"`
+	EventName   string `gorm:"index"`
+	AggregateId string `gorm:"index"`
+	Payload     string
+	OccurredAt  time.Time
+	CreatedAt   time.Time
+	// Attempts counts the failed deliveries
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+	// DeliveredAt is set once all subscribers handled the event
+	DeliveredAt *time.Time `gorm:"index"`
+	// FailedAt is set when the dispatcher gave up on the event
+	FailedAt *time.Time
+}
+
+// TableName specifies the table name for OutboxMessageModel
+func (OutboxMessageModel) TableName() string {
+	return "outbox_messages"
+}
+
+// saveDomainEvents stores the events in the outbox. It must be called with the transaction
+// which persists the aggregate, so that the events are only stored if the aggregate is.
+func saveDomainEvents(tx *gorm.DB, events []entities.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	models := make([]OutboxMessageModel, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode domain event %s: %w", event.EventName(), err)
+		}
+		models[i] = OutboxMessageModel{
+			EventName:     event.EventName(),
+			AggregateId:   event.AggregateId(),
+			Payload:       string(payload),
+			OccurredAt:    event.OccurredAt(),
+			NextAttemptAt: now,
+		}
+	}
+
+	return tx.Create(&models).Error
+}
+
+// GormOutboxRepository implements the OutboxRepository interface using GORM v2
+type GormOutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewGormOutboxRepository creates a new GormOutboxRepository
+func NewGormOutboxRepository(db *gorm.DB) repositories.OutboxRepository {
+	return &GormOutboxRepository{db: db}
+}
+
+// FindDue retrieves up to limit undelivered messages which are due at the given time, oldest first
+func (repo *GormOutboxRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*repositories.OutboxMessage, error) {
+	var models []OutboxMessageModel
+	err := repo.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*repositories.OutboxMessage, len(models))
+	for i, model := range models {
+		messages[i] = &repositories.OutboxMessage{
+			Id:          model.ID,
+			EventName:   model.EventName,
+			AggregateId: model.AggregateId,
+			Payload:     []byte(model.Payload),
+			OccurredAt:  model.OccurredAt,
+			Attempts:    model.Attempts,
+		}
+	}
+	return messages, nil
+}
+
+// MarkDelivered records that all subscribers handled the message
+func (repo *GormOutboxRepository) MarkDelivered(ctx context.Context, id int64, at time.Time) error {
+	return repo.db.WithContext(ctx).Model(&OutboxMessageModel{}).Where("id = ?", id).Update("delivered_at", at).Error
+}
+
+// MarkFailed records a failed delivery and schedules the next attempt, a zero retryAt gives up on the message
+func (repo *GormOutboxRepository) MarkFailed(ctx context.Context, id int64, deliveryErr error, retryAt time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": deliveryErr.Error(),
+	}
+	if retryAt.IsZero() {
+		updates["failed_at"] = time.Now()
+	} else {
+		updates["next_attempt_at"] = retryAt
+	}
+
+	return repo.db.WithContext(ctx).Model(&OutboxMessageModel{}).Where("id = ?", id).Updates(updates).Error
+}
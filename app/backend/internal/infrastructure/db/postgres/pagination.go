@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sortColumn maps a sort field to its column and converts the column value of a row to and from cursors
+type sortColumn[T any] struct {
+	column string
+	format func(row *T) string
+	parse  func(value string) (interface{}, error)
+}
+
+// pageCursor is the position after the last row of a page. It is encoded as opaque base64 JSON.
+type pageCursor struct {
+	Field      repositories.SortField `json:"f"`
+	Descending bool                   `json:"d"`
+	Value      string                 `json:"v"`
+	Id         uuid.UUID              `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(value string, sort repositories.SortOrder) (*pageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, repositories.ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, repositories.ErrInvalidCursor
+	}
+	if cursor.Field != sort.Field || cursor.Descending != sort.Descending {
+		return nil, fmt.Errorf("%w: cursor belongs to another sort order", repositories.ErrInvalidCursor)
+	}
+
+	return &cursor, nil
+}
+
+// findPage orders the query, selects the requested page and returns its rows and the cursor of the next page.
+// One row more than requested is loaded to find out whether there is a next page.
+func findPage[T any](query *gorm.DB, column sortColumn[T], idOf func(row *T) uuid.UUID, sort repositories.SortOrder, page repositories.PageRequest) ([]T, string, error) {
+	direction, comparison := "ASC", ">"
+	if sort.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page.Cursor, sort)
+		if err != nil {
+			return nil, "", err
+		}
+		value, err := column.parse(cursor.Value)
+		if err != nil {
+			return nil, "", repositories.ErrInvalidCursor
+		}
+		query = query.Where(
+			fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", column.column, comparison),
+			value, value, cursor.Id,
+		)
+	} else if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+
+	var rows []T
+	err := query.
+		Order(column.column + " " + direction).
+		Order("id " + direction).
+		Limit(page.Limit + 1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(rows) <= page.Limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:page.Limit]
+	last := &rows[len(rows)-1]
+	nextCursor := encodeCursor(pageCursor{
+		Field:      sort.Field,
+		Descending: sort.Descending,
+		Value:      column.format(last),
+		Id:         idOf(last),
+	})
+
+	return rows, nextCursor, nil
+}
+
+// containsPattern returns a case-insensitive LIKE pattern matching values that contain the text
+func containsPattern(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(text))
+	return "%" + escaped + "%"
+}
+
+func parseStringCursor(value string) (interface{}, error) {
+	return value, nil
+}
+
+func parseInt64Cursor(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseTimeCursor(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func formatTimeCursor(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
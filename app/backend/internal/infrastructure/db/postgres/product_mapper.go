@@ -2,15 +2,19 @@ package postgres
 
 import (
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"gorm.io/gorm"
+	"time"
 )
 
 func toDBProduct(product *entities.ValidatedProduct) *Product {
 	var p = &Product{
-		Name:      product.Name,
-		Price:     product.Price,
-		SellerId:  product.Seller.Id, // Ensure Seller is non-nil when mapping
-		CreatedAt: product.CreatedAt,
-		UpdatedAt: product.UpdatedAt,
+		Name:          product.Name,
+		PriceAmount:   product.Price.Amount,
+		PriceCurrency: string(product.Price.Currency),
+		SellerId:      product.Seller.Id, // Ensure Seller is non-nil when mapping
+		Version:       product.Version,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
 	}
 	p.Id = product.Id
 
@@ -21,18 +25,33 @@ func fromDBProduct(dbProduct *Product) *entities.Product {
 	var seller = &entities.Seller{
 		Id:        dbProduct.Seller.Id,
 		Name:      dbProduct.Seller.Name,
+		Version:   dbProduct.Seller.Version,
 		CreatedAt: dbProduct.Seller.CreatedAt,
 		UpdatedAt: dbProduct.Seller.UpdatedAt,
+		DeletedAt: fromDBDeletedAt(dbProduct.Seller.DeletedAt),
 	}
 
 	var p = &entities.Product{
-		Name:      dbProduct.Name,
-		Price:     dbProduct.Price,
+		Name: dbProduct.Name,
+		Price: entities.Money{
+			Amount:   dbProduct.PriceAmount,
+			Currency: entities.Currency(dbProduct.PriceCurrency),
+		},
 		Seller:    *seller,
+		Version:   dbProduct.Version,
 		CreatedAt: dbProduct.CreatedAt,
 		UpdatedAt: dbProduct.UpdatedAt,
+		DeletedAt: fromDBDeletedAt(dbProduct.DeletedAt),
 	}
 	p.Id = dbProduct.Id
 
 	return p
 }
+
+// fromDBDeletedAt returns the deletion time of a soft deleted row, or nil if the row is not deleted
+func fromDBDeletedAt(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// legacyPriceColumn held product prices as float before prices were stored in minor units
+const legacyPriceColumn = "price"
+
+// legacyProductColumns were added to the products and sellers tables after the legacy price column
+var legacyProductColumns = []struct {
+	model interface{}
+	field string
+}{
+	{&Product{}, "PriceAmount"},
+	{&Product{}, "PriceCurrency"},
+	{&Product{}, "Version"},
+	{&Seller{}, "Version"},
+}
+
+// ConvertLegacyPrices upgrades a products table which still stores prices as float.
+// Rows with a legacy float price are converted to minor units of the default currency,
+// rounding half away from zero, and the legacy column is dropped afterwards.
+// It must run in a transaction and does nothing for other tables.
+func ConvertLegacyPrices(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&Product{}, legacyPriceColumn) {
+		return nil
+	}
+
+	for _, column := range legacyProductColumns {
+		if tx.Migrator().HasColumn(column.model, column.field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(column.model, column.field); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", column.field, err)
+		}
+	}
+
+	var legacyPrices []struct {
+		Id    uuid.UUID
+		Price float64
+	}
+	if err := tx.Table("products").Select("id, " + legacyPriceColumn).Where(legacyPriceColumn + " IS NOT NULL").Scan(&legacyPrices).Error; err != nil {
+		return fmt.Errorf("failed to read legacy prices: %w", err)
+	}
+
+	// Convert in Go rather than SQL so that all databases round the same way as the domain
+	for _, legacyPrice := range legacyPrices {
+		price, err := entities.NewMoneyFromFloat(legacyPrice.Price, entities.DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("failed to convert legacy price of product %s: %w", legacyPrice.Id, err)
+		}
+
+		// The table is named explicitly, the model has columns which later migrations add
+		err = tx.Table("products").Where("id = ?", legacyPrice.Id).Updates(map[string]interface{}{
+			"price_amount":   price.Amount,
+			"price_currency": string(price.Currency),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to convert legacy price of product %s: %w", legacyPrice.Id, err)
+		}
+	}
+
+	return tx.Exec("ALTER TABLE products DROP COLUMN " + legacyPriceColumn).Error
+}
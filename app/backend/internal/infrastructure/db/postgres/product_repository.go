@@ -1,39 +1,71 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"time"
 )
 
-// GormProductRepository implements the ProductRepository interface using GORM v2
+// GormProductRepository implements the ProductRepository interface using GORM v2.
+// It keeps the product search index up to date with the persisted products.
 type GormProductRepository struct {
-	db *gorm.DB
+	db            *gorm.DB
+	searchBackend *lazySearchBackend
 }
 
 // NewGormProductRepository creates a new GormProductRepository
 func NewGormProductRepository(db *gorm.DB) repositories.ProductRepository {
-	return &GormProductRepository{db: db}
+	return &GormProductRepository{db: db, searchBackend: newLazySearchBackend()}
 }
 
 // Create creates a new product
-func (repo *GormProductRepository) Create(product *entities.ValidatedProduct) (*entities.Product, error) {
+func (repo *GormProductRepository) Create(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error) {
 	// Map domain entity to DB model
 	dbProduct := toDBProduct(product)
+	dbProduct.Version = 1
 
-	if err := repo.db.Create(dbProduct).Error; err != nil {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbProduct).Error; err != nil {
+			return translateError(tx, err)
+		}
+		if err := saveDomainEvents(tx, product.PendingEvents()); err != nil {
+			return err
+		}
+		return indexProductForSearch(tx, repo.searchBackend.get(tx), dbProduct.Id, dbProduct.Name)
+	})
+	if err != nil {
 		return nil, err
 	}
+	product.Version = dbProduct.Version
+	product.ClearEvents()
 
 	// Read row from DB to never return different data than persisted
-	return repo.FindById(dbProduct.Id)
+	return repo.FindById(ctx, dbProduct.Id)
 }
 
 // FindById finds a product by ID
-func (repo *GormProductRepository) FindById(id uuid.UUID) (*entities.Product, error) {
+func (repo *GormProductRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
+	return repo.findById(repo.db.WithContext(ctx), id)
+}
+
+// FindByIdIncludingDeleted finds a product by ID, even if it is soft deleted
+func (repo *GormProductRepository) FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
+	return repo.findById(repo.db.WithContext(ctx).Unscoped(), id)
+}
+
+func (repo *GormProductRepository) findById(db *gorm.DB, id uuid.UUID) (*entities.Product, error) {
 	var dbProduct Product
-	if err := repo.db.Preload("Seller").First(&dbProduct, id).Error; err != nil {
+	if err := db.Scopes(preloadSeller).First(&dbProduct, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrProductNotFound
+		}
 		return nil, err
 	}
 
@@ -41,11 +73,16 @@ func (repo *GormProductRepository) FindById(id uuid.UUID) (*entities.Product, er
 	return fromDBProduct(&dbProduct), nil
 }
 
+// preloadSeller loads the seller of the products, which is soft deleted together with its products
+func preloadSeller(db *gorm.DB) *gorm.DB {
+	return db.Preload("Seller", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+}
+
 // FindAll finds all products
-func (repo *GormProductRepository) FindAll() ([]*entities.Product, error) {
+func (repo *GormProductRepository) FindAll(ctx context.Context) ([]*entities.Product, error) {
 	var dbProducts []Product
 
-	if err := repo.db.Preload("Seller").Find(&dbProducts).Error; err != nil {
+	if err := repo.db.WithContext(ctx).Scopes(preloadSeller).Find(&dbProducts).Error; err != nil {
 		return nil, err
 	}
 
@@ -56,19 +93,223 @@ func (repo *GormProductRepository) FindAll() ([]*entities.Product, error) {
 	return products, nil
 }
 
+// productSortColumns maps the sort fields of product listings to their columns
+var productSortColumns = map[repositories.SortField]sortColumn[Product]{
+	repositories.SortByName: {
+		column: "name",
+		format: func(row *Product) string { return row.Name },
+		parse:  parseStringCursor,
+	},
+	// Prices are ordered by amount in minor units, products priced in other currencies are not converted
+	repositories.SortByPrice: {
+		column: "price_amount",
+		format: func(row *Product) string { return strconv.FormatInt(row.PriceAmount, 10) },
+		parse:  parseInt64Cursor,
+	},
+	repositories.SortByCreatedAt: {
+		column: "created_at",
+		format: func(row *Product) string { return formatTimeCursor(row.CreatedAt) },
+		parse:  parseTimeCursor,
+	},
+}
+
+// FindPage finds one page of the products matching the filter
+func (repo *GormProductRepository) FindPage(ctx context.Context, filter repositories.ProductFilter, sort repositories.SortOrder, page repositories.PageRequest) (*repositories.ProductPage, error) {
+	column, ok := productSortColumns[sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort products by %q", sort.Field)
+	}
+
+	var totalCount int64
+	if err := repo.db.WithContext(ctx).Model(&Product{}).Scopes(productFilterScope(filter)).Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	query := repo.db.WithContext(ctx).Scopes(preloadSeller, productFilterScope(filter))
+	dbProducts, nextCursor, err := findPage(query, column, func(row *Product) uuid.UUID { return row.Id }, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*entities.Product, len(dbProducts))
+	for i, dbProduct := range dbProducts {
+		products[i] = fromDBProduct(&dbProduct)
+	}
+
+	return &repositories.ProductPage{
+		Products:   products,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func productFilterScope(filter repositories.ProductFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.IncludeDeleted {
+			db = db.Unscoped()
+		}
+		if filter.NameContains != "" {
+			db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, containsPattern(filter.NameContains))
+		}
+		if filter.SellerId != nil {
+			db = db.Where("seller_id = ?", *filter.SellerId)
+		}
+		if filter.MinPrice != nil {
+			db = db.Where("price_currency = ? AND price_amount >= ?", string(filter.MinPrice.Currency), filter.MinPrice.Amount)
+		}
+		if filter.MaxPrice != nil {
+			db = db.Where("price_currency = ? AND price_amount <= ?", string(filter.MaxPrice.Currency), filter.MaxPrice.Amount)
+		}
+		if filter.CreatedAfter != nil {
+			db = db.Where("created_at >= ?", *filter.CreatedAfter)
+		}
+		if filter.CreatedBefore != nil {
+			db = db.Where("created_at < ?", *filter.CreatedBefore)
+		}
+		return db
+	}
+}
+
 // Update updates a product
-func (repo *GormProductRepository) Update(product *entities.ValidatedProduct) (*entities.Product, error) {
+func (repo *GormProductRepository) Update(ctx context.Context, product *entities.ValidatedProduct) (*entities.Product, error) {
 	dbProduct := toDBProduct(product)
-	err := repo.db.Model(&Product{}).Where("id = ?", dbProduct.Id).Updates(dbProduct).Error
+	dbProduct.Version = product.Version + 1
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Product{}).Where("id = ? AND version = ?", dbProduct.Id, product.Version).Updates(dbProduct)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionConflictError(tx, &Product{}, dbProduct.Id, repositories.ErrProductNotFound)
+		}
+		if err := saveDomainEvents(tx, product.PendingEvents()); err != nil {
+			return err
+		}
+		return indexProductForSearch(tx, repo.searchBackend.get(tx), dbProduct.Id, dbProduct.Name)
+	})
 	if err != nil {
 		return nil, err
 	}
+	product.Version = dbProduct.Version
+	product.ClearEvents()
 
 	// Read row from DB to never return different data than persisted
-	return repo.FindById(dbProduct.Id)
+	return repo.FindById(ctx, dbProduct.Id)
+}
+
+// Delete soft deletes a product if it still has the version that was read and removes it from the search index
+func (repo *GormProductRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND version = ?", id, version).Delete(&Product{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionConflictError(tx, &Product{}, id, repositories.ErrProductNotFound)
+		}
+		if err := saveDomainEvents(tx, []entities.DomainEvent{entities.ProductDeleted{ProductId: id, At: time.Now()}}); err != nil {
+			return err
+		}
+		return removeProductFromSearch(tx, repo.searchBackend.get(tx), id)
+	})
+}
+
+// DeleteBySeller soft deletes all products of the seller and removes them from the search index
+func (repo *GormProductRepository) DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Delete and collect the products in one statement, so that products added or deleted concurrently
+		// are neither left behind nor announced twice
+		var deleted []Product
+		err := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("seller_id = ?", sellerId).Delete(&deleted).Error
+		if err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		events := make([]entities.DomainEvent, len(deleted))
+		for i, product := range deleted {
+			events[i] = entities.ProductDeleted{ProductId: product.Id, At: now}
+		}
+		if err := saveDomainEvents(tx, events); err != nil {
+			return err
+		}
+
+		for _, product := range deleted {
+			if err := removeProductFromSearch(tx, repo.searchBackend.get(tx), product.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Restore restores a soft deleted product and adds it to the search index again
+func (repo *GormProductRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.Product, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dbProducts []Product
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&dbProducts).Error; err != nil {
+			return err
+		}
+		if len(dbProducts) == 0 {
+			return repositories.ErrProductNotFound
+		}
+		return restoreProducts(tx, repo.searchBackend.get(tx), dbProducts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.FindById(ctx, id)
+}
+
+// RestoreBySeller restores the products of the seller which were soft deleted at or after the given time
+func (repo *GormProductRepository) RestoreBySeller(ctx context.Context, sellerId uuid.UUID, deletedSince time.Time) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dbProducts []Product
+		err := tx.Unscoped().Where("seller_id = ? AND deleted_at >= ?", sellerId, deletedSince).Find(&dbProducts).Error
+		if err != nil || len(dbProducts) == 0 {
+			return err
+		}
+		return restoreProducts(tx, repo.searchBackend.get(tx), dbProducts)
+	})
+}
+
+// restoreProducts clears the deletion time of the products, which counts as a change of their version
+func restoreProducts(tx *gorm.DB, backend searchBackend, dbProducts []Product) error {
+	now := time.Now()
+	ids := make([]uuid.UUID, len(dbProducts))
+	events := make([]entities.DomainEvent, len(dbProducts))
+	for i, dbProduct := range dbProducts {
+		ids[i] = dbProduct.Id
+		events[i] = entities.ProductRestored{ProductId: dbProduct.Id, At: now}
+	}
+
+	err := tx.Unscoped().Model(&Product{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": now,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := saveDomainEvents(tx, events); err != nil {
+		return err
+	}
+
+	for _, dbProduct := range dbProducts {
+		if err := indexProductForSearch(tx, backend, dbProduct.Id, dbProduct.Name); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Delete deletes a product
-func (repo *GormProductRepository) Delete(id uuid.UUID) error {
-	return repo.db.Delete(&Product{}, id).Error
+// Purge permanently removes the products which were soft deleted before the given time
+func (repo *GormProductRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Unscoped().Where("deleted_at < ?", deletedBefore).Delete(&Product{})
+	return result.RowsAffected, result.Error
 }
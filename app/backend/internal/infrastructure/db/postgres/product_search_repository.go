@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProductSearchTerm lists the terms of every indexed product. It is used to find
+// similar terms for typo tolerance and to find products missing from the index.
+type ProductSearchTerm struct {
+	Term      string    `gorm:"primaryKey"`
+	ProductId uuid.UUID `gorm:"primaryKey;index"`
+}
+
+// TableName specifies the table name for ProductSearchTerm
+func (ProductSearchTerm) TableName() string {
+	return "product_search_terms"
+}
+
+// searchBackend is the full-text engine of a database. Terms are tokenized by
+// entities.TokenizeSearchText beforehand, so engines must not split or stem them again.
+type searchBackend interface {
+	// table is the table of the engine, it is created by the schema migrations
+	table() string
+	index(tx *gorm.DB, productId uuid.UUID, terms []string) error
+	remove(tx *gorm.DB, productId uuid.UUID) error
+	search(db *gorm.DB, groups []entities.SearchTermGroup, limit, offset int) ([]searchMatch, int64, error)
+}
+
+type searchMatch struct {
+	ProductId uuid.UUID
+	Rank      float64
+}
+
+// resolveSearchBackend returns the full-text engine of the database. It returns an error wrapping
+// repositories.ErrProductSearchUnavailable if the database does not support full-text search or
+// its tables do not exist, e.g. because the schema migrations are still pending.
+func resolveSearchBackend(db *gorm.DB) (searchBackend, error) {
+	var backend searchBackend
+	switch db.Dialector.Name() {
+	case "postgres":
+		backend = postgresSearchBackend{}
+	case "sqlite":
+		backend = sqliteSearchBackend{}
+	default:
+		return nil, fmt.Errorf("%w for %s databases", repositories.ErrProductSearchUnavailable, db.Dialector.Name())
+	}
+
+	if !db.Migrator().HasTable(&ProductSearchTerm{}) || !db.Migrator().HasTable(backend.table()) {
+		return nil, fmt.Errorf("%w: the search tables do not exist", repositories.ErrProductSearchUnavailable)
+	}
+
+	return backend, nil
+}
+
+// lazySearchBackend resolves the full-text engine on first use and retries until it is available,
+// so that the search starts working once pending migrations created its tables
+type lazySearchBackend struct {
+	mu      sync.Mutex
+	backend searchBackend
+	logged  bool
+}
+
+func newLazySearchBackend() *lazySearchBackend {
+	return &lazySearchBackend{}
+}
+
+// get returns the full-text engine, or nil while it is unavailable
+func (l *lazySearchBackend) get(db *gorm.DB) searchBackend {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.backend != nil {
+		return l.backend
+	}
+
+	backend, err := resolveSearchBackend(db)
+	if err != nil {
+		if !l.logged {
+			log.Printf("Product search is disabled until it is available: %v", err)
+			l.logged = true
+		}
+		return nil
+	}
+	l.backend = backend
+	return backend
+}
+
+// indexProductForSearch replaces the indexed terms of the product
+func indexProductForSearch(tx *gorm.DB, backend searchBackend, productId uuid.UUID, name string) error {
+	if backend == nil {
+		return nil
+	}
+
+	var terms []string
+	seen := map[string]bool{}
+	for _, token := range entities.TokenizeSearchText(name) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+
+	if err := tx.Where("product_id = ?", productId).Delete(&ProductSearchTerm{}).Error; err != nil {
+		return err
+	}
+	if len(terms) > 0 {
+		searchTerms := make([]ProductSearchTerm, len(terms))
+		for i, term := range terms {
+			searchTerms[i] = ProductSearchTerm{Term: term, ProductId: productId}
+		}
+		if err := tx.Create(&searchTerms).Error; err != nil {
+			return err
+		}
+	}
+
+	return backend.index(tx, productId, terms)
+}
+
+// removeProductFromSearch removes the product from the index
+func removeProductFromSearch(tx *gorm.DB, backend searchBackend, productId uuid.UUID) error {
+	if backend == nil {
+		return nil
+	}
+
+	if err := tx.Where("product_id = ?", productId).Delete(&ProductSearchTerm{}).Error; err != nil {
+		return err
+	}
+	return backend.remove(tx, productId)
+}
+
+// IndexProductsForSearch adds products to the search index which were stored before the index existed.
+// It returns an error wrapping repositories.ErrProductSearchUnavailable if there is no index yet.
+func IndexProductsForSearch(db *gorm.DB) error {
+	backend, err := resolveSearchBackend(db)
+	if err != nil {
+		return err
+	}
+
+	var products []Product
+	err = db.Where("id NOT IN (?)", db.Model(&ProductSearchTerm{}).Select("product_id")).Find(&products).Error
+	if err != nil {
+		return fmt.Errorf("failed to find products missing from the search index: %w", err)
+	}
+
+	for _, product := range products {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return indexProductForSearch(tx, backend, product.Id, product.Name)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index product %s: %w", product.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// GormProductSearchRepository implements the ProductSearchRepository interface using the
+// full-text search of PostgreSQL or, for tests, the FTS5 extension of SQLite
+type GormProductSearchRepository struct {
+	db      *gorm.DB
+	backend *lazySearchBackend
+}
+
+// NewGormProductSearchRepository creates a new GormProductSearchRepository
+func NewGormProductSearchRepository(db *gorm.DB) repositories.ProductSearchRepository {
+	return &GormProductSearchRepository{db: db, backend: newLazySearchBackend()}
+}
+
+// Search finds the products matching all term groups of the query, best matches first
+func (repo *GormProductSearchRepository) Search(ctx context.Context, query repositories.ProductSearchQuery) (*repositories.ProductSearchPage, error) {
+	backend := repo.backend.get(repo.db.WithContext(ctx))
+	if backend == nil {
+		return nil, repositories.ErrProductSearchUnavailable
+	}
+
+	matches, totalCount, err := backend.search(repo.db.WithContext(ctx), query.Groups, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ProductId
+	}
+
+	var dbProducts []Product
+	if len(ids) > 0 {
+		if err := repo.db.WithContext(ctx).Scopes(preloadSeller).Where("id IN ?", ids).Find(&dbProducts).Error; err != nil {
+			return nil, err
+		}
+	}
+	productsById := make(map[uuid.UUID]*entities.Product, len(dbProducts))
+	for i := range dbProducts {
+		productsById[dbProducts[i].Id] = fromDBProduct(&dbProducts[i])
+	}
+
+	page := &repositories.ProductSearchPage{TotalCount: totalCount}
+	for _, match := range matches {
+		if product, ok := productsById[match.ProductId]; ok {
+			page.Hits = append(page.Hits, repositories.ProductSearchHit{Product: product, Rank: match.Rank})
+		}
+	}
+
+	return page, nil
+}
+
+// FindSimilarTerms finds indexed terms at most maxDistance edits away from the term.
+// Only terms starting with the same letter are considered, typos in the first letter are rare.
+func (repo *GormProductSearchRepository) FindSimilarTerms(ctx context.Context, term string, maxDistance int) ([]string, error) {
+	if repo.backend.get(repo.db.WithContext(ctx)) == nil {
+		return nil, repositories.ErrProductSearchUnavailable
+	}
+
+	runes := []rune(term)
+	if len(runes) == 0 {
+		return nil, nil
+	}
+
+	var candidates []string
+	err := repo.db.WithContext(ctx).Model(&ProductSearchTerm{}).
+		Distinct("term").
+		Where("term LIKE ? AND LENGTH(term) BETWEEN ? AND ?", string(runes[0])+"%", len(runes)-maxDistance, len(runes)+maxDistance).
+		Pluck("term", &candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var similar []string
+	for _, candidate := range candidates {
+		if entities.EditDistance(term, candidate) <= maxDistance {
+			similar = append(similar, candidate)
+		}
+	}
+	sort.Strings(similar)
+
+	return similar, nil
+}
+
+// postgresSearchBackend stores the terms as tsvector. The vector is built from the term list
+// directly instead of with to_tsvector, whose parser cannot split Japanese text.
+type postgresSearchBackend struct{}
+
+func (postgresSearchBackend) table() string {
+	return "product_search_documents"
+}
+
+func (postgresSearchBackend) index(tx *gorm.DB, productId uuid.UUID, terms []string) error {
+	return tx.Exec(
+		`INSERT INTO product_search_documents (product_id, terms) VALUES (?, ?)
+		ON CONFLICT (product_id) DO UPDATE SET terms = EXCLUDED.terms`,
+		productId, strings.Join(terms, " "),
+	).Error
+}
+
+func (postgresSearchBackend) remove(tx *gorm.DB, productId uuid.UUID) error {
+	return tx.Exec("DELETE FROM product_search_documents WHERE product_id = ?", productId).Error
+}
+
+func (postgresSearchBackend) search(db *gorm.DB, groups []entities.SearchTermGroup, limit, offset int) ([]searchMatch, int64, error) {
+	tsQuery := postgresTsQuery(groups)
+
+	var totalCount int64
+	err := db.Raw("SELECT COUNT(*) FROM product_search_documents WHERE search_vector @@ ?::tsquery", tsQuery).
+		Scan(&totalCount).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Normalization 1 divides the rank by the logarithm of the number of terms so that focused names rank higher
+	var matches []searchMatch
+	err = db.Raw(
+		`SELECT product_id, ts_rank(search_vector, ?::tsquery, 1) AS rank FROM product_search_documents
+		WHERE search_vector @@ ?::tsquery
+		ORDER BY rank DESC, product_id
+		LIMIT ? OFFSET ?`,
+		tsQuery, tsQuery, limit, offset,
+	).Scan(&matches).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return matches, totalCount, nil
+}
+
+// postgresTsQuery builds a tsquery like ('shoe':* | 'shoes':*) & ('red').
+// Terms only consist of letters and digits, so they need no escaping.
+func postgresTsQuery(groups []entities.SearchTermGroup) string {
+	conjunction := make([]string, len(groups))
+	for i, group := range groups {
+		disjunction := make([]string, len(group.Alternatives))
+		for j, alternative := range group.Alternatives {
+			disjunction[j] = "'" + alternative + "'"
+			if group.Prefix {
+				disjunction[j] += ":*"
+			}
+		}
+		conjunction[i] = "(" + strings.Join(disjunction, " | ") + ")"
+	}
+	return strings.Join(conjunction, " & ")
+}
+
+// sqliteSearchBackend stores the terms in an FTS5 table. SQLite has to be built with FTS5,
+// for github.com/mattn/go-sqlite3 this requires the sqlite_fts5 build tag. The migrations only
+// cover PostgreSQL, so the table is created by the tests:
+//
+//	CREATE VIRTUAL TABLE product_search_fts USING fts5(product_id UNINDEXED, terms, tokenize = 'unicode61 remove_diacritics 0')
+type sqliteSearchBackend struct{}
+
+func (sqliteSearchBackend) table() string {
+	return "product_search_fts"
+}
+
+func (sqliteSearchBackend) index(tx *gorm.DB, productId uuid.UUID, terms []string) error {
+	if err := tx.Exec("DELETE FROM product_search_fts WHERE product_id = ?", productId.String()).Error; err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO product_search_fts (product_id, terms) VALUES (?, ?)", productId.String(), strings.Join(terms, " ")).Error
+}
+
+func (sqliteSearchBackend) remove(tx *gorm.DB, productId uuid.UUID) error {
+	return tx.Exec("DELETE FROM product_search_fts WHERE product_id = ?", productId.String()).Error
+}
+
+func (sqliteSearchBackend) search(db *gorm.DB, groups []entities.SearchTermGroup, limit, offset int) ([]searchMatch, int64, error) {
+	matchQuery := sqliteMatchQuery(groups)
+
+	var totalCount int64
+	err := db.Raw("SELECT COUNT(*) FROM product_search_fts WHERE product_search_fts MATCH ?", matchQuery).
+		Scan(&totalCount).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// bm25 is lower for better matches, it is negated so that higher ranks are better as in PostgreSQL
+	var matches []searchMatch
+	err = db.Raw(
+		`SELECT product_id, -bm25(product_search_fts) AS rank FROM product_search_fts
+		WHERE product_search_fts MATCH ?
+		ORDER BY rank DESC, product_id
+		LIMIT ? OFFSET ?`,
+		matchQuery, limit, offset,
+	).Scan(&matches).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return matches, totalCount, nil
+}
+
+// sqliteMatchQuery builds an FTS5 query like ("shoe"* OR "shoes"*) AND ("red")
+func sqliteMatchQuery(groups []entities.SearchTermGroup) string {
+	conjunction := make([]string, len(groups))
+	for i, group := range groups {
+		disjunction := make([]string, len(group.Alternatives))
+		for j, alternative := range group.Alternatives {
+			disjunction[j] = `"` + alternative + `"`
+			if group.Prefix {
+				disjunction[j] += "*"
+			}
+		}
+		conjunction[i] = "(" + strings.Join(disjunction, " OR ") + ")"
+	}
+	return strings.Join(conjunction, " AND ")
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RefreshTokenModel is the GORM model for refresh tokens
+type RefreshTokenModel struct {
+	ID           string `gorm:"primaryKey"`
+	UserID       string `gorm:"index"`
+	FamilyID     string `gorm:"index"`
+	TokenHash    string `gorm:"uniqueIndex"`
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+	RevokedAt    *time.Time
+	ReplacedByID string
+}
+
+// TableName specifies the table name for RefreshTokenModel
+func (RefreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+// GormRefreshTokenRepository is a PostgreSQL implementation of the RefreshTokenRepository interface
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRefreshTokenRepository creates a new GormRefreshTokenRepository
+func NewGormRefreshTokenRepository(db *gorm.DB) repositories.RefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
+
+// toRefreshTokenModel converts a RefreshToken entity to a RefreshTokenModel
+func toRefreshTokenModel(token *entities.RefreshToken) *RefreshTokenModel {
+	return &RefreshTokenModel{
+		ID:           token.ID,
+		UserID:       token.UserID,
+		FamilyID:     token.FamilyID,
+		TokenHash:    token.TokenHash,
+		ExpiresAt:    token.ExpiresAt,
+		CreatedAt:    token.CreatedAt,
+		RevokedAt:    token.RevokedAt,
+		ReplacedByID: token.ReplacedByID,
+	}
+}
+
+// toRefreshTokenEntity converts a RefreshTokenModel to a RefreshToken entity
+func toRefreshTokenEntity(model *RefreshTokenModel) *entities.RefreshToken {
+	return &entities.RefreshToken{
+		ID:           model.ID,
+		UserID:       model.UserID,
+		FamilyID:     model.FamilyID,
+		TokenHash:    model.TokenHash,
+		ExpiresAt:    model.ExpiresAt,
+		CreatedAt:    model.CreatedAt,
+		RevokedAt:    model.RevokedAt,
+		ReplacedByID: model.ReplacedByID,
+	}
+}
+
+// Save persists a refresh token to the repository
+func (r *GormRefreshTokenRepository) Save(ctx context.Context, token *entities.RefreshToken) error {
+	return r.db.WithContext(ctx).Save(toRefreshTokenModel(token)).Error
+}
+
+// FindByTokenHash retrieves a refresh token by the hash of its value
+func (r *GormRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	var model RefreshTokenModel
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toRefreshTokenEntity(&model), nil
+}
+
+// Replace revokes the token because it has been rotated into the token with the given ID, unless
+// it has been revoked already. It reports whether the token was revoked by this call.
+func (r *GormRefreshTokenRepository) Replace(ctx context.Context, id, replacedByID string, revokedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": revokedAt, "replaced_by_id": replacedByID})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeFamily revokes all tokens which descend from the same login
+func (r *GormRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeAllForUser revokes every refresh token of a user
+func (r *GormRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// DeleteExpired removes tokens which expired before the given time
+func (r *GormRefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&RefreshTokenModel{}).Error
+}
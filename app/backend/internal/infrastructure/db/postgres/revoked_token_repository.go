@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// RevokedTokenModel is the GORM model for revoked access tokens
+type RevokedTokenModel struct {
+	TokenID   string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for RevokedTokenModel
+func (RevokedTokenModel) TableName() string {
+	return "revoked_tokens"
+}
+
+// GormRevokedTokenRepository is a PostgreSQL implementation of the RevokedTokenRepository interface
+type GormRevokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRevokedTokenRepository creates a new GormRevokedTokenRepository
+func NewGormRevokedTokenRepository(db *gorm.DB) repositories.RevokedTokenRepository {
+	return &GormRevokedTokenRepository{db: db}
+}
+
+// Revoke records the token ID as revoked until the token would have expired
+func (r *GormRevokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	model := &RevokedTokenModel{
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(model).Error
+}
+
+// IsRevoked reports whether the token ID has been revoked
+func (r *GormRevokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&RevokedTokenModel{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired removes entries for tokens which expired before the given time
+func (r *GormRevokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&RevokedTokenModel{}).Error
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RoleModel is the GORM model for roles
+type RoleModel struct {
+	Name        string `gorm:"primaryKey"`
+	Description string
+	Permissions []RolePermissionModel `gorm:"foreignKey:RoleName;references:Name;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName specifies the table name for RoleModel
+func (RoleModel) TableName() string {
+	return "roles"
+}
+
+// RolePermissionModel is the GORM model for the permissions assigned to a role
+type RolePermissionModel struct {
+	RoleName   string `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey"`
+}
+
+// TableName specifies the table name for RolePermissionModel
+func (RolePermissionModel) TableName() string {
+	return "role_permissions"
+}
+
+// GormRoleRepository is a PostgreSQL implementation of the RoleRepository interface
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRoleRepository creates a new GormRoleRepository
+func NewGormRoleRepository(db *gorm.DB) repositories.RoleRepository {
+	return &GormRoleRepository{db: db}
+}
+
+func toRoleModel(role *entities.Role) *RoleModel {
+	permissions := make([]RolePermissionModel, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = RolePermissionModel{
+			RoleName:   string(role.Name),
+			Permission: string(permission),
+		}
+	}
+
+	return &RoleModel{
+		Name:        string(role.Name),
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
+
+func fromRoleModel(model *RoleModel) *entities.Role {
+	permissions := make([]entities.Permission, len(model.Permissions))
+	for i, permission := range model.Permissions {
+		permissions[i] = entities.Permission(permission.Permission)
+	}
+
+	return &entities.Role{
+		Name:        entities.UserRole(model.Name),
+		Description: model.Description,
+		Permissions: permissions,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
+
+// Save persists a role together with its permissions, replacing previously assigned permissions
+func (r *GormRoleRepository) Save(ctx context.Context, role *entities.Role) error {
+	model := toRoleModel(role)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(model).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_name = ?", model.Name).Delete(&RolePermissionModel{}).Error; err != nil {
+			return err
+		}
+		if len(model.Permissions) == 0 {
+			return nil
+		}
+		return tx.Create(&model.Permissions).Error
+	})
+}
+
+// FindByName retrieves a role by name, or nil if it does not exist
+func (r *GormRoleRepository) FindByName(ctx context.Context, name entities.UserRole) (*entities.Role, error) {
+	var model RoleModel
+	if err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", string(name)).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return fromRoleModel(&model), nil
+}
+
+// FindAll retrieves all roles
+func (r *GormRoleRepository) FindAll(ctx context.Context) ([]*entities.Role, error) {
+	var models []RoleModel
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	roles := make([]*entities.Role, len(models))
+	for i := range models {
+		roles[i] = fromRoleModel(&models[i])
+	}
+	return roles, nil
+}
+
+// Delete removes a role and its permissions
+func (r *GormRoleRepository) Delete(ctx context.Context, name entities.UserRole) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_name = ?", string(name)).Delete(&RolePermissionModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", string(name)).Delete(&RoleModel{}).Error
+	})
+}
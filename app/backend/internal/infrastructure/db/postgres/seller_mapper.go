@@ -7,7 +7,8 @@ import (
 // toDBSeller maps domain Seller entity to DB persistence model.
 func toDBSeller(seller *entities.ValidatedSeller) *Seller {
 	s := &Seller{
-		Name: seller.Name,
+		Name:    seller.Name,
+		Version: seller.Version,
 	}
 	s.Id = seller.Id
 
@@ -17,7 +18,9 @@ func toDBSeller(seller *entities.ValidatedSeller) *Seller {
 // fromDBSeller maps DB persistence model to domain Seller entity.
 func fromDBSeller(dbSeller *Seller) *entities.Seller {
 	s := &entities.Seller{
-		Name: dbSeller.Name,
+		Name:      dbSeller.Name,
+		Version:   dbSeller.Version,
+		DeletedAt: fromDBDeletedAt(dbSeller.DeletedAt),
 	}
 	s.Id = dbSeller.Id
 
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"time"
+)
+
+// SellerMembershipModel is the GORM model for seller memberships
+type SellerMembershipModel struct {
+	SellerId  uuid.UUID `gorm:"primaryKey"`
+	UserId    string    `gorm:"primaryKey;index"`
+	Role      string
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for SellerMembershipModel
+func (SellerMembershipModel) TableName() string {
+	return "seller_memberships"
+}
+
+// GormSellerMembershipRepository is a PostgreSQL implementation of the SellerMembershipRepository interface
+type GormSellerMembershipRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSellerMembershipRepository creates a new GormSellerMembershipRepository
+func NewGormSellerMembershipRepository(db *gorm.DB) repositories.SellerMembershipRepository {
+	return &GormSellerMembershipRepository{db: db}
+}
+
+func fromSellerMembershipModel(model *SellerMembershipModel) *entities.SellerMembership {
+	return &entities.SellerMembership{
+		SellerId:  model.SellerId,
+		UserId:    model.UserId,
+		Role:      entities.SellerMemberRole(model.Role),
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+func fromSellerMembershipModels(models []SellerMembershipModel) []*entities.SellerMembership {
+	memberships := make([]*entities.SellerMembership, len(models))
+	for i := range models {
+		memberships[i] = fromSellerMembershipModel(&models[i])
+	}
+	return memberships
+}
+
+// Save creates or updates a membership
+func (r *GormSellerMembershipRepository) Save(ctx context.Context, membership *entities.SellerMembership) error {
+	model := &SellerMembershipModel{
+		SellerId:  membership.SellerId,
+		UserId:    membership.UserId,
+		Role:      string(membership.Role),
+		CreatedAt: membership.CreatedAt,
+	}
+	return r.db.WithContext(ctx).Save(model).Error
+}
+
+// Find retrieves the membership of the user in the seller, or nil if the user is not a member
+func (r *GormSellerMembershipRepository) Find(ctx context.Context, sellerId uuid.UUID, userId string) (*entities.SellerMembership, error) {
+	var model SellerMembershipModel
+	if err := r.db.WithContext(ctx).Where("seller_id = ? AND user_id = ?", sellerId, userId).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fromSellerMembershipModel(&model), nil
+}
+
+// FindBySeller retrieves all memberships of the seller
+func (r *GormSellerMembershipRepository) FindBySeller(ctx context.Context, sellerId uuid.UUID) ([]*entities.SellerMembership, error) {
+	var models []SellerMembershipModel
+	if err := r.db.WithContext(ctx).Where("seller_id = ?", sellerId).Order("created_at").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return fromSellerMembershipModels(models), nil
+}
+
+// FindByUser retrieves all memberships of the user
+func (r *GormSellerMembershipRepository) FindByUser(ctx context.Context, userId string) ([]*entities.SellerMembership, error) {
+	var models []SellerMembershipModel
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("created_at").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return fromSellerMembershipModels(models), nil
+}
+
+// Delete removes the membership of the user in the seller
+func (r *GormSellerMembershipRepository) Delete(ctx context.Context, sellerId uuid.UUID, userId string) error {
+	return r.db.WithContext(ctx).Where("seller_id = ? AND user_id = ?", sellerId, userId).Delete(&SellerMembershipModel{}).Error
+}
+
+// DeleteBySeller removes all memberships of the seller
+func (r *GormSellerMembershipRepository) DeleteBySeller(ctx context.Context, sellerId uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("seller_id = ?", sellerId).Delete(&SellerMembershipModel{}).Error
+}
@@ -1,10 +1,15 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 // GormSellerRepository implements the SellerRepository interface using GORM v2
@@ -18,29 +23,56 @@ func NewGormSellerRepository(db *gorm.DB) repositories.SellerRepository {
 }
 
 // Create creates a new seller
-func (repo *GormSellerRepository) Create(seller *entities.ValidatedSeller) (*entities.Seller, error) {
+func (repo *GormSellerRepository) Create(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error) {
 	dbSeller := toDBSeller(seller)
+	dbSeller.Version = 1
 
-	if err := repo.db.Create(dbSeller).Error; err != nil {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbSeller).Error; err != nil {
+			return translateError(tx, err)
+		}
+		return saveDomainEvents(tx, seller.PendingEvents())
+	})
+	if err != nil {
 		return nil, err
 	}
+	seller.Version = dbSeller.Version
+	seller.ClearEvents()
+
+	return repo.FindById(ctx, dbSeller.Id)
+}
+
+// FindById finds a seller by ID, it returns repositories.ErrSellerNotFound if there is none
+func (repo *GormSellerRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	return repo.findById(repo.db.WithContext(ctx), id)
+}
 
-	return repo.FindById(dbSeller.Id)
+// FindByIdForUpdate finds a seller by ID and locks its row until the transaction ends.
+// SQLite has no row locks, its transactions already run one after another.
+func (repo *GormSellerRepository) FindByIdForUpdate(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	return repo.findById(repo.db.WithContext(ctx).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}), id)
 }
 
-// FindById finds a seller by ID
-func (repo *GormSellerRepository) FindById(id uuid.UUID) (*entities.Seller, error) {
+// FindByIdIncludingDeleted finds a seller by ID, even if it is soft deleted
+func (repo *GormSellerRepository) FindByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	return repo.findById(repo.db.WithContext(ctx).Unscoped(), id)
+}
+
+func (repo *GormSellerRepository) findById(db *gorm.DB, id uuid.UUID) (*entities.Seller, error) {
 	var dbSeller Seller
-	if err := repo.db.First(&dbSeller, id).Error; err != nil {
+	if err := db.First(&dbSeller, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrSellerNotFound
+		}
 		return nil, err
 	}
 	return fromDBSeller(&dbSeller), nil
 }
 
 // FindAll finds all sellers
-func (repo *GormSellerRepository) FindAll() ([]*entities.Seller, error) {
+func (repo *GormSellerRepository) FindAll(ctx context.Context) ([]*entities.Seller, error) {
 	var dbSellers []Seller
-	if err := repo.db.Find(&dbSellers).Error; err != nil {
+	if err := repo.db.WithContext(ctx).Find(&dbSellers).Error; err != nil {
 		return nil, err
 	}
 
@@ -52,19 +84,148 @@ func (repo *GormSellerRepository) FindAll() ([]*entities.Seller, error) {
 	return sellers, nil
 }
 
+// sellerSortColumns maps the sort fields of seller listings to their columns
+var sellerSortColumns = map[repositories.SortField]sortColumn[Seller]{
+	repositories.SortByName: {
+		column: "name",
+		format: func(row *Seller) string { return row.Name },
+		parse:  parseStringCursor,
+	},
+	repositories.SortByCreatedAt: {
+		column: "created_at",
+		format: func(row *Seller) string { return formatTimeCursor(row.CreatedAt) },
+		parse:  parseTimeCursor,
+	},
+}
+
+// FindPage finds one page of the sellers matching the filter
+func (repo *GormSellerRepository) FindPage(ctx context.Context, filter repositories.SellerFilter, sort repositories.SortOrder, page repositories.PageRequest) (*repositories.SellerPage, error) {
+	column, ok := sellerSortColumns[sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort sellers by %q", sort.Field)
+	}
+
+	var totalCount int64
+	if err := repo.db.WithContext(ctx).Model(&Seller{}).Scopes(sellerFilterScope(filter)).Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	dbSellers, nextCursor, err := findPage(repo.db.WithContext(ctx).Scopes(sellerFilterScope(filter)), column, func(row *Seller) uuid.UUID { return row.Id }, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	sellers := make([]*entities.Seller, len(dbSellers))
+	for i, dbSeller := range dbSellers {
+		sellers[i] = fromDBSeller(&dbSeller)
+	}
+
+	return &repositories.SellerPage{
+		Sellers:    sellers,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func sellerFilterScope(filter repositories.SellerFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.IncludeDeleted {
+			db = db.Unscoped()
+		}
+		if filter.NameContains != "" {
+			db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, containsPattern(filter.NameContains))
+		}
+		if filter.CreatedAfter != nil {
+			db = db.Where("created_at >= ?", *filter.CreatedAfter)
+		}
+		if filter.CreatedBefore != nil {
+			db = db.Where("created_at < ?", *filter.CreatedBefore)
+		}
+		return db
+	}
+}
+
 // Update updates a seller
-func (repo *GormSellerRepository) Update(seller *entities.ValidatedSeller) (*entities.Seller, error) {
+func (repo *GormSellerRepository) Update(ctx context.Context, seller *entities.ValidatedSeller) (*entities.Seller, error) {
 	dbSeller := toDBSeller(seller)
+	dbSeller.Version = seller.Version + 1
 
-	err := repo.db.Model(&Seller{}).Where("id = ?", dbSeller.Id).Updates(dbSeller).Error
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Seller{}).Where("id = ? AND version = ?", dbSeller.Id, seller.Version).Updates(dbSeller)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionConflictError(tx, &Seller{}, dbSeller.Id, repositories.ErrSellerNotFound)
+		}
+		return saveDomainEvents(tx, seller.PendingEvents())
+	})
 	if err != nil {
 		return nil, err
 	}
+	seller.Version = dbSeller.Version
+	seller.ClearEvents()
 
-	return repo.FindById(dbSeller.Id)
+	return repo.FindById(ctx, dbSeller.Id)
 }
 
-// Delete deletes a seller
-func (repo *GormSellerRepository) Delete(id uuid.UUID) error {
-	return repo.db.Delete(&Seller{}, id).Error
+// Delete soft deletes a seller if it still has the version that was read
+func (repo *GormSellerRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND version = ?", id, version).Delete(&Seller{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionConflictError(tx, &Seller{}, id, repositories.ErrSellerNotFound)
+		}
+		return saveDomainEvents(tx, []entities.DomainEvent{entities.SellerDeleted{SellerId: id, At: time.Now()}})
+	})
+}
+
+// Restore restores a soft deleted seller, its products are restored separately
+func (repo *GormSellerRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.Seller, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Unscoped().Model(&Seller{}).Where("id = ? AND deleted_at IS NOT NULL", id).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repositories.ErrSellerNotFound
+		}
+		return saveDomainEvents(tx, []entities.DomainEvent{entities.SellerRestored{SellerId: id, At: now}})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.FindById(ctx, id)
+}
+
+// Purge permanently removes the sellers which were soft deleted before the given time and whose products
+// were purged already, together with their memberships
+func (repo *GormSellerRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		err := tx.Unscoped().Model(&Seller{}).
+			Where("deleted_at < ? AND NOT EXISTS (SELECT 1 FROM products WHERE products.seller_id = sellers.id)", deletedBefore).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Where("seller_id IN ?", ids).Delete(&SellerMembershipModel{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&Seller{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
 }
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// GormUnitOfWork implements the UnitOfWork interface with GORM transactions. The repositories of a
+// transaction use the transaction's *gorm.DB, their own transactions become savepoints of it.
+type GormUnitOfWork struct {
+	db            *gorm.DB
+	searchBackend *lazySearchBackend
+}
+
+// NewGormUnitOfWork creates a new GormUnitOfWork
+func NewGormUnitOfWork(db *gorm.DB) repositories.UnitOfWork {
+	return &GormUnitOfWork{db: db, searchBackend: newLazySearchBackend()}
+}
+
+// Do runs fn in a transaction and commits it unless fn fails
+func (u *GormUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormTransaction{db: tx, searchBackend: u.searchBackend})
+	})
+}
+
+// gormTransaction creates the repositories of a transaction. They share the search backend of
+// the unit of work, so it is resolved once rather than per transaction.
+type gormTransaction struct {
+	db            *gorm.DB
+	searchBackend *lazySearchBackend
+}
+
+func (t *gormTransaction) Products() repositories.ProductRepository {
+	return &GormProductRepository{db: t.db, searchBackend: t.searchBackend}
+}
+
+func (t *gormTransaction) Sellers() repositories.SellerRepository {
+	return &GormSellerRepository{db: t.db}
+}
+
+func (t *gormTransaction) SellerMemberships() repositories.SellerMembershipRepository {
+	return &GormSellerMembershipRepository{db: t.db}
+}
+
+func (t *gormTransaction) Users() repositories.UserRepository {
+	return &GormUserRepository{db: t.db}
+}
+
+func (t *gormTransaction) ExchangeRates() repositories.ExchangeRateRepository {
+	return &GormExchangeRateRepository{db: t.db}
+}
+
+func (t *gormTransaction) Audit() repositories.AuditRepository {
+	return &GormAuditRepository{db: t.db}
+}
@@ -1,10 +1,12 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -18,6 +20,14 @@ type UserModel struct {
 	Status       string
 	CreatedAt    int64
 	UpdatedAt    int64
+	// StatusChangedAt is stored as Unix timestamp, 0 if the status never changed
+	StatusChangedAt int64
+	StatusReason    string
+	// FailedLoginAttempts counts consecutive failed logins
+	FailedLoginAttempts int
+	// LockedUntil is stored as Unix timestamp, 0 if the lock does not expire
+	LockedUntil int64
+	Version     int `gorm:"not null;default:1"`
 }
 
 // TableName specifies the table name for UserModel
@@ -32,9 +42,6 @@ type GormUserRepository struct {
 
 // NewGormUserRepository creates a new GormUserRepository
 func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
-	// Ensure the users table exists
-	db.AutoMigrate(&UserModel{})
-
 	return &GormUserRepository{
 		db: db,
 	}
@@ -43,15 +50,28 @@ func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
 // toModel converts a User entity to a UserModel
 func toModel(user *entities.User) *UserModel {
 	return &UserModel{
-		ID:           user.ID,
-		Username:     user.Username,
-		Email:        user.Email,
-		PasswordHash: user.PasswordHash,
-		Role:         string(user.Role),
-		Status:       string(user.Status),
-		CreatedAt:    user.CreatedAt.Unix(),
-		UpdatedAt:    user.UpdatedAt.Unix(),
+		ID:                  user.ID,
+		Username:            user.Username,
+		Email:               user.Email,
+		PasswordHash:        user.PasswordHash,
+		Role:                string(user.Role),
+		Status:              string(user.Status),
+		CreatedAt:           user.CreatedAt.Unix(),
+		UpdatedAt:           user.UpdatedAt.Unix(),
+		StatusChangedAt:     toUnixOrZero(user.StatusChangedAt),
+		StatusReason:        user.StatusReason,
+		FailedLoginAttempts: user.FailedLoginAttempts,
+		LockedUntil:         toUnixOrZero(user.LockedUntil),
+		Version:             user.Version,
+	}
+}
+
+// toUnixOrZero converts a time to a Unix timestamp, keeping the zero time as 0
+func toUnixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
 	}
+	return t.Unix()
 }
 
 // toEntity converts a UserModel to a User entity
@@ -68,19 +88,75 @@ func toEntity(model *UserModel) *entities.User {
 	// Convert Unix timestamps to time.Time
 	user.CreatedAt = time.Unix(model.CreatedAt, 0)
 	user.UpdatedAt = time.Unix(model.UpdatedAt, 0)
+	if model.StatusChangedAt != 0 {
+		user.StatusChangedAt = time.Unix(model.StatusChangedAt, 0)
+	}
+	user.StatusReason = model.StatusReason
+	user.FailedLoginAttempts = model.FailedLoginAttempts
+	if model.LockedUntil != 0 {
+		user.LockedUntil = time.Unix(model.LockedUntil, 0)
+	}
+	user.Version = model.Version
+	// Loading a user does not register it again
+	user.ClearEvents()
 	return user
 }
 
-// Save persists a user to the repository
-func (r *GormUserRepository) Save(user *entities.User) error {
+// Save persists a user to the repository together with its pending domain events.
+// Users with version 0 are created, others are only updated if they still have the version that was read.
+// It returns repositories.ErrAlreadyExists if the username or the email is taken
+// and repositories.ErrVersionConflict if the user was changed since it was read.
+func (r *GormUserRepository) Save(ctx context.Context, user *entities.User) error {
 	model := toModel(user)
-	return r.db.Save(model).Error
+	model.Version = user.Version + 1
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if user.Version == 0 {
+			if err := tx.Create(model).Error; err != nil {
+				return translateError(tx, err)
+			}
+		} else {
+			// Select all columns so that fields reset to zero values, e.g. FailedLoginAttempts, are written too
+			result := tx.Model(&UserModel{}).Where("id = ? AND version = ?", user.ID, user.Version).Select("*").Updates(model)
+			if result.Error != nil {
+				return translateError(tx, result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return versionConflictError(tx, &UserModel{}, user.ID, repositories.ErrVersionConflict)
+			}
+		}
+		return saveDomainEvents(tx, user.PendingEvents())
+	})
+	if err != nil {
+		return err
+	}
+	user.Version = model.Version
+	user.ClearEvents()
+	return nil
+}
+
+// IncrementFailedLogins atomically counts a failed login of the user and bumps its version, so that
+// concurrent failures are never lost and saves of users read before the failure are rejected.
+// It returns the updated user, or nil if there is no user with the ID.
+func (r *GormUserRepository) IncrementFailedLogins(ctx context.Context, id string) (*entities.User, error) {
+	var model UserModel
+	result := r.db.WithContext(ctx).Model(&model).Clauses(clause.Returning{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": gorm.Expr("failed_login_attempts + 1"),
+		"version":               gorm.Expr("version + 1"),
+		"updated_at":            time.Now().Unix(),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return toEntity(&model), nil
 }
 
 // FindByID retrieves a user by ID
-func (r *GormUserRepository) FindByID(id string) (*entities.User, error) {
+func (r *GormUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
 	var model UserModel
-	if err := r.db.Where("id = ?", id).First(&model).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -90,9 +166,9 @@ func (r *GormUserRepository) FindByID(id string) (*entities.User, error) {
 }
 
 // FindByEmail retrieves a user by email
-func (r *GormUserRepository) FindByEmail(email string) (*entities.User, error) {
+func (r *GormUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
 	var model UserModel
-	if err := r.db.Where("email = ?", email).First(&model).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&model).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -102,9 +178,9 @@ func (r *GormUserRepository) FindByEmail(email string) (*entities.User, error) {
 }
 
 // FindByUsername retrieves a user by username
-func (r *GormUserRepository) FindByUsername(username string) (*entities.User, error) {
+func (r *GormUserRepository) FindByUsername(ctx context.Context, username string) (*entities.User, error) {
 	var model UserModel
-	if err := r.db.Where("username = ?", username).First(&model).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&model).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -114,9 +190,9 @@ func (r *GormUserRepository) FindByUsername(username string) (*entities.User, er
 }
 
 // FindAll retrieves all users
-func (r *GormUserRepository) FindAll() ([]*entities.User, error) {
+func (r *GormUserRepository) FindAll(ctx context.Context) ([]*entities.User, error) {
 	var models []UserModel
-	if err := r.db.Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -128,8 +204,8 @@ func (r *GormUserRepository) FindAll() ([]*entities.User, error) {
 }
 
 // FindWithFilter retrieves users matching the given filter
-func (r *GormUserRepository) FindWithFilter(filter repositories.UserFilter) ([]*entities.User, error) {
-	query := r.db.Model(&UserModel{})
+func (r *GormUserRepository) FindWithFilter(ctx context.Context, filter repositories.UserFilter) ([]*entities.User, error) {
+	query := r.db.WithContext(ctx).Model(&UserModel{})
 
 	if filter.Username != "" {
 		query = query.Where("username LIKE ?", "%"+filter.Username+"%")
@@ -157,6 +233,14 @@ func (r *GormUserRepository) FindWithFilter(filter repositories.UserFilter) ([]*
 }
 
 // Delete removes a user from the repository
-func (r *GormUserRepository) Delete(id string) error {
-	return r.db.Delete(&UserModel{}, "id = ?", id).Error
+func (r *GormUserRepository) Delete(ctx context.Context, id string, version int) error {
+	db := r.db.WithContext(ctx)
+	result := db.Where("id = ? AND version = ?", id, version).Delete(&UserModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionConflictError(db, &UserModel{}, id, nil)
+	}
+	return nil
 }
@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// WebhookSubscriptionModel is the GORM model for webhook subscriptions
+type WebhookSubscriptionModel struct {
+	ID  uuid.UUID `gorm:"primaryKey"`
+	URL string
+	// EventTypes is the comma separated list of subscribed event types
+	EventTypes string
+	Secret     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName specifies the table name for WebhookSubscriptionModel
+func (WebhookSubscriptionModel) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDeliveryModel is the GORM model for the webhook delivery log
+type WebhookDeliveryModel struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	SubscriptionID uuid.UUID `gorm:"index:idx_webhook_deliveries_subscription_event"`
+	EventID        uuid.UUID `gorm:"index:idx_webhook_deliveries_subscription_event"`
+	EventType      string
+	Payload        string
+	Status         string `gorm:"index"`
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	LastError      string
+	ReplayOf       *uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	// DeliveredAt is nil until the endpoint accepted the delivery
+	DeliveredAt *time.Time
+}
+
+// TableName specifies the table name for WebhookDeliveryModel
+func (WebhookDeliveryModel) TableName() string {
+	return "webhook_deliveries"
+}
+
+// GormWebhookSubscriptionRepository implements the WebhookSubscriptionRepository interface using GORM v2
+type GormWebhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookSubscriptionRepository creates a new GormWebhookSubscriptionRepository
+func NewGormWebhookSubscriptionRepository(db *gorm.DB) repositories.WebhookSubscriptionRepository {
+	return &GormWebhookSubscriptionRepository{db: db}
+}
+
+func toWebhookSubscriptionModel(subscription *entities.WebhookSubscription) *WebhookSubscriptionModel {
+	return &WebhookSubscriptionModel{
+		ID:         subscription.Id,
+		URL:        subscription.URL,
+		EventTypes: strings.Join(subscription.EventTypes, ","),
+		Secret:     subscription.Secret,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func fromWebhookSubscriptionModel(model *WebhookSubscriptionModel) *entities.WebhookSubscription {
+	return &entities.WebhookSubscription{
+		Id:         model.ID,
+		URL:        model.URL,
+		EventTypes: strings.Split(model.EventTypes, ","),
+		Secret:     model.Secret,
+		CreatedAt:  model.CreatedAt,
+		UpdatedAt:  model.UpdatedAt,
+	}
+}
+
+// Save creates or replaces a subscription
+func (repo *GormWebhookSubscriptionRepository) Save(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	return repo.db.WithContext(ctx).Save(toWebhookSubscriptionModel(subscription)).Error
+}
+
+// FindById retrieves a subscription by id, or nil if it does not exist
+func (repo *GormWebhookSubscriptionRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	var model WebhookSubscriptionModel
+	if err := repo.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fromWebhookSubscriptionModel(&model), nil
+}
+
+// FindAll retrieves all subscriptions, oldest first
+func (repo *GormWebhookSubscriptionRepository) FindAll(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	var models []WebhookSubscriptionModel
+	if err := repo.db.WithContext(ctx).Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*entities.WebhookSubscription, len(models))
+	for i := range models {
+		subscriptions[i] = fromWebhookSubscriptionModel(&models[i])
+	}
+	return subscriptions, nil
+}
+
+// Delete removes a subscription together with its deliveries
+func (repo *GormWebhookSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&WebhookDeliveryModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&WebhookSubscriptionModel{}).Error
+	})
+}
+
+// GormWebhookDeliveryRepository implements the WebhookDeliveryRepository interface using GORM v2
+type GormWebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookDeliveryRepository creates a new GormWebhookDeliveryRepository
+func NewGormWebhookDeliveryRepository(db *gorm.DB) repositories.WebhookDeliveryRepository {
+	return &GormWebhookDeliveryRepository{db: db}
+}
+
+func toWebhookDeliveryModel(delivery *entities.WebhookDelivery) *WebhookDeliveryModel {
+	model := &WebhookDeliveryModel{
+		ID:             delivery.Id,
+		SubscriptionID: delivery.SubscriptionId,
+		EventID:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Payload:        string(delivery.Payload),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		ReplayOf:       delivery.ReplayOf,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		deliveredAt := delivery.DeliveredAt
+		model.DeliveredAt = &deliveredAt
+	}
+	return model
+}
+
+func fromWebhookDeliveryModel(model *WebhookDeliveryModel) *entities.WebhookDelivery {
+	delivery := &entities.WebhookDelivery{
+		Id:             model.ID,
+		SubscriptionId: model.SubscriptionID,
+		EventId:        model.EventID,
+		EventType:      model.EventType,
+		Payload:        []byte(model.Payload),
+		Status:         entities.WebhookDeliveryStatus(model.Status),
+		Attempts:       model.Attempts,
+		NextAttemptAt:  model.NextAttemptAt,
+		ResponseStatus: model.ResponseStatus,
+		LastError:      model.LastError,
+		ReplayOf:       model.ReplayOf,
+		CreatedAt:      model.CreatedAt,
+		UpdatedAt:      model.UpdatedAt,
+	}
+	if model.DeliveredAt != nil {
+		delivery.DeliveredAt = *model.DeliveredAt
+	}
+	return delivery
+}
+
+func fromWebhookDeliveryModels(models []WebhookDeliveryModel) []*entities.WebhookDelivery {
+	deliveries := make([]*entities.WebhookDelivery, len(models))
+	for i := range models {
+		deliveries[i] = fromWebhookDeliveryModel(&models[i])
+	}
+	return deliveries
+}
+
+// Save creates or replaces a delivery
+func (repo *GormWebhookDeliveryRepository) Save(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	return repo.db.WithContext(ctx).Save(toWebhookDeliveryModel(delivery)).Error
+}
+
+// FindById retrieves a delivery by id, or nil if it does not exist
+func (repo *GormWebhookDeliveryRepository) FindById(ctx context.Context, id uuid.UUID) (*entities.WebhookDelivery, error) {
+	var model WebhookDeliveryModel
+	if err := repo.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fromWebhookDeliveryModel(&model), nil
+}
+
+// ExistsForEvent reports whether the event was already delivered to the subscription, replays aside
+func (repo *GormWebhookDeliveryRepository) ExistsForEvent(ctx context.Context, subscriptionId, eventId uuid.UUID) (bool, error) {
+	var count int64
+	err := repo.db.WithContext(ctx).Model(&WebhookDeliveryModel{}).
+		Where("subscription_id = ? AND event_id = ? AND replay_of IS NULL", subscriptionId, eventId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindBySubscription retrieves one page of the deliveries of a subscription, newest first
+func (repo *GormWebhookDeliveryRepository) FindBySubscription(ctx context.Context, subscriptionId uuid.UUID, page repositories.PageRequest) (*repositories.WebhookDeliveryPage, error) {
+	page = page.WithDefaults()
+	query := repo.db.WithContext(ctx).Model(&WebhookDeliveryModel{}).Where("subscription_id = ?", subscriptionId)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var models []WebhookDeliveryModel
+	if err := query.Order("created_at DESC, id DESC").Limit(page.Limit).Offset(page.Offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return &repositories.WebhookDeliveryPage{
+		Deliveries: fromWebhookDeliveryModels(models),
+		TotalCount: totalCount,
+	}, nil
+}
+
+// FindDue retrieves up to limit pending deliveries which are due at the given time, oldest first
+func (repo *GormWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.WebhookDelivery, error) {
+	var models []WebhookDeliveryModel
+	err := repo.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", string(entities.WebhookDeliveryPending), now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return fromWebhookDeliveryModels(models), nil
+}
@@ -0,0 +1,60 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGormAuditRepository(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM audit_entries")
+
+	repo := postgres.NewGormAuditRepository(gormDB)
+	ctx := context.Background()
+
+	created := entities.NewAuditEntry("owner-id", entities.AuditActionCreate, entities.AuditAggregateSeller, "seller-id",
+		nil, entities.AuditSnapshot{"name": "Acme"}, "request-1")
+	created.OccurredAt = time.Now().Add(-time.Hour)
+	renamed := entities.NewAuditEntry("owner-id", entities.AuditActionUpdate, entities.AuditAggregateSeller, "seller-id",
+		entities.AuditSnapshot{"name": "Acme"}, entities.AuditSnapshot{"name": "Acme Corp"}, "request-2")
+	password := entities.NewAuditEntry("admin-id", entities.AuditActionUpdate, entities.AuditAggregateUser, "user-id",
+		entities.AuditSnapshot{"password": entities.AuditSecret("old")}, entities.AuditSnapshot{"password": entities.AuditSecret("new")}, "request-3")
+	for _, entry := range []*entities.AuditEntry{created, renamed, password} {
+		require.NoError(t, repo.Append(ctx, entry))
+	}
+
+	page, err := repo.FindPage(ctx, repositories.AuditFilter{AggregateType: entities.AuditAggregateSeller}, repositories.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.TotalCount)
+	if assert.Len(t, page.Entries, 2) {
+		assert.Equal(t, renamed.Id, page.Entries[0].Id, "Entries are listed newest first")
+		assert.Equal(t, "request-2", page.Entries[0].RequestId)
+		assert.Equal(t, []entities.AuditChange{{Field: "name", Before: "Acme", After: "Acme Corp"}}, page.Entries[0].Changes)
+		assert.Equal(t, []entities.AuditChange{{Field: "name", After: "Acme"}}, page.Entries[1].Changes)
+	}
+
+	page, err = repo.FindPage(ctx, repositories.AuditFilter{ActorId: "admin-id", Action: entities.AuditActionUpdate}, repositories.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	if assert.Len(t, page.Entries, 1) {
+		assert.Equal(t, []entities.AuditChange{{Field: "password", Before: entities.AuditRedacted, After: entities.AuditRedacted}}, page.Entries[0].Changes)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	page, err = repo.FindPage(ctx, repositories.AuditFilter{AggregateId: "seller-id", OccurredAfter: &since}, repositories.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.TotalCount)
+
+	page, err = repo.FindPage(ctx, repositories.AuditFilter{}, repositories.PageRequest{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.TotalCount)
+	if assert.Len(t, page.Entries, 1) {
+		assert.Equal(t, renamed.Id, page.Entries[0].Id)
+	}
+}
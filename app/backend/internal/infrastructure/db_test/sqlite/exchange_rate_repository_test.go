@@ -0,0 +1,53 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateRepositoryFindEffective(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM exchange_rates")
+
+	repo := postgres.NewGormExchangeRateRepository(gormDB)
+
+	for _, rate := range []struct {
+		rate string
+		day  int
+	}{{"1.07", 1}, {"1.08", 3}, {"1.09", 5}} {
+		exchangeRate, err := entities.NewExchangeRate(entities.CurrencyEUR, entities.CurrencyUSD, rate.rate, time.Date(2024, 5, rate.day, 0, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Save(context.Background(), exchangeRate))
+	}
+
+	// The latest rate at or before the given time is in effect
+	found, err := repo.FindEffective(context.Background(), entities.CurrencyEUR, entities.CurrencyUSD, time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "1.08", found.Rate)
+		assert.True(t, found.EffectiveDate.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)))
+	}
+
+	// Saving a rate for the same pair and day replaces it
+	replacement, _ := entities.NewExchangeRate(entities.CurrencyEUR, entities.CurrencyUSD, "1.085", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, repo.Save(context.Background(), replacement))
+	found, err = repo.FindEffective(context.Background(), entities.CurrencyEUR, entities.CurrencyUSD, time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "1.085", found.Rate)
+	}
+
+	// No rate is in effect before the first one or for other pairs
+	missing, err := repo.FindEffective(context.Background(), entities.CurrencyEUR, entities.CurrencyUSD, time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	missing, err = repo.FindEffective(context.Background(), entities.CurrencyUSD, entities.CurrencyEUR, time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
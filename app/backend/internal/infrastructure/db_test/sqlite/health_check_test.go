@@ -0,0 +1,43 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/migration"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestDatabaseHealthCheck(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	check := postgres.NewDatabaseHealthCheck(gormDB)
+
+	assert.Equal(t, "database", check.Name())
+	assert.NoError(t, check.Check(context.Background()))
+}
+
+func TestMigrationHealthCheckReportsPendingMigrations(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:migration_health?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	migrator, err := migration.NewMigrator(gormDB, loadTestMigrations(t))
+	require.NoError(t, err)
+
+	check := postgres.NewMigrationHealthCheck(migrator)
+
+	assert.Equal(t, "migrations", check.Name())
+	assert.EqualError(t, check.Check(context.Background()), "pending migrations 0001_create_notes, 0002_add_note_author")
+
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, check.Check(context.Background()))
+
+	// A build which lacks the second migration must not serve the migrated database
+	older, err := migration.NewMigrator(gormDB, loadTestMigrations(t)[:1])
+	require.NoError(t, err)
+	assert.EqualError(t, postgres.NewMigrationHealthCheck(older).Check(context.Background()), "unknown migrations 0002_add_note_author")
+}
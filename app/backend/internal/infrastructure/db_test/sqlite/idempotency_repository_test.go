@@ -0,0 +1,121 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGormIdempotencyRepository_Reserve(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormIdempotencyRepository(gormDB)
+	defer gormDB.Exec("DELETE FROM idempotency_records")
+	ctx := context.Background()
+	now := time.Now()
+
+	record, err := entities.NewIdempotencyRecord("key-1", "user-1", "POST /api/v1/products", "hash", now, time.Hour)
+	assert.NoError(t, err)
+	existing, err := repo.Reserve(ctx, record)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	assert.NoError(t, record.Complete(201, map[string]string{"Location": "/api/v1/products/1"}, []byte(`{"Name":"Shoe"}`)))
+	assert.NoError(t, repo.Save(ctx, record))
+
+	// The key is taken until it expires
+	retry, _ := entities.NewIdempotencyRecord("key-1", "user-1", "POST /api/v1/products", "hash", now.Add(time.Minute), time.Hour)
+	existing, err = repo.Reserve(ctx, retry)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, 201, existing.ResponseStatus)
+		assert.Equal(t, "/api/v1/products/1", existing.ResponseHeaders["Location"])
+		assert.Equal(t, `{"Name":"Shoe"}`, string(existing.ResponseBody))
+	}
+
+	// Other users can use the same key
+	otherUser, _ := entities.NewIdempotencyRecord("key-1", "user-2", "POST /api/v1/products", "hash", now, time.Hour)
+	existing, err = repo.Reserve(ctx, otherUser)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// An expired record is replaced
+	later, _ := entities.NewIdempotencyRecord("key-1", "user-1", "POST /api/v1/products", "other-hash", now.Add(2*time.Hour), time.Hour)
+	existing, err = repo.Reserve(ctx, later)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	again, _ := entities.NewIdempotencyRecord("key-1", "user-1", "POST /api/v1/products", "hash", now.Add(2*time.Hour), time.Hour)
+	existing, err = repo.Reserve(ctx, again)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, "other-hash", existing.RequestHash)
+		assert.False(t, existing.IsCompleted())
+	}
+}
+
+func TestGormIdempotencyRepository_SaveAndDeleteRequireReservation(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormIdempotencyRepository(gormDB)
+	defer gormDB.Exec("DELETE FROM idempotency_records")
+	ctx := context.Background()
+	now := time.Now()
+
+	// A retry takes over the key of an abandoned request
+	abandoned, _ := entities.NewIdempotencyRecord("key-1", "user-1", "PUT /api/v1/sellers", "hash", now, time.Hour)
+	_, err := repo.Reserve(ctx, abandoned)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Delete(ctx, abandoned))
+	retry, _ := entities.NewIdempotencyRecord("key-1", "user-1", "PUT /api/v1/sellers", "hash", now.Add(2*time.Minute), time.Hour)
+	_, err = repo.Reserve(ctx, retry)
+	assert.NoError(t, err)
+
+	// The abandoned request can neither record its response nor release the key of the retry
+	assert.NoError(t, abandoned.Complete(200, map[string]string{}, []byte("late")))
+	assert.ErrorIs(t, repo.Save(ctx, abandoned), repositories.ErrIdempotencyKeyReleased)
+	assert.NoError(t, repo.Delete(ctx, abandoned))
+
+	assert.NoError(t, retry.Complete(201, map[string]string{}, []byte("retry")))
+	assert.NoError(t, repo.Save(ctx, retry))
+	again, _ := entities.NewIdempotencyRecord("key-1", "user-1", "PUT /api/v1/sellers", "hash", now.Add(3*time.Minute), time.Hour)
+	existing, err := repo.Reserve(ctx, again)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, retry.Token, existing.Token)
+		assert.Equal(t, "retry", string(existing.ResponseBody))
+	}
+}
+
+func TestGormIdempotencyRepository_DeleteExpired(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormIdempotencyRepository(gormDB)
+	defer gormDB.Exec("DELETE FROM idempotency_records")
+	ctx := context.Background()
+	now := time.Now()
+
+	expiring, _ := entities.NewIdempotencyRecord("key-1", "user-1", "POST /api/v1/sellers", "hash", now, time.Minute)
+	lasting, _ := entities.NewIdempotencyRecord("key-2", "user-1", "POST /api/v1/sellers", "hash", now, time.Hour)
+	_, err := repo.Reserve(ctx, expiring)
+	assert.NoError(t, err)
+	_, err = repo.Reserve(ctx, lasting)
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.DeleteExpired(ctx, now.Add(2*time.Minute)))
+
+	var count int64
+	gormDB.Model(&postgres.IdempotencyRecordModel{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, repo.Delete(ctx, lasting))
+	gormDB.Model(&postgres.IdempotencyRecordModel{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,194 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/migration"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// testMigrations are written in SQL which runs on both SQLite and PostgreSQL
+var testMigrations = fstest.MapFS{
+	"0001_create_notes.up.sql":       {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);\nINSERT INTO notes (id, body) VALUES (1, 'first?');")},
+	"0001_create_notes.down.sql":     {Data: []byte("DROP TABLE notes;")},
+	"0002_add_note_author.up.sql":    {Data: []byte("ALTER TABLE notes ADD COLUMN author TEXT;")},
+	"0002_add_note_author.down.sql":  {Data: []byte("ALTER TABLE notes DROP COLUMN author;")},
+	"README.md":                      {Data: []byte("Files which are no migrations are ignored")},
+	"0003_irreversible.up.sql.draft": {Data: []byte("DROP TABLE notes;")},
+}
+
+func loadTestMigrations(t *testing.T) []migration.Migration {
+	migrations, err := migration.LoadSQL(testMigrations)
+	require.NoError(t, err)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// setupMigrationDatabase opens an empty private database, the shared one of the other tests has all tables already
+func setupMigrationDatabase(t *testing.T) *gorm.DB {
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return gormDB
+}
+
+func statesOf(t *testing.T, migrator *migration.Migrator) []migration.State {
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	states := make([]migration.State, len(statuses))
+	for i, status := range statuses {
+		states[i] = status.State
+	}
+	return states
+}
+
+func TestLoadSQL(t *testing.T) {
+	migrations := loadTestMigrations(t)
+
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "0001_create_notes", migrations[0].ID())
+	assert.Equal(t, "0002_add_note_author", migrations[1].ID())
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+	assert.NotNil(t, migrations[0].Down)
+
+	_, err := migration.LoadSQL(fstest.MapFS{"0001_create_notes.down.sql": {Data: []byte("DROP TABLE notes;")}})
+	assert.ErrorContains(t, err, "has no up script")
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	gormDB := setupMigrationDatabase(t)
+	migrator, err := migration.NewMigrator(gormDB, loadTestMigrations(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.Equal(t, []migration.State{migration.StatePending, migration.StatePending}, statesOf(t, migrator))
+	assert.False(t, gormDB.Migrator().HasTable("schema_migrations"), "Status must not change the schema")
+
+	migrated, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, migrated, 2)
+	assert.True(t, gormDB.Migrator().HasColumn("notes", "author"))
+	assert.Equal(t, []migration.State{migration.StateApplied, migration.StateApplied}, statesOf(t, migrator))
+
+	// The script is passed to the driver as is, the question mark is no placeholder
+	var body string
+	require.NoError(t, gormDB.Raw("SELECT body FROM notes WHERE id = 1").Scan(&body).Error)
+	assert.Equal(t, "first?", body)
+
+	migrated, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, migrated)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "0002_add_note_author", reverted[0].ID())
+	assert.False(t, gormDB.Migrator().HasColumn("notes", "author"))
+	assert.Equal(t, []migration.State{migration.StateApplied, migration.StatePending}, statesOf(t, migrator))
+
+	reverted, err = migrator.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.False(t, gormDB.Migrator().HasTable("notes"))
+}
+
+func TestMigratorRefusesModifiedMigrations(t *testing.T) {
+	gormDB := setupMigrationDatabase(t)
+	migrator, err := migration.NewMigrator(gormDB, loadTestMigrations(t)[:1])
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	modified := loadTestMigrations(t)
+	modified[0].Checksum = "edited"
+	migrator, err = migration.NewMigrator(gormDB, modified)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(context.Background())
+	assert.ErrorIs(t, err, migration.ErrChecksumMismatch)
+	// Nothing is applied while the history differs
+	assert.False(t, gormDB.Migrator().HasColumn("notes", "author"))
+	assert.Equal(t, []migration.State{migration.StateModified, migration.StatePending}, statesOf(t, migrator))
+}
+
+func TestMigratorRefusesToRevertIrreversibleMigrations(t *testing.T) {
+	gormDB := setupMigrationDatabase(t)
+	migrations := append(loadTestMigrations(t), migration.NewGoMigration(3, "backfill_authors", func(tx *gorm.DB) error {
+		return tx.Exec("UPDATE notes SET author = 'unknown'").Error
+	}, nil))
+	migrator, err := migration.NewMigrator(gormDB, migrations)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	_, err = migrator.Down(context.Background(), 1)
+
+	assert.ErrorIs(t, err, migration.ErrIrreversible)
+	assert.Equal(t, []migration.State{migration.StateApplied, migration.StateApplied, migration.StateApplied}, statesOf(t, migrator))
+}
+
+func TestMigratorRollsBackFailedMigrations(t *testing.T) {
+	gormDB := setupMigrationDatabase(t)
+	migrations := append(loadTestMigrations(t), migration.NewGoMigration(3, "broken", func(tx *gorm.DB) error {
+		return tx.Exec("UPDATE missing_table SET x = 1").Error
+	}, nil))
+	migrator, err := migration.NewMigrator(gormDB, migrations)
+	require.NoError(t, err)
+
+	migrated, err := migrator.Up(context.Background())
+
+	assert.ErrorContains(t, err, "failed to apply migration 0003_broken")
+	assert.Len(t, migrated, 2)
+	assert.Equal(t, []migration.State{migration.StateApplied, migration.StateApplied, migration.StatePending}, statesOf(t, migrator))
+}
+
+func TestNewMigratorRejectsDuplicateVersions(t *testing.T) {
+	noop := func(tx *gorm.DB) error { return nil }
+
+	_, err := migration.NewMigrator(nil, []migration.Migration{
+		migration.NewGoMigration(1, "first", noop, noop),
+		migration.NewGoMigration(1, "second", noop, noop),
+	})
+
+	assert.ErrorContains(t, err, "migration version 1 is used by first and second")
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	upPath, downPath, err := migration.Create(dir, "Add Product Tags!", loadTestMigrations(t))
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0003_add_product_tags.up.sql"), upPath)
+	assert.Equal(t, filepath.Join(dir, "0003_add_product_tags.down.sql"), downPath)
+	created, err := migration.LoadSQL(os.DirFS(dir))
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, int64(3), created[0].Version)
+
+	_, _, err = migration.Create(dir, "add product tags", loadTestMigrations(t))
+	assert.Error(t, err, "Existing migrations must not be overwritten")
+	_, _, err = migration.Create(dir, "!!!", nil)
+	assert.Error(t, err)
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	migrations, err := postgres.Migrations()
+	require.NoError(t, err)
+
+	_, err = migration.NewMigrator(nil, migrations)
+	require.NoError(t, err)
+	for _, m := range migrations {
+		assert.NotNil(t, m.Down, "Migration %s should be reversible", m.ID())
+	}
+}
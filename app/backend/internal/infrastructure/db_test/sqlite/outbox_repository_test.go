@@ -0,0 +1,143 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRepositoriesStoreDomainEventsInOutbox(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	productRepo := postgres.NewGormProductRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("Seller"))
+	_, err := sellerRepo.Create(context.Background(), seller)
+	assert.NoError(t, err)
+	assert.Empty(t, seller.PendingEvents(), "stored events are cleared")
+
+	product := entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller)
+	assert.NoError(t, product.UpdatePrice(entities.Money{Amount: 1200, Currency: entities.CurrencyUSD}))
+	validatedProduct, _ := entities.NewValidatedProduct(product)
+	_, err = productRepo.Create(context.Background(), validatedProduct)
+	assert.NoError(t, err)
+
+	// A failing write stores no events
+	duplicate, _ := entities.NewValidatedProduct(product)
+	_, err = productRepo.Create(context.Background(), duplicate)
+	assert.Error(t, err)
+
+	messages, err := outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.NoError(t, err)
+	var names []string
+	for _, message := range messages {
+		names = append(names, message.EventName)
+	}
+	assert.Equal(t, []string{entities.EventSellerCreated, entities.EventProductCreated, entities.EventProductPriceChanged}, names)
+
+	event, err := entities.DecodeDomainEvent(messages[2].EventName, messages[2].Payload)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1200), event.(entities.ProductPriceChanged).NewPrice.Amount)
+	assert.Equal(t, product.Id.String(), messages[2].AggregateId)
+
+	// Loaded aggregates have no events
+	loadedProduct, err := productRepo.FindById(context.Background(), product.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, loadedProduct.PendingEvents())
+}
+
+func TestRepositoriesStoreDeleteEventsInOutbox(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	productRepo := postgres.NewGormProductRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("Seller"))
+	_, _ = sellerRepo.Create(context.Background(), seller)
+	product, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *seller))
+	_, _ = productRepo.Create(context.Background(), product)
+	gormDB.Exec("DELETE FROM outbox_messages")
+
+	assert.NoError(t, productRepo.Delete(context.Background(), product.Id, product.Version))
+	assert.NoError(t, sellerRepo.Delete(context.Background(), seller.Id, seller.Version))
+	// Deleting missing rows raises no event
+	assert.ErrorIs(t, productRepo.Delete(context.Background(), product.Id, product.Version), repositories.ErrProductNotFound)
+
+	messages, err := outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, entities.EventProductDeleted, messages[0].EventName)
+		assert.Equal(t, product.Id.String(), messages[0].AggregateId)
+		assert.Equal(t, entities.EventSellerDeleted, messages[1].EventName)
+		assert.Equal(t, seller.Id.String(), messages[1].AggregateId)
+	}
+}
+
+func TestGormUserRepository_StoresDomainEventsInOutbox(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM users")
+
+	userRepo := postgres.NewGormUserRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+
+	user, _ := entities.NewUser("outbox-user", "outbox", "outbox@example.com", "hash")
+	assert.NoError(t, userRepo.Save(context.Background(), user))
+
+	// Loading a user does not register it again
+	loadedUser, err := userRepo.FindByID(context.Background(), "outbox-user")
+	assert.NoError(t, err)
+	assert.NoError(t, loadedUser.Lock("fraud", time.Time{}))
+	assert.NoError(t, userRepo.Save(context.Background(), loadedUser))
+
+	messages, err := outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, entities.EventUserRegistered, messages[0].EventName)
+		assert.Equal(t, entities.EventUserLocked, messages[1].EventName)
+	}
+}
+
+func TestGormOutboxRepository_Delivery(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+	for _, name := range []string{"First", "Second", "Third"} {
+		seller, _ := entities.NewValidatedSeller(entities.NewSeller(name))
+		_, err := sellerRepo.Create(context.Background(), seller)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	messages, err := outboxRepo.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 3)
+
+	assert.NoError(t, outboxRepo.MarkDelivered(context.Background(), messages[0].Id, now))
+	assert.NoError(t, outboxRepo.MarkFailed(context.Background(), messages[1].Id, errors.New("unavailable"), now.Add(time.Minute)))
+	assert.NoError(t, outboxRepo.MarkFailed(context.Background(), messages[2].Id, errors.New("broken"), time.Time{}))
+
+	// Delivered and given up messages are never due again, failed ones when their retry is due
+	due, err := outboxRepo.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = outboxRepo.FindDue(context.Background(), now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, messages[1].Id, due[0].Id)
+		assert.Equal(t, 1, due[0].Attempts)
+	}
+}
@@ -0,0 +1,159 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func createProducts(t *testing.T, repo repositories.ProductRepository, seller *entities.ValidatedSeller, prices map[string]int64) {
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"Red Shoe", "Blue Shoe", "Hat", "Green Shoe", "Scarf"} {
+		product := entities.NewProduct(name, entities.Money{Amount: prices[name], Currency: entities.CurrencyUSD}, *seller)
+		product.CreatedAt = createdAt
+		createdAt = createdAt.Add(24 * time.Hour)
+
+		validatedProduct, err := entities.NewValidatedProduct(product)
+		assert.NoError(t, err)
+		_, err = repo.Create(context.Background(), validatedProduct)
+		assert.NoError(t, err)
+	}
+}
+
+func productNames(page *repositories.ProductPage) []string {
+	names := make([]string, len(page.Products))
+	for i, product := range page.Products {
+		names[i] = product.Name
+	}
+	return names
+}
+
+func TestGormProductRepository_FindPage(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	seller := getPersistedSeller(gormDB)
+	createProducts(t, repo, &seller, map[string]int64{"Red Shoe": 4999, "Blue Shoe": 2999, "Hat": 1999, "Green Shoe": 2999, "Scarf": 999})
+
+	// Offset pagination
+	sortByPrice := repositories.SortOrder{Field: repositories.SortByPrice}
+	page, err := repo.FindPage(context.Background(), repositories.ProductFilter{}, sortByPrice, repositories.PageRequest{Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), page.TotalCount)
+	assert.Len(t, page.Products, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Filters
+	sellerId := seller.Id
+	minPrice := entities.Money{Amount: 2000, Currency: entities.CurrencyUSD}
+	createdBefore := time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)
+	page, err = repo.FindPage(context.Background(), repositories.ProductFilter{
+		NameContains:  "SHOE",
+		SellerId:      &sellerId,
+		MinPrice:      &minPrice,
+		CreatedBefore: &createdBefore,
+	}, repositories.SortOrder{Field: repositories.SortByName, Descending: true}, repositories.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Red Shoe", "Blue Shoe"}, productNames(page))
+	assert.Equal(t, int64(2), page.TotalCount)
+	assert.Empty(t, page.NextCursor)
+
+	// Prices of other currencies do not match a price range
+	otherCurrency := entities.Money{Amount: 0, Currency: entities.CurrencyEUR}
+	page, err = repo.FindPage(context.Background(), repositories.ProductFilter{MinPrice: &otherCurrency}, sortByPrice, repositories.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Products)
+
+	// Wildcards in the name filter are matched literally
+	page, err = repo.FindPage(context.Background(), repositories.ProductFilter{NameContains: "%"}, sortByPrice, repositories.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Products)
+
+	otherSeller := uuid.New()
+	page, err = repo.FindPage(context.Background(), repositories.ProductFilter{SellerId: &otherSeller}, sortByPrice, repositories.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Products)
+}
+
+func TestGormProductRepository_FindPageWithCursor(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	seller := getPersistedSeller(gormDB)
+	createProducts(t, repo, &seller, map[string]int64{"Red Shoe": 4999, "Blue Shoe": 2999, "Hat": 1999, "Green Shoe": 2999, "Scarf": 999})
+
+	// Walk through all pages, products with the same price must neither be skipped nor repeated
+	for _, sort := range []repositories.SortOrder{
+		{Field: repositories.SortByPrice},
+		{Field: repositories.SortByPrice, Descending: true},
+		{Field: repositories.SortByName},
+		{Field: repositories.SortByCreatedAt, Descending: true},
+	} {
+		var names []string
+		pageRequest := repositories.PageRequest{Limit: 2}
+		for {
+			page, err := repo.FindPage(context.Background(), repositories.ProductFilter{}, sort, pageRequest)
+			if !assert.NoError(t, err) {
+				return
+			}
+			names = append(names, productNames(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			pageRequest.Cursor = page.NextCursor
+		}
+
+		allPage, err := repo.FindPage(context.Background(), repositories.ProductFilter{}, sort, repositories.PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, productNames(allPage), names, "sort %+v", sort)
+	}
+
+	page, err := repo.FindPage(context.Background(), repositories.ProductFilter{}, repositories.SortOrder{Field: repositories.SortByPrice}, repositories.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Scarf", "Hat"}, productNames(page))
+
+	// A cursor cannot be used with another sort order
+	_, err = repo.FindPage(context.Background(), repositories.ProductFilter{}, repositories.SortOrder{Field: repositories.SortByName}, repositories.PageRequest{Limit: 2, Cursor: page.NextCursor})
+	assert.True(t, errors.Is(err, repositories.ErrInvalidCursor))
+
+	_, err = repo.FindPage(context.Background(), repositories.ProductFilter{}, repositories.SortOrder{Field: repositories.SortByName}, repositories.PageRequest{Limit: 2, Cursor: "garbage"})
+	assert.True(t, errors.Is(err, repositories.ErrInvalidCursor))
+}
+
+func TestSellerRepositoryFindPage(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormSellerRepository(gormDB)
+	for _, name := range []string{"Shoe Store", "Hat Shop", "Shoe Outlet"} {
+		validatedSeller, _ := entities.NewValidatedSeller(entities.NewSeller(name))
+		_, err := repo.Create(context.Background(), validatedSeller)
+		assert.NoError(t, err)
+	}
+
+	sortByName := repositories.SortOrder{Field: repositories.SortByName}
+	page, err := repo.FindPage(context.Background(), repositories.SellerFilter{NameContains: "shoe"}, sortByName, repositories.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.TotalCount)
+	if assert.Len(t, page.Sellers, 1) {
+		assert.Equal(t, "Shoe Outlet", page.Sellers[0].Name)
+	}
+
+	page, err = repo.FindPage(context.Background(), repositories.SellerFilter{NameContains: "shoe"}, sortByName, repositories.PageRequest{Limit: 1, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Sellers, 1) {
+		assert.Equal(t, "Shoe Store", page.Sellers[0].Name)
+	}
+	assert.Empty(t, page.NextCursor)
+
+	_, err = repo.FindPage(context.Background(), repositories.SellerFilter{}, repositories.SortOrder{Field: repositories.SortByPrice}, repositories.PageRequest{Limit: 1})
+	assert.Error(t, err)
+}
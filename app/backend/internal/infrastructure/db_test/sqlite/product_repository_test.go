@@ -1,7 +1,10 @@
 package sqlite_test
 
 import (
+	"context"
+	"errors"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -15,16 +18,28 @@ func setupDatabase() (*gorm.DB, func()) {
 		panic("Failed to connect to database")
 	}
 
-	// AutoMigrate our Product model
-	err = database.AutoMigrate(&postgres.Product{}, &postgres.Seller{})
+	// The schema migrations are written for PostgreSQL, so the tables are derived from the models
+	err = database.AutoMigrate(
+		&postgres.Seller{}, &postgres.Product{}, &postgres.UserModel{}, &postgres.RefreshTokenModel{},
+		&postgres.RevokedTokenModel{}, &postgres.LoginAttemptModel{}, &postgres.RoleModel{}, &postgres.RolePermissionModel{},
+		&postgres.SellerMembershipModel{}, &postgres.ExchangeRateModel{}, &postgres.OutboxMessageModel{},
+		&postgres.WebhookSubscriptionModel{}, &postgres.WebhookDeliveryModel{}, &postgres.IdempotencyRecordModel{},
+		&postgres.ProductSearchTerm{}, &postgres.AuditEntryModel{},
+	)
 	if err != nil {
 		panic("Failed to migrate database")
 	}
+	if err := createSearchTable(database); err != nil {
+		panic("Failed to create search table")
+	}
 
 	// Cleanup function to truncate tables
 	cleanup := func() {
 		database.Exec("DELETE FROM sellers")
 		database.Exec("DELETE FROM products")
+		database.Exec("DELETE FROM product_search_terms")
+		clearSearchTable(database)
+		database.Exec("DELETE FROM outbox_messages")
 	}
 
 	return database, cleanup
@@ -40,10 +55,10 @@ func TestGormProductRepository_Save(t *testing.T) {
 	seller := getPersistedSeller(gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
 
-	_, err := repo.Create(validProduct)
+	_, err := repo.Create(context.Background(), validProduct)
 	if err != nil {
 		t.Errorf("Unexpected error during save: %s", err)
 	}
@@ -58,11 +73,11 @@ func TestGormProductRepository_FindById(t *testing.T) {
 	seller := getPersistedSeller(gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	foundProduct, err := repo.FindById(validProduct.Id)
+	foundProduct, err := repo.FindById(context.Background(), validProduct.Id)
 	if err != nil || foundProduct.Name != "TestProduct" {
 		t.Error("Error fetching or product mismatch")
 	}
@@ -77,20 +92,20 @@ func TestGormProductRepository_Update(t *testing.T) {
 	seller := getPersistedSeller(gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	_, err := repo.Create(validProduct)
+	_, err := repo.Create(context.Background(), validProduct)
 	if err != nil {
 		t.Fatalf("Unexpected error during save: %s", err)
 	}
 
 	validProduct.Name = "UpdatedProduct"
-	_, err = repo.Update(validProduct)
+	_, err = repo.Update(context.Background(), validProduct)
 	if err != nil {
 		t.Errorf("Unexpected error during update: %s", err)
 	}
 
-	updatedProduct, _ := repo.FindById(validProduct.Id)
+	updatedProduct, _ := repo.FindById(context.Background(), validProduct.Id)
 	if updatedProduct.Name != "UpdatedProduct" {
 		t.Error("UpdateName failed or fetched wrong product")
 	}
@@ -105,11 +120,11 @@ func TestGormProductRepository_GetAll(t *testing.T) {
 	seller := getPersistedSeller(gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	products, err := repo.FindAll()
+	products, err := repo.FindAll(context.Background())
 	if err != nil || len(products) != 1 {
 		t.Error("Error fetching all products or product count mismatch")
 	}
@@ -123,19 +138,22 @@ func TestGormProductRepository_Delete(t *testing.T) {
 
 	seller := entities.NewSeller("TestSeller")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	err := repo.Delete(validProduct.Id)
+	err := repo.Delete(context.Background(), validProduct.Id, validProduct.Version)
 	if err != nil {
 		t.Errorf("Unexpected error during delete: %s", err)
 	}
 
-	_, err = repo.FindById(validProduct.Id)
+	_, err = repo.FindById(context.Background(), validProduct.Id)
 	if err == nil {
 		t.Error("Product should have been deleted, but was found")
 	}
+	if !errors.Is(err, repositories.ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, but got %s", err)
+	}
 }
 
 func getPersistedSeller(gormDB *gorm.DB) entities.ValidatedSeller {
@@ -143,7 +161,63 @@ func getPersistedSeller(gormDB *gorm.DB) entities.ValidatedSeller {
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
 
 	repo := postgres.NewGormSellerRepository(gormDB)
-	repo.Create(validatedSeller)
+	repo.Create(context.Background(), validatedSeller)
 
 	return *validatedSeller
 }
+
+func TestConvertLegacyPrices(t *testing.T) {
+	// Use a private database, the legacy schema must not leak into other tests
+	gormDB, err := gorm.Open(sqlite.Open("file:legacy_prices?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %s", err)
+	}
+
+	// Schema and rows as written before prices were stored in minor units
+	statements := []string{
+		"CREATE TABLE sellers (id TEXT PRIMARY KEY, name TEXT, created_at DATETIME, updated_at DATETIME)",
+		"CREATE TABLE products (id TEXT PRIMARY KEY, name TEXT, price REAL, seller_id TEXT, created_at DATETIME, updated_at DATETIME)",
+		"INSERT INTO sellers VALUES ('6f1c1d1e-5b3c-4d8e-9a6b-2f6a1f1e0c01', 'Legacy Seller', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+		"INSERT INTO products VALUES ('6f1c1d1e-5b3c-4d8e-9a6b-2f6a1f1e0c02', 'Legacy Product', 10.99, '6f1c1d1e-5b3c-4d8e-9a6b-2f6a1f1e0c01', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+		"INSERT INTO products VALUES ('6f1c1d1e-5b3c-4d8e-9a6b-2f6a1f1e0c03', 'Rounded Product', 1.005, '6f1c1d1e-5b3c-4d8e-9a6b-2f6a1f1e0c01', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+	}
+	for _, statement := range statements {
+		if err := gormDB.Exec(statement).Error; err != nil {
+			t.Fatalf("Failed to prepare legacy schema: %s", err)
+		}
+	}
+
+	if err := postgres.ConvertLegacyPrices(gormDB); err != nil {
+		t.Fatalf("Unexpected error during migration: %s", err)
+	}
+
+	if gormDB.Migrator().HasColumn(&postgres.Product{}, "price") {
+		t.Error("Expected legacy price column to be dropped")
+	}
+
+	// The columns added by later migrations are needed to read the products through the repository
+	if err := gormDB.AutoMigrate(&postgres.Seller{}, &postgres.Product{}); err != nil {
+		t.Fatalf("Failed to migrate database: %s", err)
+	}
+	repo := postgres.NewGormProductRepository(gormDB)
+	products, err := repo.FindAll(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	prices := map[string]entities.Money{}
+	for _, product := range products {
+		prices[product.Name] = product.Price
+	}
+	if prices["Legacy Product"] != (entities.Money{Amount: 1099, Currency: entities.CurrencyUSD}) {
+		t.Errorf("Expected 10.99 USD, but got %s", prices["Legacy Product"])
+	}
+	if prices["Rounded Product"] != (entities.Money{Amount: 101, Currency: entities.CurrencyUSD}) {
+		t.Errorf("Expected 1.01 USD, but got %s", prices["Rounded Product"])
+	}
+
+	// Running the migration again is a no-op
+	if err := postgres.ConvertLegacyPrices(gormDB); err != nil {
+		t.Errorf("Unexpected error during second migration: %s", err)
+	}
+}
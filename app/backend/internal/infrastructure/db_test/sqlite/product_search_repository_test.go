@@ -0,0 +1,157 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func createSearchableProducts(t *testing.T, repo repositories.ProductRepository, seller entities.ValidatedSeller, names ...string) []*entities.Product {
+	var products []*entities.Product
+	for _, name := range names {
+		validatedProduct, err := entities.NewValidatedProduct(entities.NewProduct(name, entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, seller))
+		assert.NoError(t, err)
+		product, err := repo.Create(context.Background(), validatedProduct)
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+	return products
+}
+
+func searchProductNames(t *testing.T, searchRepo repositories.ProductSearchRepository, text string) []string {
+	page, err := searchRepo.Search(context.Background(), repositories.ProductSearchQuery{Groups: entities.ParseSearchQuery(text), Limit: 10})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, hit := range page.Hits {
+		names = append(names, hit.Product.Name)
+	}
+	assert.Equal(t, int64(len(names)), page.TotalCount)
+	return names
+}
+
+func TestGormProductSearchRepository_Search(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+	createSearchableProducts(t, repo, getPersistedSeller(gormDB),
+		"Red Shoes", "Red Running Shoes with red laces", "Blue Shoes", "Red Socks")
+
+	// All words must match, products with fewer other words rank higher
+	assert.Equal(t, []string{"Red Shoes", "Red Running Shoes with red laces"}, searchProductNames(t, searchRepo, "red shoes"))
+
+	// Words also match as prefix
+	assert.ElementsMatch(t, []string{"Red Shoes", "Red Running Shoes with red laces", "Blue Shoes"}, searchProductNames(t, searchRepo, "sho"))
+
+	// Paging
+	page, err := searchRepo.Search(context.Background(), repositories.ProductSearchQuery{Groups: entities.ParseSearchQuery("red"), Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.TotalCount)
+	assert.Len(t, page.Hits, 1)
+	assert.NotNil(t, page.Hits[0].Product.Seller)
+}
+
+func TestGormProductSearchRepository_SearchJapanese(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+	createSearchableProducts(t, repo, getPersistedSeller(gormDB), "黒いスニーカー", "白いｽﾆｰｶｰ", "黒い革靴", "靴下")
+
+	// Katakana, hiragana and half-width katakana match each other
+	assert.ElementsMatch(t, []string{"黒いスニーカー", "白いｽﾆｰｶｰ"}, searchProductNames(t, searchRepo, "すにーかー"))
+	assert.Equal(t, []string{"黒いスニーカー"}, searchProductNames(t, searchRepo, "黒い スニーカー"))
+
+	// Single characters match within words
+	assert.ElementsMatch(t, []string{"黒い革靴", "靴下"}, searchProductNames(t, searchRepo, "靴"))
+	assert.Equal(t, []string{"黒い革靴"}, searchProductNames(t, searchRepo, "革靴"))
+}
+
+func TestGormProductSearchRepository_FindSimilarTerms(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+	createSearchableProducts(t, repo, getPersistedSeller(gormDB), "Leather Sneakers", "Sneaker Socks", "Snow Boots")
+
+	terms, err := searchRepo.FindSimilarTerms(context.Background(), "snaekers", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sneakers"}, terms)
+
+	terms, err = searchRepo.FindSimilarTerms(context.Background(), "boits", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"boots"}, terms)
+}
+
+func TestGormProductRepository_MaintainsSearchIndex(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+	seller := getPersistedSeller(gormDB)
+	products := createSearchableProducts(t, repo, seller, "Red Shoes")
+
+	// Update replaces the indexed terms
+	products[0].Name = "Green Boots"
+	validatedProduct, err := entities.NewValidatedProduct(products[0])
+	assert.NoError(t, err)
+	_, err = repo.Update(context.Background(), validatedProduct)
+	assert.NoError(t, err)
+	assert.Empty(t, searchProductNames(t, searchRepo, "shoes"))
+	assert.Equal(t, []string{"Green Boots"}, searchProductNames(t, searchRepo, "boots"))
+
+	// Delete removes the product from the index
+	assert.NoError(t, repo.Delete(context.Background(), products[0].Id, validatedProduct.Version))
+	assert.Empty(t, searchProductNames(t, searchRepo, "boots"))
+	terms, err := searchRepo.FindSimilarTerms(context.Background(), "boots", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, terms)
+
+	// Products stored without the repository are indexed on startup, the deleted product stays in the table
+	assert.NoError(t, gormDB.Omit("Seller").Create(&postgres.Product{Id: uuid.New(), Name: "Yellow Hat", PriceAmount: 999, PriceCurrency: "USD", SellerId: seller.Id}).Error)
+	assert.Empty(t, searchProductNames(t, searchRepo, "hat"))
+	assert.NoError(t, postgres.IndexProductsForSearch(gormDB))
+	assert.Equal(t, []string{"Yellow Hat"}, searchProductNames(t, searchRepo, "hat"))
+}
+
+func TestProductSearchBecomesAvailableAfterMigration(t *testing.T) {
+	// The search tables do not exist yet, e.g. because the migrations are applied after the deployment
+	gormDB, err := gorm.Open(sqlite.Open("file:search_migration?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.AutoMigrate(&postgres.Seller{}, &postgres.Product{}, &postgres.OutboxMessageModel{}))
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+	check := postgres.NewSearchIndexHealthCheck(gormDB)
+	seller := getPersistedSeller(gormDB)
+	createSearchableProducts(t, repo, seller, "Red Shoes")
+
+	_, err = searchRepo.Search(context.Background(), repositories.ProductSearchQuery{Groups: entities.ParseSearchQuery("shoes"), Limit: 10})
+	assert.ErrorIs(t, err, repositories.ErrProductSearchUnavailable)
+	assert.Equal(t, "search_index", check.Name())
+	assert.ErrorIs(t, check.Check(context.Background()), repositories.ErrProductSearchUnavailable)
+
+	// Once the migrations created the search tables, the readiness check indexes the products stored in the meantime
+	require.NoError(t, gormDB.AutoMigrate(&postgres.ProductSearchTerm{}))
+	require.NoError(t, gormDB.Exec("CREATE VIRTUAL TABLE product_search_fts USING fts5(product_id UNINDEXED, terms, tokenize = 'unicode61 remove_diacritics 0')").Error)
+	assert.NoError(t, check.Check(context.Background()))
+	assert.Equal(t, []string{"Red Shoes"}, searchProductNames(t, searchRepo, "shoes"))
+
+	// The repositories created before maintain the index from now on
+	createSearchableProducts(t, repo, seller, "Blue Shoes")
+	assert.ElementsMatch(t, []string{"Red Shoes", "Blue Shoes"}, searchProductNames(t, searchRepo, "shoes"))
+}
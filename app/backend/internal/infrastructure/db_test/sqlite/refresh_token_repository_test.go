@@ -0,0 +1,160 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenRepositoryRevokeFamily(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM refresh_tokens")
+
+	repo := postgres.NewGormRefreshTokenRepository(gormDB)
+
+	familyID := uuid.New().String()
+	first, _ := entities.NewRefreshToken(uuid.New().String(), "user-id", familyID, "hash-1", time.Now().Add(time.Hour))
+	second, _ := entities.NewRefreshToken(uuid.New().String(), "user-id", familyID, "hash-2", time.Now().Add(time.Hour))
+	assert.NoError(t, repo.Save(context.Background(), first))
+	assert.NoError(t, repo.Save(context.Background(), second))
+
+	found, err := repo.FindByTokenHash(context.Background(), "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+	assert.False(t, found.IsRevoked())
+
+	assert.NoError(t, repo.RevokeFamily(context.Background(), familyID, time.Now()))
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		found, err = repo.FindByTokenHash(context.Background(), hash)
+		assert.NoError(t, err)
+		assert.True(t, found.IsRevoked())
+	}
+
+	missing, err := repo.FindByTokenHash(context.Background(), "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestRefreshTokenRepositoryReplace(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM refresh_tokens")
+
+	repo := postgres.NewGormRefreshTokenRepository(gormDB)
+	token, _ := entities.NewRefreshToken(uuid.New().String(), "user-id", uuid.New().String(), "hash-replaced", time.Now().Add(time.Hour))
+	assert.NoError(t, repo.Save(context.Background(), token))
+
+	replaced, err := repo.Replace(context.Background(), token.ID, "successor-1", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+
+	// A concurrent rotation of the same token does not revoke it again
+	replaced, err = repo.Replace(context.Background(), token.ID, "successor-2", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+
+	found, err := repo.FindByTokenHash(context.Background(), "hash-replaced")
+	assert.NoError(t, err)
+	assert.True(t, found.IsRevoked())
+	assert.Equal(t, "successor-1", found.ReplacedByID)
+}
+
+func TestRevokedTokenRepository(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM revoked_tokens")
+
+	repo := postgres.NewGormRevokedTokenRepository(gormDB)
+
+	assert.NoError(t, repo.Revoke(context.Background(), "token-id", time.Now().Add(time.Minute)))
+	// Revoking twice is idempotent
+	assert.NoError(t, repo.Revoke(context.Background(), "token-id", time.Now().Add(time.Minute)))
+
+	revoked, err := repo.IsRevoked(context.Background(), "token-id")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, repo.DeleteExpired(context.Background(), time.Now().Add(time.Hour)))
+
+	revoked, err = repo.IsRevoked(context.Background(), "token-id")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestLoginAttemptRepository(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM login_attempts")
+
+	repo := postgres.NewGormLoginAttemptRepository(gormDB)
+
+	missing, err := repo.FindByClientIP(context.Background(), "192.0.2.1")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	now := time.Now()
+	for i := 1; i <= 2; i++ {
+		attempt, err := repo.RecordFailure(context.Background(), "192.0.2.1", now, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, i, attempt.FailedAttempts)
+	}
+
+	// Failures outside of the window are forgotten
+	attempt, err := repo.RecordFailure(context.Background(), "192.0.2.1", now.Add(2*time.Minute), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.FailedAttempts)
+
+	assert.NoError(t, repo.Block(context.Background(), "192.0.2.1", now.Add(time.Hour)))
+	found, err := repo.FindByClientIP(context.Background(), "192.0.2.1")
+	assert.NoError(t, err)
+	assert.True(t, found.IsBlocked(now))
+	assert.False(t, found.IsBlocked(now.Add(time.Hour)))
+
+	assert.NoError(t, repo.Delete(context.Background(), "192.0.2.1"))
+	missing, err = repo.FindByClientIP(context.Background(), "192.0.2.1")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestRoleRepository(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM role_permissions")
+	defer gormDB.Exec("DELETE FROM roles")
+
+	repo := postgres.NewGormRoleRepository(gormDB)
+
+	role, _ := entities.NewRole("editor", "Edits the catalog", []entities.Permission{
+		entities.PermissionProductWrite,
+		entities.PermissionSellerWrite,
+	})
+	assert.NoError(t, repo.Save(context.Background(), role))
+
+	found, err := repo.FindByName(context.Background(), "editor")
+	assert.NoError(t, err)
+	assert.Equal(t, "Edits the catalog", found.Description)
+	assert.ElementsMatch(t, role.Permissions, found.Permissions)
+
+	// Saving replaces the permissions
+	assert.NoError(t, found.SetPermissions([]entities.Permission{entities.PermissionUserRead}))
+	assert.NoError(t, repo.Save(context.Background(), found))
+
+	found, err = repo.FindByName(context.Background(), "editor")
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Permission{entities.PermissionUserRead}, found.Permissions)
+
+	roles, err := repo.FindAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, roles, 1)
+
+	assert.NoError(t, repo.Delete(context.Background(), "editor"))
+	missing, err := repo.FindByName(context.Background(), "editor")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,15 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import "gorm.io/gorm"
+
+// createSearchTable creates the FTS5 table of the SQLite search backend
+func createSearchTable(database *gorm.DB) error {
+	return database.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS product_search_fts USING fts5(product_id UNINDEXED, terms, tokenize = 'unicode61 remove_diacritics 0')").Error
+}
+
+// clearSearchTable removes the indexed products
+func clearSearchTable(database *gorm.DB) {
+	database.Exec("DELETE FROM product_search_fts")
+}
@@ -0,0 +1,12 @@
+//go:build !sqlite_fts5
+
+package sqlite_test
+
+import "gorm.io/gorm"
+
+// createSearchTable does nothing without FTS5, products are stored without search index then
+func createSearchTable(database *gorm.DB) error {
+	return nil
+}
+
+func clearSearchTable(database *gorm.DB) {}
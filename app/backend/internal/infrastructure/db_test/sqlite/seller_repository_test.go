@@ -1,6 +1,8 @@
 package sqlite_test
 
 import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
 	"testing"
 
@@ -17,7 +19,7 @@ func TestSellerRepositorySave(t *testing.T) {
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
 
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.Nil(t, err)
 
 	// More assertions related to saving can go here.
@@ -31,9 +33,9 @@ func TestSellerRepositoryFindById(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 
-	fetchedSeller, err := repo.FindById(validatedSeller.Seller.Id)
+	fetchedSeller, err := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.Nil(t, err)
 	assert.Equal(t, "John", fetchedSeller.Name)
 
@@ -48,15 +50,15 @@ func TestSellerRepositoryGetAll(t *testing.T) {
 
 	seller1 := entities.NewSeller("John")
 	validatedSeller1, _ := entities.NewValidatedSeller(seller1)
-	_, err := repo.Create(validatedSeller1)
+	_, err := repo.Create(context.Background(), validatedSeller1)
 	assert.NoError(t, err)
 
 	seller2 := entities.NewSeller("Jane")
 	validatedSeller2, _ := entities.NewValidatedSeller(seller2)
-	_, err = repo.Create(validatedSeller2)
+	_, err = repo.Create(context.Background(), validatedSeller2)
 	assert.NoError(t, err)
 
-	allSellers, err := repo.FindAll()
+	allSellers, err := repo.FindAll(context.Background())
 	assert.Nil(t, err)
 	assert.Len(t, allSellers, 2)
 	// You could further assert the contents of the sellers if needed.
@@ -70,15 +72,15 @@ func TestSellerRepositoryUpdate(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.NoError(t, err)
 
 	// UpdateName name and validate
 	validatedSeller.Seller.Name = "Johnny"
-	_, err = repo.Update(validatedSeller)
+	_, err = repo.Update(context.Background(), validatedSeller)
 	assert.Nil(t, err)
 
-	updatedSeller, _ := repo.FindById(validatedSeller.Seller.Id)
+	updatedSeller, _ := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.Equal(t, "Johnny", updatedSeller.Name)
 }
 
@@ -90,14 +92,93 @@ func TestSellerRepositoryDelete(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.NoError(t, err)
 
-	err = repo.Delete(validatedSeller.Seller.Id)
+	err = repo.Delete(context.Background(), validatedSeller.Seller.Id, validatedSeller.Seller.Version)
 	assert.Nil(t, err)
 
 	// Try to find the deleted seller
-	deletedSeller, err := repo.FindById(validatedSeller.Seller.Id)
+	deletedSeller, err := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.NotNil(t, err) // Expect an error since the seller should be deleted
 	assert.Nil(t, deletedSeller)
 }
+
+func TestSellerMembershipRepository(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM seller_memberships")
+
+	repo := postgres.NewGormSellerMembershipRepository(gormDB)
+
+	seller := entities.NewSeller("Example Seller")
+	owner, _ := entities.NewSellerMembership(seller.Id, "owner-id", entities.SellerMemberRoleOwner)
+	member, _ := entities.NewSellerMembership(seller.Id, "member-id", entities.SellerMemberRoleMember)
+	assert.NoError(t, repo.Save(context.Background(), owner))
+	assert.NoError(t, repo.Save(context.Background(), member))
+
+	found, err := repo.Find(context.Background(), seller.Id, "owner-id")
+	assert.NoError(t, err)
+	assert.True(t, found.IsOwner())
+
+	// Saving again updates the role
+	member.Role = entities.SellerMemberRoleOwner
+	assert.NoError(t, repo.Save(context.Background(), member))
+	found, err = repo.Find(context.Background(), seller.Id, "member-id")
+	assert.NoError(t, err)
+	assert.True(t, found.IsOwner())
+
+	memberships, err := repo.FindBySeller(context.Background(), seller.Id)
+	assert.NoError(t, err)
+	assert.Len(t, memberships, 2)
+
+	memberships, err = repo.FindByUser(context.Background(), "owner-id")
+	assert.NoError(t, err)
+	assert.Len(t, memberships, 1)
+
+	assert.NoError(t, repo.Delete(context.Background(), seller.Id, "owner-id"))
+	missing, err := repo.Find(context.Background(), seller.Id, "owner-id")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	assert.NoError(t, repo.DeleteBySeller(context.Background(), seller.Id))
+	memberships, err = repo.FindBySeller(context.Background(), seller.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, memberships)
+}
+
+func TestSellerRepositoryCancelledContext(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormSellerRepository(gormDB)
+	validatedSeller, _ := entities.NewValidatedSeller(entities.NewSeller("John"))
+
+	// Queries of cancelled requests are not executed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.Create(ctx, validatedSeller)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.FindById(ctx, validatedSeller.Seller.Id)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.FindById(context.Background(), validatedSeller.Seller.Id)
+	assert.ErrorIs(t, err, repositories.ErrSellerNotFound)
+}
+
+func TestSellerRepositoryCreateDuplicate(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormSellerRepository(gormDB)
+
+	validatedSeller, _ := entities.NewValidatedSeller(entities.NewSeller("John"))
+	_, err := repo.Create(context.Background(), validatedSeller)
+	assert.NoError(t, err)
+
+	_, err = repo.Create(context.Background(), validatedSeller)
+	assert.ErrorIs(t, err, repositories.ErrAlreadyExists)
+	assert.ErrorIs(t, err, entities.ErrConflict)
+}
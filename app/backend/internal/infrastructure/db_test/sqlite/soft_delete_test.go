@@ -0,0 +1,112 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGormProductRepository_SoftDeleteAndRestore(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	ctx := context.Background()
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+
+	seller := getPersistedSeller(gormDB)
+	product := createSearchableProducts(t, repo, seller, "Restorable shoe")[0]
+	require.NoError(t, repo.Delete(ctx, product.Id, product.Version))
+
+	_, err := repo.FindById(ctx, product.Id)
+	assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+	deleted, err := repo.FindByIdIncludingDeleted(ctx, product.Id)
+	require.NoError(t, err)
+	assert.True(t, deleted.IsDeleted())
+	assert.Empty(t, searchProductNames(t, searchRepo, "restorable"))
+
+	page, err := repo.FindPage(ctx, repositories.ProductFilter{}, repositories.SortOrder{Field: repositories.SortByName}, repositories.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Products)
+	page, err = repo.FindPage(ctx, repositories.ProductFilter{IncludeDeleted: true}, repositories.SortOrder{Field: repositories.SortByName}, repositories.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Products, 1)
+
+	restored, err := repo.Restore(ctx, product.Id)
+	require.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+	assert.Equal(t, product.Version+1, restored.Version)
+	assert.Equal(t, []string{"Restorable shoe"}, searchProductNames(t, searchRepo, "restorable"))
+
+	_, err = repo.Restore(ctx, product.Id)
+	assert.ErrorIs(t, err, repositories.ErrProductNotFound, "Only deleted products can be restored")
+}
+
+func TestGormProductRepository_RestoreBySeller(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	ctx := context.Background()
+	repo := postgres.NewGormProductRepository(gormDB)
+
+	seller := getPersistedSeller(gormDB)
+	products := createSearchableProducts(t, repo, seller, "Deleted before", "Deleted with seller")
+	require.NoError(t, repo.Delete(ctx, products[0].Id, products[0].Version))
+	deletedSince := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, repo.DeleteBySeller(ctx, seller.Id))
+
+	require.NoError(t, repo.RestoreBySeller(ctx, seller.Id, deletedSince))
+
+	_, err := repo.FindById(ctx, products[0].Id)
+	assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+	_, err = repo.FindById(ctx, products[1].Id)
+	assert.NoError(t, err)
+}
+
+func TestGormRepositories_Purge(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	ctx := context.Background()
+	productRepo := postgres.NewGormProductRepository(gormDB)
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	membershipRepo := postgres.NewGormSellerMembershipRepository(gormDB)
+
+	seller := getPersistedSeller(gormDB)
+	require.NoError(t, membershipRepo.Save(ctx, &entities.SellerMembership{SellerId: seller.Id, UserId: "owner-id", Role: entities.SellerMemberRoleOwner}))
+	products := createSearchableProducts(t, productRepo, seller, "Expired", "Recent")
+	require.NoError(t, sellerRepo.Delete(ctx, seller.Id, seller.Version))
+	require.NoError(t, productRepo.DeleteBySeller(ctx, seller.Id))
+	expired := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, gormDB.Unscoped().Model(&postgres.Product{}).Where("id = ?", products[0].Id).Update("deleted_at", expired).Error)
+	require.NoError(t, gormDB.Unscoped().Model(&postgres.Seller{}).Where("id = ?", seller.Id).Update("deleted_at", expired).Error)
+	deletedBefore := time.Now().Add(-24 * time.Hour)
+
+	purged, err := productRepo.Purge(ctx, deletedBefore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = productRepo.FindByIdIncludingDeleted(ctx, products[0].Id)
+	assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+
+	// The seller is kept as long as one of its products is left
+	purged, err = sellerRepo.Purge(ctx, deletedBefore)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	require.NoError(t, gormDB.Unscoped().Model(&postgres.Product{}).Where("id = ?", products[1].Id).Update("deleted_at", expired).Error)
+	_, err = productRepo.Purge(ctx, deletedBefore)
+	require.NoError(t, err)
+	purged, err = sellerRepo.Purge(ctx, deletedBefore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = sellerRepo.FindByIdIncludingDeleted(ctx, seller.Id)
+	assert.ErrorIs(t, err, repositories.ErrSellerNotFound)
+	membership, err := membershipRepo.Find(ctx, seller.Id, "owner-id")
+	require.NoError(t, err)
+	assert.Nil(t, membership)
+}
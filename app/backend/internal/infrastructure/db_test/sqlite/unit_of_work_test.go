@@ -0,0 +1,118 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGormUnitOfWork_CommitsAndRollsBack(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM seller_memberships")
+
+	unitOfWork := postgres.NewGormUnitOfWork(gormDB)
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	productRepo := postgres.NewGormProductRepository(gormDB)
+	membershipRepo := postgres.NewGormSellerMembershipRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+	auditRepo := postgres.NewGormAuditRepository(gormDB)
+
+	// A failing unit of work leaves nothing behind, not even its domain events
+	failure := errors.New("payment provider unavailable")
+	rolledBack, _ := entities.NewValidatedSeller(entities.NewSeller("Rolled Back"))
+	err := unitOfWork.Do(context.Background(), func(tx repositories.Transaction) error {
+		if _, err := tx.Sellers().Create(context.Background(), rolledBack); err != nil {
+			return err
+		}
+		product, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *rolledBack))
+		if _, err := tx.Products().Create(context.Background(), product); err != nil {
+			return err
+		}
+		membership, _ := entities.NewSellerMembership(rolledBack.Id, "owner", entities.SellerMemberRoleOwner)
+		if err := tx.SellerMemberships().Save(context.Background(), membership); err != nil {
+			return err
+		}
+		entry := entities.NewAuditEntry("owner", entities.AuditActionCreate, entities.AuditAggregateSeller, rolledBack.Id.String(), nil, entities.AuditSnapshot{"name": "Rolled Back"}, "")
+		if err := tx.Audit().Append(context.Background(), entry); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	sellers, _ := sellerRepo.FindAll(context.Background())
+	assert.Empty(t, sellers)
+	products, _ := productRepo.FindAll(context.Background())
+	assert.Empty(t, products)
+	memberships, _ := membershipRepo.FindBySeller(context.Background(), rolledBack.Id)
+	assert.Empty(t, memberships)
+	messages, _ := outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.Empty(t, messages)
+	auditPage, _ := auditRepo.FindPage(context.Background(), repositories.AuditFilter{AggregateId: rolledBack.Id.String()}, repositories.PageRequest{})
+	assert.Empty(t, auditPage.Entries)
+
+	// A successful unit of work commits every write
+	committed, _ := entities.NewValidatedSeller(entities.NewSeller("Committed"))
+	err = unitOfWork.Do(context.Background(), func(tx repositories.Transaction) error {
+		if _, err := tx.Sellers().Create(context.Background(), committed); err != nil {
+			return err
+		}
+		// Writes are visible within the transaction
+		stored, err := tx.Sellers().FindById(context.Background(), committed.Id)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "Committed", stored.Name)
+
+		membership, _ := entities.NewSellerMembership(committed.Id, "owner", entities.SellerMemberRoleOwner)
+		return tx.SellerMemberships().Save(context.Background(), membership)
+	})
+	assert.NoError(t, err)
+
+	sellers, _ = sellerRepo.FindAll(context.Background())
+	assert.Len(t, sellers, 1)
+	memberships, _ = membershipRepo.FindBySeller(context.Background(), committed.Id)
+	assert.Len(t, memberships, 1)
+	messages, _ = outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, entities.EventSellerCreated, messages[0].EventName)
+	}
+}
+
+func TestGormProductRepository_DeleteBySeller(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	productRepo := postgres.NewGormProductRepository(gormDB)
+	outboxRepo := postgres.NewGormOutboxRepository(gormDB)
+
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("Seller"))
+	other, _ := entities.NewValidatedSeller(entities.NewSeller("Other"))
+	_, _ = sellerRepo.Create(context.Background(), seller)
+	_, _ = sellerRepo.Create(context.Background(), other)
+	for _, owner := range []*entities.ValidatedSeller{seller, seller, other} {
+		product, _ := entities.NewValidatedProduct(entities.NewProduct("Shoe", entities.Money{Amount: 1000, Currency: entities.CurrencyUSD}, *owner))
+		_, err := productRepo.Create(context.Background(), product)
+		assert.NoError(t, err)
+	}
+	gormDB.Exec("DELETE FROM outbox_messages")
+
+	assert.NoError(t, productRepo.DeleteBySeller(context.Background(), seller.Id))
+
+	products, _ := productRepo.FindAll(context.Background())
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, other.Id, products[0].Seller.Id)
+	}
+	messages, _ := outboxRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.Len(t, messages, 2)
+	for _, message := range messages {
+		assert.Equal(t, entities.EventProductDeleted, message.EventName)
+	}
+}
@@ -0,0 +1,177 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGormProductRepository_UpdateDetectsStaleVersion(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	seller := getPersistedSeller(gormDB)
+	validProduct, _ := entities.NewValidatedProduct(entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, seller))
+	created, err := repo.Create(context.Background(), validProduct)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	// Two clients read the same version
+	first, _ := repo.FindById(context.Background(), created.Id)
+	second, _ := repo.FindById(context.Background(), created.Id)
+
+	assert.NoError(t, first.UpdateName("First"))
+	validFirst, _ := entities.NewValidatedProduct(first)
+	updated, err := repo.Update(context.Background(), validFirst)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	// The second write would overwrite the first one
+	assert.NoError(t, second.UpdateName("Second"))
+	validSecond, _ := entities.NewValidatedProduct(second)
+	_, err = repo.Update(context.Background(), validSecond)
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	assert.ErrorIs(t, err, entities.ErrConflict)
+
+	stored, _ := repo.FindById(context.Background(), created.Id)
+	assert.Equal(t, "First", stored.Name)
+	assert.Equal(t, 2, stored.Version)
+
+	// Deleting the version read before the update would discard it
+	assert.ErrorIs(t, repo.Delete(context.Background(), created.Id, second.Version), repositories.ErrVersionConflict)
+
+	// Deleted products are reported as missing, not as conflict
+	assert.NoError(t, repo.Delete(context.Background(), created.Id, stored.Version))
+	_, err = repo.Update(context.Background(), validFirst)
+	assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+}
+
+func TestSellerRepositoryUpdateDetectsStaleVersion(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormSellerRepository(gormDB)
+	validatedSeller, _ := entities.NewValidatedSeller(entities.NewSeller("John"))
+	_, err := repo.Create(context.Background(), validatedSeller)
+	assert.NoError(t, err)
+
+	stale, _ := repo.FindById(context.Background(), validatedSeller.Seller.Id)
+
+	validatedSeller.Seller.Name = "Johnny"
+	updated, err := repo.Update(context.Background(), validatedSeller)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	assert.NoError(t, stale.UpdateName("Jack"))
+	validatedStale, _ := entities.NewValidatedSeller(stale)
+	_, err = repo.Update(context.Background(), validatedStale)
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+
+	stored, _ := repo.FindById(context.Background(), validatedSeller.Seller.Id)
+	assert.Equal(t, "Johnny", stored.Name)
+}
+
+func TestGormUserRepository_SaveDetectsStaleVersion(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM users")
+
+	repo := postgres.NewGormUserRepository(gormDB)
+
+	user, _ := entities.NewUser("version-user", "version", "version@example.com", "hash")
+	assert.NoError(t, repo.Save(context.Background(), user))
+	assert.Equal(t, 1, user.Version)
+
+	first, _ := repo.FindByID(context.Background(), "version-user")
+	second, _ := repo.FindByID(context.Background(), "version-user")
+
+	_, err := first.RecordFailedLogin(time.Now(), 5, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(context.Background(), first))
+	assert.Equal(t, 2, first.Version)
+
+	// Zero values are written too
+	first.ResetFailedLogins()
+	assert.NoError(t, repo.Save(context.Background(), first))
+	stored, _ := repo.FindByID(context.Background(), "version-user")
+	assert.Equal(t, 0, stored.FailedLoginAttempts)
+	assert.Equal(t, 3, stored.Version)
+
+	assert.NoError(t, second.Lock("fraud", time.Time{}))
+	assert.ErrorIs(t, repo.Save(context.Background(), second), repositories.ErrVersionConflict)
+}
+
+func TestGormUserRepository_IncrementFailedLogins(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM users")
+
+	repo := postgres.NewGormUserRepository(gormDB)
+
+	user, _ := entities.NewUser("failing-user", "failing", "failing@example.com", "hash")
+	assert.NoError(t, repo.Save(context.Background(), user))
+
+	// Concurrent failures are all counted
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.IncrementFailedLogins(context.Background(), "failing-user")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	counted, err := repo.IncrementFailedLogins(context.Background(), "failing-user")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, counted.FailedLoginAttempts)
+	assert.Equal(t, 7, counted.Version)
+
+	// Users read before the failures are stale
+	assert.NoError(t, user.Lock("fraud", time.Time{}))
+	assert.ErrorIs(t, repo.Save(context.Background(), user), repositories.ErrVersionConflict)
+
+	missing, err := repo.IncrementFailedLogins(context.Background(), "missing-user")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestGormSellerRepository_DeleteDetectsStaleVersion(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	repo := postgres.NewGormSellerRepository(gormDB)
+	seller := getPersistedSeller(gormDB)
+	stale := seller.Seller.Version
+
+	assert.NoError(t, seller.Seller.UpdateName("Renamed"))
+	_, err := repo.Update(context.Background(), &seller)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, repo.Delete(context.Background(), seller.Id, stale), repositories.ErrVersionConflict)
+	assert.NoError(t, repo.Delete(context.Background(), seller.Id, seller.Version))
+	assert.ErrorIs(t, repo.Delete(context.Background(), seller.Id, seller.Version), repositories.ErrSellerNotFound)
+}
+
+func TestGormUserRepository_DeleteDetectsStaleVersion(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+	defer gormDB.Exec("DELETE FROM users")
+
+	repo := postgres.NewGormUserRepository(gormDB)
+	user, _ := entities.NewUser("deleted-user", "deleted", "deleted@example.com", "hash")
+	assert.NoError(t, repo.Save(context.Background(), user))
+	assert.NoError(t, user.UpdateEmail("changed@example.com"))
+	assert.NoError(t, repo.Save(context.Background(), user))
+
+	assert.ErrorIs(t, repo.Delete(context.Background(), "deleted-user", 1), repositories.ErrVersionConflict)
+	assert.NoError(t, repo.Delete(context.Background(), "deleted-user", user.Version))
+	assert.NoError(t, repo.Delete(context.Background(), "deleted-user", user.Version), "Users which are gone are deleted already")
+}
@@ -0,0 +1,89 @@
+package sqlite_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGormWebhookRepositories(t *testing.T) {
+	gormDB, cleanup := setupDatabase()
+	defer cleanup()
+
+	subscriptionRepo := postgres.NewGormWebhookSubscriptionRepository(gormDB)
+	deliveryRepo := postgres.NewGormWebhookDeliveryRepository(gormDB)
+	defer gormDB.Exec("DELETE FROM webhook_subscriptions")
+	defer gormDB.Exec("DELETE FROM webhook_deliveries")
+
+	subscription, err := entities.NewWebhookSubscription("https://partner.example.com/hooks", []string{entities.EventProductCreated, entities.EventProductDeleted}, "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, subscriptionRepo.Save(context.Background(), subscription))
+
+	loaded, err := subscriptionRepo.FindById(context.Background(), subscription.Id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, loaded) {
+		assert.Equal(t, subscription.URL, loaded.URL)
+		assert.Equal(t, subscription.EventTypes, loaded.EventTypes)
+		assert.Equal(t, "secret", loaded.Secret)
+	}
+
+	eventId := entities.NewSeller("Seller").Id
+	first := entities.NewWebhookDelivery(subscription.Id, eventId, entities.EventProductCreated, []byte(`{"Type":"product.created"}`))
+	second := entities.NewWebhookDelivery(subscription.Id, entities.NewSeller("Seller").Id, entities.EventProductDeleted, []byte(`{}`))
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	assert.NoError(t, deliveryRepo.Save(context.Background(), first))
+	assert.NoError(t, deliveryRepo.Save(context.Background(), second))
+
+	exists, err := deliveryRepo.ExistsForEvent(context.Background(), subscription.Id, eventId)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Delivered and future deliveries are not due
+	first.RecordSuccess(200, time.Now())
+	assert.NoError(t, deliveryRepo.Save(context.Background(), first))
+	second.RecordFailure(500, "endpoint responded with status 500", time.Now().Add(time.Hour))
+	assert.NoError(t, deliveryRepo.Save(context.Background(), second))
+	due, err := deliveryRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	replay := first.Replay()
+	replay.CreatedAt = second.CreatedAt.Add(time.Second)
+	assert.NoError(t, deliveryRepo.Save(context.Background(), replay))
+	due, err = deliveryRepo.FindDue(context.Background(), time.Now(), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, replay.Id, due[0].Id)
+		assert.Equal(t, first.Id, *due[0].ReplayOf)
+		assert.Equal(t, first.Payload, due[0].Payload)
+	}
+
+	loadedDelivery, err := deliveryRepo.FindById(context.Background(), first.Id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, loadedDelivery) {
+		assert.Equal(t, entities.WebhookDeliverySucceeded, loadedDelivery.Status)
+		assert.Equal(t, 200, loadedDelivery.ResponseStatus)
+		assert.False(t, loadedDelivery.DeliveredAt.IsZero())
+	}
+
+	page, err := deliveryRepo.FindBySubscription(context.Background(), subscription.Id, repositories.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.TotalCount)
+	if assert.Len(t, page.Deliveries, 2) {
+		assert.Equal(t, replay.Id, page.Deliveries[0].Id, "newest first")
+		assert.Equal(t, second.Id, page.Deliveries[1].Id)
+	}
+
+	// Deleting a subscription removes its deliveries
+	assert.NoError(t, subscriptionRepo.Delete(context.Background(), subscription.Id))
+	loaded, err = subscriptionRepo.FindById(context.Background(), subscription.Id)
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+	loadedDelivery, err = deliveryRepo.FindById(context.Background(), first.Id)
+	assert.NoError(t, err)
+	assert.Nil(t, loadedDelivery)
+}
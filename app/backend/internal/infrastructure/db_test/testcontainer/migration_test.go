@@ -0,0 +1,98 @@
+package testcontainer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+)
+
+// baselineSchema is the schema which GORM AutoMigrate created before the versioned migrations
+const baselineSchema = `
+CREATE TABLE "sellers" (
+	"id" text,
+	"name" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE TABLE "products" (
+	"id" text,
+	"name" text,
+	"price" decimal,
+	"seller_id" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_products_seller" FOREIGN KEY ("seller_id") REFERENCES "sellers"("id")
+);
+CREATE INDEX "idx_products_seller_id" ON "products" ("seller_id");
+CREATE TABLE "users" (
+	"id" text,
+	"username" text,
+	"email" text,
+	"password_hash" text,
+	"role" text,
+	"status" text,
+	"created_at" bigint,
+	"updated_at" bigint,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX "idx_users_username" ON "users" ("username");
+`
+
+func TestMigrationsUpgradeBaselineSchema(t *testing.T) {
+	gormDB, cleanup := startDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	sellerId, productId := uuid.New(), uuid.New()
+	if err := gormDB.Exec(baselineSchema).Error; err != nil {
+		t.Fatalf("Failed to create baseline schema: %s", err)
+	}
+	gormDB.Exec(`INSERT INTO sellers (id, name, created_at, updated_at) VALUES (?, 'Seller', now(), now())`, sellerId)
+	gormDB.Exec(`INSERT INTO products (id, name, price, seller_id, created_at, updated_at) VALUES (?, 'Product', 12.5, ?, now(), now())`, productId, sellerId)
+	gormDB.Exec(`INSERT INTO users (id, username, email, password_hash, role, status, created_at, updated_at)
+		VALUES ('user-id', 'user', 'user@example.com', 'hash', 'user', 'active', 1700000000, 1700000000)`)
+
+	migrator, err := postgres.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %s", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to migrate baseline schema: %s", err)
+	}
+
+	seller, err := postgres.NewGormSellerRepository(gormDB).FindById(ctx, sellerId)
+	if err != nil || seller.Version != 1 {
+		t.Errorf("Existing seller should be readable with version 1, got %+v, %v", seller, err)
+	}
+
+	product, err := postgres.NewGormProductRepository(gormDB).FindById(ctx, productId)
+	if err != nil {
+		t.Fatalf("Existing product should be readable: %s", err)
+	}
+	if product.Version != 1 || product.Price != (entities.Money{Amount: 1250, Currency: entities.DefaultCurrency}) {
+		t.Errorf("Existing product should have version 1 and a converted price, got %+v", product)
+	}
+
+	userRepo := postgres.NewGormUserRepository(gormDB)
+	user, err := userRepo.FindByID(ctx, "user-id")
+	if err != nil || user == nil {
+		t.Fatalf("Existing user should be readable: %v", err)
+	}
+	if user.Version != 1 || user.FailedLoginAttempts != 0 || !user.LockedUntil.IsZero() || !user.StatusChangedAt.IsZero() {
+		t.Errorf("Added user columns should default for existing rows, got %+v", user)
+	}
+
+	// The existing rows can be changed with the version that was read
+	if _, err := userRepo.IncrementFailedLogins(ctx, "user-id"); err != nil {
+		t.Errorf("Failed to record failed login of existing user: %s", err)
+	}
+	if err := userRepo.Delete(ctx, "user-id", 2); err != nil {
+		t.Errorf("Failed to delete existing user: %s", err)
+	}
+}
@@ -15,6 +15,22 @@ import (
 )
 
 func setupDatabase(t *testing.T) (*gorm.DB, func()) {
+	database, cleanup := startDatabase(t)
+
+	// Apply the schema migrations like the server does
+	migrator, err := postgres.NewMigrator(database)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %s", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate database: %s", err)
+	}
+
+	return database, cleanup
+}
+
+// startDatabase starts an empty database without any migrations applied
+func startDatabase(t *testing.T) (*gorm.DB, func()) {
 	ctx := context.Background()
 
 	// Define PostgreSQL container
@@ -59,12 +75,6 @@ func setupDatabase(t *testing.T) (*gorm.DB, func()) {
 		t.Fatalf("Failed to connect to database: %s", err)
 	}
 
-	// AutoMigrate our models
-	err = database.AutoMigrate(&postgres.Product{}, &postgres.Seller{})
-	if err != nil {
-		t.Fatalf("Failed to migrate database: %s", err)
-	}
-
 	// Cleanup function
 	cleanup := func() {
 		// Clean up database
@@ -89,10 +99,10 @@ func TestGormProductRepository_Save(t *testing.T) {
 	seller := getPersistedSeller(t, gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
 
-	_, err := repo.Create(validProduct)
+	_, err := repo.Create(context.Background(), validProduct)
 	if err != nil {
 		t.Errorf("Unexpected error during save: %s", err)
 	}
@@ -107,11 +117,11 @@ func TestGormProductRepository_FindById(t *testing.T) {
 	seller := getPersistedSeller(t, gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	foundProduct, err := repo.FindById(validProduct.Id)
+	foundProduct, err := repo.FindById(context.Background(), validProduct.Id)
 	if err != nil || foundProduct.Name != "TestProduct" {
 		t.Error("Error fetching or product mismatch")
 	}
@@ -126,20 +136,20 @@ func TestGormProductRepository_Update(t *testing.T) {
 	seller := getPersistedSeller(t, gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	_, err := repo.Create(validProduct)
+	_, err := repo.Create(context.Background(), validProduct)
 	if err != nil {
 		t.Fatalf("Unexpected error during save: %s", err)
 	}
 
 	validProduct.Name = "UpdatedProduct"
-	_, err = repo.Update(validProduct)
+	_, err = repo.Update(context.Background(), validProduct)
 	if err != nil {
 		t.Errorf("Unexpected error during update: %s", err)
 	}
 
-	updatedProduct, _ := repo.FindById(validProduct.Id)
+	updatedProduct, _ := repo.FindById(context.Background(), validProduct.Id)
 	if updatedProduct.Name != "UpdatedProduct" {
 		t.Error("UpdateName failed or fetched wrong product")
 	}
@@ -154,11 +164,11 @@ func TestGormProductRepository_GetAll(t *testing.T) {
 	seller := getPersistedSeller(t, gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	products, err := repo.FindAll()
+	products, err := repo.FindAll(context.Background())
 	if err != nil || len(products) != 1 {
 		t.Error("Error fetching all products or product count mismatch")
 	}
@@ -173,16 +183,16 @@ func TestGormProductRepository_Delete(t *testing.T) {
 	seller := getPersistedSeller(t, gormDB)
 	validatedSeller, _ := entities.NewValidatedSeller(&seller.Seller)
 
-	product := entities.NewProduct("TestProduct", 9.99, *validatedSeller)
+	product := entities.NewProduct("TestProduct", entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *validatedSeller)
 	validProduct, _ := entities.NewValidatedProduct(product)
-	repo.Create(validProduct)
+	repo.Create(context.Background(), validProduct)
 
-	err := repo.Delete(validProduct.Id)
+	err := repo.Delete(context.Background(), validProduct.Id, validProduct.Version)
 	if err != nil {
 		t.Errorf("Unexpected error during delete: %s", err)
 	}
 
-	_, err = repo.FindById(validProduct.Id)
+	_, err = repo.FindById(context.Background(), validProduct.Id)
 	if err == nil {
 		t.Error("Product should have been deleted, but was found")
 	}
@@ -193,7 +203,7 @@ func getPersistedSeller(t *testing.T, gormDB *gorm.DB) entities.ValidatedSeller
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
 
 	repo := postgres.NewGormSellerRepository(gormDB)
-	repo.Create(validatedSeller)
+	repo.Create(context.Background(), validatedSeller)
 
 	return *validatedSeller
 }
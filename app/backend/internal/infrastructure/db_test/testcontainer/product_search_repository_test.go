@@ -0,0 +1,55 @@
+package testcontainer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/db/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductSearchRepositorySearch(t *testing.T) {
+	gormDB, cleanup := setupDatabase(t)
+	defer cleanup()
+
+	sellerRepo := postgres.NewGormSellerRepository(gormDB)
+	seller, _ := entities.NewValidatedSeller(entities.NewSeller("John"))
+	_, err := sellerRepo.Create(context.Background(), seller)
+	assert.NoError(t, err)
+
+	repo := postgres.NewGormProductRepository(gormDB)
+	searchRepo := postgres.NewGormProductSearchRepository(gormDB)
+
+	var products []*entities.Product
+	for _, name := range []string{"Red Shoes", "Red Running Shoes with laces", "Blue Socks", "黒いスニーカー", "白いｽﾆｰｶｰ"} {
+		validatedProduct, _ := entities.NewValidatedProduct(entities.NewProduct(name, entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, *seller))
+		product, err := repo.Create(context.Background(), validatedProduct)
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+
+	search := func(text string) []string {
+		page, err := searchRepo.Search(context.Background(), repositories.ProductSearchQuery{Groups: entities.ParseSearchQuery(text), Limit: 10})
+		assert.NoError(t, err)
+
+		var names []string
+		for _, hit := range page.Hits {
+			names = append(names, hit.Product.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Red Shoes", "Red Running Shoes with laces"}, search("red sho"))
+	assert.ElementsMatch(t, []string{"黒いスニーカー", "白いｽﾆｰｶｰ"}, search("すにーかー"))
+	assert.Equal(t, []string{"黒いスニーカー"}, search("黒"))
+
+	// Updates replace the indexed terms
+	products[2].Name = "Blue Shoes"
+	validatedProduct, _ := entities.NewValidatedProduct(products[2])
+	_, err = repo.Update(context.Background(), validatedProduct)
+	assert.NoError(t, err)
+	assert.Empty(t, search("socks"))
+	assert.Equal(t, []string{"Blue Shoes"}, search("blue"))
+}
@@ -1,6 +1,7 @@
 package testcontainer_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
@@ -17,7 +18,7 @@ func TestSellerRepositorySave(t *testing.T) {
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
 
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.Nil(t, err)
 
 	// More assertions related to saving can go here.
@@ -31,9 +32,9 @@ func TestSellerRepositoryFindById(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 
-	fetchedSeller, err := repo.FindById(validatedSeller.Seller.Id)
+	fetchedSeller, err := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.Nil(t, err)
 	assert.Equal(t, "John", fetchedSeller.Name)
 
@@ -48,15 +49,15 @@ func TestSellerRepositoryGetAll(t *testing.T) {
 
 	seller1 := entities.NewSeller("John")
 	validatedSeller1, _ := entities.NewValidatedSeller(seller1)
-	_, err := repo.Create(validatedSeller1)
+	_, err := repo.Create(context.Background(), validatedSeller1)
 	assert.NoError(t, err)
 
 	seller2 := entities.NewSeller("Jane")
 	validatedSeller2, _ := entities.NewValidatedSeller(seller2)
-	_, err = repo.Create(validatedSeller2)
+	_, err = repo.Create(context.Background(), validatedSeller2)
 	assert.NoError(t, err)
 
-	allSellers, err := repo.FindAll()
+	allSellers, err := repo.FindAll(context.Background())
 	assert.Nil(t, err)
 	assert.Len(t, allSellers, 2)
 	// You could further assert the contents of the sellers if needed.
@@ -70,15 +71,15 @@ func TestSellerRepositoryUpdate(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.NoError(t, err)
 
 	// UpdateName name and validate
 	validatedSeller.Seller.Name = "Johnny"
-	_, err = repo.Update(validatedSeller)
+	_, err = repo.Update(context.Background(), validatedSeller)
 	assert.Nil(t, err)
 
-	updatedSeller, _ := repo.FindById(validatedSeller.Seller.Id)
+	updatedSeller, _ := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.Equal(t, "Johnny", updatedSeller.Name)
 }
 
@@ -90,14 +91,14 @@ func TestSellerRepositoryDelete(t *testing.T) {
 
 	seller := entities.NewSeller("John")
 	validatedSeller, _ := entities.NewValidatedSeller(seller)
-	_, err := repo.Create(validatedSeller)
+	_, err := repo.Create(context.Background(), validatedSeller)
 	assert.NoError(t, err)
 
-	err = repo.Delete(validatedSeller.Seller.Id)
+	err = repo.Delete(context.Background(), validatedSeller.Seller.Id, validatedSeller.Seller.Version)
 	assert.Nil(t, err)
 
 	// Try to find the deleted seller
-	deletedSeller, err := repo.FindById(validatedSeller.Seller.Id)
+	deletedSeller, err := repo.FindById(context.Background(), validatedSeller.Seller.Id)
 	assert.NotNil(t, err) // Expect an error since the seller should be deleted
 	assert.Nil(t, deletedSeller)
 }
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPSender implements the WebhookSender interface with a plain HTTP client
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender creates a new HTTPSender which gives up on endpoints not answering within the timeout
+func NewHTTPSender(timeout time.Duration) interfaces.WebhookSender {
+	return &HTTPSender{client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts the request body as JSON together with the request headers, cancelling the context aborts the request
+func (s *HTTPSender) Send(ctx context.Context, request interfaces.WebhookRequest) (int, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("User-Agent", "go-ddd-webhooks/1.0")
+	for name, value := range request.Headers {
+		httpRequest.Header.Set(name, value)
+	}
+
+	response, err := s.client.Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	// Drain the body so the connection can be reused, the content itself is not recorded
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	return response.StatusCode, nil
+}
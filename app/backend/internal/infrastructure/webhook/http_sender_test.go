@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(time.Second)
+	status, err := sender.Send(context.Background(), interfaces.WebhookRequest{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Webhook-Event": "product.created"},
+		Body:    []byte(`{"Type":"product.created"}`),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if status != http.StatusAccepted {
+		t.Errorf("Expected status 202, but got %d", status)
+	}
+	if received.Method != http.MethodPost || received.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON POST, but got %s %s", received.Method, received.Header.Get("Content-Type"))
+	}
+	if received.Header.Get("X-Webhook-Event") != "product.created" || string(body) != `{"Type":"product.created"}` {
+		t.Errorf("Unexpected request %v %s", received.Header, body)
+	}
+}
+
+func TestHTTPSender_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	status, err := NewHTTPSender(50*time.Millisecond).Send(context.Background(), interfaces.WebhookRequest{URL: server.URL})
+	if err == nil || status != 0 {
+		t.Errorf("Expected a timeout, but got status %d and error %v", status, err)
+	}
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Context keys under which the authenticated principal is stored
+const (
+	ContextKeyUserID    = "userID"
+	ContextKeyUserEmail = "userEmail"
+	ContextKeyClaims    = "claims"
+	ContextKeyUser      = "user"
+)
+
+// AccessTokenAuthorizer decides whether a validated access token may still be used
+type AccessTokenAuthorizer interface {
+	// AuthorizeAccessToken returns the user of the token unless the token was revoked
+	// or the user is no longer active
+	AuthorizeAccessToken(ctx context.Context, userID, tokenID string, issuedAt time.Time) (*entities.User, error)
+}
+
+// PermissionChecker resolves the permissions granted to a role
+type PermissionChecker interface {
+	// HasPermission reports whether the role grants the permission
+	HasPermission(ctx context.Context, role entities.UserRole, permission entities.Permission) (bool, error)
+}
+
+// Auth provides route level authentication and authorization middleware.
+// Controllers attach it to the routes which are not public:
+//
+//	e.GET("/api/v1/things", c.List)                          // public
+//	e.POST("/api/v1/things", c.Create, auth.Authenticated()) // any signed in user
+//	e.DELETE("/api/v1/things/:id", c.Delete, auth.Authenticated(),
+//		auth.RequirePermission(entities.PermissionProductWrite)) // users whose role grants the permission
+type Auth struct {
+	tokenManager *auth.TokenManager
+	authorizer   AccessTokenAuthorizer
+	permissions  PermissionChecker
+}
+
+// NewAuth creates a new Auth
+func NewAuth(tokenManager *auth.TokenManager, authorizer AccessTokenAuthorizer, permissions PermissionChecker) *Auth {
+	return &Auth{
+		tokenManager: tokenManager,
+		authorizer:   authorizer,
+		permissions:  permissions,
+	}
+}
+
+// Authenticated returns a middleware that checks for a valid authentication token.
+// It expects a Bearer token in the Authorization header.
+// The token is validated by the TokenManager (signature, kid, exp, iss and aud),
+// afterwards the token must not be revoked and the user must still be active.
+// If the token is valid, the user, user ID, email and claims are set in the context and the user ID
+// is added to the common.RequestScope of the request context,
+// otherwise a 401 Unauthorized response is returned.
+func (a *Auth) Authenticated() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// Get Authorization header
+			authHeader := ctx.Request().Header.Get("Authorization")
+			if authHeader == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authorization header is required")
+			}
+
+			// Check if it starts with "Bearer "
+			if !strings.HasPrefix(authHeader, "Bearer ") {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid authorization format")
+			}
+
+			// Validate token
+			claims, err := a.tokenManager.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+			}
+
+			// Reject revoked tokens and tokens of users whose status changed after issue
+			user, err := a.authorizer.AuthorizeAccessToken(ctx.Request().Context(), claims.UserID(), claims.ID, claims.IssuedAt.Time)
+			if err != nil {
+				if errors.Is(err, services.ErrTokenRevoked) || errors.Is(err, services.ErrUserNotActive) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+				}
+				return err
+			}
+
+			ctx.Set(ContextKeyUserID, claims.UserID())
+			ctx.Set(ContextKeyUserEmail, claims.Email)
+			ctx.Set(ContextKeyClaims, claims)
+			ctx.Set(ContextKeyUser, user)
+			ctx.SetRequest(ctx.Request().WithContext(common.WithUserId(ctx.Request().Context(), claims.UserID())))
+
+			return next(ctx)
+		}
+	}
+}
+
+// Optional returns a middleware that authenticates requests with an Authorization header like Authenticated,
+// requests without one pass unauthenticated. It lets public routes offer more to privileged users.
+func (a *Auth) Optional() echo.MiddlewareFunc {
+	authenticated := a.Authenticated()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withUser := authenticated(next)
+		return func(ctx echo.Context) error {
+			if ctx.Request().Header.Get("Authorization") == "" {
+				return next(ctx)
+			}
+			return withUser(ctx)
+		}
+	}
+}
+
+// RequireRole returns a middleware that only lets users with one of the given roles pass.
+// It must be placed after Authenticated.
+func (a *Auth) RequireRole(roles ...entities.UserRole) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			user := CurrentUser(ctx)
+			if user == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+			}
+
+			for _, role := range roles {
+				if user.Role == role {
+					return next(ctx)
+				}
+			}
+
+			return echo.NewHTTPError(http.StatusForbidden, "Insufficient role")
+		}
+	}
+}
+
+// RequirePermission returns a middleware that only lets users pass whose role grants all given permissions.
+// It must be placed after Authenticated.
+func (a *Auth) RequirePermission(permissions ...entities.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			user := CurrentUser(ctx)
+			if user == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+			}
+
+			for _, permission := range permissions {
+				granted, err := a.HasPermission(ctx, permission)
+				if err != nil {
+					return err
+				}
+				if !granted {
+					return echo.NewHTTPError(http.StatusForbidden, "Missing permission "+string(permission))
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// HasPermission reports whether the role of the authenticated user grants the permission.
+// Unauthenticated requests have no permissions.
+func (a *Auth) HasPermission(ctx echo.Context, permission entities.Permission) (bool, error) {
+	user := CurrentUser(ctx)
+	if user == nil {
+		return false, nil
+	}
+	return a.permissions.HasPermission(ctx.Request().Context(), user.Role, permission)
+}
+
+// CurrentUser returns the authenticated user, or nil if the request is not authenticated
+func CurrentUser(ctx echo.Context) *entities.User {
+	user, _ := ctx.Get(ContextKeyUser).(*entities.User)
+	return user
+}
+
+// UserID returns the ID of the authenticated user, or an empty string if the request is not authenticated
+func UserID(ctx echo.Context) string {
+	userID, _ := ctx.Get(ContextKeyUserID).(string)
+	return userID
+}
+
+// Claims returns the claims of the access token, or nil if the request is not authenticated
+func Claims(ctx echo.Context) *auth.Claims {
+	claims, _ := ctx.Get(ContextKeyClaims).(*auth.Claims)
+	return claims
+}
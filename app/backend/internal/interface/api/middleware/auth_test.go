@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stubAuthorizer authorizes tokens of the known users only
+type stubAuthorizer struct {
+	users map[string]*entities.User
+}
+
+func (s *stubAuthorizer) AuthorizeAccessToken(_ context.Context, userID, _ string, _ time.Time) (*entities.User, error) {
+	user, ok := s.users[userID]
+	if !ok || !user.IsActive() {
+		return nil, services.ErrUserNotActive
+	}
+	return user, nil
+}
+
+// stubPermissionChecker grants the permissions of the default roles
+type stubPermissionChecker struct{}
+
+func (s *stubPermissionChecker) HasPermission(_ context.Context, name entities.UserRole, permission entities.Permission) (bool, error) {
+	for _, role := range entities.DefaultRoles() {
+		if role.Name == name {
+			return role.HasPermission(permission), nil
+		}
+	}
+	return false, nil
+}
+
+func newTestAuth(t *testing.T, users ...*entities.User) (*Auth, *auth.TokenManager) {
+	tokenManager, err := auth.NewTokenManager(config.NewJWTConfig())
+	require.NoError(t, err)
+
+	authorizer := &stubAuthorizer{users: make(map[string]*entities.User)}
+	for _, user := range users {
+		authorizer.users[user.ID] = user
+	}
+
+	return NewAuth(tokenManager, authorizer, &stubPermissionChecker{}), tokenManager
+}
+
+func serve(e *echo.Echo, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuth_Authenticated(t *testing.T) {
+	user, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	authMiddleware, tokenManager := newTestAuth(t, user)
+
+	e := echo.New()
+	e.GET("/protected", func(ctx echo.Context) error {
+		assert.Equal(t, user.ID, UserID(ctx))
+		assert.Equal(t, user, CurrentUser(ctx))
+		assert.Equal(t, user.Email, Claims(ctx).Email)
+		assert.Equal(t, user.ID, common.RequestScopeFrom(ctx.Request().Context()).UserId)
+		return ctx.NoContent(http.StatusNoContent)
+	}, authMiddleware.Authenticated())
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "invalid").Code)
+
+	token, err := tokenManager.GenerateToken(user.ID, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, serve(e, token).Code)
+
+	// Tokens of users who are no longer active are rejected
+	require.NoError(t, user.UpdateStatus(entities.StatusLocked))
+	assert.Equal(t, http.StatusUnauthorized, serve(e, token).Code)
+}
+
+func TestAuth_RequireRole(t *testing.T) {
+	user, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	admin, _ := entities.NewUser("admin-id", "admin", "admin@example.com", "hashed-password")
+	require.NoError(t, admin.UpdateRole(entities.RoleAdmin))
+	authMiddleware, tokenManager := newTestAuth(t, user, admin)
+
+	e := echo.New()
+	e.GET("/protected", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, authMiddleware.Authenticated(), authMiddleware.RequireRole(entities.RoleAdmin))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "").Code)
+
+	userToken, err := tokenManager.GenerateToken(user.ID, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve(e, userToken).Code)
+
+	adminToken, err := tokenManager.GenerateToken(admin.ID, admin.Email)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, serve(e, adminToken).Code)
+}
+
+func TestAuth_RequirePermission(t *testing.T) {
+	user, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
+	manager, _ := entities.NewUser("manager-id", "manager", "manager@example.com", "hashed-password")
+	require.NoError(t, manager.UpdateRole(entities.RoleCatalogManager))
+	authMiddleware, tokenManager := newTestAuth(t, user, manager)
+
+	e := echo.New()
+	e.GET("/protected", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, authMiddleware.Authenticated(), authMiddleware.RequirePermission(entities.PermissionProductWrite))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "").Code)
+
+	userToken, err := tokenManager.GenerateToken(user.ID, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve(e, userToken).Code)
+
+	managerToken, err := tokenManager.GenerateToken(manager.ID, manager.Email)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, serve(e, managerToken).Code)
+}
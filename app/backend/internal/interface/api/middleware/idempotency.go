@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header with the client chosen key which makes retries of a request safe
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks responses which were recorded for an earlier request with the same key
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers recorded together with the body
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "ETag"}
+
+// IdempotencyStore records the responses to requests sent with an idempotency key
+type IdempotencyStore interface {
+	// Begin reserves the key for a new request, or returns the completed record of an earlier request with the key
+	Begin(ctx context.Context, key, userId, route, requestHash string) (*entities.IdempotencyRecord, error)
+	// Complete records the response to the request
+	Complete(ctx context.Context, record *entities.IdempotencyRecord, status int, headers map[string]string, body []byte) error
+	// Abort releases the key of a request which did not complete
+	Abort(ctx context.Context, record *entities.IdempotencyRecord) error
+}
+
+// Idempotency provides route level middleware which makes retries of commands safe.
+// Controllers attach it to create and update routes after the authentication middleware:
+//
+//	e.POST("/api/v1/things", c.Create, auth.Authenticated(), idempotency.Idempotent())
+type Idempotency struct {
+	store         IdempotencyStore
+	requestConfig *config.RequestConfig
+}
+
+// NewIdempotency creates a new Idempotency
+func NewIdempotency(store IdempotencyStore, requestConfig *config.RequestConfig) *Idempotency {
+	return &Idempotency{store: store, requestConfig: requestConfig}
+}
+
+// Idempotent returns a middleware that honors the Idempotency-Key header. The first request with a key is handled
+// and its response recorded, retries with the same key, user, route and payload get the recorded response with the
+// Idempotent-Replayed header instead of executing the command again. Reusing a key for another payload is answered
+// with 409 Conflict, bodies larger than the configured MaxBodySize with 413 Request Entity Too Large.
+// Server errors are not recorded, so that requests failing with them can be retried.
+// Requests without the header are handled as usual.
+func (i *Idempotency) Idempotent() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(ctx)
+			}
+			if len(key) > entities.MaxIdempotencyKeyLength {
+				return entities.NewValidationError(HeaderIdempotencyKey, entities.ValidationCodeTooLong,
+					fmt.Sprintf("must be at most %d characters long", entities.MaxIdempotencyKeyLength))
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(ctx.Response(), ctx.Request().Body, i.requestConfig.MaxBodySize))
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("Request body must be at most %d bytes", maxBytesErr.Limit))
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+			}
+			ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			route := ctx.Request().Method + " " + ctx.Path()
+			record, err := i.store.Begin(ctx.Request().Context(), key, UserID(ctx), route, requestHash(ctx.Request(), body))
+			if err != nil {
+				return err
+			}
+			if record.IsCompleted() {
+				return replay(ctx, record)
+			}
+
+			return i.record(ctx, next, record)
+		}
+	}
+}
+
+// record handles the request and records its response. Handler errors are rendered right away,
+// so that error responses are recorded like any other response.
+func (i *Idempotency) record(ctx echo.Context, next echo.HandlerFunc, record *entities.IdempotencyRecord) error {
+	// The key must be released even if the request was cancelled
+	storeCtx := context.WithoutCancel(ctx.Request().Context())
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Response().Writer}
+	ctx.Response().Writer = recorder
+	completed := false
+	defer func() {
+		ctx.Response().Writer = recorder.ResponseWriter
+		if !completed {
+			if err := i.store.Abort(storeCtx, record); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+		}
+	}()
+
+	if err := next(ctx); err != nil {
+		ctx.Error(err)
+	}
+
+	status := ctx.Response().Status
+	if status >= http.StatusInternalServerError {
+		return nil
+	}
+
+	headers := map[string]string{}
+	for _, name := range replayedHeaders {
+		if value := ctx.Response().Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if err := i.store.Complete(storeCtx, record, status, headers, recorder.body.Bytes()); err != nil {
+		log.Printf("Failed to record response for idempotency key: %v", err)
+		return nil
+	}
+	completed = true
+	return nil
+}
+
+// replay sends the recorded response of an earlier request
+func replay(ctx echo.Context, record *entities.IdempotencyRecord) error {
+	for name, value := range record.ResponseHeaders {
+		ctx.Response().Header().Set(name, value)
+	}
+	ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+	ctx.Response().WriteHeader(record.ResponseStatus)
+	_, err := ctx.Response().Write(record.ResponseBody)
+	return err
+}
+
+// requestHash identifies the payload of a request by its method, URI and body
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubIdempotencyStore keeps the records in memory
+type stubIdempotencyStore struct {
+	records map[string]*entities.IdempotencyRecord
+}
+
+func newStubIdempotencyStore() *stubIdempotencyStore {
+	return &stubIdempotencyStore{records: make(map[string]*entities.IdempotencyRecord)}
+}
+
+func (s *stubIdempotencyStore) Begin(_ context.Context, key, userId, route, requestHash string) (*entities.IdempotencyRecord, error) {
+	if existing, ok := s.records[key+userId+route]; ok {
+		if !existing.Matches(requestHash) {
+			return nil, services.ErrIdempotencyKeyReused
+		}
+		return existing, nil
+	}
+
+	record, err := entities.NewIdempotencyRecord(key, userId, route, requestHash, time.Now(), time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	s.records[key+userId+route] = record
+	return record, nil
+}
+
+func (s *stubIdempotencyStore) Complete(_ context.Context, record *entities.IdempotencyRecord, status int, headers map[string]string, body []byte) error {
+	return record.Complete(status, headers, body)
+}
+
+func (s *stubIdempotencyStore) Abort(_ context.Context, record *entities.IdempotencyRecord) error {
+	delete(s.records, record.Key+record.UserId+record.Route)
+	return nil
+}
+
+// serveIdempotent sends a request with the idempotency key to the handler as authenticated user
+func serveIdempotent(handler echo.HandlerFunc, key, body string) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(req, rec)
+	ctx.SetPath("/api/v1/products")
+	ctx.Set(ContextKeyUserID, "user-1")
+
+	return rec, handler(ctx)
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := NewIdempotency(newStubIdempotencyStore(), config.NewRequestConfig()).Idempotent()(func(ctx echo.Context) error {
+		calls++
+		ctx.Response().Header().Set("ETag", `"1"`)
+		return ctx.JSON(http.StatusCreated, map[string]int{"call": calls})
+	})
+
+	first, err := serveIdempotent(handler, "key-1", `{"Name":"Shoe"}`)
+	assert.NoError(t, err)
+	retry, err := serveIdempotent(handler, "key-1", `{"Name":"Shoe"}`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+	assert.Equal(t, echo.MIMEApplicationJSON, retry.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "true", retry.Header().Get(HeaderIdempotentReplayed))
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+
+	// Requests without a key are always handled
+	_, err = serveIdempotent(handler, "", `{"Name":"Shoe"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotentRejectsKeyReusedForAnotherPayload(t *testing.T) {
+	calls := 0
+	handler := NewIdempotency(newStubIdempotencyStore(), config.NewRequestConfig()).Idempotent()(func(ctx echo.Context) error {
+		calls++
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	_, err := serveIdempotent(handler, "key-1", `{"Name":"Shoe"}`)
+	assert.NoError(t, err)
+	_, err = serveIdempotent(handler, "key-1", `{"Name":"Boot"}`)
+
+	assert.ErrorIs(t, err, services.ErrIdempotencyKeyReused)
+	assert.ErrorIs(t, err, entities.ErrConflict)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotentRecordsClientErrorsButNotServerErrors(t *testing.T) {
+	handlerErrors := []error{errors.New("database unavailable"), echo.NewHTTPError(http.StatusNotFound, "Seller not found")}
+	calls := 0
+	handler := NewIdempotency(newStubIdempotencyStore(), config.NewRequestConfig()).Idempotent()(func(ctx echo.Context) error {
+		calls++
+		return handlerErrors[min(calls, len(handlerErrors))-1]
+	})
+
+	// The server error releases the key, so the retry is handled again
+	first, _ := serveIdempotent(handler, "key-1", `{}`)
+	second, _ := serveIdempotent(handler, "key-1", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, second.Header().Get(HeaderIdempotentReplayed))
+
+	// The error response of the second attempt is replayed
+	third, _ := serveIdempotent(handler, "key-1", `{}`)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusNotFound, third.Code)
+	assert.Equal(t, "true", third.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestIdempotentRejectsLongKeys(t *testing.T) {
+	handler := NewIdempotency(newStubIdempotencyStore(), config.NewRequestConfig()).Idempotent()(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	_, err := serveIdempotent(handler, strings.Repeat("k", entities.MaxIdempotencyKeyLength+1), `{}`)
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestIdempotentRejectsLargeBodies(t *testing.T) {
+	calls := 0
+	requestConfig := config.NewRequestConfig()
+	requestConfig.MaxBodySize = 16
+	handler := NewIdempotency(newStubIdempotencyStore(), requestConfig).Idempotent()(func(ctx echo.Context) error {
+		calls++
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	_, err := serveIdempotent(handler, "key-1", `{"Name":"A very long product name"}`)
+
+	var httpErr *echo.HTTPError
+	if assert.ErrorAs(t, err, &httpErr) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Code)
+	}
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/config"
+)
+
+// Headers carrying the request scope
+const (
+	RequestIDHeader = "X-Request-ID"
+	TenantIDHeader  = "X-Tenant-ID"
+)
+
+// maxRequestIDLength limits client supplied request IDs, longer ones are replaced
+const maxRequestIDLength = 128
+
+// RequestContext returns a middleware that prepares the context of the request for the lower layers.
+// The context gets the configured deadline and a common.RequestScope with the request ID and the tenant.
+// The request ID is taken from the X-Request-ID header or generated, and echoed in the response.
+// Handlers pass ctx.Request().Context() to the services, so a client disconnect or the deadline
+// cancels the database queries of the request.
+func RequestContext(requestConfig *config.RequestConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			requestID := ctx.Request().Header.Get(RequestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = uuid.New().String()
+			}
+			ctx.Response().Header().Set(RequestIDHeader, requestID)
+
+			requestCtx := common.WithRequestScope(ctx.Request().Context(), common.RequestScope{
+				RequestId: requestID,
+				TenantId:  ctx.Request().Header.Get(TenantIDHeader),
+			})
+			if requestConfig.Timeout > 0 {
+				var cancel context.CancelFunc
+				requestCtx, cancel = context.WithTimeout(requestCtx, requestConfig.Timeout)
+				defer cancel()
+			}
+			ctx.SetRequest(ctx.Request().WithContext(requestCtx))
+
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestContext(t *testing.T) {
+	var scope common.RequestScope
+	var deadline time.Time
+	e := echo.New()
+	e.Use(RequestContext(&config.RequestConfig{Timeout: time.Minute}))
+	e.GET("/scoped", func(ctx echo.Context) error {
+		scope = common.RequestScopeFrom(ctx.Request().Context())
+		deadline, _ = ctx.Request().Context().Deadline()
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	// Request IDs sent by the client are kept
+	req := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+	req.Header.Set(RequestIDHeader, "client-request-id")
+	req.Header.Set(TenantIDHeader, "tenant-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, "client-request-id", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, common.RequestScope{RequestId: "client-request-id", TenantId: "tenant-1"}, scope)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	// Missing and oversized request IDs are replaced by generated ones
+	for _, requestID := range []string{"", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+		req.Header.Set(RequestIDHeader, requestID)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.NotEmpty(t, scope.RequestId)
+		assert.NotEqual(t, requestID, scope.RequestId)
+		assert.Equal(t, scope.RequestId, rec.Header().Get(RequestIDHeader))
+		assert.Empty(t, scope.TenantId)
+	}
+}
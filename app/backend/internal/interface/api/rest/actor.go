@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"net/http"
+)
+
+// actorFromContext returns the authenticated user as the actor of application commands
+func actorFromContext(ctx echo.Context, authMiddleware *middleware.Auth) (common.Actor, error) {
+	managesAllSellers, err := authMiddleware.HasPermission(ctx, entities.PermissionSellerManageAny)
+	if err != nil {
+		return common.Actor{}, err
+	}
+
+	return common.Actor{
+		UserId:            middleware.UserID(ctx),
+		ManagesAllSellers: managesAllSellers,
+	}, nil
+}
+
+// authorizeIncludeDeleted only lets users who manage all sellers list soft deleted products and sellers
+func authorizeIncludeDeleted(ctx echo.Context, authMiddleware *middleware.Auth) error {
+	if middleware.CurrentUser(ctx) == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication is required to include deleted entries")
+	}
+	managesAllSellers, err := authMiddleware.HasPermission(ctx, entities.PermissionSellerManageAny)
+	if err != nil {
+		return err
+	}
+	if !managesAllSellers {
+		return echo.NewHTTPError(http.StatusForbidden, "Missing permission "+string(entities.PermissionSellerManageAny))
+	}
+	return nil
+}
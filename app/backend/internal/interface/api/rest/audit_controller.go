@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
+	"net/http"
+)
+
+// AuditController handles the audit trail endpoints
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+// NewAuditController creates a new AuditController and registers routes
+func NewAuditController(e *echo.Echo, auditService *services.AuditService, authMiddleware *middleware.Auth) {
+	controller := &AuditController{
+		auditService: auditService,
+	}
+
+	// Protected routes (require authentication and the audit:read permission)
+	audit := e.Group(
+		"/api/v1/audit",
+		authMiddleware.Authenticated(),
+		authMiddleware.RequirePermission(entities.PermissionAuditRead),
+	)
+	audit.GET("", controller.ListEntries)
+}
+
+// ListEntries @Summary List audit entries
+// @Description List who changed which product, seller or user how, newest first
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param actor_id query string false "ID of the acting user"
+// @Param action query string false "Action" Enums(create, update, delete, restore, add_member, remove_member)
+// @Param aggregate_type query string false "Aggregate type" Enums(product, seller, user)
+// @Param aggregate_id query string false "ID of the aggregate"
+// @Param request_id query string false "X-Request-ID of the request which executed the command"
+// @Param from query string false "Earliest time, RFC 3339 timestamp or date"
+// @Param to query string false "Latest time, RFC 3339 timestamp or date (inclusive)"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} response.ListAuditEntriesResponse
+// @Header 200 {string} Link "Links to the first, previous and next page"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /audit [get]
+func (c *AuditController) ListEntries(ctx echo.Context) error {
+	var req request.ListAuditEntriesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse query parameters")
+	}
+	filter, err := req.ToAuditFilter()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	page, err := req.ToPageRequest()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	page = page.WithDefaults()
+
+	entries, err := c.auditService.FindEntries(ctx.Request().Context(), filter, page)
+	if err != nil {
+		return err
+	}
+
+	pageResult := common.PageResult{Limit: page.Limit, Offset: page.Offset, TotalCount: entries.TotalCount}
+	response := mapper.ToAuditEntryListResponse(entries.Entries)
+	response.PageResponse = mapper.ToPageResponse(pageResult)
+	setPaginationLinks(ctx, pageResult)
+
+	return ctx.JSON(http.StatusOK, response)
+}
@@ -1,40 +1,47 @@
 package rest
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/services"
-	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
 	"net/http"
-	"strings"
-	"time"
 )
 
 // AuthController handles authentication-related endpoints
 type AuthController struct {
-	userService *services.UserService
-	jwtConfig   *config.JWTConfig
+	userService  *services.UserService
+	authService  *services.AuthService
+	tokenManager *auth.TokenManager
 }
 
 // NewAuthController creates a new AuthController and registers routes
-func NewAuthController(e *echo.Echo, userService *services.UserService, jwtConfig *config.JWTConfig) {
+func NewAuthController(
+	e *echo.Echo,
+	userService *services.UserService,
+	authService *services.AuthService,
+	tokenManager *auth.TokenManager,
+	authMiddleware *middleware.Auth,
+) {
 	controller := &AuthController{
-		userService: userService,
-		jwtConfig:   jwtConfig,
+		userService:  userService,
+		authService:  authService,
+		tokenManager: tokenManager,
 	}
 
 	// Public routes
 	e.POST("/api/v1/register", controller.Register)
 	e.POST("/api/v1/login", controller.Login)
+	e.POST("/api/v1/auth/refresh", controller.Refresh)
+	e.GET("/.well-known/jwks.json", controller.GetJWKS)
 
 	// Protected routes (require authentication)
-	auth := e.Group("/api/v1/auth")
-	auth.Use(controller.AuthMiddleware)
-	auth.GET("/profile", controller.GetProfile)
+	e.GET("/api/v1/auth/profile", controller.GetProfile, authMiddleware.Authenticated())
+	e.POST("/api/v1/auth/logout", controller.Logout, authMiddleware.Authenticated())
 }
 
 // Register @Summary Register a new user
@@ -43,48 +50,37 @@ func NewAuthController(e *echo.Echo, userService *services.UserService, jwtConfi
 // @Accept json
 // @Produce json
 // @Param request body object{username=string,email=string,password=string} true "Registration details"
-// @Success 201 {object} object{user=object{id=string,username=string,email=string,role=string,status=string},token=string}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 201 {object} object{user=object{id=string,username=string,email=string,role=string,status=string},token=string,refresh_token=string,token_type=string,expires_in=integer}
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /register [post]
 func (c *AuthController) Register(ctx echo.Context) error {
 	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Username string `json:"username" validate:"required,min=3,max=50"`
+		Email    string `json:"email" validate:"required,email,max=254"`
+		Password string `json:"password" validate:"required,min=8,max=128"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
-
-	// Validate input
-	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Username, email, and password are required"})
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
 	// Register user
-	user, err := c.userService.RegisterUser(req.Username, req.Email, req.Password)
+	user, err := c.userService.RegisterUser(ctx.Request().Context(), req.Username, req.Email, req.Password)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	// Generate token
-	token, err := c.generateToken(user.ID, user.Email)
+	// Generate tokens
+	response, err := c.issueTokens(ctx.Request().Context(), user)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate token"})
+		return err
 	}
 
-	return ctx.JSON(http.StatusCreated, map[string]interface{}{
-		"user": map[string]string{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     string(user.Role),
-			"status":   string(user.Status),
-		},
-		"token": token,
-	})
+	return ctx.JSON(http.StatusCreated, response)
 }
 
 // Login @Summary User login
@@ -93,43 +89,118 @@ func (c *AuthController) Register(ctx echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param request body object{email=string,password=string} true "Login credentials"
-// @Success 200 {object} object{user=object{id=string,username=string,email=string,role=string,status=string},token=string}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{user=object{id=string,username=string,email=string,role=string,status=string},token=string,refresh_token=string,token_type=string,expires_in=integer}
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 429 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /login [post]
 func (c *AuthController) Login(ctx echo.Context) error {
 	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,max=254"`
+		Password string `json:"password" validate:"required,max=128"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
 	// Authenticate user
-	user, err := c.userService.Authenticate(req.Email, req.Password)
+	user, err := c.userService.Authenticate(ctx.Request().Context(), req.Email, req.Password, ctx.RealIP())
+	if errors.Is(err, services.ErrAccountLocked) {
+		return echo.NewHTTPError(http.StatusForbidden, "Account is locked")
+	}
+	if errors.Is(err, entities.ErrUnauthorized) {
+		// Do not reveal whether the account exists or why the credentials were rejected
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid credentials")
+	}
 	if err != nil {
-		return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials"})
+		return err
 	}
 
-	// Generate token
-	token, err := c.generateToken(user.ID, user.Email)
+	// Generate tokens
+	response, err := c.issueTokens(ctx.Request().Context(), user)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate token"})
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, map[string]interface{}{
-		"user": map[string]string{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     string(user.Role),
-			"status":   string(user.Status),
-		},
-		"token": token,
-	})
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// Refresh @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token.
+// @Description The presented refresh token is revoked; presenting it again revokes all tokens of the session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body object{refresh_token=string} true "Refresh token"
+// @Success 200 {object} object{user=object{id=string,username=string,email=string,role=string,status=string},token=string,refresh_token=string,token_type=string,expires_in=integer}
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx echo.Context) error {
+	var req struct {
+		RefreshToken string `json:"refresh_token" validate:"required,max=512"`
+	}
+
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
+	}
+
+	user, refreshToken, err := c.authService.RotateRefreshToken(ctx.Request().Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, entities.ErrUnauthorized) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid refresh token")
+		}
+		return err
+	}
+
+	token, err := c.tokenManager.GenerateToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, c.tokenResponse(user, token, refreshToken))
+}
+
+// Logout @Summary User logout
+// @Description Revoke the current access token and the refresh tokens of the session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body object{refresh_token=string} false "Refresh token of the session"
+// @Success 204 "No Content"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx echo.Context) error {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+
+	// Get claims from context (set by the authentication middleware)
+	claims := middleware.Claims(ctx)
+
+	err := c.authService.Logout(ctx.Request().Context(), req.RefreshToken, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
 }
 
 // GetProfile @Summary Get user profile
@@ -139,21 +210,21 @@ func (c *AuthController) Login(ctx echo.Context) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Success 200 {object} object{user=object{id=string,username=string,email=string,role=string,status=string}}
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /auth/profile [get]
 func (c *AuthController) GetProfile(ctx echo.Context) error {
-	// Get user ID from context (set by AuthMiddleware)
-	userID := ctx.Get("userID").(string)
+	// Get user ID from context (set by the authentication middleware)
+	userID := middleware.UserID(ctx)
 
 	// Get user from database
-	userEntity, err := c.userService.GetUserByID(userID)
+	userEntity, err := c.userService.GetUserByID(ctx.Request().Context(), userID)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return err
 	}
 	if userEntity == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]interface{}{
@@ -167,110 +238,41 @@ func (c *AuthController) GetProfile(ctx echo.Context) error {
 	})
 }
 
-// AuthMiddleware is a middleware that checks for a valid authentication token
-// It expects a Bearer token in the Authorization header
-// The token should be in the format "Bearer <token>"
-// The token is validated using the validateToken method
-// If the token is valid, the user ID and email are set in the context
-// If the token is invalid, a 401 Unauthorized response is returned
-func (c *AuthController) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		// Get Authorization header
-		authHeader := ctx.Request().Header.Get("Authorization")
-		if authHeader == "" {
-			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Authorization header is required"})
-		}
-
-		// Check if it starts with "Bearer "
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid authorization format"})
-		}
-
-		// Extract token
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-
-		// Validate token
-		claims, err := c.validateToken(token)
-		if err != nil {
-			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
-		}
-
-		// Set user ID in context
-		ctx.Set("userID", claims["id"])
-		ctx.Set("userEmail", claims["email"])
-
-		return next(ctx)
-	}
-}
-
-// generateToken generates a simple token for the given user
-func (c *AuthController) generateToken(userID, email string) (string, error) {
-	// Create claims
-	claims := map[string]interface{}{
-		"id":    userID,
-		"email": email,
-		"exp":   time.Now().Add(c.jwtConfig.TokenExpiry).Unix(),
-	}
-
-	// Convert claims to JSON
-	claimsJSON, err := json.Marshal(claims)
-	if err != nil {
-		return "", err
-	}
-
-	// Base64 encode claims
-	encodedClaims := base64.StdEncoding.EncodeToString(claimsJSON)
-
-	// Create signature
-	h := hmac.New(sha256.New, []byte(c.jwtConfig.SecretKey))
-	h.Write([]byte(encodedClaims))
-	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
-
-	// Combine to create token
-	token := fmt.Sprintf("%s.%s", encodedClaims, signature)
-
-	return token, nil
+// GetJWKS returns the public keys used to verify issued tokens as a JSON Web Key Set.
+// Tokens signed with symmetric keys (HS256) cannot be verified by third parties,
+// so the set is empty unless an asymmetric signing method is configured.
+func (c *AuthController) GetJWKS(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.tokenManager.JWKS())
 }
 
-// validateToken validates a token and returns the claims
-func (c *AuthController) validateToken(token string) (map[string]interface{}, error) {
-	// Split token into parts
-	parts := strings.Split(token, ".")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid token format")
-	}
-
-	encodedClaims := parts[0]
-	signature := parts[1]
-
-	// Verify signature
-	h := hmac.New(sha256.New, []byte(c.jwtConfig.SecretKey))
-	h.Write([]byte(encodedClaims))
-	expectedSignature := base64.StdEncoding.EncodeToString(h.Sum(nil))
-
-	if signature != expectedSignature {
-		return nil, fmt.Errorf("invalid token signature")
-	}
-
-	// Decode claims
-	claimsJSON, err := base64.StdEncoding.DecodeString(encodedClaims)
+// issueTokens issues an access token and a refresh token starting a new session
+func (c *AuthController) issueTokens(ctx context.Context, user *entities.User) (map[string]interface{}, error) {
+	token, err := c.tokenManager.GenerateToken(user.ID, user.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse claims
-	var claims map[string]interface{}
-	err = json.Unmarshal(claimsJSON, &claims)
+	refreshToken, err := c.authService.IssueRefreshToken(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check expiration
-	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
-			return nil, fmt.Errorf("token expired")
-		}
-	}
+	return c.tokenResponse(user, token, refreshToken), nil
+}
 
-	return claims, nil
+// tokenResponse builds the response body returned whenever tokens are issued
+func (c *AuthController) tokenResponse(user *entities.User, token, refreshToken string) map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]string{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"role":     string(user.Role),
+			"status":   string(user.Status),
+		},
+		"token":         token,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int64(c.tokenManager.TokenExpiry().Seconds()),
+	}
 }
@@ -125,7 +125,7 @@ func TestAuthController_Register(t *testing.T) {
 	// Test case: Register a new user
 	t.Run("Register a new user", func(t *testing.T) {
 		// Setup mock expectations
-		testUser, _ := entities.NewUser("user-id", "test@example.com", "hashed-password")
+		testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
 		mockUserService.On("RegisterUser", "test@example.com", "password123").Return(testUser, nil)
 
 		// Create request body
@@ -247,7 +247,7 @@ func TestAuthController_Login(t *testing.T) {
 	// Test case: Login with valid credentials
 	t.Run("Login with valid credentials", func(t *testing.T) {
 		// Setup mock expectations
-		testUser, _ := entities.NewUser("user-id", "test@example.com", "hashed-password")
+		testUser, _ := entities.NewUser("user-id", "testuser", "test@example.com", "hashed-password")
 		mockUserService.On("Authenticate", "test@example.com", "password123").Return(testUser, nil)
 
 		// Create request body
@@ -0,0 +1,31 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+)
+
+func ToAuditEntryResponse(entry *entities.AuditEntry) *response.AuditEntryResponse {
+	changes := make([]response.AuditChangeResponse, len(entry.Changes))
+	for i, change := range entry.Changes {
+		changes[i] = response.AuditChangeResponse{Field: change.Field, Before: change.Before, After: change.After}
+	}
+	return &response.AuditEntryResponse{
+		Id:            entry.Id.String(),
+		ActorId:       entry.ActorId,
+		Action:        string(entry.Action),
+		AggregateType: string(entry.AggregateType),
+		AggregateId:   entry.AggregateId,
+		Changes:       changes,
+		RequestId:     entry.RequestId,
+		OccurredAt:    entry.OccurredAt,
+	}
+}
+
+func ToAuditEntryListResponse(entries []*entities.AuditEntry) *response.ListAuditEntriesResponse {
+	responseList := make([]*response.AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		responseList[i] = ToAuditEntryResponse(entry)
+	}
+	return &response.ListAuditEntriesResponse{Entries: responseList}
+}
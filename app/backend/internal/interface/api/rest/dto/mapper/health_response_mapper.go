@@ -0,0 +1,36 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+func ToHealthResponse(result *common.HealthResult) *response.HealthResponse {
+	checks := make([]response.HealthCheckResponse, 0, len(result.Checks))
+	for _, check := range result.Checks {
+		status := healthStatusOK
+		if check.Error != "" {
+			status = healthStatusUnavailable
+		}
+		checks = append(checks, response.HealthCheckResponse{
+			Name:       check.Name,
+			Status:     status,
+			Error:      check.Error,
+			DurationMs: check.Duration.Milliseconds(),
+		})
+	}
+
+	status := healthStatusOK
+	if !result.Healthy {
+		status = healthStatusUnavailable
+	}
+	return &response.HealthResponse{
+		Status: status,
+		Checks: checks,
+	}
+}
@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+)
+
+func ToPageResponse(page common.PageResult) response.PageResponse {
+	return response.PageResponse{
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		TotalCount: page.TotalCount,
+		NextCursor: page.NextCursor,
+	}
+}
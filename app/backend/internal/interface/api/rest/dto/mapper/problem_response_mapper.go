@@ -0,0 +1,21 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"net/http"
+)
+
+// ToProblemResponse creates the problem details of an error response with the given status
+func ToProblemResponse(status int, detail string, fields []entities.FieldError) *response.ProblemResponse {
+	problem := &response.ProblemResponse{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+	for _, field := range fields {
+		problem.Errors = append(problem.Errors, response.FieldErrorResponse{Field: field.Field, Code: field.Code, Message: field.Message})
+	}
+	return problem
+}
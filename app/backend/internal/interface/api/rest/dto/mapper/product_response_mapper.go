@@ -7,11 +7,28 @@ import (
 
 func ToProductResponse(product *common.ProductResult) *response.ProductResponse {
 	return &response.ProductResponse{
-		Id:        product.Id.String(),
-		Name:      product.Name,
-		Price:     product.Price,
-		CreatedAt: product.CreatedAt,
-		UpdatedAt: product.UpdatedAt,
+		Id:             product.Id.String(),
+		Name:           product.Name,
+		Price:          product.Price,
+		Version:        product.Version,
+		CreatedAt:      product.CreatedAt,
+		UpdatedAt:      product.UpdatedAt,
+		DeletedAt:      product.DeletedAt,
+		ConvertedPrice: product.ConvertedPrice,
+		ExchangeRate:   ToExchangeRateResponse(product.ExchangeRate),
+	}
+}
+
+func ToExchangeRateResponse(rate *common.ExchangeRateResult) *response.ExchangeRateResponse {
+	if rate == nil {
+		return nil
+	}
+
+	return &response.ExchangeRateResponse{
+		BaseCurrency:  string(rate.BaseCurrency),
+		QuoteCurrency: string(rate.QuoteCurrency),
+		Rate:          rate.Rate,
+		EffectiveDate: rate.EffectiveDate,
 	}
 }
 
@@ -0,0 +1,22 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+)
+
+func ToSearchProductsResponse(searchResult *query.ProductSearchQueryResult) *response.SearchProductsResponse {
+	results := []*response.ProductSearchResultResponse{}
+	for _, result := range searchResult.Result {
+		results = append(results, &response.ProductSearchResultResponse{
+			Product:   ToProductResponse(result.Product),
+			Rank:      result.Rank,
+			Highlight: result.Highlight,
+		})
+	}
+
+	return &response.SearchProductsResponse{
+		Results:      results,
+		PageResponse: ToPageResponse(searchResult.Page),
+	}
+}
@@ -9,8 +9,10 @@ func ToSellerResponse(product *common.SellerResult) *response.SellerResponse {
 	return &response.SellerResponse{
 		Id:        product.Id.String(),
 		Name:      product.Name,
+		Version:   product.Version,
 		CreatedAt: product.CreatedAt,
 		UpdatedAt: product.UpdatedAt,
+		DeletedAt: product.DeletedAt,
 	}
 }
 
@@ -0,0 +1,64 @@
+package mapper
+
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+)
+
+func ToWebhookSubscriptionResponse(subscription *entities.WebhookSubscription) *response.WebhookSubscriptionResponse {
+	return &response.WebhookSubscriptionResponse{
+		Id:         subscription.Id.String(),
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func ToCreateWebhookSubscriptionResponse(subscription *entities.WebhookSubscription) *response.CreateWebhookSubscriptionResponse {
+	return &response.CreateWebhookSubscriptionResponse{
+		WebhookSubscriptionResponse: *ToWebhookSubscriptionResponse(subscription),
+		Secret:                      subscription.Secret,
+	}
+}
+
+func ToWebhookSubscriptionListResponse(subscriptions []*entities.WebhookSubscription) []*response.WebhookSubscriptionResponse {
+	responseList := make([]*response.WebhookSubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		responseList[i] = ToWebhookSubscriptionResponse(subscription)
+	}
+	return responseList
+}
+
+func ToWebhookDeliveryResponse(delivery *entities.WebhookDelivery) *response.WebhookDeliveryResponse {
+	deliveryResponse := &response.WebhookDeliveryResponse{
+		Id:             delivery.Id.String(),
+		EventId:        delivery.EventId.String(),
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == entities.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		deliveryResponse.NextAttemptAt = &nextAttemptAt
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		deliveredAt := delivery.DeliveredAt
+		deliveryResponse.DeliveredAt = &deliveredAt
+	}
+	if delivery.ReplayOf != nil {
+		deliveryResponse.ReplayOf = delivery.ReplayOf.String()
+	}
+	return deliveryResponse
+}
+
+func ToWebhookDeliveryListResponse(deliveries []*entities.WebhookDelivery) *response.ListWebhookDeliveriesResponse {
+	responseList := make([]*response.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responseList[i] = ToWebhookDeliveryResponse(delivery)
+	}
+	return &response.ListWebhookDeliveriesResponse{Deliveries: responseList}
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+type AddSellerMemberRequest struct {
+	UserId string `json:"UserId" validate:"required,max=255"`
+	// Role is "owner" or "member", the default
+	Role string `json:"Role" validate:"oneof=owner member"`
+}
+
+func (req *AddSellerMemberRequest) ToAddSellerMemberCommand(sellerId uuid.UUID) (*command.AddSellerMemberCommand, error) {
+	role := entities.SellerMemberRole(req.Role)
+	if role == "" {
+		role = entities.SellerMemberRoleMember
+	}
+
+	return &command.AddSellerMemberCommand{
+		SellerId: sellerId,
+		UserId:   req.UserId,
+		Role:     role,
+	}, nil
+}
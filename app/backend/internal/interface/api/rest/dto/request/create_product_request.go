@@ -3,12 +3,14 @@ package request
 import (
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
 )
 
 type CreateProductRequest struct {
-	Name     string  `json:"Name"`
-	Price    float64 `json:"Price"`
-	SellerId string  `json:"SellerId"`
+	Name string `json:"Name" validate:"required,max=255"`
+	// Price with an exact decimal amount, e.g. {"Amount":"10.99","Currency":"USD"}
+	Price    entities.Money `json:"Price" validate:"required,gt=0,max=1000000"`
+	SellerId string         `json:"SellerId" validate:"required,uuid"`
 }
 
 func (req *CreateProductRequest) ToCreateProductCommand() (*command.CreateProductCommand, error) {
@@ -3,7 +3,7 @@ package request
 import "github.com/sklinkert/go-ddd/internal/application/command"
 
 type CreateSellerRequest struct {
-	Name string `json:"Name"`
+	Name string `json:"Name" validate:"required,max=255"`
 }
 
 func (req *CreateSellerRequest) ToCreateSellerCommand() (*command.CreateSellerCommand, error) {
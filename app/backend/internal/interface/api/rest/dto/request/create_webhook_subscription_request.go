@@ -0,0 +1,8 @@
+package request
+
+type CreateWebhookSubscriptionRequest struct {
+	// URL is the absolute http(s) endpoint the deliveries are posted to
+	URL string `json:"URL" validate:"required,url,max=2048"`
+	// EventTypes are the events to deliver, e.g. "product.created"
+	EventTypes []string `json:"EventTypes" validate:"required,max=50"`
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+)
+
+// ListAuditEntriesRequest holds the query parameters of the audit trail, it is paged by offset only
+type ListAuditEntriesRequest struct {
+	PageRequest
+	ActorId string `query:"actor_id"`
+	// Action is one of create, update, delete, restore, add_member or remove_member
+	Action string `query:"action"`
+	// AggregateType is one of product, seller or user
+	AggregateType string `query:"aggregate_type"`
+	AggregateId   string `query:"aggregate_id"`
+	RequestId     string `query:"request_id"`
+	// From and To are RFC 3339 timestamps or dates, To includes the whole day
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+func (req *ListAuditEntriesRequest) ToAuditFilter() (repositories.AuditFilter, error) {
+	filter := repositories.AuditFilter{
+		ActorId:       req.ActorId,
+		Action:        entities.AuditAction(req.Action),
+		AggregateType: entities.AuditAggregateType(req.AggregateType),
+		AggregateId:   req.AggregateId,
+		RequestId:     req.RequestId,
+	}
+
+	var err error
+	if filter.OccurredAfter, err = parseOptionalTime(req.From, false); err != nil {
+		return repositories.AuditFilter{}, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.OccurredBefore, err = parseOptionalTime(req.To, true); err != nil {
+		return repositories.AuditFilter{}, fmt.Errorf("invalid to: %w", err)
+	}
+
+	return filter, nil
+}
+
+func (req *ListAuditEntriesRequest) ToPageRequest() (repositories.PageRequest, error) {
+	if req.Cursor != "" || req.Sort != "" {
+		return repositories.PageRequest{}, errors.New("audit entries are paged by limit and offset, newest first")
+	}
+	return req.toPageRequest()
+}
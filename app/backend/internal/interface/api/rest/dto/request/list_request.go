@@ -0,0 +1,200 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"strings"
+	"time"
+)
+
+// PageRequest holds the pagination and sort query parameters shared by all listings
+type PageRequest struct {
+	// Limit is the page size, at most 100
+	Limit int `query:"limit"`
+	// Offset skips rows, it cannot be combined with a cursor
+	Offset int `query:"offset"`
+	// Cursor is the NextCursor of the previous page
+	Cursor string `query:"cursor"`
+	// Sort is a field name, prefixed with "-" for descending order, e.g. "-created_at"
+	Sort string `query:"sort"`
+}
+
+func (req *PageRequest) toPageRequest() (repositories.PageRequest, error) {
+	if req.Limit < 0 || req.Limit > repositories.MaxPageSize {
+		return repositories.PageRequest{}, fmt.Errorf("limit must be between 1 and %d", repositories.MaxPageSize)
+	}
+	if req.Offset < 0 {
+		return repositories.PageRequest{}, errors.New("offset cannot be negative")
+	}
+	if req.Offset > 0 && req.Cursor != "" {
+		return repositories.PageRequest{}, errors.New("use either offset or cursor")
+	}
+
+	return repositories.PageRequest{Limit: req.Limit, Offset: req.Offset, Cursor: req.Cursor}, nil
+}
+
+func (req *PageRequest) toSortOrder(allowed ...repositories.SortField) (repositories.SortOrder, error) {
+	if req.Sort == "" {
+		return repositories.SortOrder{}, nil
+	}
+
+	sort := repositories.SortOrder{
+		Field:      repositories.SortField(strings.TrimPrefix(req.Sort, "-")),
+		Descending: strings.HasPrefix(req.Sort, "-"),
+	}
+	for _, field := range allowed {
+		if sort.Field == field {
+			return sort, nil
+		}
+	}
+
+	return repositories.SortOrder{}, fmt.Errorf("cannot sort by %q", sort.Field)
+}
+
+// ListProductsRequest holds the query parameters of the product listing
+type ListProductsRequest struct {
+	PageRequest
+	// Name matches products whose name contains it, ignoring case
+	Name     string `query:"name"`
+	SellerId string `query:"seller_id"`
+	// MinPrice and MaxPrice are inclusive decimal amounts in PriceCurrency
+	MinPrice      string `query:"min_price"`
+	MaxPrice      string `query:"max_price"`
+	PriceCurrency string `query:"price_currency"`
+	// CreatedFrom and CreatedTo are RFC 3339 timestamps or dates, CreatedTo includes the whole day
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	// Currency converts prices to this currency
+	Currency string `query:"currency"`
+	// IncludeDeleted also lists soft deleted products, it is reserved to users who manage all sellers
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+func (req *ListProductsRequest) ToListProductsQuery() (*query.ListProductsQuery, error) {
+	page, err := req.toPageRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := req.toSortOrder(repositories.SortByName, repositories.SortByPrice, repositories.SortByCreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	listQuery := &query.ListProductsQuery{
+		Filter: repositories.ProductFilter{NameContains: req.Name, IncludeDeleted: req.IncludeDeleted},
+		Sort:   sort,
+		Page:   page,
+	}
+
+	if req.SellerId != "" {
+		sellerId, err := uuid.Parse(req.SellerId)
+		if err != nil {
+			return nil, errors.New("invalid seller_id")
+		}
+		listQuery.Filter.SellerId = &sellerId
+	}
+
+	priceCurrency := entities.DefaultCurrency
+	if req.PriceCurrency != "" {
+		if priceCurrency, err = entities.ParseCurrency(req.PriceCurrency); err != nil {
+			return nil, err
+		}
+	}
+	if listQuery.Filter.MinPrice, err = parseOptionalMoney(req.MinPrice, priceCurrency); err != nil {
+		return nil, fmt.Errorf("invalid min_price: %w", err)
+	}
+	if listQuery.Filter.MaxPrice, err = parseOptionalMoney(req.MaxPrice, priceCurrency); err != nil {
+		return nil, fmt.Errorf("invalid max_price: %w", err)
+	}
+
+	if listQuery.Filter.CreatedAfter, err = parseOptionalTime(req.CreatedFrom, false); err != nil {
+		return nil, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if listQuery.Filter.CreatedBefore, err = parseOptionalTime(req.CreatedTo, true); err != nil {
+		return nil, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	if req.Currency != "" {
+		if listQuery.DisplayCurrency, err = entities.ParseCurrency(req.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	return listQuery, nil
+}
+
+// ListSellersRequest holds the query parameters of the seller listing
+type ListSellersRequest struct {
+	PageRequest
+	// Name matches sellers whose name contains it, ignoring case
+	Name string `query:"name"`
+	// CreatedFrom and CreatedTo are RFC 3339 timestamps or dates, CreatedTo includes the whole day
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	// IncludeDeleted also lists soft deleted sellers, it is reserved to users who manage all sellers
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+func (req *ListSellersRequest) ToListSellersQuery() (*query.ListSellersQuery, error) {
+	page, err := req.toPageRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := req.toSortOrder(repositories.SortByName, repositories.SortByCreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	listQuery := &query.ListSellersQuery{
+		Filter: repositories.SellerFilter{NameContains: req.Name, IncludeDeleted: req.IncludeDeleted},
+		Sort:   sort,
+		Page:   page,
+	}
+
+	if listQuery.Filter.CreatedAfter, err = parseOptionalTime(req.CreatedFrom, false); err != nil {
+		return nil, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if listQuery.Filter.CreatedBefore, err = parseOptionalTime(req.CreatedTo, true); err != nil {
+		return nil, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	return listQuery, nil
+}
+
+func parseOptionalMoney(amount string, currency entities.Currency) (*entities.Money, error) {
+	if amount == "" {
+		return nil, nil
+	}
+
+	money, err := entities.ParseMoney(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &money, nil
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp or a date. As exclusive upper bound, a date is moved to the next day.
+func parseOptionalTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, errors.New("expected an RFC 3339 timestamp or a date like 2024-05-01")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,18 @@
+package request
+
+import (
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+)
+
+// ListWebhookDeliveriesRequest holds the query parameters of the delivery log, it is paged by offset only
+type ListWebhookDeliveriesRequest struct {
+	PageRequest
+}
+
+func (req *ListWebhookDeliveriesRequest) ToPageRequest() (repositories.PageRequest, error) {
+	if req.Cursor != "" || req.Sort != "" {
+		return repositories.PageRequest{}, errors.New("deliveries are paged by limit and offset, newest first")
+	}
+	return req.toPageRequest()
+}
@@ -0,0 +1,31 @@
+package request
+
+import (
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"strings"
+)
+
+// SearchProductsRequest holds the query parameters of the product search
+type SearchProductsRequest struct {
+	// Query is the search text, all of its words must match
+	Query string `query:"q"`
+	// Limit is the page size, at most 100
+	Limit int `query:"limit"`
+	// Offset skips results
+	Offset int `query:"offset"`
+}
+
+func (req *SearchProductsRequest) ToSearchProductsQuery() (*query.SearchProductsQuery, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, errors.New("q is required")
+	}
+
+	pageRequest := PageRequest{Limit: req.Limit, Offset: req.Offset}
+	page, err := pageRequest.toPageRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	return &query.SearchProductsQuery{Text: req.Query, Page: page}, nil
+}
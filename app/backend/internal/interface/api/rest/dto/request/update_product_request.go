@@ -0,0 +1,35 @@
+package request
+
+import (
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+)
+
+// UpdateProductRequest replaces all mutable fields of a product (PUT)
+type UpdateProductRequest struct {
+	Name  string         `json:"Name" validate:"required,max=255"`
+	Price entities.Money `json:"Price" validate:"required,gt=0,max=1000000"`
+}
+
+func (req *UpdateProductRequest) ToUpdateProductCommand(id uuid.UUID) (*command.UpdateProductCommand, error) {
+	return &command.UpdateProductCommand{
+		Id:    id,
+		Name:  &req.Name,
+		Price: &req.Price,
+	}, nil
+}
+
+// PatchProductRequest changes only the fields present in the request body (PATCH)
+type PatchProductRequest struct {
+	Name  *string         `json:"Name" validate:"min=1,max=255"`
+	Price *entities.Money `json:"Price" validate:"gt=0,max=1000000"`
+}
+
+func (req *PatchProductRequest) ToUpdateProductCommand(id uuid.UUID) (*command.UpdateProductCommand, error) {
+	return &command.UpdateProductCommand{
+		Id:    id,
+		Name:  req.Name,
+		Price: req.Price,
+	}, nil
+}
@@ -6,8 +6,8 @@ import (
 )
 
 type UpdateSellerRequest struct {
-	Id   uuid.UUID `json:"Id"`
-	Name string    `json:"Name"`
+	Id   uuid.UUID `json:"Id" validate:"required"`
+	Name string    `json:"Name" validate:"required,max=255"`
 }
 
 func (req *UpdateSellerRequest) ToUpdateSellerCommand() (*command.UpdateSellerCommand, error) {
@@ -0,0 +1,223 @@
+package request
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// validationRule checks a non-empty value against the rule parameter, e.g. "255" of "max=255".
+// It returns the code and message of the violation, or an empty code if the value is valid.
+type validationRule func(value reflect.Value, param string) (code, message string)
+
+// validationRules are the rules available in `validate` struct tags
+var validationRules = map[string]validationRule{
+	"required": validateRequired,
+	"min":      validateMin,
+	"max":      validateMax,
+	"gt":       validateGreaterThan,
+	"email":    validateEmail,
+	"uuid":     validateUUID,
+	"url":      validateURL,
+	"oneof":    validateOneOf,
+}
+
+// Validate checks a request against the rules in the `validate` tags of its fields and returns an
+// *entities.ValidationError listing every invalid field, e.g. `validate:"required,max=255"`.
+//
+// Rules are separated by commas:
+//   - required: the value must not be empty, blank strings are empty
+//   - min=N, max=N: bounds of the length of strings and slices, or of the value of numbers and money
+//   - gt=N: numbers and money must be greater than N
+//   - email, uuid, url: the string must be an email address, a UUID or an absolute http(s) URL
+//   - oneof=a b: the string must be one of the space separated values
+//
+// Empty values and nil pointers are only checked by required, so optional fields may be omitted.
+// Fields are named after their json or query tag, the fields of embedded structs are validated too.
+func Validate(req interface{}) error {
+	errs := &entities.ValidationError{}
+	validateStruct(reflect.Indirect(reflect.ValueOf(req)), errs)
+	return errs.Err()
+}
+
+func validateStruct(value reflect.Value, errs *entities.ValidationError) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateStruct(value.Field(i), errs)
+			continue
+		}
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			validateField(fieldName(field), value.Field(i), strings.Split(tag, ","), errs)
+		}
+	}
+}
+
+// validateField records the first rule the value violates
+func validateField(name string, value reflect.Value, rules []string, errs *entities.ValidationError) {
+	present := !isEmpty(value)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		ruleName, param, _ := strings.Cut(rule, "=")
+		check, ok := validationRules[ruleName]
+		if !ok {
+			panic(fmt.Sprintf("unknown validation rule %q of field %s", ruleName, name))
+		}
+		if !present && ruleName != "required" {
+			continue
+		}
+		if code, message := check(value, param); code != "" {
+			errs.Add(name, code, message)
+			return
+		}
+	}
+}
+
+// fieldName returns the name clients use for the field
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// isEmpty reports whether the value is missing, a non-nil pointer is present even if it points to an empty value
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+func validateRequired(value reflect.Value, _ string) (string, string) {
+	if isEmpty(value) {
+		return entities.ValidationCodeRequired, "is required"
+	}
+	return "", ""
+}
+
+func validateMin(value reflect.Value, param string) (string, string) {
+	switch value.Kind() {
+	case reflect.String:
+		if utf8.RuneCountInString(value.String()) < parseLength(param) {
+			return entities.ValidationCodeTooShort, fmt.Sprintf("must be at least %s characters long", param)
+		}
+	case reflect.Slice:
+		if value.Len() < parseLength(param) {
+			return entities.ValidationCodeTooShort, fmt.Sprintf("must contain at least %s items", param)
+		}
+	default:
+		if compareNumber(value, param) < 0 {
+			return entities.ValidationCodeTooSmall, fmt.Sprintf("must be at least %s", param)
+		}
+	}
+	return "", ""
+}
+
+func validateMax(value reflect.Value, param string) (string, string) {
+	switch value.Kind() {
+	case reflect.String:
+		if utf8.RuneCountInString(value.String()) > parseLength(param) {
+			return entities.ValidationCodeTooLong, fmt.Sprintf("must be at most %s characters long", param)
+		}
+	case reflect.Slice:
+		if value.Len() > parseLength(param) {
+			return entities.ValidationCodeTooLong, fmt.Sprintf("must contain at most %s items", param)
+		}
+	default:
+		if compareNumber(value, param) > 0 {
+			return entities.ValidationCodeTooLarge, fmt.Sprintf("must be at most %s", param)
+		}
+	}
+	return "", ""
+}
+
+func validateGreaterThan(value reflect.Value, param string) (string, string) {
+	if compareNumber(value, param) <= 0 {
+		return entities.ValidationCodeTooSmall, fmt.Sprintf("must be greater than %s", param)
+	}
+	return "", ""
+}
+
+func validateEmail(value reflect.Value, _ string) (string, string) {
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return entities.ValidationCodeInvalid, "must be an email address"
+	}
+	return "", ""
+}
+
+func validateUUID(value reflect.Value, _ string) (string, string) {
+	if _, err := uuid.Parse(value.String()); err != nil {
+		return entities.ValidationCodeInvalid, "must be a UUID"
+	}
+	return "", ""
+}
+
+func validateURL(value reflect.Value, _ string) (string, string) {
+	parsed, err := url.Parse(value.String())
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return entities.ValidationCodeInvalid, "must be an absolute http or https URL"
+	}
+	return "", ""
+}
+
+func validateOneOf(value reflect.Value, param string) (string, string) {
+	allowed := strings.Fields(param)
+	for _, option := range allowed {
+		if value.String() == option {
+			return "", ""
+		}
+	}
+	return entities.ValidationCodeNotAllowed, "must be one of " + strings.Join(allowed, ", ")
+}
+
+func parseLength(param string) int {
+	var length int
+	if _, err := fmt.Sscan(param, &length); err != nil {
+		panic(fmt.Sprintf("invalid length %q in validation rule", param))
+	}
+	return length
+}
+
+// compareNumber compares a number or money, in major units, with the rule parameter
+func compareNumber(value reflect.Value, param string) int {
+	bound, ok := new(big.Rat).SetString(param)
+	if !ok {
+		panic(fmt.Sprintf("invalid number %q in validation rule", param))
+	}
+
+	number := new(big.Rat)
+	switch {
+	case value.Type() == reflect.TypeOf(entities.Money{}):
+		number.SetString(value.Interface().(entities.Money).Decimal())
+	case value.CanInt():
+		number.SetInt64(value.Int())
+	case value.CanUint():
+		number.SetUint64(value.Uint())
+	case value.CanFloat():
+		number.SetFloat64(value.Float())
+	default:
+		panic(fmt.Sprintf("cannot compare %s with %q in validation rule", value.Type(), param))
+	}
+
+	return number.Cmp(bound)
+}
@@ -0,0 +1,87 @@
+package request
+
+import (
+	"errors"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func validationFields(t *testing.T, err error) []entities.FieldError {
+	var validationErr *entities.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	return validationErr.Fields
+}
+
+func TestValidateReportsAllInvalidFields(t *testing.T) {
+	req := CreateProductRequest{
+		Name:     "   ",
+		Price:    entities.Money{Amount: 0, Currency: entities.CurrencyUSD},
+		SellerId: "not-a-uuid",
+	}
+
+	err := Validate(&req)
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	assert.Equal(t, []entities.FieldError{
+		{Field: "Name", Code: entities.ValidationCodeRequired, Message: "is required"},
+		{Field: "Price", Code: entities.ValidationCodeTooSmall, Message: "must be greater than 0"},
+		{Field: "SellerId", Code: entities.ValidationCodeInvalid, Message: "must be a UUID"},
+	}, validationFields(t, err))
+}
+
+func TestValidateAcceptsValidRequest(t *testing.T) {
+	req := CreateProductRequest{
+		Name:     "Shoe",
+		Price:    entities.Money{Amount: 1099, Currency: entities.CurrencyUSD},
+		SellerId: "123e4567-e89b-12d3-a456-426614174000",
+	}
+
+	assert.NoError(t, Validate(&req))
+}
+
+func TestValidateBounds(t *testing.T) {
+	tooExpensive := entities.Money{Amount: 100000001, Currency: entities.CurrencyUSD}
+	fields := validationFields(t, Validate(&UpdateProductRequest{Name: strings.Repeat("x", 256), Price: tooExpensive}))
+
+	assert.Equal(t, []entities.FieldError{
+		{Field: "Name", Code: entities.ValidationCodeTooLong, Message: "must be at most 255 characters long"},
+		{Field: "Price", Code: entities.ValidationCodeTooLarge, Message: "must be at most 1000000"},
+	}, fields)
+}
+
+func TestValidateOptionalFields(t *testing.T) {
+	// Absent fields of a patch are not validated
+	assert.NoError(t, Validate(&PatchProductRequest{}))
+
+	// Present fields are, even if they are empty
+	empty := ""
+	fields := validationFields(t, Validate(&PatchProductRequest{Name: &empty}))
+	assert.Equal(t, []entities.FieldError{
+		{Field: "Name", Code: entities.ValidationCodeTooShort, Message: "must be at least 1 characters long"},
+	}, fields)
+}
+
+func TestValidateFormats(t *testing.T) {
+	var req struct {
+		Email string   `json:"email" validate:"required,email"`
+		URL   string   `json:"url" validate:"url"`
+		Role  string   `json:"role" validate:"oneof=owner member"`
+		Tags  []string `json:"tags" validate:"required"`
+	}
+	req.Email = "Jane <jane@example.com>"
+	req.URL = "ftp://example.com"
+	req.Role = "admin"
+
+	fields := validationFields(t, Validate(&req))
+
+	assert.Equal(t, []entities.FieldError{
+		{Field: "email", Code: entities.ValidationCodeInvalid, Message: "must be an email address"},
+		{Field: "url", Code: entities.ValidationCodeInvalid, Message: "must be an absolute http or https URL"},
+		{Field: "role", Code: entities.ValidationCodeNotAllowed, Message: "must be one of owner, member"},
+		{Field: "tags", Code: entities.ValidationCodeRequired, Message: "is required"},
+	}, fields)
+}
@@ -0,0 +1,27 @@
+package response
+
+import "time"
+
+type AuditChangeResponse struct {
+	Field string
+	// Before and After are null if the field was not set, secrets are "[redacted]"
+	Before interface{}
+	After  interface{}
+}
+
+type AuditEntryResponse struct {
+	Id string
+	// ActorId is empty for background jobs and anonymous requests
+	ActorId       string
+	Action        string
+	AggregateType string
+	AggregateId   string
+	Changes       []AuditChangeResponse
+	RequestId     string `json:",omitempty"`
+	OccurredAt    time.Time
+}
+
+type ListAuditEntriesResponse struct {
+	Entries []*AuditEntryResponse
+	PageResponse
+}
@@ -0,0 +1,15 @@
+package response
+
+import "time"
+
+type ExchangeRateResponse struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	// Rate is the exact decimal amount of the quote currency one unit of the base currency is worth
+	Rate          string
+	EffectiveDate time.Time
+}
+
+type ImportExchangeRatesResponse struct {
+	Imported int
+}
@@ -0,0 +1,16 @@
+package response
+
+// HealthResponse reports the health of the server, Status is "ok" or "unavailable"
+type HealthResponse struct {
+	Status string
+	Checks []HealthCheckResponse
+}
+
+// HealthCheckResponse reports the outcome of a single check
+type HealthCheckResponse struct {
+	Name   string
+	Status string
+	// Error describes why the check failed
+	Error      string `json:",omitempty"`
+	DurationMs int64
+}
@@ -0,0 +1,11 @@
+package response
+
+// PageResponse describes the page of a listing. The same links are sent in the Link header.
+type PageResponse struct {
+	Limit  int
+	Offset int
+	// TotalCount is the number of matching items across all pages
+	TotalCount int64
+	// NextCursor is passed as cursor parameter to get the following page, it is missing on the last page
+	NextCursor string `json:",omitempty"`
+}
@@ -0,0 +1,25 @@
+package response
+
+// ProblemResponse is the body of every error response, an RFC 7807 problem details object
+// sent with the content type application/problem+json
+type ProblemResponse struct {
+	// Type is always "about:blank", the problem is identified by its status
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the failed request
+	Instance string `json:"instance,omitempty"`
+	// RequestId is the X-Request-ID of the request, it is logged together with server errors
+	RequestId string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of validation problems
+	Errors []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+// FieldErrorResponse describes why the value of a field is invalid
+type FieldErrorResponse struct {
+	Field string `json:"field"`
+	// Code identifies the violated rule, e.g. "required", "too_long" or "invalid"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -1,15 +1,27 @@
 package response
 
-import "time"
+import (
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"time"
+)
 
 type ProductResponse struct {
-	Id        string
-	Name      string
-	Price     float64
+	Id   string
+	Name string
+	// Price is serialized with an exact decimal amount, e.g. {"Amount":"10.99","Currency":"USD"}
+	Price entities.Money
+	// Version is sent as ETag as well, pass it as If-Match to update or delete only this version
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is only present for soft deleted products, which are listed with include_deleted
+	DeletedAt *time.Time `json:",omitempty"`
+	// ConvertedPrice and ExchangeRate are only present when a currency was requested
+	ConvertedPrice *entities.Money       `json:",omitempty"`
+	ExchangeRate   *ExchangeRateResponse `json:",omitempty"`
 }
 
 type ListProductsResponse struct {
 	Products []*ProductResponse `json:"Products"`
+	PageResponse
 }
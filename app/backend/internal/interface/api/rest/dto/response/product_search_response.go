@@ -0,0 +1,14 @@
+package response
+
+type ProductSearchResultResponse struct {
+	Product *ProductResponse
+	// Rank is the relevance of the match, higher is better
+	Rank float64
+	// Highlight is the HTML-escaped product name with the matches wrapped in <mark> tags
+	Highlight string
+}
+
+type SearchProductsResponse struct {
+	Results []*ProductSearchResultResponse `json:"Results"`
+	PageResponse
+}
@@ -3,12 +3,17 @@ package response
 import "time"
 
 type SellerResponse struct {
-	Id        string
-	Name      string
+	Id   string
+	Name string
+	// Version is sent as ETag as well, pass it as If-Match to update or delete only this version
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is only present for soft deleted sellers, which are listed with include_deleted
+	DeletedAt *time.Time `json:",omitempty"`
 }
 
 type ListSellersResponse struct {
 	Sellers []*SellerResponse
+	PageResponse
 }
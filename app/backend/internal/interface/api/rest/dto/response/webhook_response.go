@@ -0,0 +1,40 @@
+package response
+
+import "time"
+
+type WebhookSubscriptionResponse struct {
+	Id         string
+	URL        string
+	EventTypes []string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// CreateWebhookSubscriptionResponse is only returned once, the secret cannot be retrieved later
+type CreateWebhookSubscriptionResponse struct {
+	WebhookSubscriptionResponse
+	// Secret verifies the X-Webhook-Signature header of the deliveries
+	Secret string
+}
+
+type WebhookDeliveryResponse struct {
+	Id        string
+	EventId   string
+	EventType string
+	// Status is one of pending, succeeded or failed
+	Status   string
+	Attempts int
+	// ResponseStatus is the HTTP status of the last attempt, 0 if the endpoint could not be reached
+	ResponseStatus int
+	LastError      string     `json:",omitempty"`
+	NextAttemptAt  *time.Time `json:",omitempty"`
+	DeliveredAt    *time.Time `json:",omitempty"`
+	// ReplayOf is the id of the replayed delivery
+	ReplayOf  string `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []*WebhookDeliveryResponse
+	PageResponse
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
+	"log"
+	"net/http"
+)
+
+// ProblemContentType is the content type of error responses
+const ProblemContentType = "application/problem+json"
+
+// errorStatuses maps the kinds of domain and application errors to HTTP statuses
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{entities.ErrValidation, http.StatusBadRequest},
+	{entities.ErrUnauthorized, http.StatusUnauthorized},
+	{entities.ErrForbidden, http.StatusForbidden},
+	{entities.ErrNotFound, http.StatusNotFound},
+	{entities.ErrConflict, http.StatusConflict},
+	{entities.ErrTooManyRequests, http.StatusTooManyRequests},
+}
+
+// HTTPErrorHandler renders the errors returned by handlers and middleware as RFC 7807 problem details.
+// Errors of a known kind, e.g. entities.ErrNotFound, get the status of their kind and their message as detail,
+// an echo.HTTPError keeps its status and message. Any other error is logged and answered with a bare 500,
+// so internal details never reach clients. Version conflicts of writes conditional on an If-Match header
+// are answered with 412 Precondition Failed, other version conflicts with 409 Conflict.
+func HTTPErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+
+	status, detail := http.StatusInternalServerError, ""
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		status, detail = httpErr.Code, fmt.Sprint(httpErr.Message)
+	} else {
+		for _, errorStatus := range errorStatuses {
+			if errors.Is(err, errorStatus.kind) {
+				status, detail = errorStatus.status, err.Error()
+				break
+			}
+		}
+		if errors.Is(err, repositories.ErrVersionConflict) && ctx.Request().Header.Get(headerIfMatch) != "" {
+			status = http.StatusPreconditionFailed
+		}
+	}
+
+	var fields []entities.FieldError
+	var validationErr *entities.ValidationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+	}
+
+	requestId := common.RequestScopeFrom(ctx.Request().Context()).RequestId
+	if status >= http.StatusInternalServerError {
+		log.Printf("Request %s %s (%s) failed: %v", ctx.Request().Method, ctx.Request().URL.Path, requestId, err)
+	}
+
+	problem := mapper.ToProblemResponse(status, detail, fields)
+	problem.Instance = ctx.Request().URL.Path
+	problem.RequestId = requestId
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(status)
+	} else {
+		ctx.Response().Header().Set(echo.HeaderContentType, ProblemContentType)
+		err = ctx.JSON(status, problem)
+	}
+	if err != nil {
+		log.Printf("Failed to send error response: %v", err)
+	}
+}
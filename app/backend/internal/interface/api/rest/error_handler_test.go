@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedDetail string
+	}{
+		{"validation", entities.NewValidationError("name", entities.ValidationCodeRequired, "cannot be empty"), http.StatusBadRequest, "name cannot be empty"},
+		{"unauthorized", entities.NewError(entities.ErrUnauthorized, "invalid token"), http.StatusUnauthorized, "invalid token"},
+		{"forbidden", entities.NewError(entities.ErrForbidden, "not a member of the seller"), http.StatusForbidden, "not a member of the seller"},
+		{"not found", fmt.Errorf("loading: %w", entities.NewError(entities.ErrNotFound, "product not found")), http.StatusNotFound, "loading: product not found"},
+		{"conflict", entities.NewError(entities.ErrConflict, "email already taken"), http.StatusConflict, "email already taken"},
+		{"too many requests", entities.NewError(entities.ErrTooManyRequests, "too many failed login attempts"), http.StatusTooManyRequests, "too many failed login attempts"},
+		{"echo error", echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests"), http.StatusTooManyRequests, "Too many requests"},
+		{"internal", errors.New("connection refused"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			req = req.WithContext(common.WithRequestScope(req.Context(), common.RequestScope{RequestId: "request-1"}))
+			rec := httptest.NewRecorder()
+
+			HTTPErrorHandler(tc.err, echo.New().NewContext(req, rec))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+
+			var problem response.ProblemResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, http.StatusText(tc.expectedStatus), problem.Title)
+			assert.Equal(t, tc.expectedStatus, problem.Status)
+			assert.Equal(t, tc.expectedDetail, problem.Detail)
+			assert.Equal(t, "/api/v1/products", problem.Instance)
+			assert.Equal(t, "request-1", problem.RequestId)
+		})
+	}
+}
+
+func TestHTTPErrorHandlerListsInvalidFields(t *testing.T) {
+	validationErr := entities.NewValidationError("name", entities.ValidationCodeRequired, "cannot be empty")
+	validationErr.Add("price", entities.ValidationCodeTooSmall, "must be greater than 0")
+	rec := httptest.NewRecorder()
+
+	HTTPErrorHandler(validationErr, echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/products", nil), rec))
+
+	var problem response.ProblemResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "name", Code: entities.ValidationCodeRequired, Message: "cannot be empty"},
+		{Field: "price", Code: entities.ValidationCodeTooSmall, Message: "must be greater than 0"},
+	}, problem.Errors)
+}
+
+func TestHTTPErrorHandlerVersionConflicts(t *testing.T) {
+	// Conditional writes fail with 412, writes that lost a race with another one with 409
+	for header, expectedStatus := range map[string]int{`"1"`: http.StatusPreconditionFailed, "": http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/1", nil)
+		if header != "" {
+			req.Header.Set("If-Match", header)
+		}
+		rec := httptest.NewRecorder()
+
+		HTTPErrorHandler(repositories.ErrVersionConflict, echo.New().NewContext(req, rec))
+
+		assert.Equal(t, expectedStatus, rec.Code)
+	}
+}
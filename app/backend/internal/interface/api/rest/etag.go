@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// setETag sends the version of the returned aggregate as entity tag, e.g. "3"
+func setETag(c echo.Context, version int) {
+	c.Response().Header().Set(headerETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// setConvertedETag sends a weak entity tag of the version and the display currency, e.g. W/"3-EUR", for a response
+// whose price was converted. The converted price changes with the exchange rates, so the tag is never a version
+// which writes can be made conditional on.
+func setConvertedETag(c echo.Context, version int, currency entities.Currency) {
+	c.Response().Header().Set(headerETag, "W/"+strconv.Quote(strconv.Itoa(version)+"-"+string(currency)))
+}
+
+// ifMatchVersion returns the version a write is conditional on, or nil if the request has no If-Match header
+// or If-Match is *. Entity tags that are no version, e.g. weak tags or tags of another representation, never match,
+// so that writes conditional on them fail with 412 Precondition Failed.
+func ifMatchVersion(c echo.Context) *int {
+	tag := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if tag == "" || tag == "*" {
+		return nil
+	}
+
+	version := -1
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		if parsed, err := strconv.Atoi(unquoted); err == nil {
+			version = parsed
+		}
+	}
+	return &version
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	version := func(v int) *int { return &v }
+	tests := []struct {
+		header   string
+		expected *int
+	}{
+		{"", nil},
+		{"*", nil},
+		{`"3"`, version(3)},
+		{`W/"3"`, version(-1)},
+		{`W/"3-EUR"`, version(-1)},
+		{"3", version(-1)},
+		{`"abc"`, version(-1)},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", tc.header)
+
+		assert.Equal(t, tc.expected, ifMatchVersion(echo.New().NewContext(req, httptest.NewRecorder())), tc.header)
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	setETag(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), 7)
+
+	assert.Equal(t, `"7"`, rec.Header().Get("ETag"))
+}
+
+func TestSetConvertedETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	setConvertedETag(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), 7, entities.CurrencyEUR)
+
+	assert.Equal(t, `W/"7-EUR"`, rec.Header().Get("ETag"))
+}
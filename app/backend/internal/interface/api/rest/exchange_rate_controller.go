@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"net/http"
+)
+
+// maxExchangeRateFileSize limits the size of uploaded rate files
+const maxExchangeRateFileSize = 10 << 20
+
+// ExchangeRateController handles exchange rate endpoints
+type ExchangeRateController struct {
+	exchangeRateService *services.ExchangeRateService
+}
+
+// NewExchangeRateController creates a new ExchangeRateController and registers routes
+func NewExchangeRateController(e *echo.Echo, exchangeRateService *services.ExchangeRateService, authMiddleware *middleware.Auth) {
+	controller := &ExchangeRateController{
+		exchangeRateService: exchangeRateService,
+	}
+
+	// Protected routes (require authentication and the exchange-rate:manage permission)
+	exchangeRates := e.Group(
+		"/api/v1/exchange-rates",
+		authMiddleware.Authenticated(),
+		authMiddleware.RequirePermission(entities.PermissionExchangeRateManage),
+	)
+	exchangeRates.POST("/import", controller.ImportRates)
+}
+
+// ImportRates @Summary Import exchange rates
+// @Description Load daily exchange rates from a CSV file with the header "date,base,quote,rate",
+// @Description e.g. "2024-05-01,EUR,USD,1.0712". The file is rejected as a whole if any row is invalid.
+// @Tags exchange-rates
+// @Accept text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file body string true "CSV file"
+// @Success 200 {object} response.ImportExchangeRatesResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /exchange-rates/import [post]
+func (c *ExchangeRateController) ImportRates(ctx echo.Context) error {
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxExchangeRateFileSize)
+
+	imported, err := c.exchangeRateService.ImportRatesCSV(ctx.Request().Context(), body)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &response.ImportExchangeRatesResponse{Imported: imported})
+}
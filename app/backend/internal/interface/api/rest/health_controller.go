@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
+	"net/http"
+)
+
+// HealthController handles the probes of the orchestrator. They are served outside of the API base path
+// and are therefore not part of the API documentation.
+type HealthController struct {
+	service interfaces.HealthService
+}
+
+// NewHealthController creates a new HealthController and registers routes
+func NewHealthController(e *echo.Echo, service interfaces.HealthService) *HealthController {
+	controller := &HealthController{
+		service: service,
+	}
+
+	// Public routes
+	e.GET("/healthz", controller.LivenessController)
+	e.GET("/readyz", controller.ReadinessController)
+
+	return controller
+}
+
+// LivenessController answers 200 as long as the server handles requests
+func (hc *HealthController) LivenessController(c echo.Context) error {
+	return c.JSON(http.StatusOK, mapper.ToHealthResponse(hc.service.Liveness()))
+}
+
+// ReadinessController answers 200 if the server is ready to handle requests and 503 otherwise,
+// the response lists the outcome of every check
+func (hc *HealthController) ReadinessController(c echo.Context) error {
+	result := hc.service.Readiness(c.Request().Context())
+
+	status := http.StatusOK
+	if !result.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, mapper.ToHealthResponse(result))
+}
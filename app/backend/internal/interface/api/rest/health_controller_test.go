@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type stubHealthCheck struct {
+	err error
+}
+
+func (c stubHealthCheck) Name() string {
+	return "database"
+}
+
+func (c stubHealthCheck) Check(ctx context.Context) error {
+	return c.err
+}
+
+func getHealth(t *testing.T, check stubHealthCheck, path string) (int, response.HealthResponse) {
+	e := echo.New()
+	NewHealthController(e, services.NewHealthService(time.Second, check))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body response.HealthResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestReadiness(t *testing.T) {
+	status, body := getHealth(t, stubHealthCheck{}, "/readyz")
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+	assert.Equal(t, []response.HealthCheckResponse{{Name: "database", Status: "ok"}}, body.Checks)
+}
+
+func TestReadinessUnavailable(t *testing.T) {
+	status, body := getHealth(t, stubHealthCheck{err: errors.New("connection refused")}, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, "unavailable", body.Checks[0].Status)
+	assert.Equal(t, "connection refused", body.Checks[0].Error)
+}
+
+func TestLivenessDoesNotDependOnChecks(t *testing.T) {
+	status, body := getHealth(t, stubHealthCheck{err: errors.New("connection refused")}, "/healthz")
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+	assert.Empty(t, body.Checks)
+}
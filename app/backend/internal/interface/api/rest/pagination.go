@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// setPaginationLinks sets the Link header (RFC 8288) to the first, previous and next page of a listing.
+// Cursor requests only link to the next page because cursors cannot go back.
+func setPaginationLinks(c echo.Context, page common.PageResult) {
+	var links []string
+	addLink := func(rel string, change func(values url.Values)) {
+		pageURL := *c.Request().URL
+		values := pageURL.Query()
+		values.Set("limit", strconv.Itoa(page.Limit))
+		change(values)
+		pageURL.RawQuery = values.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, pageURL.String(), rel))
+	}
+
+	if c.QueryParam("cursor") != "" {
+		if page.NextCursor != "" {
+			addLink("next", func(values url.Values) { values.Set("cursor", page.NextCursor) })
+		}
+	} else {
+		addLink("first", func(values url.Values) { values.Del("offset") })
+		if page.Offset > 0 {
+			addLink("prev", func(values url.Values) { values.Set("offset", strconv.Itoa(max(page.Offset-page.Limit, 0))) })
+		}
+		if int64(page.Offset+page.Limit) < page.TotalCount {
+			addLink("next", func(values url.Values) { values.Set("offset", strconv.Itoa(page.Offset+page.Limit)) })
+		}
+	}
+
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+}
@@ -1,62 +1,85 @@
 package rest
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/sklinkert/go-ddd/internal/application/command"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
 	"net/http"
 )
 
 type ProductController struct {
-	service interfaces.ProductService
+	service        interfaces.ProductService
+	authMiddleware *middleware.Auth
 }
 
-func NewProductController(e *echo.Echo, service interfaces.ProductService) *ProductController {
+func NewProductController(e *echo.Echo, service interfaces.ProductService, authMiddleware *middleware.Auth, idempotency *middleware.Idempotency) *ProductController {
 	controller := &ProductController{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 
-	e.POST("/api/v1/products", controller.CreateProductController)
-	e.GET("/api/v1/products", controller.GetAllProductsController)
+	// Public routes, listing deleted products requires authentication
+	e.GET("/api/v1/products", controller.GetAllProductsController, authMiddleware.Optional())
 	e.GET("/api/v1/products/:id", controller.GetProductByIdController)
-	e.Use(middleware.Recover())
+
+	// Protected routes (require authentication and the product:write permission),
+	// creates and updates can be retried safely with an Idempotency-Key
+	canWrite := authMiddleware.RequirePermission(entities.PermissionProductWrite)
+	e.POST("/api/v1/products", controller.CreateProductController, authMiddleware.Authenticated(), canWrite, idempotency.Idempotent())
+	e.PUT("/api/v1/products/:id", controller.PutProductController, authMiddleware.Authenticated(), canWrite, idempotency.Idempotent())
+	e.PATCH("/api/v1/products/:id", controller.PatchProductController, authMiddleware.Authenticated(), canWrite, idempotency.Idempotent())
+	e.DELETE("/api/v1/products/:id", controller.DeleteProductController, authMiddleware.Authenticated(), canWrite)
+	e.POST("/api/v1/products/:id/restore", controller.RestoreProductController, authMiddleware.Authenticated(), canWrite)
+	e.Use(echomiddleware.Recover())
 
 	return controller
 }
 
 // CreateProductController @Summary Create a new product
-// @Description Create a new product with the provided details
+// @Description Create a new product for a seller the authenticated user is a member of
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
 // @Success 201 {object} response.ProductResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 413 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /products [post]
 func (pc *ProductController) CreateProductController(c echo.Context) error {
 	var createProductRequest request.CreateProductRequest
 
 	if err := c.Bind(&createProductRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to parse request body",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&createProductRequest); err != nil {
+		return err
 	}
 
 	productCommand, err := createProductRequest.ToCreateProductCommand()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	if productCommand.Actor, err = actorFromContext(c, pc.authMiddleware); err != nil {
+		return err
 	}
 
-	result, err := pc.service.CreateProduct(productCommand)
+	result, err := pc.service.CreateProduct(c.Request().Context(), productCommand)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create product",
-		})
+		return err
 	}
 
 	response := mapper.ToProductResponse(result.Result)
@@ -65,59 +88,288 @@ func (pc *ProductController) CreateProductController(c echo.Context) error {
 }
 
 // GetAllProductsController @Summary Get all products
-// @Description Get a list of all products
+// @Description Get a page of products, optionally filtered, sorted and with prices converted to another currency.
+// @Description Links to the first, previous and next page are sent in the Link header.
 // @Tags products
 // @Accept json
 // @Produce json
-// @Success 200 {array} response.ProductResponse
-// @Failure 500 {object} map[string]string
+// @Param limit query int false "Page size, default 20, at most 100"
+// @Param offset query int false "Number of products to skip, cannot be combined with cursor"
+// @Param cursor query string false "NextCursor of the previous page"
+// @Param sort query string false "name, price or created_at, prefixed with - for descending order" default(created_at)
+// @Param name query string false "Only products whose name contains this text, ignoring case"
+// @Param seller_id query string false "Only products of this seller"
+// @Param min_price query string false "Minimum price in price_currency, e.g. 9.99"
+// @Param max_price query string false "Maximum price in price_currency, e.g. 99.99"
+// @Param price_currency query string false "Currency of the price range, only products priced in it match" default(USD)
+// @Param created_from query string false "Created at or after this RFC 3339 timestamp or date"
+// @Param created_to query string false "Created before this RFC 3339 timestamp or on or before this date"
+// @Param currency query string false "Currency to convert prices to, e.g. EUR"
+// @Param include_deleted query bool false "Also list soft deleted products, requires the seller:manage-any permission"
+// @Success 200 {object} response.ListProductsResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /products [get]
 func (pc *ProductController) GetAllProductsController(c echo.Context) error {
-	products, err := pc.service.FindAllProducts()
+	var listProductsRequest request.ListProductsRequest
+	if err := c.Bind(&listProductsRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse query parameters")
+	}
+
+	listQuery, err := listProductsRequest.ToListProductsQuery()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch products",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if listQuery.Filter.IncludeDeleted {
+		if err := authorizeIncludeDeleted(c, pc.authMiddleware); err != nil {
+			return err
+		}
+	}
+
+	products, err := pc.service.FindAllProducts(c.Request().Context(), listQuery)
+	if errors.Is(err, services.ErrExchangeRateNotFound) {
+		return echo.NewHTTPError(http.StatusBadRequest, "No exchange rate for the requested currency")
+	}
+	if err != nil {
+		return err
 	}
 
 	response := mapper.ToProductListResponse(products.Result)
+	response.PageResponse = mapper.ToPageResponse(products.Page)
+	setPaginationLinks(c, products.Page)
 
 	return c.JSON(http.StatusOK, response)
 }
 
 // GetProductByIdController @Summary Get a product by ID
-// @Description Get a product by its ID
+// @Description Get a product by its ID, optionally with its price converted to another currency
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param currency query string false "Currency to convert the price to, e.g. EUR"
 // @Success 200 {object} response.ProductResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the product, a weak tag if the price was converted"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /products/{id} [get]
 func (pc *ProductController) GetProductByIdController(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	displayCurrency, err := displayCurrencyFromQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Unsupported currency")
 	}
 
-	product, err := pc.service.FindProductById(id)
+	product, err := pc.service.FindProductById(c.Request().Context(), id, displayCurrency)
+	if errors.Is(err, services.ErrExchangeRateNotFound) {
+		return echo.NewHTTPError(http.StatusBadRequest, "No exchange rate for the requested currency")
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch product",
-		})
+		return err
 	}
 
 	if product == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Product not found",
-		})
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
 	}
 
 	response := mapper.ToProductResponse(product.Result)
 
+	if displayCurrency != "" {
+		setConvertedETag(c, product.Result.Version, displayCurrency)
+	} else {
+		setETag(c, product.Result.Version)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// PutProductController @Summary Update a product
+// @Description Replace the name and price of a product of a seller the authenticated user is a member of
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param product body request.UpdateProductRequest true "Product details"
+// @Param If-Match header string false "Only update the product if it still has this ETag"
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
+// @Success 200 {object} response.ProductResponse
+// @Header 200 {string} ETag "Version of the updated product"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 413 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /products/{id} [put]
+func (pc *ProductController) PutProductController(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	var updateProductRequest request.UpdateProductRequest
+	if err := c.Bind(&updateProductRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&updateProductRequest); err != nil {
+		return err
+	}
+
+	updateCommand, err := updateProductRequest.ToUpdateProductCommand(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product details")
+	}
+
+	return pc.updateProduct(c, updateCommand)
+}
+
+// PatchProductController @Summary Partially update a product
+// @Description Change the name and/or price of a product of a seller the authenticated user is a member of
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param product body request.PatchProductRequest true "Fields to change"
+// @Param If-Match header string false "Only update the product if it still has this ETag"
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
+// @Success 200 {object} response.ProductResponse
+// @Header 200 {string} ETag "Version of the updated product"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 413 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /products/{id} [patch]
+func (pc *ProductController) PatchProductController(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	var patchProductRequest request.PatchProductRequest
+	if err := c.Bind(&patchProductRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&patchProductRequest); err != nil {
+		return err
+	}
+
+	updateCommand, err := patchProductRequest.ToUpdateProductCommand(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product details")
+	}
+
+	return pc.updateProduct(c, updateCommand)
+}
+
+func (pc *ProductController) updateProduct(c echo.Context, updateCommand *command.UpdateProductCommand) error {
+	var err error
+	if updateCommand.Actor, err = actorFromContext(c, pc.authMiddleware); err != nil {
+		return err
+	}
+	updateCommand.ExpectedVersion = ifMatchVersion(c)
+
+	result, err := pc.service.UpdateProduct(c.Request().Context(), updateCommand)
+	if err != nil {
+		return err
+	}
+
+	response := mapper.ToProductResponse(result.Result)
+
+	setETag(c, result.Result.Version)
 	return c.JSON(http.StatusOK, response)
 }
+
+// DeleteProductController @Summary Delete a product
+// @Description Soft delete a product of a seller the authenticated user is a member of, it can be restored until it is purged
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "Only delete the product if it still has this ETag"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /products/{id} [delete]
+func (pc *ProductController) DeleteProductController(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	actor, err := actorFromContext(c, pc.authMiddleware)
+	if err != nil {
+		return err
+	}
+
+	err = pc.service.DeleteProduct(c.Request().Context(), id, ifMatchVersion(c), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreProductController @Summary Restore a deleted product
+// @Description Restore a soft deleted product of a seller the authenticated user is a member of.
+// @Description Products of a deleted seller are restored together with the seller.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} response.ProductResponse
+// @Header 200 {string} ETag "Version of the restored product"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /products/{id}/restore [post]
+func (pc *ProductController) RestoreProductController(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product Id format")
+	}
+
+	actor, err := actorFromContext(c, pc.authMiddleware)
+	if err != nil {
+		return err
+	}
+
+	result, err := pc.service.RestoreProduct(c.Request().Context(), id, actor)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, result.Result.Version)
+	return c.JSON(http.StatusOK, mapper.ToProductResponse(result.Result))
+}
+
+// displayCurrencyFromQuery returns the optional currency query parameter, or an empty currency if it is not set
+func displayCurrencyFromQuery(c echo.Context) (entities.Currency, error) {
+	code := c.QueryParam("currency")
+	if code == "" {
+		return "", nil
+	}
+	return entities.ParseCurrency(code)
+}
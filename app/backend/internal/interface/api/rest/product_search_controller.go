@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
+	"net/http"
+)
+
+// ProductSearchController handles the product search endpoint
+type ProductSearchController struct {
+	service interfaces.ProductSearchService
+}
+
+// NewProductSearchController creates a new ProductSearchController and registers routes
+func NewProductSearchController(e *echo.Echo, service interfaces.ProductSearchService) *ProductSearchController {
+	controller := &ProductSearchController{
+		service: service,
+	}
+
+	// Public routes
+	e.GET("/api/v1/products/search", controller.SearchProductsController)
+
+	return controller
+}
+
+// SearchProductsController @Summary Search products
+// @Description Full-text search over product names, best matches first. All words of q must match,
+// @Description words of three or more letters also match longer words and misspelled words match similar indexed words.
+// @Description Japanese text is matched regardless of katakana or hiragana and full-width or half-width forms.
+// @Description Links to the first, previous and next page are sent in the Link header.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Page size, default 20, at most 100"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} response.SearchProductsResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Failure 503 {object} response.ProblemResponse
+// @Router /products/search [get]
+func (sc *ProductSearchController) SearchProductsController(c echo.Context) error {
+	var searchRequest request.SearchProductsRequest
+	if err := c.Bind(&searchRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse query parameters")
+	}
+
+	searchQuery, err := searchRequest.ToSearchProductsQuery()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := sc.service.SearchProducts(c.Request().Context(), searchQuery)
+	if errors.Is(err, services.ErrProductSearchUnavailable) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Product search is not available")
+	}
+	if err != nil {
+		return err
+	}
+
+	setPaginationLinks(c, result.Page)
+
+	return c.JSON(http.StatusOK, mapper.ToSearchProductsResponse(result))
+}
@@ -0,0 +1,223 @@
+package rest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
+	"net/http"
+)
+
+// RoleController handles role management endpoints
+type RoleController struct {
+	roleService *services.RoleService
+}
+
+// NewRoleController creates a new RoleController and registers routes
+func NewRoleController(e *echo.Echo, roleService *services.RoleService, authMiddleware *middleware.Auth) {
+	controller := &RoleController{
+		roleService: roleService,
+	}
+
+	// Protected routes (require authentication and the role:manage permission)
+	roles := e.Group(
+		"/api/v1/roles",
+		authMiddleware.Authenticated(),
+		authMiddleware.RequirePermission(entities.PermissionRoleManage),
+	)
+	roles.GET("", controller.ListRoles)
+	roles.GET("/permissions", controller.ListPermissions)
+	roles.GET("/:name", controller.GetRole)
+	roles.POST("", controller.CreateRole)
+	roles.PUT("/:name", controller.UpdateRole)
+	roles.DELETE("/:name", controller.DeleteRole)
+}
+
+// roleRequest is the request body for creating and updating roles
+type roleRequest struct {
+	// Name is only read when creating roles, updates take it from the path
+	Name        string   `json:"name" validate:"max=50"`
+	Description string   `json:"description" validate:"max=500"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r *roleRequest) permissions() []entities.Permission {
+	permissions := make([]entities.Permission, len(r.Permissions))
+	for i, permission := range r.Permissions {
+		permissions[i] = entities.Permission(permission)
+	}
+	return permissions
+}
+
+// roleResponse converts a role to its response format
+func roleResponse(role *entities.Role) map[string]interface{} {
+	permissions := make([]string, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = string(permission)
+	}
+
+	return map[string]interface{}{
+		"name":        string(role.Name),
+		"description": role.Description,
+		"permissions": permissions,
+		"built_in":    role.IsBuiltIn(),
+	}
+}
+
+// ListRoles @Summary List roles
+// @Description List all roles and their permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} object{name=string,description=string,permissions=[]string,built_in=bool}
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /roles [get]
+func (c *RoleController) ListRoles(ctx echo.Context) error {
+	roles, err := c.roleService.GetAllRoles(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	response := make([]map[string]interface{}, len(roles))
+	for i, role := range roles {
+		response[i] = roleResponse(role)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// ListPermissions @Summary List permissions
+// @Description List all permissions which can be granted to roles
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} string
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Router /roles/permissions [get]
+func (c *RoleController) ListPermissions(ctx echo.Context) error {
+	permissions := entities.AllPermissions()
+
+	response := make([]string, len(permissions))
+	for i, permission := range permissions {
+		response[i] = string(permission)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetRole @Summary Get role
+// @Description Get a role by name
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} object{name=string,description=string,permissions=[]string,built_in=bool}
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /roles/{name} [get]
+func (c *RoleController) GetRole(ctx echo.Context) error {
+	role, err := c.roleService.GetRole(ctx.Request().Context(), entities.UserRole(ctx.Param("name")))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, roleResponse(role))
+}
+
+// CreateRole @Summary Create role
+// @Description Create a new role with the given permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param role body object{name=string,description=string,permissions=[]string} true "Role details"
+// @Success 201 {object} object{name=string,description=string,permissions=[]string,built_in=bool}
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /roles [post]
+func (c *RoleController) CreateRole(ctx echo.Context) error {
+	var req roleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return entities.NewValidationError("name", entities.ValidationCodeRequired, "is required")
+	}
+
+	role, err := c.roleService.CreateRole(ctx.Request().Context(), entities.UserRole(req.Name), req.Description, req.permissions())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, roleResponse(role))
+}
+
+// UpdateRole @Summary Update role
+// @Description Update the description of a role and replace its permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Param role body object{description=string,permissions=[]string} true "Role details"
+// @Success 200 {object} object{name=string,description=string,permissions=[]string,built_in=bool}
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /roles/{name} [put]
+func (c *RoleController) UpdateRole(ctx echo.Context) error {
+	var req roleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+
+	role, err := c.roleService.UpdateRole(ctx.Request().Context(), entities.UserRole(ctx.Param("name")), req.Description, req.permissions())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, roleResponse(role))
+}
+
+// DeleteRole @Summary Delete role
+// @Description Delete a custom role which is not assigned to any user
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Success 204 "No Content"
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /roles/{name} [delete]
+func (c *RoleController) DeleteRole(ctx echo.Context) error {
+	err := c.roleService.DeleteRole(ctx.Request().Context(), entities.UserRole(ctx.Param("name")))
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -4,25 +4,40 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
 	"net/http"
 )
 
 type SellerController struct {
-	service interfaces.SellerService
+	service        interfaces.SellerService
+	authMiddleware *middleware.Auth
 }
 
-func NewSellerController(e *echo.Echo, service interfaces.SellerService) *SellerController {
+func NewSellerController(e *echo.Echo, service interfaces.SellerService, authMiddleware *middleware.Auth, idempotency *middleware.Idempotency) *SellerController {
 	controller := &SellerController{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 
-	e.POST("/api/v1/sellers", controller.CreateSellerController)
-	e.GET("/api/v1/sellers", controller.GetAllSellersController)
+	// Public routes, listing deleted sellers requires authentication
+	e.GET("/api/v1/sellers", controller.GetAllSellersController, authMiddleware.Optional())
 	e.GET("/api/v1/sellers/:id", controller.GetSellerByIdController)
-	e.PUT("/api/v1/sellers", controller.PutSellerController)
-	e.DELETE("/api/v1/sellers/:id", controller.DeleteSellerController)
+
+	// Protected routes (require authentication and the seller:write permission),
+	// creates and updates can be retried safely with an Idempotency-Key
+	canWrite := authMiddleware.RequirePermission(entities.PermissionSellerWrite)
+	e.POST("/api/v1/sellers", controller.CreateSellerController, authMiddleware.Authenticated(), canWrite, idempotency.Idempotent())
+	e.PUT("/api/v1/sellers", controller.PutSellerController, authMiddleware.Authenticated(), canWrite, idempotency.Idempotent())
+	e.DELETE("/api/v1/sellers/:id", controller.DeleteSellerController, authMiddleware.Authenticated(), canWrite)
+	e.POST("/api/v1/sellers/:id/restore", controller.RestoreSellerController, authMiddleware.Authenticated(), canWrite)
+	e.POST("/api/v1/sellers/:id/members", controller.AddSellerMemberController, authMiddleware.Authenticated(), canWrite)
+	e.DELETE("/api/v1/sellers/:id/members/:userId", controller.RemoveSellerMemberController, authMiddleware.Authenticated(), canWrite)
+
+	// Sellers of the authenticated user
+	e.GET("/api/v1/me/sellers", controller.GetMySellersController, authMiddleware.Authenticated())
 
 	return controller
 }
@@ -32,31 +47,38 @@ func NewSellerController(e *echo.Echo, service interfaces.SellerService) *Seller
 // @Tags sellers
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
 // @Success 201 {object} response.SellerResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 413 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /sellers [post]
 func (sc *SellerController) CreateSellerController(c echo.Context) error {
 	var createSellerRequest request.CreateSellerRequest
 
 	if err := c.Bind(&createSellerRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to parse request body",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&createSellerRequest); err != nil {
+		return err
 	}
 
 	sellerCommand, err := createSellerRequest.ToCreateSellerCommand()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
 	}
 
-	commandResult, err := sc.service.CreateSeller(sellerCommand)
+	if sellerCommand.Actor, err = actorFromContext(c, sc.authMiddleware); err != nil {
+		return err
+	}
+
+	commandResult, err := sc.service.CreateSeller(c.Request().Context(), sellerCommand)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create seller",
-		})
+		return err
 	}
 
 	response := mapper.ToSellerResponse(commandResult.Result)
@@ -65,22 +87,49 @@ func (sc *SellerController) CreateSellerController(c echo.Context) error {
 }
 
 // @Summary Get all sellers
-// @Description Get a list of all sellers
+// @Description Get a page of sellers, optionally filtered and sorted.
+// @Description Links to the first, previous and next page are sent in the Link header.
 // @Tags sellers
 // @Accept json
 // @Produce json
-// @Success 200 {array} response.SellerResponse
-// @Failure 500 {object} map[string]string
+// @Param limit query int false "Page size, default 20, at most 100"
+// @Param offset query int false "Number of sellers to skip, cannot be combined with cursor"
+// @Param cursor query string false "NextCursor of the previous page"
+// @Param sort query string false "name or created_at, prefixed with - for descending order" default(created_at)
+// @Param name query string false "Only sellers whose name contains this text, ignoring case"
+// @Param created_from query string false "Created at or after this RFC 3339 timestamp or date"
+// @Param created_to query string false "Created before this RFC 3339 timestamp or on or before this date"
+// @Param include_deleted query bool false "Also list soft deleted sellers, requires the seller:manage-any permission"
+// @Success 200 {object} response.ListSellersResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /sellers [get]
 func (sc *SellerController) GetAllSellersController(c echo.Context) error {
-	sellers, err := sc.service.FindAllSellers()
+	var listSellersRequest request.ListSellersRequest
+	if err := c.Bind(&listSellersRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse query parameters")
+	}
+
+	listQuery, err := listSellersRequest.ToListSellersQuery()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if listQuery.Filter.IncludeDeleted {
+		if err := authorizeIncludeDeleted(c, sc.authMiddleware); err != nil {
+			return err
+		}
+	}
+
+	sellers, err := sc.service.FindAllSellers(c.Request().Context(), listQuery)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch sellers",
-		})
+		return err
 	}
 
 	response := mapper.ToSellerListResponse(sellers.Result)
+	response.PageResponse = mapper.ToPageResponse(sellers.Page)
+	setPaginationLinks(c, sellers.Page)
 
 	return c.JSON(http.StatusOK, response)
 }
@@ -92,9 +141,10 @@ func (sc *SellerController) GetAllSellersController(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Seller ID"
 // @Success 200 {object} response.SellerResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the seller"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /sellers/{id} [get]
 func (sc *SellerController) GetSellerByIdController(c echo.Context) error {
 	// Hack: split the Id from the URL
@@ -103,26 +153,21 @@ func (sc *SellerController) GetSellerByIdController(c echo.Context) error {
 
 	id, err := uuid.Parse(idRaw)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
 	}
 
-	seller, err := sc.service.FindSellerById(id)
+	seller, err := sc.service.FindSellerById(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch seller",
-		})
+		return err
 	}
 
 	if seller == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Seller not found",
-		})
+		return echo.NewHTTPError(http.StatusNotFound, "Seller not found")
 	}
 
 	response := mapper.ToSellerResponse(seller.Result)
 
+	setETag(c, seller.Result.Version)
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -131,48 +176,65 @@ func (sc *SellerController) GetSellerByIdController(c echo.Context) error {
 // @Tags sellers
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param seller body request.UpdateSellerRequest true "Updated seller"
+// @Param If-Match header string false "Only update the seller if it still has this ETag"
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
 // @Success 200 {object} response.SellerResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the updated seller"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 409 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 413 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /sellers [put]
 func (sc *SellerController) PutSellerController(c echo.Context) error {
 	var updateSellerRequest request.UpdateSellerRequest
 
 	if err := c.Bind(&updateSellerRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to parse request body",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&updateSellerRequest); err != nil {
+		return err
 	}
 
 	updateSellerCommand, err := updateSellerRequest.ToUpdateSellerCommand()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
+	}
+
+	if updateSellerCommand.Actor, err = actorFromContext(c, sc.authMiddleware); err != nil {
+		return err
 	}
+	updateSellerCommand.ExpectedVersion = ifMatchVersion(c)
 
-	commandResult, err := sc.service.UpdateSeller(updateSellerCommand)
+	commandResult, err := sc.service.UpdateSeller(c.Request().Context(), updateSellerCommand)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update seller",
-		})
+		return err
 	}
 
 	response := mapper.ToSellerResponse(commandResult.Result)
 
+	setETag(c, commandResult.Result.Version)
 	return c.JSON(http.StatusOK, response)
 }
 
 // @Summary Delete a seller
-// @Description Delete a seller by its ID
+// @Description Soft delete a seller together with its products, the owners can restore it until it is purged
 // @Tags sellers
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "Seller ID"
+// @Param If-Match header string false "Only delete the seller if it still has this ETag"
 // @Success 204 {string} string "No Content"
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /sellers/{id} [delete]
 func (sc *SellerController) DeleteSellerController(c echo.Context) error {
 	// Hack: split the Id from the URL
@@ -181,16 +243,154 @@ func (sc *SellerController) DeleteSellerController(c echo.Context) error {
 
 	id, err := uuid.Parse(idRaw)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller Id format",
-		})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
+	}
+
+	actor, err := actorFromContext(c, sc.authMiddleware)
+	if err != nil {
+		return err
+	}
+
+	err = sc.service.DeleteSeller(c.Request().Context(), id, ifMatchVersion(c), actor)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Restore a deleted seller
+// @Description Restore a soft deleted seller of the authenticated owner together with the products deleted with it
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Seller ID"
+// @Success 200 {object} response.SellerResponse
+// @Header 200 {string} ETag "Version of the restored seller"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /sellers/{id}/restore [post]
+func (sc *SellerController) RestoreSellerController(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
+	}
+
+	actor, err := actorFromContext(c, sc.authMiddleware)
+	if err != nil {
+		return err
+	}
+
+	commandResult, err := sc.service.RestoreSeller(c.Request().Context(), id, actor)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, commandResult.Result.Version)
+	return c.JSON(http.StatusOK, mapper.ToSellerResponse(commandResult.Result))
+}
+
+// @Summary Get my sellers
+// @Description Get all sellers the authenticated user owns or is a member of
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.ListSellersResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /me/sellers [get]
+func (sc *SellerController) GetMySellersController(c echo.Context) error {
+	sellers, err := sc.service.FindSellersByMember(c.Request().Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	response := mapper.ToSellerListResponse(sellers.Result)
+	response.PageResponse = mapper.ToPageResponse(sellers.Page)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// @Summary Add a seller member
+// @Description Add a user to a seller as owner or member, or change the role of an existing member.
+// @Description Only owners of the seller may manage its members. The seller and the user must exist.
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Seller ID"
+// @Param member body request.AddSellerMemberRequest true "Member"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /sellers/{id}/members [post]
+func (sc *SellerController) AddSellerMemberController(c echo.Context) error {
+	sellerId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
+	}
+
+	var addMemberRequest request.AddSellerMemberRequest
+	if err := c.Bind(&addMemberRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse request body")
+	}
+	if err := request.Validate(&addMemberRequest); err != nil {
+		return err
+	}
+
+	memberCommand, err := addMemberRequest.ToAddSellerMemberCommand(sellerId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if memberCommand.Actor, err = actorFromContext(c, sc.authMiddleware); err != nil {
+		return err
+	}
+
+	err = sc.service.AddSellerMember(c.Request().Context(), memberCommand)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Remove a seller member
+// @Description Remove a user from a seller. Only owners of the seller may manage its members.
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Seller ID"
+// @Param userId path string true "User ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /sellers/{id}/members/{userId} [delete]
+func (sc *SellerController) RemoveSellerMemberController(c echo.Context) error {
+	sellerId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seller Id format")
+	}
+
+	actor, err := actorFromContext(c, sc.authMiddleware)
+	if err != nil {
+		return err
 	}
 
-	err = sc.service.DeleteSeller(id)
+	err = sc.service.RemoveSellerMember(c.Request().Context(), sellerId, c.Param("userId"), actor)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete seller",
-		})
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
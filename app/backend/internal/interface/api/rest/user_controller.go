@@ -1,61 +1,42 @@
 package rest
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/command"
 	"github.com/sklinkert/go-ddd/internal/application/services"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
 	"net/http"
 )
 
 // UserController handles user management endpoints
 type UserController struct {
 	userService *services.UserService
+	roleService *services.RoleService
 }
 
 // NewUserController creates a new UserController and registers routes
-func NewUserController(e *echo.Echo, userService *services.UserService) {
+func NewUserController(e *echo.Echo, userService *services.UserService, roleService *services.RoleService, authMiddleware *middleware.Auth) {
 	controller := &UserController{
 		userService: userService,
+		roleService: roleService,
 	}
 
-	// Protected routes (require authentication)
-	users := e.Group("/api/v1/users")
-	users.Use(controller.AdminMiddleware)
-	users.GET("", controller.ListUsers)
-	users.GET("/:id", controller.GetUser)
-	users.POST("", controller.CreateUser)
-	users.PUT("/:id", controller.UpdateUser)
-	users.DELETE("/:id", controller.DeleteUser)
-	users.PUT("/:id/role", controller.UpdateUserRole)
-	users.PUT("/:id/status", controller.UpdateUserStatus)
-}
-
-// AdminMiddleware is a middleware that checks if the user has admin role
-func (c *UserController) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		// Get user ID from context (set by AuthMiddleware)
-		userID, ok := ctx.Get("userID").(string)
-		if !ok {
-			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
-		}
+	// Protected routes (require authentication and the permission of the route)
+	canRead := authMiddleware.RequirePermission(entities.PermissionUserRead)
+	canWrite := authMiddleware.RequirePermission(entities.PermissionUserWrite)
 
-		// Get user from database
-		user, err := c.userService.GetUserByID(userID)
-		if err != nil {
-			return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
-		}
-		if user == nil {
-			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "User not found"})
-		}
-
-		// Check if user has admin role
-		if user.Role != entities.RoleAdmin {
-			return ctx.JSON(http.StatusForbidden, map[string]string{"error": "Admin role required"})
-		}
-
-		return next(ctx)
-	}
+	users := e.Group("/api/v1/users", authMiddleware.Authenticated())
+	users.GET("", controller.ListUsers, canRead)
+	users.GET("/:id", controller.GetUser, canRead)
+	users.POST("", controller.CreateUser, canWrite)
+	users.PUT("/:id", controller.UpdateUser, canWrite)
+	users.DELETE("/:id", controller.DeleteUser, canWrite)
+	users.PUT("/:id/role", controller.UpdateUserRole, authMiddleware.RequirePermission(entities.PermissionRoleManage))
+	users.PUT("/:id/status", controller.UpdateUserStatus, canWrite)
 }
 
 // ListUsers @Summary List users
@@ -63,14 +44,15 @@ func (c *UserController) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param username query string false "Filter by username"
 // @Param email query string false "Filter by email"
 // @Param role query string false "Filter by role"
 // @Param status query string false "Filter by status"
 // @Success 200 {array} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users [get]
 func (c *UserController) ListUsers(ctx echo.Context) error {
 	// Get filter parameters
@@ -85,13 +67,13 @@ func (c *UserController) ListUsers(ctx echo.Context) error {
 	var users []*entities.User
 	var err error
 	if filter.Username != "" || filter.Email != "" || filter.Role != "" || filter.Status != "" {
-		users, err = c.userService.FindUsers(filter)
+		users, err = c.userService.FindUsers(ctx.Request().Context(), filter)
 	} else {
-		users, err = c.userService.GetAllUsers()
+		users, err = c.userService.GetAllUsers(ctx.Request().Context())
 	}
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get users"})
+		return err
 	}
 
 	// Convert to response format
@@ -114,27 +96,30 @@ func (c *UserController) ListUsers(ctx echo.Context) error {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "User ID"
 // @Success 200 {object} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the user"
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users/{id} [get]
 func (c *UserController) GetUser(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+		return echo.NewHTTPError(http.StatusBadRequest, "User ID is required")
 	}
 
-	user, err := c.userService.GetUserByID(id)
+	user, err := c.userService.GetUserByID(ctx.Request().Context(), id)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return err
 	}
 	if user == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
 	}
 
+	setETag(ctx, user.Version)
 	return ctx.JSON(http.StatusOK, map[string]string{
 		"id":       user.ID,
 		"username": user.Username,
@@ -149,56 +134,47 @@ func (c *UserController) GetUser(ctx echo.Context) error {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param user body object{username=string,email=string,password=string,role=string,status=string} true "User details"
 // @Success 201 {object} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users [post]
 func (c *UserController) CreateUser(ctx echo.Context) error {
 	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Role     string `json:"role"`
-		Status   string `json:"status"`
+		Username string `json:"username" validate:"required,min=3,max=50"`
+		Email    string `json:"email" validate:"required,email,max=254"`
+		Password string `json:"password" validate:"required,min=8,max=128"`
+		Role     string `json:"role" validate:"max=50"`
+		Status   string `json:"status" validate:"oneof=active inactive locked"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
-
-	// Validate input
-	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Username, email, and password are required"})
-	}
-
-	// Register user
-	user, err := c.userService.RegisterUser(req.Username, req.Email, req.Password)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
-	// Update role and status if provided
+	// Assigning roles requires the role:manage permission
 	if req.Role != "" {
-		err = c.userService.UpdateUserRole(user.ID, entities.UserRole(req.Role))
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		if err := c.checkRoleAssignment(ctx, entities.UserRole(req.Role)); err != nil {
+			return err
 		}
 	}
 
-	if req.Status != "" {
-		err = c.userService.UpdateUserStatus(user.ID, entities.UserStatus(req.Status))
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
-	}
-
-	// Get updated user
-	user, err = c.userService.GetUserByID(user.ID)
+	// Create the user with its role and status at once
+	user, err := c.userService.CreateUser(ctx.Request().Context(), &command.CreateUserCommand{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     entities.UserRole(req.Role),
+		Status:   entities.UserStatus(req.Status),
+	})
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return err
 	}
 
 	return ctx.JSON(http.StatusCreated, map[string]string{
@@ -215,64 +191,60 @@ func (c *UserController) CreateUser(ctx echo.Context) error {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "User ID"
 // @Param user body object{username=string,email=string,password=string} true "User details"
+// @Param If-Match header string false "Only update the user if it still has this ETag"
 // @Success 200 {object} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the updated user"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users/{id} [put]
 func (c *UserController) UpdateUser(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+		return echo.NewHTTPError(http.StatusBadRequest, "User ID is required")
 	}
 
 	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Username string `json:"username" validate:"min=3,max=50"`
+		Email    string `json:"email" validate:"email,max=254"`
+		Password string `json:"password" validate:"min=8,max=128"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
-	// Update username if provided
-	if req.Username != "" {
-		err := c.userService.UpdateUserUsername(id, req.Username)
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
+	if err := c.checkUserManagement(ctx, id); err != nil {
+		return err
 	}
 
-	// Update email if provided
+	// Only the provided fields are updated
+	updateCommand := &command.UpdateUserCommand{Id: id, ExpectedVersion: ifMatchVersion(ctx)}
+	if req.Username != "" {
+		updateCommand.Username = &req.Username
+	}
 	if req.Email != "" {
-		err := c.userService.UpdateUserEmail(id, req.Email)
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
+		updateCommand.Email = &req.Email
 	}
-
-	// Update password if provided
 	if req.Password != "" {
-		err := c.userService.UpdateUserPassword(id, req.Password)
-		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
+		updateCommand.Password = &req.Password
 	}
 
-	// Get updated user
-	user, err := c.userService.GetUserByID(id)
+	user, err := c.userService.UpdateUser(ctx.Request().Context(), updateCommand)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
-	}
-	if user == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		return err
 	}
 
+	setETag(ctx, user.Version)
 	return ctx.JSON(http.StatusOK, map[string]string{
 		"id":       user.ID,
 		"username": user.Username,
@@ -287,47 +259,54 @@ func (c *UserController) UpdateUser(ctx echo.Context) error {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "User ID"
 // @Param role body object{role=string} true "User role"
+// @Param If-Match header string false "Only update the user if it still has this ETag"
 // @Success 200 {object} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the updated user"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users/{id}/role [put]
 func (c *UserController) UpdateUserRole(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+		return echo.NewHTTPError(http.StatusBadRequest, "User ID is required")
 	}
 
 	var req struct {
-		Role string `json:"role"`
+		Role string `json:"role" validate:"required,max=50"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
-	if req.Role == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Role is required"})
+	if err := c.checkUserManagement(ctx, id); err != nil {
+		return err
 	}
 
-	err := c.userService.UpdateUserRole(id, entities.UserRole(req.Role))
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := c.checkRoleAssignment(ctx, entities.UserRole(req.Role)); err != nil {
+		return err
 	}
 
-	// Get updated user
-	user, err := c.userService.GetUserByID(id)
+	user, err := c.userService.UpdateUserRole(ctx.Request().Context(), &command.UpdateUserRoleCommand{
+		Id:              id,
+		Role:            entities.UserRole(req.Role),
+		ExpectedVersion: ifMatchVersion(ctx),
+	})
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
-	}
-	if user == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		return err
 	}
 
+	setETag(ctx, user.Version)
 	return ctx.JSON(http.StatusOK, map[string]string{
 		"id":       user.ID,
 		"username": user.Username,
@@ -338,57 +317,64 @@ func (c *UserController) UpdateUserRole(ctx echo.Context) error {
 }
 
 // UpdateUserStatus @Summary Update user status
-// @Description Update a user's status
+// @Description Update a user's status, e.g. lock an account or unlock it by setting it active.
+// @Description The reason is recorded with the status change.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "User ID"
-// @Param status body object{status=string} true "User status"
-// @Success 200 {object} object{id=string,username=string,email=string,role=string,status=string}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param status body object{status=string,reason=string} true "User status and reason for the change"
+// @Param If-Match header string false "Only update the user if it still has this ETag"
+// @Success 200 {object} object{id=string,username=string,email=string,role=string,status=string,status_reason=string}
+// @Header 200 {string} ETag "Version of the updated user"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users/{id}/status [put]
 func (c *UserController) UpdateUserStatus(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+		return echo.NewHTTPError(http.StatusBadRequest, "User ID is required")
 	}
 
 	var req struct {
-		Status string `json:"status"`
+		Status string `json:"status" validate:"required,oneof=active inactive locked"`
+		Reason string `json:"reason" validate:"max=500"`
 	}
 
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
-
-	if req.Status == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Status is required"})
+	if err := request.Validate(&req); err != nil {
+		return err
 	}
 
-	err := c.userService.UpdateUserStatus(id, entities.UserStatus(req.Status))
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := c.checkUserManagement(ctx, id); err != nil {
+		return err
 	}
 
-	// Get updated user
-	user, err := c.userService.GetUserByID(id)
+	user, err := c.userService.UpdateUserStatus(ctx.Request().Context(), &command.UpdateUserStatusCommand{
+		Id:              id,
+		Status:          entities.UserStatus(req.Status),
+		Reason:          req.Reason,
+		ExpectedVersion: ifMatchVersion(ctx),
+	})
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
-	}
-	if user == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		return err
 	}
 
+	setETag(ctx, user.Version)
 	return ctx.JSON(http.StatusOK, map[string]string{
-		"id":       user.ID,
-		"username": user.Username,
-		"email":    user.Email,
-		"role":     string(user.Role),
-		"status":   string(user.Status),
+		"id":            user.ID,
+		"username":      user.Username,
+		"email":         user.Email,
+		"role":          string(user.Role),
+		"status":        string(user.Status),
+		"status_reason": user.StatusReason,
 	})
 }
 
@@ -397,23 +383,80 @@ func (c *UserController) UpdateUserStatus(ctx echo.Context) error {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path string true "User ID"
+// @Param If-Match header string false "Only delete the user if it still has this ETag"
 // @Success 204 "No Content"
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 412 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /users/{id} [delete]
 func (c *UserController) DeleteUser(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+		return echo.NewHTTPError(http.StatusBadRequest, "User ID is required")
 	}
 
-	err := c.userService.DeleteUser(id)
+	if err := c.checkUserManagement(ctx, id); err != nil {
+		return err
+	}
+
+	err := c.userService.DeleteUser(ctx.Request().Context(), id, ifMatchVersion(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
+		return err
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// checkUserManagement verifies that the current user holds every permission of the user to be changed,
+// so that e.g. support staff cannot reset the password of an admin and take over the account
+func (c *UserController) checkUserManagement(ctx echo.Context, id string) error {
+	user, err := c.userService.GetUserByID(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	granted, err := c.roleService.Includes(ctx.Request().Context(), middleware.CurrentUser(ctx).Role, user.Role)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return entities.NewError(entities.ErrForbidden, "cannot change a user with permissions you lack")
+	}
+	return nil
+}
+
+// checkRoleAssignment verifies that the current user may assign roles, that the role exists
+// and that it grants no permissions the current user lacks
+func (c *UserController) checkRoleAssignment(ctx echo.Context, role entities.UserRole) error {
+	granted, err := c.roleService.HasPermission(ctx.Request().Context(), middleware.CurrentUser(ctx).Role, entities.PermissionRoleManage)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return entities.NewError(entities.ErrForbidden, "missing permission "+string(entities.PermissionRoleManage))
+	}
+
+	if _, err := c.roleService.GetRole(ctx.Request().Context(), role); err != nil {
+		if errors.Is(err, services.ErrRoleNotFound) {
+			return entities.NewValidationError("role", entities.ValidationCodeNotAllowed, "does not exist")
+		}
+		return err
+	}
+
+	granted, err = c.roleService.Includes(ctx.Request().Context(), middleware.CurrentUser(ctx).Role, role)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return entities.NewError(entities.ErrForbidden, "cannot assign a role with permissions you lack")
+	}
+
+	return nil
+}
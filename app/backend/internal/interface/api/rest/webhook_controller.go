@@ -0,0 +1,241 @@
+package rest
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/mapper"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
+	"net/http"
+)
+
+// WebhookController handles webhook subscription endpoints
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookController creates a new WebhookController and registers routes
+func NewWebhookController(e *echo.Echo, webhookService *services.WebhookService, authMiddleware *middleware.Auth) {
+	controller := &WebhookController{
+		webhookService: webhookService,
+	}
+
+	// Protected routes (require authentication and the webhook:manage permission)
+	webhooks := e.Group(
+		"/api/v1/webhooks",
+		authMiddleware.Authenticated(),
+		authMiddleware.RequirePermission(entities.PermissionWebhookManage),
+	)
+	webhooks.GET("", controller.ListSubscriptions)
+	webhooks.GET("/event-types", controller.ListEventTypes)
+	webhooks.GET("/:id", controller.GetSubscription)
+	webhooks.POST("", controller.CreateSubscription)
+	webhooks.DELETE("/:id", controller.DeleteSubscription)
+	webhooks.GET("/:id/deliveries", controller.ListDeliveries)
+	webhooks.POST("/:id/deliveries/:deliveryId/replay", controller.ReplayDelivery)
+}
+
+// ListSubscriptions @Summary List webhook subscriptions
+// @Description List all registered webhook endpoints
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} response.WebhookSubscriptionResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks [get]
+func (c *WebhookController) ListSubscriptions(ctx echo.Context) error {
+	subscriptions, err := c.webhookService.GetSubscriptions(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapper.ToWebhookSubscriptionListResponse(subscriptions))
+}
+
+// ListEventTypes @Summary List webhook event types
+// @Description List the event types webhook endpoints can subscribe to
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} string
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Router /webhooks/event-types [get]
+func (c *WebhookController) ListEventTypes(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, entities.WebhookEventTypes())
+}
+
+// GetSubscription @Summary Get webhook subscription
+// @Description Get a webhook subscription by ID
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} response.WebhookSubscriptionResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks/{id} [get]
+func (c *WebhookController) GetSubscription(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	subscription, err := c.webhookService.GetSubscription(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapper.ToWebhookSubscriptionResponse(subscription))
+}
+
+// CreateSubscription @Summary Create webhook subscription
+// @Description Register an endpoint for the given event types. The response contains the secret which signs
+// @Description the deliveries, it is only shown once. Deliveries are POSTed as JSON with the headers
+// @Description X-Webhook-Id, X-Webhook-Event, X-Webhook-Timestamp and X-Webhook-Signature, the signature is
+// @Description "sha256=" followed by the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param subscription body request.CreateWebhookSubscriptionRequest true "Subscription details"
+// @Success 201 {object} response.CreateWebhookSubscriptionResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks [post]
+func (c *WebhookController) CreateSubscription(ctx echo.Context) error {
+	var req request.CreateWebhookSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(&req); err != nil {
+		return err
+	}
+
+	subscription, err := c.webhookService.CreateSubscription(ctx.Request().Context(), req.URL, req.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapper.ToCreateWebhookSubscriptionResponse(subscription))
+}
+
+// DeleteSubscription @Summary Delete webhook subscription
+// @Description Delete a webhook subscription together with its delivery log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Subscription ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks/{id} [delete]
+func (c *WebhookController) DeleteSubscription(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	err = c.webhookService.DeleteSubscription(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries @Summary List webhook deliveries
+// @Description List the delivery log of a webhook subscription, newest first
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Subscription ID"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param offset query int false "Number of deliveries to skip"
+// @Success 200 {object} response.ListWebhookDeliveriesResponse
+// @Header 200 {string} Link "Links to the first, previous and next page"
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid subscription ID format")
+	}
+
+	var req request.ListWebhookDeliveriesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse query parameters")
+	}
+	page, err := req.ToPageRequest()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	page = page.WithDefaults()
+
+	deliveries, err := c.webhookService.GetDeliveries(ctx.Request().Context(), id, page)
+	if err != nil {
+		return err
+	}
+
+	pageResult := common.PageResult{Limit: page.Limit, Offset: page.Offset, TotalCount: deliveries.TotalCount}
+	response := mapper.ToWebhookDeliveryListResponse(deliveries.Deliveries)
+	response.PageResponse = mapper.ToPageResponse(pageResult)
+	setPaginationLinks(ctx, pageResult)
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// ReplayDelivery @Summary Replay webhook delivery
+// @Description Send the event of a delivery again. The replay is a new delivery with the same X-Webhook-Id.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Subscription ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} response.WebhookDeliveryResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 403 {object} response.ProblemResponse
+// @Failure 404 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /webhooks/{id}/deliveries/{deliveryId}/replay [post]
+func (c *WebhookController) ReplayDelivery(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid subscription ID format")
+	}
+	deliveryId, err := uuid.Parse(ctx.Param("deliveryId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid delivery ID format")
+	}
+
+	replay, err := c.webhookService.ReplayDelivery(ctx.Request().Context(), id, deliveryId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusAccepted, mapper.ToWebhookDeliveryResponse(replay))
+}
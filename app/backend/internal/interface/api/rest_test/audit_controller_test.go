@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// MockAuditRepository returns its entries for every filter and remembers the last query
+type MockAuditRepository struct {
+	entries []*entities.AuditEntry
+	filter  repositories.AuditFilter
+	page    repositories.PageRequest
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, entry *entities.AuditEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *MockAuditRepository) FindPage(ctx context.Context, filter repositories.AuditFilter, page repositories.PageRequest) (*repositories.AuditPage, error) {
+	m.filter, m.page = filter, page
+	return &repositories.AuditPage{Entries: m.entries, TotalCount: int64(len(m.entries))}, nil
+}
+
+func TestListAuditEntries(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	repo := &MockAuditRepository{}
+	repo.entries = append(repo.entries, entities.NewAuditEntry("owner-id", entities.AuditActionUpdate, entities.AuditAggregateSeller, "seller-id",
+		entities.AuditSnapshot{"name": "Acme"}, entities.AuditSnapshot{"name": "Acme Corp"}, "request-id"))
+	rest.NewAuditController(e, services.NewAuditService(repo), authMiddleware)
+	url := "/api/v1/audit?aggregate_type=seller&actor_id=owner-id&from=2024-05-01&to=2024-05-31&limit=10"
+
+	// Act & Assert: the audit trail is reserved to users with the audit:read permission
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	authorizeRequest(t, tokenManager, req, "owner-id")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, url, nil)
+	authorizeRequest(t, tokenManager, req, testAdminId)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body response.ListAuditEntriesResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.TotalCount)
+	if assert.Len(t, body.Entries, 1) {
+		assert.Equal(t, "update", body.Entries[0].Action)
+		assert.Equal(t, "request-id", body.Entries[0].RequestId)
+		assert.Equal(t, []response.AuditChangeResponse{{Field: "name", Before: "Acme", After: "Acme Corp"}}, body.Entries[0].Changes)
+	}
+	assert.Equal(t, entities.AuditAggregateSeller, repo.filter.AggregateType)
+	assert.Equal(t, "owner-id", repo.filter.ActorId)
+	if assert.NotNil(t, repo.filter.OccurredBefore) {
+		assert.Equal(t, "2024-06-01", repo.filter.OccurredBefore.Format("2006-01-02"), "The end date includes the whole day")
+	}
+	assert.Equal(t, 10, repo.page.Limit)
+}
+
+func TestListAuditEntriesRejectsInvalidParameters(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewAuditController(e, services.NewAuditService(&MockAuditRepository{}), authMiddleware)
+
+	for _, query := range []string{"from=yesterday", "sort=-occurred_at", "cursor=abc", "limit=1000"} {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+query, nil)
+		authorizeRequest(t, tokenManager, req, testAdminId)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
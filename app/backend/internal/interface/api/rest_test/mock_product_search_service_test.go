@@ -0,0 +1,19 @@
+package rest_test
+
+import (
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductSearchService struct {
+	mock.Mock
+}
+
+func (m *MockProductSearchService) SearchProducts(ctx context.Context, searchQuery *query.SearchProductsQuery) (*query.ProductSearchQueryResult, error) {
+	args := m.Called(searchQuery)
+	if result, ok := args.Get(0).(*query.ProductSearchQueryResult); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
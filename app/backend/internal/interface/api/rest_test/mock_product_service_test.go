@@ -1,12 +1,20 @@
 package rest_test
 
 import (
+	"context"
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/mapper"
 	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
 	"github.com/stretchr/testify/mock"
+	"testing"
 	"time"
 )
 
@@ -14,7 +22,7 @@ type MockProductService struct {
 	mock.Mock
 }
 
-func (m *MockProductService) CreateProduct(productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error) {
+func (m *MockProductService) CreateProduct(ctx context.Context, productCommand *command.CreateProductCommand) (*command.CreateProductCommandResult, error) {
 	args := m.Called(productCommand)
 
 	var now = time.Now()
@@ -48,8 +56,12 @@ func (m *MockProductService) CreateProduct(productCommand *command.CreateProduct
 	return &result, args.Error(1)
 }
 
-func (m *MockProductService) FindAllProducts() (*query.ProductQueryListResult, error) {
-	args := m.Called()
+func (m *MockProductService) FindAllProducts(ctx context.Context, listQuery *query.ListProductsQuery) (*query.ProductQueryListResult, error) {
+	args := m.Called(listQuery)
+
+	if productQueryListResult, ok := args.Get(0).(*query.ProductQueryListResult); ok {
+		return productQueryListResult, args.Error(1)
+	}
 
 	productQueryListResult := &query.ProductQueryListResult{}
 
@@ -60,8 +72,8 @@ func (m *MockProductService) FindAllProducts() (*query.ProductQueryListResult, e
 	return productQueryListResult, args.Error(1)
 }
 
-func (m *MockProductService) FindProductById(id uuid.UUID) (*query.ProductQueryResult, error) {
-	args := m.Called(id)
+func (m *MockProductService) FindProductById(ctx context.Context, id uuid.UUID, displayCurrency entities.Currency) (*query.ProductQueryResult, error) {
+	args := m.Called(id, displayCurrency)
 
 	productQueryResult := &query.ProductQueryResult{
 		Result: mapper.NewProductResultFromEntity(args.Get(0).(*entities.Product)),
@@ -69,3 +81,98 @@ func (m *MockProductService) FindProductById(id uuid.UUID) (*query.ProductQueryR
 
 	return productQueryResult, args.Error(1)
 }
+
+func (m *MockProductService) UpdateProduct(ctx context.Context, updateCommand *command.UpdateProductCommand) (*command.UpdateProductCommandResult, error) {
+	args := m.Called(updateCommand)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return &command.UpdateProductCommandResult{
+		Result: mapper.NewProductResultFromEntity(args.Get(0).(*entities.Product)),
+	}, args.Error(1)
+}
+
+func (m *MockProductService) DeleteProduct(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error {
+	args := m.Called(id, expectedVersion, actor)
+	return args.Error(0)
+}
+
+func (m *MockProductService) RestoreProduct(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreProductCommandResult, error) {
+	args := m.Called(id, actor)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return &command.RestoreProductCommandResult{
+		Result: mapper.NewProductResultFromEntity(args.Get(0).(*entities.Product)),
+	}, args.Error(1)
+}
+
+// testAdminId is the user whose tokens MockAccessTokenAuthorizer authorizes as tokens of an admin
+const testAdminId = "admin-id"
+
+// MockAccessTokenAuthorizer authorizes every token as a token of a seller owner, except the tokens of testAdminId
+type MockAccessTokenAuthorizer struct{}
+
+func (m *MockAccessTokenAuthorizer) AuthorizeAccessToken(ctx context.Context, userID, _ string, _ time.Time) (*entities.User, error) {
+	user, err := entities.NewUser(userID, "testuser", "test@example.com", "hashed-password")
+	if err != nil {
+		return nil, err
+	}
+	user.Role = entities.RoleSellerOwner
+	if userID == testAdminId {
+		user.Role = entities.RoleAdmin
+	}
+	return user, nil
+}
+
+// MockPermissionChecker grants the permissions of the default roles
+type MockPermissionChecker struct{}
+
+func (m *MockPermissionChecker) HasPermission(ctx context.Context, name entities.UserRole, permission entities.Permission) (bool, error) {
+	for _, role := range entities.DefaultRoles() {
+		if role.Name == name {
+			return role.HasPermission(permission), nil
+		}
+	}
+	return false, nil
+}
+
+// newTestAuthMiddleware returns an auth middleware accepting tokens of the returned token manager
+func newTestAuthMiddleware(t *testing.T) (*middleware.Auth, *auth.TokenManager) {
+	tokenManager, err := auth.NewTokenManager(config.NewJWTConfig())
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %s", err)
+	}
+	return middleware.NewAuth(tokenManager, &MockAccessTokenAuthorizer{}, &MockPermissionChecker{}), tokenManager
+}
+
+// MockIdempotencyStore reserves every idempotency key and records nothing
+type MockIdempotencyStore struct{}
+
+func (m *MockIdempotencyStore) Begin(ctx context.Context, key, userId, route, requestHash string) (*entities.IdempotencyRecord, error) {
+	return entities.NewIdempotencyRecord(key, userId, route, requestHash, time.Now(), time.Hour)
+}
+
+func (m *MockIdempotencyStore) Complete(ctx context.Context, record *entities.IdempotencyRecord, status int, headers map[string]string, body []byte) error {
+	return nil
+}
+
+func (m *MockIdempotencyStore) Abort(ctx context.Context, record *entities.IdempotencyRecord) error {
+	return nil
+}
+
+// newTestIdempotency returns idempotency middleware which handles every request
+func newTestIdempotency() *middleware.Idempotency {
+	return middleware.NewIdempotency(&MockIdempotencyStore{}, config.NewRequestConfig())
+}
+
+// newEcho returns an Echo instance rendering handler errors like the server
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = rest.HTTPErrorHandler
+	return e
+}
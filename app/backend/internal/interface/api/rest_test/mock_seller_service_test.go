@@ -1,26 +1,52 @@
 package rest
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/application/interfaces"
 	"github.com/sklinkert/go-ddd/internal/application/mapper"
 	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"github.com/sklinkert/go-ddd/internal/interface/api/middleware"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
+	"net/http"
+	"testing"
+	"time"
 )
 
 type MockSellerService struct {
 	sellers map[uuid.UUID]*entities.ValidatedSeller
+	deleted map[uuid.UUID]*entities.ValidatedSeller
+	members map[uuid.UUID]map[string]entities.SellerMemberRole
 }
 
 func NewMockSellerService() interfaces.SellerService {
 	return &MockSellerService{
 		sellers: make(map[uuid.UUID]*entities.ValidatedSeller),
+		deleted: make(map[uuid.UUID]*entities.ValidatedSeller),
+		members: make(map[uuid.UUID]map[string]entities.SellerMemberRole),
 	}
 }
 
-func (m *MockSellerService) CreateSeller(seller *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error) {
+func (m *MockSellerService) authorize(sellerId uuid.UUID, actor common.Actor, requireOwner bool) error {
+	if actor.ManagesAllSellers {
+		return nil
+	}
+	role, exists := m.members[sellerId][actor.UserId]
+	if !exists || (requireOwner && role != entities.SellerMemberRoleOwner) {
+		return services.ErrNotSellerMember
+	}
+	return nil
+}
+
+func (m *MockSellerService) CreateSeller(ctx context.Context, seller *command.CreateSellerCommand) (*command.CreateSellerCommandResult, error) {
 	var result command.CreateSellerCommandResult
 
 	newSeller := entities.NewSeller(seller.Name)
@@ -29,15 +55,20 @@ func (m *MockSellerService) CreateSeller(seller *command.CreateSellerCommand) (*
 	if err != nil {
 		return nil, err
 	}
+	validatedSeller.Version = 1
 
 	m.sellers[validatedSeller.Id] = validatedSeller
+	m.members[validatedSeller.Id] = map[string]entities.SellerMemberRole{}
+	if seller.Actor.UserId != "" {
+		m.members[validatedSeller.Id][seller.Actor.UserId] = entities.SellerMemberRoleOwner
+	}
 
 	result.Result = mapper.NewSellerResultFromEntity(&validatedSeller.Seller)
 
 	return &result, nil
 }
 
-func (m *MockSellerService) FindAllSellers() (*query.SellerQueryListResult, error) {
+func (m *MockSellerService) FindAllSellers(ctx context.Context, listQuery *query.ListSellersQuery) (*query.SellerQueryListResult, error) {
 	var allSellers query.SellerQueryListResult
 	for _, v := range m.sellers {
 		allSellers.Result = append(allSellers.Result, mapper.NewSellerResultFromEntity(&v.Seller))
@@ -45,7 +76,17 @@ func (m *MockSellerService) FindAllSellers() (*query.SellerQueryListResult, erro
 	return &allSellers, nil
 }
 
-func (m *MockSellerService) FindSellerById(id uuid.UUID) (*query.SellerQueryResult, error) {
+func (m *MockSellerService) FindSellersByMember(ctx context.Context, userId string) (*query.SellerQueryListResult, error) {
+	var memberSellers query.SellerQueryListResult
+	for id, members := range m.members {
+		if _, exists := members[userId]; exists && m.sellers[id] != nil {
+			memberSellers.Result = append(memberSellers.Result, mapper.NewSellerResultFromEntity(&m.sellers[id].Seller))
+		}
+	}
+	return &memberSellers, nil
+}
+
+func (m *MockSellerService) FindSellerById(ctx context.Context, id uuid.UUID) (*query.SellerQueryResult, error) {
 	if seller, exists := m.sellers[id]; exists {
 		return &query.SellerQueryResult{
 			Result: mapper.NewSellerResultFromEntity(&seller.Seller),
@@ -54,9 +95,16 @@ func (m *MockSellerService) FindSellerById(id uuid.UUID) (*query.SellerQueryResu
 	return nil, errors.New("seller not found")
 }
 
-func (m *MockSellerService) UpdateSeller(updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error) {
+func (m *MockSellerService) UpdateSeller(ctx context.Context, updateCommand *command.UpdateSellerCommand) (*command.UpdateSellerCommandResult, error) {
 	if _, exists := m.sellers[updateCommand.Id]; exists {
+		if err := m.authorize(updateCommand.Id, updateCommand.Actor, false); err != nil {
+			return nil, err
+		}
+		if updateCommand.ExpectedVersion != nil && *updateCommand.ExpectedVersion != m.sellers[updateCommand.Id].Version {
+			return nil, services.ErrVersionConflict
+		}
 		m.sellers[updateCommand.Id].Name = updateCommand.Name
+		m.sellers[updateCommand.Id].Version++
 		return &command.UpdateSellerCommandResult{
 			Result: mapper.NewSellerResultFromEntity(&m.sellers[updateCommand.Id].Seller),
 		}, nil
@@ -64,10 +112,156 @@ func (m *MockSellerService) UpdateSeller(updateCommand *command.UpdateSellerComm
 	return nil, errors.New("seller not found")
 }
 
-func (m *MockSellerService) DeleteSeller(id uuid.UUID) error {
-	if _, exists := m.sellers[id]; exists {
+func (m *MockSellerService) DeleteSeller(ctx context.Context, id uuid.UUID, expectedVersion *int, actor common.Actor) error {
+	if seller, exists := m.sellers[id]; exists {
+		if err := m.authorize(id, actor, true); err != nil {
+			return err
+		}
+		if expectedVersion != nil && *expectedVersion != seller.Version {
+			return services.ErrVersionConflict
+		}
+		now := time.Now()
+		seller.DeletedAt = &now
+		m.deleted[id] = seller
 		delete(m.sellers, id)
 		return nil
 	}
 	return errors.New("seller not found")
 }
+
+func (m *MockSellerService) RestoreSeller(ctx context.Context, id uuid.UUID, actor common.Actor) (*command.RestoreSellerCommandResult, error) {
+	seller, exists := m.sellers[id]
+	if !exists {
+		if seller, exists = m.deleted[id]; !exists {
+			return nil, services.ErrSellerNotFound
+		}
+	}
+	if err := m.authorize(id, actor, true); err != nil {
+		return nil, err
+	}
+	seller.DeletedAt = nil
+	m.sellers[id] = seller
+	delete(m.deleted, id)
+	return &command.RestoreSellerCommandResult{
+		Result: mapper.NewSellerResultFromEntity(&seller.Seller),
+	}, nil
+}
+
+func (m *MockSellerService) AddSellerMember(ctx context.Context, memberCommand *command.AddSellerMemberCommand) error {
+	if _, exists := m.sellers[memberCommand.SellerId]; !exists {
+		return errors.New("seller not found")
+	}
+	if err := m.authorize(memberCommand.SellerId, memberCommand.Actor, true); err != nil {
+		return err
+	}
+	m.members[memberCommand.SellerId][memberCommand.UserId] = memberCommand.Role
+	return nil
+}
+
+func (m *MockSellerService) RemoveSellerMember(ctx context.Context, sellerId uuid.UUID, userId string, actor common.Actor) error {
+	if _, exists := m.sellers[sellerId]; !exists {
+		return errors.New("seller not found")
+	}
+	if err := m.authorize(sellerId, actor, true); err != nil {
+		return err
+	}
+	delete(m.members[sellerId], userId)
+	return nil
+}
+
+// testAdminId is the user whose tokens MockAccessTokenAuthorizer authorizes as tokens of an admin
+const testAdminId = "admin-id"
+
+// testSupportId is the user whose tokens MockAccessTokenAuthorizer authorizes as tokens of support staff
+const testSupportId = "support-id"
+
+// testRoleManagerId is the user whose tokens MockAccessTokenAuthorizer authorizes as tokens of a testRoleManager
+const testRoleManagerId = "role-manager-id"
+
+// testRoleManager may manage users and assign roles, but lacks most other permissions
+const testRoleManager entities.UserRole = "role_manager"
+
+// testRoles returns the default roles and testRoleManager
+func testRoles() []*entities.Role {
+	roleManager, _ := entities.NewRole(testRoleManager, "Assigns roles to users",
+		[]entities.Permission{entities.PermissionUserRead, entities.PermissionUserWrite, entities.PermissionRoleManage})
+	return append(entities.DefaultRoles(), roleManager)
+}
+
+// MockAccessTokenAuthorizer authorizes every token as a token of a seller owner, except the tokens of testAdminId,
+// testSupportId and testRoleManagerId
+type MockAccessTokenAuthorizer struct{}
+
+func (m *MockAccessTokenAuthorizer) AuthorizeAccessToken(ctx context.Context, userID, _ string, _ time.Time) (*entities.User, error) {
+	user, err := entities.NewUser(userID, "testuser", "test@example.com", "hashed-password")
+	if err != nil {
+		return nil, err
+	}
+	user.Role = entities.RoleSellerOwner
+	switch userID {
+	case testAdminId:
+		user.Role = entities.RoleAdmin
+	case testSupportId:
+		user.Role = entities.RoleSupport
+	case testRoleManagerId:
+		user.Role = testRoleManager
+	}
+	return user, nil
+}
+
+// MockPermissionChecker grants the permissions of the testRoles
+type MockPermissionChecker struct{}
+
+func (m *MockPermissionChecker) HasPermission(ctx context.Context, name entities.UserRole, permission entities.Permission) (bool, error) {
+	for _, role := range testRoles() {
+		if role.Name == name {
+			return role.HasPermission(permission), nil
+		}
+	}
+	return false, nil
+}
+
+// newTestAuthMiddleware returns an auth middleware accepting tokens of the returned token manager
+func newTestAuthMiddleware(t *testing.T) (*middleware.Auth, *auth.TokenManager) {
+	tokenManager, err := auth.NewTokenManager(config.NewJWTConfig())
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %s", err)
+	}
+	return middleware.NewAuth(tokenManager, &MockAccessTokenAuthorizer{}, &MockPermissionChecker{}), tokenManager
+}
+
+// MockIdempotencyStore reserves every idempotency key and records nothing
+type MockIdempotencyStore struct{}
+
+func (m *MockIdempotencyStore) Begin(ctx context.Context, key, userId, route, requestHash string) (*entities.IdempotencyRecord, error) {
+	return entities.NewIdempotencyRecord(key, userId, route, requestHash, time.Now(), time.Hour)
+}
+
+func (m *MockIdempotencyStore) Complete(ctx context.Context, record *entities.IdempotencyRecord, status int, headers map[string]string, body []byte) error {
+	return nil
+}
+
+func (m *MockIdempotencyStore) Abort(ctx context.Context, record *entities.IdempotencyRecord) error {
+	return nil
+}
+
+// newTestIdempotency returns idempotency middleware which handles every request
+func newTestIdempotency() *middleware.Idempotency {
+	return middleware.NewIdempotency(&MockIdempotencyStore{}, config.NewRequestConfig())
+}
+
+// newEcho returns an Echo instance rendering handler errors like the server
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = rest.HTTPErrorHandler
+	return e
+}
+
+// authorizeRequest sets the access token of the given user on the request
+func authorizeRequest(t *testing.T, tokenManager *auth.TokenManager, req *http.Request, userID string) {
+	token, err := tokenManager.GenerateToken(userID, "test@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %s", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
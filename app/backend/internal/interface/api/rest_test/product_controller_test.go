@@ -6,12 +6,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/sklinkert/go-ddd/internal/application/command"
 	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/application/services"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
@@ -20,21 +24,26 @@ import (
 
 func TestCreateProduct(t *testing.T) {
 	// Setup
-	e := echo.New()
+	e := newEcho()
 	mockService := new(MockProductService)
-	reqBody := map[string]interface{}{"Name": "TestProduct", "Price": 9.99, "SellerId": "123e4567-e89b-12d3-a456-426614174000"}
+	reqBody := map[string]interface{}{
+		"Name":     "TestProduct",
+		"Price":    map[string]interface{}{"Amount": "9.99", "Currency": "USD"},
+		"SellerId": "123e4567-e89b-12d3-a456-426614174000",
+	}
 	reqBodyBytes, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/products", bytes.NewReader(reqBodyBytes))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	ctrl := rest.NewProductController(e, mockService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	ctrl := rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
 
 	createProductCommandResult := &command.CreateProductCommandResult{
 		Result: &common.ProductResult{
 			Id:    uuid.New(),
 			Name:  "TestProduct",
-			Price: 9.99,
+			Price: entities.Money{Amount: 999, Currency: entities.CurrencyUSD},
 		},
 	}
 	mockService.On("CreateProduct", mock.Anything).Return(createProductCommandResult, nil)
@@ -57,6 +66,7 @@ func TestCreateProduct(t *testing.T) {
 	delete(reqBody, "SellerId")
 	delete(responseBody, "CreatedAt")
 	delete(responseBody, "UpdatedAt")
+	delete(responseBody, "Version")
 
 	// Assertions
 	assert.Equal(t, http.StatusCreated, rec.Code)
@@ -64,20 +74,78 @@ func TestCreateProduct(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateProductRejectsInexactPrice(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	ctrl := rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	reqBody := `{"Name": "TestProduct", "Price": {"Amount": "9.999", "Currency": "USD"}, "SellerId": "123e4567-e89b-12d3-a456-426614174000"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products", bytes.NewReader([]byte(reqBody)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	// Execute
+	err := ctrl.CreateProductController(c)
+
+	// Assertions
+	if assert.Error(t, err) {
+		e.HTTPErrorHandler(err, c)
+	}
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, rest.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+	mockService.AssertNotCalled(t, "CreateProduct", mock.Anything)
+}
+
+func TestCreateProductListsInvalidFields(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	ctrl := rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	reqBody := `{"Name": "", "Price": {"Amount": "0", "Currency": "USD"}, "SellerId": "123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products", bytes.NewReader([]byte(reqBody)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// Execute
+	err := ctrl.CreateProductController(c)
+
+	// Assertions
+	if assert.Error(t, err) {
+		e.HTTPErrorHandler(err, c)
+	}
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem response.ProblemResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "Name", Code: "required", Message: "is required"},
+		{Field: "Price", Code: "too_small", Message: "must be greater than 0"},
+		{Field: "SellerId", Code: "invalid", Message: "must be a UUID"},
+	}, problem.Errors)
+	mockService.AssertNotCalled(t, "CreateProduct", mock.Anything)
+}
+
 func TestGetAllProducts(t *testing.T) {
 	// Setup
-	e := echo.New()
+	e := newEcho()
 	mockService := new(MockProductService) // Assuming you have a mock of ProductService
 
 	expectedProducts := []*entities.Product{
 		{
 			Id:    uuid.New(),
 			Name:  "TestProduct1",
-			Price: 9.99,
+			Price: entities.Money{Amount: 999, Currency: entities.CurrencyUSD},
 		}, {
 			Id:    uuid.New(),
 			Name:  "TestProduct2",
-			Price: 14.99,
+			Price: entities.Money{Amount: 1499, Currency: entities.CurrencyUSD},
 		},
 	}
 
@@ -85,8 +153,9 @@ func TestGetAllProducts(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	ctrl := rest.NewProductController(e, mockService)
-	mockService.On("FindAllProducts").Return(expectedProducts, nil)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	ctrl := rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+	mockService.On("FindAllProducts", mock.Anything).Return(expectedProducts, nil)
 
 	var expectedListResponse response.ListProductsResponse
 	for _, product := range expectedProducts {
@@ -109,3 +178,315 @@ func TestGetAllProducts(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAllProductsInDisplayCurrency(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	inCurrency := func(currency entities.Currency) interface{} {
+		return mock.MatchedBy(func(listQuery *query.ListProductsQuery) bool { return listQuery.DisplayCurrency == currency })
+	}
+	mockService.On("FindAllProducts", inCurrency(entities.CurrencyEUR)).Return([]*entities.Product{}, nil)
+	mockService.On("FindAllProducts", inCurrency(entities.CurrencyJPY)).Return([]*entities.Product{}, services.ErrExchangeRateNotFound)
+
+	testCases := []struct {
+		query  string
+		status int
+	}{
+		{"?currency=eur", http.StatusOK},
+		{"?currency=JPY", http.StatusBadRequest},
+		{"?currency=XXX", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products"+tc.query, nil))
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestGetProductETagDependsOnRepresentation(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	product := &entities.Product{Id: uuid.New(), Name: "Shoe", Price: entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, Version: 3}
+	mockService.On("FindProductById", product.Id, entities.Currency("")).Return(product, nil)
+	mockService.On("FindProductById", product.Id, entities.CurrencyEUR).Return(product, nil)
+
+	testCases := []struct {
+		query string
+		etag  string
+	}{
+		{"", `"3"`},
+		// Converted prices change with the exchange rates, so their tag is weak and names the currency
+		{"?currency=EUR", `W/"3-EUR"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/"+product.Id.String()+tc.query, nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.etag, rec.Header().Get("ETag"))
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestGetAllProductsPaginated(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	sellerId := uuid.New()
+	expectedQuery := mock.MatchedBy(func(listQuery *query.ListProductsQuery) bool {
+		return listQuery.Page == repositories.PageRequest{Limit: 2, Offset: 2} &&
+			listQuery.Sort == repositories.SortOrder{Field: repositories.SortByPrice, Descending: true} &&
+			listQuery.Filter.NameContains == "shoe" &&
+			*listQuery.Filter.SellerId == sellerId &&
+			*listQuery.Filter.MinPrice == entities.Money{Amount: 500, Currency: entities.CurrencyEUR} &&
+			listQuery.Filter.CreatedBefore.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	})
+	mockService.On("FindAllProducts", expectedQuery).Return(&query.ProductQueryListResult{
+		Result: []*common.ProductResult{{Id: uuid.New(), Name: "Red shoe", Price: entities.Money{Amount: 990, Currency: entities.CurrencyEUR}}},
+		Page:   common.PageResult{Limit: 2, Offset: 2, TotalCount: 7, NextCursor: "next"},
+	}, nil)
+
+	// Execute
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products?limit=2&offset=2&sort=-price&name=shoe&seller_id="+
+		sellerId.String()+"&min_price=5&price_currency=EUR&created_to=2024-05-01", nil))
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+
+	var receivedListResponse response.ListProductsResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &receivedListResponse)) {
+		assert.Len(t, receivedListResponse.Products, 1)
+		assert.Equal(t, int64(7), receivedListResponse.TotalCount)
+		assert.Equal(t, "next", receivedListResponse.NextCursor)
+	}
+
+	link := rec.Header().Get("Link")
+	assert.Contains(t, link, `offset=0&price_currency=EUR&seller_id=`+sellerId.String()+`&sort=-price>; rel="prev"`)
+	assert.Contains(t, link, `offset=4&price_currency=EUR&seller_id=`+sellerId.String()+`&sort=-price>; rel="next"`)
+}
+
+func TestGetAllProductsRejectsInvalidListParameters(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+	mockService.On("FindAllProducts", mock.Anything).Return([]*entities.Product{}, services.ErrInvalidCursor)
+
+	for _, parameters := range []string{
+		"limit=101",
+		"limit=abc",
+		"offset=-1",
+		"offset=20&cursor=abc",
+		"sort=seller",
+		"seller_id=abc",
+		"min_price=1.999",
+		"created_from=yesterday",
+		"cursor=abc",
+	} {
+		t.Run(parameters, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products?"+parameters, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestPatchProduct(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	productId := uuid.New()
+	updatedProduct := &entities.Product{Id: productId, Name: "TestProduct", Price: entities.Money{Amount: 1999, Currency: entities.CurrencyUSD}}
+	mockService.On("UpdateProduct", mock.MatchedBy(func(updateCommand *command.UpdateProductCommand) bool {
+		return updateCommand.Id == productId &&
+			updateCommand.Name == nil &&
+			updateCommand.Price != nil && updateCommand.Price.Amount == 1999 &&
+			updateCommand.Actor.UserId == "user-id"
+	})).Return(updatedProduct, nil)
+
+	token, err := tokenManager.GenerateToken("user-id", "test@example.com")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/products/"+productId.String(), bytes.NewReader([]byte(`{"Price": {"Amount": "19.99", "Currency": "USD"}}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	// Execute
+	e.ServeHTTP(rec, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var receivedResponse response.ProductResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &receivedResponse)) {
+		assert.Equal(t, "19.99 USD", receivedResponse.Price.String())
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestPutProductMapsServiceErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+	}{
+		"not found":  {err: services.ErrProductNotFound, status: http.StatusNotFound},
+		"not member": {err: services.ErrNotSellerMember, status: http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			e := newEcho()
+			mockService := new(MockProductService)
+			authMiddleware, tokenManager := newTestAuthMiddleware(t)
+			rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+			mockService.On("UpdateProduct", mock.Anything).Return(nil, tc.err)
+
+			token, err := tokenManager.GenerateToken("user-id", "test@example.com")
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/products/"+uuid.New().String(), bytes.NewReader([]byte(`{"Name": "TestProduct", "Price": {"Amount": "9.99", "Currency": "USD"}}`)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			// Execute
+			e.ServeHTTP(rec, req)
+
+			// Assertions
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	productId := uuid.New()
+	mockService.On("DeleteProduct", productId, (*int)(nil), mock.Anything).Return(nil)
+
+	token, err := tokenManager.GenerateToken("user-id", "test@example.com")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/"+productId.String(), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	// Execute
+	e.ServeHTTP(rec, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	mockService.AssertExpectations(t)
+
+	// Anonymous deletes are rejected
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/products/"+productId.String(), nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRestoreProduct(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	product := &entities.Product{Id: uuid.New(), Name: "Restored", Price: entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, Version: 3}
+	mockService.On("RestoreProduct", product.Id, mock.Anything).Return(product, nil)
+
+	token, err := tokenManager.GenerateToken("user-id", "test@example.com")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products/"+product.Id.String()+"/restore", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	// Execute
+	e.ServeHTTP(rec, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	var restored response.ProductResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restored)) {
+		assert.Equal(t, product.Id.String(), restored.Id)
+		assert.Nil(t, restored.DeletedAt)
+	}
+	mockService.AssertExpectations(t)
+
+	// Anonymous restores are rejected
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products/"+product.Id.String()+"/restore", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestGetAllProductsIncludingDeleted(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewProductController(e, mockService, authMiddleware, newTestIdempotency())
+
+	deletedAt := time.Now()
+	includingDeleted := mock.MatchedBy(func(listQuery *query.ListProductsQuery) bool { return listQuery.Filter.IncludeDeleted })
+	mockService.On("FindAllProducts", includingDeleted).Return([]*entities.Product{
+		{Id: uuid.New(), Name: "Deleted", Price: entities.Money{Amount: 999, Currency: entities.CurrencyUSD}, DeletedAt: &deletedAt},
+	}, nil)
+
+	newRequest := func(userId string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?include_deleted=true", nil)
+		if userId != "" {
+			token, err := tokenManager.GenerateToken(userId, "test@example.com")
+			assert.NoError(t, err)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		return req
+	}
+
+	// Execute & Assert: anonymous users and users who do not manage all sellers are rejected
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest(""))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest("user-id"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "FindAllProducts", mock.Anything)
+
+	// Execute & Assert: admins see deleted products
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest(testAdminId))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var receivedListResponse response.ListProductsResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &receivedListResponse)) && assert.Len(t, receivedListResponse.Products, 1) {
+		assert.NotNil(t, receivedListResponse.Products[0].DeletedAt)
+	}
+	mockService.AssertExpectations(t)
+}
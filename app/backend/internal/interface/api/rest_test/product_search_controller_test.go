@@ -0,0 +1,94 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/sklinkert/go-ddd/internal/application/common"
+	"github.com/sklinkert/go-ddd/internal/application/query"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSearchProducts(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductSearchService)
+	rest.NewProductSearchController(e, mockService)
+
+	expectedQuery := mock.MatchedBy(func(searchQuery *query.SearchProductsQuery) bool {
+		return searchQuery.Text == "黒い スニーカー" && searchQuery.Page == repositories.PageRequest{Limit: 1}
+	})
+	mockService.On("SearchProducts", expectedQuery).Return(&query.ProductSearchQueryResult{
+		Result: []*common.ProductSearchResult{{
+			Product:   &common.ProductResult{Id: uuid.New(), Name: "黒いスニーカー", Price: entities.Money{Amount: 5000, Currency: entities.CurrencyJPY}},
+			Rank:      0.8,
+			Highlight: "<mark>黒い</mark><mark>スニーカー</mark>",
+		}},
+		Page: common.PageResult{Limit: 1, TotalCount: 3},
+	}, nil)
+
+	// Execute
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/search?limit=1&q="+url.QueryEscape("黒い スニーカー"), nil))
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+
+	var receivedResponse response.SearchProductsResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &receivedResponse)) {
+		assert.Len(t, receivedResponse.Results, 1)
+		assert.Equal(t, "黒いスニーカー", receivedResponse.Results[0].Product.Name)
+		assert.Equal(t, "<mark>黒い</mark><mark>スニーカー</mark>", receivedResponse.Results[0].Highlight)
+		assert.Equal(t, 0.8, receivedResponse.Results[0].Rank)
+		assert.Equal(t, int64(3), receivedResponse.TotalCount)
+	}
+	assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+}
+
+func TestSearchProductsRejectsInvalidParameters(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductSearchService)
+	rest.NewProductSearchController(e, mockService)
+	mockService.On("SearchProducts", mock.Anything).Return(nil, services.ErrEmptySearchQuery)
+
+	for _, parameters := range []string{
+		"",
+		"q=",
+		"q=shoes&limit=101",
+		"q=shoes&offset=-1",
+		"q=%3F%21",
+	} {
+		// Execute
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/search?"+parameters, nil))
+
+		// Assertions
+		assert.Equal(t, http.StatusBadRequest, rec.Code, parameters)
+	}
+}
+
+func TestSearchProductsUnavailable(t *testing.T) {
+	// Setup
+	e := newEcho()
+	mockService := new(MockProductSearchService)
+	rest.NewProductSearchController(e, mockService)
+	mockService.On("SearchProducts", mock.Anything).Return(nil, services.ErrProductSearchUnavailable)
+
+	// Execute
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/search?q=shoes", nil))
+
+	// Assertions
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -2,10 +2,12 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/sklinkert/go-ddd/internal/application/command"
+	"github.com/sklinkert/go-ddd/internal/application/common"
 	"github.com/sklinkert/go-ddd/internal/domain/entities"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
 	"github.com/sklinkert/go-ddd/internal/interface/api/rest/dto/request"
@@ -19,7 +21,8 @@ import (
 func TestCreateSeller(t *testing.T) {
 	// Arrange
 	mockService := NewMockSellerService()
-	controller := rest.NewSellerController(echo.New(), mockService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	controller := rest.NewSellerController(newEcho(), mockService, authMiddleware, newTestIdempotency())
 
 	// Create a seller for testing
 	seller := entities.NewSeller("TestSeller")
@@ -28,7 +31,7 @@ func TestCreateSeller(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/sellers", bytes.NewReader(sellerJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
-	c := echo.New().NewContext(req, rec)
+	c := newEcho().NewContext(req, rec)
 
 	// Act
 	if err := controller.CreateSellerController(c); err != nil {
@@ -47,10 +50,15 @@ func TestCreateSeller(t *testing.T) {
 
 func TestPutSeller(t *testing.T) {
 	// Arrange
+	e := newEcho()
 	mockService := NewMockSellerService()
-	controller := rest.NewSellerController(echo.New(), mockService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
 
-	createdSeller, err := mockService.CreateSeller(&command.CreateSellerCommand{Name: "TestSeller"})
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "TestSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
 	assert.NoError(t, err)
 
 	updateRequest := request.UpdateSellerRequest{
@@ -61,13 +69,11 @@ func TestPutSeller(t *testing.T) {
 	sellerJSON, _ := json.Marshal(updateRequest)
 	req := httptest.NewRequest(http.MethodPut, "/api/v1/sellers", bytes.NewReader(sellerJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	authorizeRequest(t, tokenManager, req, "owner-id")
 	rec := httptest.NewRecorder()
-	c := echo.New().NewContext(req, rec)
 
 	// Act
-	if err := controller.PutSellerController(c); err != nil {
-		t.Fatal(err)
-	}
+	e.ServeHTTP(rec, req)
 
 	// Assert
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -79,38 +85,207 @@ func TestPutSeller(t *testing.T) {
 	assert.Equal(t, updateRequest.Name, receivedResponse.Name)
 }
 
+func TestPutSellerIfMatch(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	mockService := NewMockSellerService()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
+
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "TestSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
+	assert.NoError(t, err)
+
+	getRec := httptest.NewRecorder()
+	e.ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/sellers/%s", createdSeller.Result.Id), nil))
+	etag := getRec.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	put := func(name string) *httptest.ResponseRecorder {
+		sellerJSON, _ := json.Marshal(request.UpdateSellerRequest{Id: createdSeller.Result.Id, Name: name})
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/sellers", bytes.NewReader(sellerJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("If-Match", etag)
+		authorizeRequest(t, tokenManager, req, "owner-id")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act & Assert: the first write with the read version passes
+	rec := put("first")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	// Act & Assert: a second write with the same version is stale
+	rec = put("second")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, rest.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+}
+
+func TestPutSellerRequiresMembership(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	mockService := NewMockSellerService()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
+
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "TestSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
+	assert.NoError(t, err)
+
+	sellerJSON, _ := json.Marshal(request.UpdateSellerRequest{Id: createdSeller.Result.Id, Name: "hijacked"})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/sellers", bytes.NewReader(sellerJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	authorizeRequest(t, tokenManager, req, "stranger-id")
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
 func TestDeleteSeller(t *testing.T) {
 	// Arrange
+	e := newEcho()
 	mockService := NewMockSellerService()
-	controller := rest.NewSellerController(echo.New(), mockService)
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
 
-	createdSeller, err := mockService.CreateSeller(&command.CreateSellerCommand{Name: "TestSeller"})
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "TestSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
 	assert.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/sellers/%s", createdSeller.Result.Id), nil)
+	authorizeRequest(t, tokenManager, req, "owner-id")
 	rec := httptest.NewRecorder()
-	c := echo.New().NewContext(req, rec)
 
 	// Act
-	if err := controller.DeleteSellerController(c); err != nil {
-		t.Fatal(err)
-	}
+	e.ServeHTTP(rec, req)
 
 	// Assert
 	assert.Equal(t, http.StatusNoContent, rec.Code)
 }
 
+func TestRestoreSeller(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	mockService := NewMockSellerService()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
+
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "TestSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mockService.DeleteSeller(context.Background(), createdSeller.Result.Id, nil, common.Actor{UserId: "owner-id"}))
+	restoreURL := fmt.Sprintf("/api/v1/sellers/%s/restore", createdSeller.Result.Id)
+
+	// Act & Assert: only owners restore the seller
+	req := httptest.NewRequest(http.MethodPost, restoreURL, nil)
+	authorizeRequest(t, tokenManager, req, "other-id")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, restoreURL, nil)
+	authorizeRequest(t, tokenManager, req, "owner-id")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var restoredSeller response.SellerResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restoredSeller))
+	assert.Equal(t, createdSeller.Result.Id.String(), restoredSeller.Id)
+	assert.Nil(t, restoredSeller.DeletedAt)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sellers/"+restoredSeller.Id, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGetAllSellersIncludingDeletedRequiresPermission(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, NewMockSellerService(), authMiddleware, newTestIdempotency())
+
+	// Act & Assert
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sellers?include_deleted=true", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sellers?include_deleted=true", nil)
+	authorizeRequest(t, tokenManager, req, "owner-id")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/sellers?include_deleted=true", nil)
+	authorizeRequest(t, tokenManager, req, testAdminId)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGetMySellers(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	mockService := NewMockSellerService()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, mockService, authMiddleware, newTestIdempotency())
+
+	ownSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "OwnSeller",
+		Actor: common.Actor{UserId: "owner-id"},
+	})
+	assert.NoError(t, err)
+	_, err = mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{
+		Name:  "OtherSeller",
+		Actor: common.Actor{UserId: "other-id"},
+	})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me/sellers", nil)
+	authorizeRequest(t, tokenManager, req, "owner-id")
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var sellers response.ListSellersResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &sellers)
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 1, len(sellers.Sellers)) {
+		assert.Equal(t, ownSeller.Result.Id.String(), sellers.Sellers[0].Id)
+	}
+}
+
 func TestGetSellerById(t *testing.T) {
 	// Arrange
 	mockService := NewMockSellerService()
-	controller := rest.NewSellerController(echo.New(), mockService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	controller := rest.NewSellerController(newEcho(), mockService, authMiddleware, newTestIdempotency())
 
-	createdSeller, err := mockService.CreateSeller(&command.CreateSellerCommand{Name: "TestSeller"})
+	createdSeller, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{Name: "TestSeller"})
 	assert.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/sellers/%s", createdSeller.Result.Id), nil)
 	rec := httptest.NewRecorder()
-	c := echo.New().NewContext(req, rec)
+	c := newEcho().NewContext(req, rec)
 
 	// Act
 	if err := controller.GetSellerByIdController(c); err != nil {
@@ -131,17 +306,18 @@ func TestGetSellerById(t *testing.T) {
 func TestGetAllSellers(t *testing.T) {
 	// Arrange
 	mockService := NewMockSellerService()
-	controller := rest.NewSellerController(echo.New(), mockService)
+	authMiddleware, _ := newTestAuthMiddleware(t)
+	controller := rest.NewSellerController(newEcho(), mockService, authMiddleware, newTestIdempotency())
 
-	_, err := mockService.CreateSeller(&command.CreateSellerCommand{Name: "TestSeller1"})
+	_, err := mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{Name: "TestSeller1"})
 	assert.NoError(t, err)
 
-	_, err = mockService.CreateSeller(&command.CreateSellerCommand{Name: "TestSeller2"})
+	_, err = mockService.CreateSeller(context.Background(), &command.CreateSellerCommand{Name: "TestSeller2"})
 	assert.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/sellers", nil)
 	rec := httptest.NewRecorder()
-	c := echo.New().NewContext(req, rec)
+	c := newEcho().NewContext(req, rec)
 
 	// Act
 	if err := controller.GetAllSellersController(c); err != nil {
@@ -157,3 +333,36 @@ func TestGetAllSellers(t *testing.T) {
 
 	assert.Equal(t, 2, len(sellers.Sellers))
 }
+
+func TestSellerWritesRequireAuthentication(t *testing.T) {
+	// Arrange
+	e := newEcho()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	rest.NewSellerController(e, NewMockSellerService(), authMiddleware, newTestIdempotency())
+
+	sellerJSON, _ := json.Marshal(request.CreateSellerRequest{Name: "TestSeller"})
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sellers", bytes.NewReader(sellerJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return req
+	}
+
+	// Act & Assert: anonymous writes are rejected
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest())
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Act & Assert: authenticated writes pass
+	token, err := tokenManager.GenerateToken("user-id", "test@example.com")
+	assert.NoError(t, err)
+	req := newRequest()
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// Act & Assert: reads stay public
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sellers", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,247 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"github.com/sklinkert/go-ddd/internal/application/services"
+	"github.com/sklinkert/go-ddd/internal/config"
+	"github.com/sklinkert/go-ddd/internal/domain/entities"
+	"github.com/sklinkert/go-ddd/internal/domain/repositories"
+	"github.com/sklinkert/go-ddd/internal/infrastructure/auth"
+	"github.com/sklinkert/go-ddd/internal/interface/api/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// MockUserRepository keeps users in memory by ID
+type MockUserRepository struct {
+	users map[string]*entities.User
+}
+
+func NewMockUserRepository(users ...*entities.User) *MockUserRepository {
+	repo := &MockUserRepository{users: make(map[string]*entities.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, user *entities.User) error {
+	user.Version++
+	stored := *user
+	m.users[user.ID] = &stored
+	return nil
+}
+
+func (m *MockUserRepository) IncrementFailedLogins(ctx context.Context, id string) (*entities.User, error) {
+	user, err := m.FindByID(ctx, id)
+	if err != nil || user == nil {
+		return user, err
+	}
+	user.FailedLoginAttempts++
+	return user, m.Save(ctx, user)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *user
+	return &found, nil
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return m.FindByID(ctx, user.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepository) FindByUsername(ctx context.Context, username string) (*entities.User, error) {
+	for _, user := range m.users {
+		if user.Username == username {
+			return m.FindByID(ctx, user.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepository) FindAll(ctx context.Context) ([]*entities.User, error) {
+	return m.FindWithFilter(ctx, repositories.UserFilter{})
+}
+
+func (m *MockUserRepository) FindWithFilter(ctx context.Context, filter repositories.UserFilter) ([]*entities.User, error) {
+	var users []*entities.User
+	for id := range m.users {
+		user, _ := m.FindByID(ctx, id)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id string, version int) error {
+	if user, ok := m.users[id]; ok && user.Version != version {
+		return repositories.ErrVersionConflict
+	}
+	delete(m.users, id)
+	return nil
+}
+
+// MockRoleRepository serves the testRoles
+type MockRoleRepository struct{}
+
+func (m *MockRoleRepository) Save(ctx context.Context, role *entities.Role) error {
+	return nil
+}
+
+func (m *MockRoleRepository) FindByName(ctx context.Context, name entities.UserRole) (*entities.Role, error) {
+	for _, role := range testRoles() {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRoleRepository) FindAll(ctx context.Context) ([]*entities.Role, error) {
+	return testRoles(), nil
+}
+
+func (m *MockRoleRepository) Delete(ctx context.Context, name entities.UserRole) error {
+	return nil
+}
+
+// MockUnitOfWork runs the work on the mock user and audit repositories, it cannot roll back
+type MockUnitOfWork struct {
+	users *MockUserRepository
+	audit *MockAuditRepository
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	return fn(m)
+}
+
+func (m *MockUnitOfWork) Products() repositories.ProductRepository {
+	return nil
+}
+
+func (m *MockUnitOfWork) Sellers() repositories.SellerRepository {
+	return nil
+}
+
+func (m *MockUnitOfWork) SellerMemberships() repositories.SellerMembershipRepository {
+	return nil
+}
+
+func (m *MockUnitOfWork) Users() repositories.UserRepository {
+	return m.users
+}
+
+func (m *MockUnitOfWork) ExchangeRates() repositories.ExchangeRateRepository {
+	return nil
+}
+
+func (m *MockUnitOfWork) Audit() repositories.AuditRepository {
+	return m.audit
+}
+
+// newTestUserController registers a user controller serving the given users
+func newTestUserController(t *testing.T, users ...*entities.User) (http.Handler, *auth.TokenManager, *MockUserRepository) {
+	passwordConfig := config.NewPasswordConfig()
+	passwordConfig.Argon2Memory = 1024
+	passwordConfig.Argon2Iterations = 1
+	passwordConfig.Argon2Parallelism = 1
+	hasher, err := auth.NewPasswordHasher(passwordConfig)
+	require.NoError(t, err)
+
+	e := newEcho()
+	authMiddleware, tokenManager := newTestAuthMiddleware(t)
+	userRepo := NewMockUserRepository(users...)
+	auditRepo := &MockAuditRepository{}
+	unitOfWork := &MockUnitOfWork{users: userRepo, audit: auditRepo}
+	userService := services.NewUserService(userRepo, nil, hasher, config.NewLoginProtectionConfig(), unitOfWork, services.NewAuditService(auditRepo))
+	roleService := services.NewRoleService(&MockRoleRepository{}, userRepo)
+	rest.NewUserController(e, userService, roleService, authMiddleware)
+	return e, tokenManager, userRepo
+}
+
+func TestUpdateUserRejectsChangesToMorePrivilegedUsers(t *testing.T) {
+	// Arrange
+	admin, _ := entities.NewUser("target-admin-id", "admin", "admin@example.com", "admin-hash")
+	admin.Role = entities.RoleAdmin
+	customer, _ := entities.NewUser("customer-id", "customer", "customer@example.com", "customer-hash")
+	handler, tokenManager, userRepo := newTestUserController(t, admin, customer)
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/api/v1/users/target-admin-id", `{"password":"taken-over"}`},
+		{http.MethodPut, "/api/v1/users/target-admin-id/status", `{"status":"locked"}`},
+		{http.MethodDelete, "/api/v1/users/target-admin-id", ""},
+	}
+	for _, r := range requests {
+		// Act: support staff tries to take over or lock out an admin
+		req := httptest.NewRequest(r.method, r.path, bytes.NewBufferString(r.body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeRequest(t, tokenManager, req, testSupportId)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, rec.Code, r.method+" "+r.path)
+	}
+	stored, _ := userRepo.FindByID(context.Background(), "target-admin-id")
+	assert.Equal(t, "admin-hash", stored.PasswordHash)
+	assert.Equal(t, entities.StatusActive, stored.Status)
+
+	// Support staff still helps regular customers
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/customer-id", bytes.NewBufferString(`{"password":"new-password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	authorizeRequest(t, tokenManager, req, testSupportId)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	stored, _ = userRepo.FindByID(context.Background(), "customer-id")
+	assert.NotEqual(t, "customer-hash", stored.PasswordHash)
+}
+
+func TestRoleAssignmentIsLimitedToTheActorsPermissions(t *testing.T) {
+	// Arrange
+	customer, _ := entities.NewUser("customer-id", "customer", "customer@example.com", "customer-hash")
+	handler, tokenManager, userRepo := newTestUserController(t, customer)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeRequest(t, tokenManager, req, testRoleManagerId)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act & Assert: a role manager cannot grant a role above their own
+	rec := send(http.MethodPut, "/api/v1/users/customer-id/role", `{"role":"admin"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = send(http.MethodPost, "/api/v1/users", `{"username":"newadmin","email":"new@example.com","password":"password123","role":"admin","status":"active"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	users, _ := userRepo.FindAll(context.Background())
+	assert.Len(t, users, 1, "The user must not be created with the default role instead")
+
+	// Roles within their own permissions can be granted, together with the status of a new user
+	rec = send(http.MethodPut, "/api/v1/users/customer-id/role", `{"role":"support"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = send(http.MethodPost, "/api/v1/users", `{"username":"helper","email":"helper@example.com","password":"password123","role":"support","status":"inactive"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	created, _ := userRepo.FindByEmail(context.Background(), "helper@example.com")
+	require.NotNil(t, created)
+	assert.Equal(t, entities.RoleSupport, created.Role)
+	assert.Equal(t, entities.StatusInactive, created.Status)
+	assert.Equal(t, 1, created.Version, "The user is created with its role and status in a single save")
+}